@@ -0,0 +1,110 @@
+package vespyr
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// ADXLine is the average directional index, which is the ADX
+	// indicator's primary value.
+	ADXLine = "adx"
+	// ADXPlusDI is the positive directional indicator.
+	ADXPlusDI = "plus-di"
+	// ADXMinusDI is the negative directional indicator.
+	ADXMinusDI = "minus-di"
+)
+
+// ADXIndicator calculates Wilder's average directional index, which
+// measures the strength of a trend, along with the directional
+// indicators that it's derived from.
+type ADXIndicator struct {
+	period    uint
+	trueRange *wilderAverage
+	plusDM    *wilderAverage
+	minusDM   *wilderAverage
+	adx       *wilderAverage
+	plusDI    float64
+	minusDI   float64
+	previous  *CandlestickModel
+	lastTime  time.Time
+}
+
+// NewADXIndicator returns a new ADXIndicator.
+func NewADXIndicator(period uint) *ADXIndicator {
+	return &ADXIndicator{
+		period:    period,
+		trueRange: &wilderAverage{period: period},
+		plusDM:    &wilderAverage{period: period},
+		minusDM:   &wilderAverage{period: period},
+		adx:       &wilderAverage{period: period},
+	}
+}
+
+// AddCandlestick adds a candlestick to the indicator.
+func (a *ADXIndicator) AddCandlestick(c *CandlestickModel) error {
+	if c.Volume == 0 {
+		return nil
+	}
+
+	a.lastTime = c.StartTime
+	previous := a.previous
+	a.previous = c
+	if previous == nil {
+		return nil
+	}
+
+	up := c.High - previous.High
+	down := previous.Low - c.Low
+	var plusDM, minusDM float64
+	if up > down && up > 0 {
+		plusDM = up
+	}
+	if down > up && down > 0 {
+		minusDM = down
+	}
+
+	a.trueRange.add(trueRange(c, previous.Close, true))
+	a.plusDM.add(plusDM)
+	a.minusDM.add(minusDM)
+	if !a.trueRange.ready() {
+		return nil
+	}
+
+	a.plusDI, a.minusDI = 0, 0
+	if a.trueRange.value != 0 {
+		a.plusDI = 100 * a.plusDM.value / a.trueRange.value
+		a.minusDI = 100 * a.minusDM.value / a.trueRange.value
+	}
+
+	var dx float64
+	if sum := a.plusDI + a.minusDI; sum != 0 {
+		dx = 100 * math.Abs(a.plusDI-a.minusDI) / sum
+	}
+	a.adx.add(dx)
+
+	return nil
+}
+
+// Name returns the name of the indicator.
+func (a *ADXIndicator) Name() string {
+	return fmt.Sprintf("%s-%d", IndicatorADX, a.period)
+}
+
+// Value returns the last calculated ADX value.
+func (a *ADXIndicator) Value() (*IndicatorValue, error) {
+	if !a.adx.ready() {
+		return nil, ErrNotEnoughData
+	}
+	return &IndicatorValue{
+		Time:          a.lastTime,
+		Value:         a.adx.value,
+		IndicatorName: a.Name(),
+		Components: []*IndicatorComponent{
+			{Name: ADXLine, Value: a.adx.value},
+			{Name: ADXPlusDI, Value: a.plusDI},
+			{Name: ADXMinusDI, Value: a.minusDI},
+		},
+	}, nil
+}
@@ -0,0 +1,68 @@
+package vespyr
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// trueRange returns the largest of the candlestick's range and the
+// distances from the previous close to its high and low.
+func trueRange(c *CandlestickModel, previousClose float64, hasPrevious bool) float64 {
+	tr := c.High - c.Low
+	if hasPrevious {
+		tr = math.Max(tr, math.Abs(c.High-previousClose))
+		tr = math.Max(tr, math.Abs(c.Low-previousClose))
+	}
+	return tr
+}
+
+// ATRIndicator calculates the average true range, Wilder's moving
+// average of the true range. The first candlestick's true range is its
+// high minus its low.
+type ATRIndicator struct {
+	period        uint
+	average       *wilderAverage
+	previousClose float64
+	hasPrevious   bool
+	lastTime      time.Time
+}
+
+// NewATRIndicator returns a new ATRIndicator.
+func NewATRIndicator(period uint) *ATRIndicator {
+	return &ATRIndicator{
+		period:  period,
+		average: &wilderAverage{period: period},
+	}
+}
+
+// AddCandlestick adds a candlestick to the indicator.
+func (a *ATRIndicator) AddCandlestick(c *CandlestickModel) error {
+	if c.Volume == 0 {
+		return nil
+	}
+
+	a.average.add(trueRange(c, a.previousClose, a.hasPrevious))
+	a.previousClose = c.Close
+	a.hasPrevious = true
+	a.lastTime = c.StartTime
+
+	return nil
+}
+
+// Name returns the name of the indicator.
+func (a *ATRIndicator) Name() string {
+	return fmt.Sprintf("%s-%d", IndicatorATR, a.period)
+}
+
+// Value returns the last calculated ATR value.
+func (a *ATRIndicator) Value() (*IndicatorValue, error) {
+	if !a.average.ready() {
+		return nil, ErrNotEnoughData
+	}
+	return &IndicatorValue{
+		Time:          a.lastTime,
+		Value:         a.average.value,
+		IndicatorName: a.Name(),
+	}, nil
+}
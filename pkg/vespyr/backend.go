@@ -15,6 +15,7 @@ type Backend interface {
 	FindCandlesticks(time.Time, time.Time, Product, int64) ([]*CandlestickModel, error)
 	FindMostRecentCandlestick(Product) (*CandlestickModel, error)
 	FindCandlestickByID(int64) (*CandlestickModel, error)
+	FindMissingCandlestickTimes(Product, time.Time, time.Time) ([]time.Time, error)
 
 	// Market orders
 	CreateMarketOrder(*MarketOrderModel) error
@@ -25,6 +26,19 @@ type Backend interface {
 	CreateTradingStrategy(*TradingStrategyModel) error
 	UpdateTradingStrategy(*TradingStrategyModel) error
 	FindActiveTradingStrategies(Product) ([]*TradingStrategyModel, error)
+
+	// Import jobs
+	CreateImportJob(*ImportJobModel, []*ImportBatchModel) error
+	FindImportJobByID(int64) (*ImportJobModel, error)
+	UpdateImportJob(*ImportJobModel) error
+	FindImportBatches(int64) ([]*ImportBatchModel, error)
+	UpdateImportBatch(*ImportBatchModel) error
+
+	// Backtest runs
+	CreateBacktestRun(*BacktestRunModel, []*BacktestTradeModel) error
+	FindBacktestRunByID(int64) (*BacktestRunModel, error)
+	FindBacktestRuns(string, int) ([]*BacktestRunModel, error)
+	FindBacktestTrades(int64) ([]*BacktestTradeModel, error)
 }
 
 // DBConn contains the supported backend operations.
@@ -88,6 +102,24 @@ func (d *DBConn) FindCandlesticks(startTime, endTime time.Time,
 	return projections, nil
 }
 
+// FindMissingCandlestickTimes returns the start times of the minute
+// buckets within a range that don't have a candlestick.
+func (d *DBConn) FindMissingCandlestickTimes(product Product,
+	startTime, endTime time.Time) ([]time.Time, error) {
+	var times []time.Time
+	if _, err := d.conn.Query(&times, `
+SELECT s.t FROM generate_series(?::timestamptz, ?::timestamptz, '1 minute') AS s(t)
+LEFT JOIN candlesticks c ON c.start_time = s.t AND c.product = ?
+WHERE c.id IS NULL
+ORDER BY s.t ASC`,
+		CandlestickBucket(startTime, dbCandlestickBucketSize),
+		CandlestickBucket(endTime, dbCandlestickBucketSize),
+		string(product)); err != nil {
+		return nil, errors.Wrapf(err, "error finding missing candlesticks")
+	}
+	return times, nil
+}
+
 func (d *DBConn) FindMarketOrderByID(id int64) (*MarketOrderModel, error) {
 	m := &MarketOrderModel{ID: id}
 	if err := d.conn.Select(m); err != nil {
@@ -126,3 +158,99 @@ func (d *DBConn) FindActiveTradingStrategies(p Product) ([]*TradingStrategyModel
 	}
 	return strategies, nil
 }
+
+// CreateImportJob inserts an import job along with its batches.
+func (d *DBConn) CreateImportJob(job *ImportJobModel, batches []*ImportBatchModel) error {
+	err := d.conn.RunInTransaction(func(tx *pg.Tx) error {
+		if err := tx.Insert(job); err != nil {
+			return errors.Wrapf(err, "error inserting import job")
+		}
+		for _, b := range batches {
+			b.ImportJobID = job.ID
+		}
+		if len(batches) == 0 {
+			return nil
+		}
+		if _, err := tx.Model(&batches).Insert(); err != nil {
+			return errors.Wrapf(err, "error inserting import batches")
+		}
+		return nil
+	})
+	return errors.Wrapf(err, "error creating import job")
+}
+
+func (d *DBConn) FindImportJobByID(id int64) (*ImportJobModel, error) {
+	m := &ImportJobModel{ID: id}
+	if err := d.conn.Select(m); err != nil {
+		return nil, errors.Wrapf(err, "error finding import job")
+	}
+	return m, nil
+}
+
+func (d *DBConn) UpdateImportJob(m *ImportJobModel) error {
+	_, err := d.conn.Model(m).Update()
+	return errors.Wrapf(err, "error updating import job")
+}
+
+func (d *DBConn) FindImportBatches(jobID int64) ([]*ImportBatchModel, error) {
+	var batches []*ImportBatchModel
+	if err := d.conn.Model(&batches).Where("import_job_id = ?", jobID).Order("start_time ASC").Select(); err != nil {
+		return nil, errors.Wrapf(err, "error finding import batches")
+	}
+	return batches, nil
+}
+
+func (d *DBConn) UpdateImportBatch(m *ImportBatchModel) error {
+	_, err := d.conn.Model(m).Update()
+	return errors.Wrapf(err, "error updating import batch")
+}
+
+// CreateBacktestRun inserts a backtest run along with its trades.
+func (d *DBConn) CreateBacktestRun(run *BacktestRunModel, trades []*BacktestTradeModel) error {
+	err := d.conn.RunInTransaction(func(tx *pg.Tx) error {
+		if err := tx.Insert(run); err != nil {
+			return errors.Wrapf(err, "error inserting backtest run")
+		}
+		for _, t := range trades {
+			t.BacktestRunID = run.ID
+		}
+		if len(trades) == 0 {
+			return nil
+		}
+		if _, err := tx.Model(&trades).Insert(); err != nil {
+			return errors.Wrapf(err, "error inserting backtest trades")
+		}
+		return nil
+	})
+	return errors.Wrapf(err, "error creating backtest run")
+}
+
+func (d *DBConn) FindBacktestRunByID(id int64) (*BacktestRunModel, error) {
+	m := &BacktestRunModel{ID: id}
+	if err := d.conn.Select(m); err != nil {
+		return nil, errors.Wrapf(err, "error finding backtest run")
+	}
+	return m, nil
+}
+
+// FindBacktestRuns returns the most recent backtest runs, optionally
+// limited to a single sweep.
+func (d *DBConn) FindBacktestRuns(sweepName string, limit int) ([]*BacktestRunModel, error) {
+	var runs []*BacktestRunModel
+	query := d.conn.Model(&runs).Order("id DESC").Limit(limit)
+	if sweepName != "" {
+		query = query.Where("sweep_name = ?", sweepName)
+	}
+	if err := query.Select(); err != nil {
+		return nil, errors.Wrapf(err, "error finding backtest runs")
+	}
+	return runs, nil
+}
+
+func (d *DBConn) FindBacktestTrades(runID int64) ([]*BacktestTradeModel, error) {
+	var trades []*BacktestTradeModel
+	if err := d.conn.Model(&trades).Where("backtest_run_id = ?", runID).Order("time ASC").Select(); err != nil {
+		return nil, errors.Wrapf(err, "error finding backtest trades")
+	}
+	return trades, nil
+}
@@ -0,0 +1,254 @@
+package vespyr
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// BacktestPeriodDay groups daily portfolio values by day.
+	BacktestPeriodDay = "day"
+	// BacktestPeriodWeek groups daily portfolio values by week,
+	// starting on Monday.
+	BacktestPeriodWeek = "week"
+	// BacktestPeriodMonth groups daily portfolio values by month.
+	BacktestPeriodMonth = "month"
+)
+
+var backtestPeriodStarts = map[string]func(time.Time) time.Time{
+	BacktestPeriodDay: func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	},
+	BacktestPeriodWeek: func(t time.Time) time.Time {
+		daysSinceMonday := (int(t.Weekday()) + 6) % 7
+		return time.Date(t.Year(), t.Month(), t.Day()-daysSinceMonday, 0, 0, 0, 0, t.Location())
+	},
+	BacktestPeriodMonth: func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	},
+}
+
+// holdingTimeBuckets are the upper bounds of the holding time
+// distribution. Longer trades fall in a final, unbounded bucket.
+var holdingTimeBuckets = []time.Duration{
+	6 * time.Hour,
+	24 * time.Hour,
+	3 * 24 * time.Hour,
+	7 * 24 * time.Hour,
+	14 * 24 * time.Hour,
+	28 * 24 * time.Hour,
+}
+
+// BacktestPeriodReturn is the return of a strategy and of buying and
+// holding over a calendar period.
+type BacktestPeriodReturn struct {
+	Start           time.Time
+	Return          float64
+	BenchmarkReturn float64
+}
+
+// HoldingTimeBucket counts the trades held for at most Max and longer
+// than the previous bucket's Max. The last bucket's Max is 0, meaning
+// it's unbounded.
+type HoldingTimeBucket struct {
+	Max   time.Duration
+	Count int
+}
+
+// MaxDrawdownDuration returns the longest number of days that the
+// portfolio spent below a previous peak.
+func (b *BacktestResults) MaxDrawdownDuration() int {
+	peak := b.InitialBudget
+	var current, longest int
+	for _, v := range b.PortfolioValuePerDay {
+		if v >= peak {
+			peak = v
+			current = 0
+			continue
+		}
+		current++
+		if current > longest {
+			longest = current
+		}
+	}
+	return longest
+}
+
+// TotalReturn returns the fractional return of the final daily
+// portfolio value.
+func (b *BacktestResults) TotalReturn() float64 {
+	days := len(b.PortfolioValuePerDay)
+	if days == 0 || b.InitialBudget == 0 {
+		return 0
+	}
+	return b.PortfolioValuePerDay[days-1]/b.InitialBudget - 1
+}
+
+// BenchmarkReturn returns the fractional return of buying the trade
+// currency at the start of the backtest and holding it.
+func (b *BacktestResults) BenchmarkReturn() float64 {
+	days := len(b.BenchmarkValuePerDay)
+	if days == 0 || b.InitialBudget == 0 {
+		return 0
+	}
+	return b.BenchmarkValuePerDay[days-1]/b.InitialBudget - 1
+}
+
+// ExcessReturn returns how much the strategy's return beat buying and
+// holding.
+func (b *BacktestResults) ExcessReturn() float64 {
+	return b.TotalReturn() - b.BenchmarkReturn()
+}
+
+// TimeInMarket returns the fraction of ticks that the budget was
+// invested.
+func (b *BacktestResults) TimeInMarket() float64 {
+	if b.Ticks == 0 {
+		return 0
+	}
+	return float64(b.TicksInMarket) / float64(b.Ticks)
+}
+
+// AverageWin returns the average profit of profitable trades, or 0
+// without any.
+func (b *BacktestResults) AverageWin() float64 {
+	if b.ProfitTrades == 0 {
+		return 0
+	}
+	return b.GrossProfit / float64(b.ProfitTrades)
+}
+
+// AverageLoss returns the average loss of losing trades, or 0 without
+// any.
+func (b *BacktestResults) AverageLoss() float64 {
+	if b.LossTrades == 0 {
+		return 0
+	}
+	return b.GrossLoss / float64(b.LossTrades)
+}
+
+// AverageHoldingTime returns the average time that trades were held.
+func (b *BacktestResults) AverageHoldingTime() time.Duration {
+	if len(b.HoldingTimes) == 0 {
+		return 0
+	}
+	var total time.Duration
+	for _, d := range b.HoldingTimes {
+		total += d
+	}
+	return total / time.Duration(len(b.HoldingTimes))
+}
+
+// HoldingTimeDistribution counts trades by how long they were held.
+func (b *BacktestResults) HoldingTimeDistribution() []*HoldingTimeBucket {
+	var buckets []*HoldingTimeBucket
+	for _, max := range holdingTimeBuckets {
+		buckets = append(buckets, &HoldingTimeBucket{Max: max})
+	}
+	buckets = append(buckets, &HoldingTimeBucket{})
+
+	for _, d := range b.HoldingTimes {
+		i := sort.Search(len(holdingTimeBuckets), func(i int) bool {
+			return d <= holdingTimeBuckets[i]
+		})
+		buckets[i].Count++
+	}
+	return buckets
+}
+
+// PeriodReturns returns the strategy's and the benchmark's return in
+// each day, week or month of the backtest. A period's return is
+// measured from the last daily value of the previous period. Daily
+// values are sampled on the first tick of each day, so each one is
+// the value at the close of the day before and counts towards that
+// day's period.
+func (b *BacktestResults) PeriodReturns(period string) ([]*BacktestPeriodReturn, error) {
+	periodStart, ok := backtestPeriodStarts[period]
+	if !ok {
+		return nil, errors.Errorf("error: unknown period: %s", period)
+	}
+	if len(b.Days) != len(b.PortfolioValuePerDay) || len(b.Days) != len(b.BenchmarkValuePerDay) {
+		return nil, errors.New("error: daily values are missing their times")
+	}
+
+	var returns []*BacktestPeriodReturn
+	previous, previousBenchmark := b.InitialBudget, b.InitialBudget
+	for i, day := range b.Days {
+		start := periodStart(day.AddDate(0, 0, -1))
+		if len(returns) == 0 || !returns[len(returns)-1].Start.Equal(start) {
+			if i > 0 {
+				previous = b.PortfolioValuePerDay[i-1]
+				previousBenchmark = b.BenchmarkValuePerDay[i-1]
+			}
+			returns = append(returns, &BacktestPeriodReturn{Start: start})
+		}
+
+		r := returns[len(returns)-1]
+		r.Return = b.PortfolioValuePerDay[i]/previous - 1
+		r.BenchmarkReturn = b.BenchmarkValuePerDay[i]/previousBenchmark - 1
+	}
+
+	return returns, nil
+}
+
+func formatHoldingTime(d time.Duration) string {
+	switch {
+	case d >= 7*24*time.Hour && d%(7*24*time.Hour) == 0:
+		return fmt.Sprintf("%dw", d/(7*24*time.Hour))
+	case d >= 24*time.Hour && d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	}
+	return fmt.Sprintf("%dh", d/time.Hour)
+}
+
+// WriteBacktestReport writes monthly and weekly return tables compared
+// against buying and holding, followed by the distribution of holding
+// times.
+func WriteBacktestReport(w io.Writer, results *BacktestResults) error {
+	for _, p := range []struct {
+		period string
+		title  string
+		layout string
+	}{
+		{BacktestPeriodMonth, "Monthly returns", "Jan 2006"},
+		{BacktestPeriodWeek, "Weekly returns", "02 Jan 2006"},
+	} {
+		returns, err := results.PeriodReturns(p.period)
+		if err != nil {
+			return errors.Wrapf(err, "error calculating %s returns", p.period)
+		}
+
+		fmt.Fprintf(w, "\n%s:\n", p.title)
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, p.period+"\treturn_%\tbuy_and_hold_%\texcess_%")
+		for _, r := range returns {
+			fmt.Fprintf(tw, "%s\t%f\t%f\t%f\n", r.Start.Format(p.layout),
+				100*r.Return, 100*r.BenchmarkReturn, 100*(r.Return-r.BenchmarkReturn))
+		}
+		if err := tw.Flush(); err != nil {
+			return errors.Wrapf(err, "error writing %s returns", p.period)
+		}
+	}
+
+	fmt.Fprintln(w, "\nHolding times:")
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "held\ttrades")
+	var previous time.Duration
+	for _, bucket := range results.HoldingTimeDistribution() {
+		label := fmt.Sprintf("> %s", formatHoldingTime(previous))
+		if bucket.Max != 0 {
+			label = fmt.Sprintf("<= %s", formatHoldingTime(bucket.Max))
+			if previous != 0 {
+				label = fmt.Sprintf("%s - %s", formatHoldingTime(previous), formatHoldingTime(bucket.Max))
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%d\n", label, bucket.Count)
+		previous = bucket.Max
+	}
+	return errors.Wrapf(tw.Flush(), "error writing holding times")
+}
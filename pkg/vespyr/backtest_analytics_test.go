@@ -0,0 +1,158 @@
+package vespyr_test
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/DavidHuie/vespyr/pkg/vespyr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func analyticsTestResults() *vespyr.BacktestResults {
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2018, month, d, 0, 0, 0, 0, time.UTC)
+	}
+	return &vespyr.BacktestResults{
+		InitialBudget: 100,
+		GrossProfit:   30,
+		GrossLoss:     10,
+		ProfitTrades:  2,
+		LossTrades:    1,
+		Days: []time.Time{
+			day(time.January, 30), day(time.January, 31), day(time.February, 1),
+			day(time.February, 2), day(time.February, 6),
+		},
+		PortfolioValuePerDay: []float64{110, 99, 105, 120, 90},
+		BenchmarkValuePerDay: []float64{100, 100, 100, 100, 125},
+		Ticks:                20,
+		TicksInMarket:        5,
+		HoldingTimes:         []time.Duration{2 * time.Hour, 30 * time.Hour, 40 * 24 * time.Hour},
+	}
+}
+
+func TestBacktestResultsAnalytics(t *testing.T) {
+	results := analyticsTestResults()
+
+	assert.Equal(t, 2, results.MaxDrawdownDuration())
+	assert.InDelta(t, .25, results.MaxDrawdown(), 1e-9)
+	assert.InDelta(t, -.1, results.TotalReturn(), 1e-9)
+	assert.InDelta(t, .25, results.BenchmarkReturn(), 1e-9)
+	assert.InDelta(t, -.35, results.ExcessReturn(), 1e-9)
+	assert.Equal(t, .25, results.TimeInMarket())
+	assert.Equal(t, float64(15), results.AverageWin())
+	assert.Equal(t, float64(10), results.AverageLoss())
+	assert.Equal(t, (2*time.Hour+30*time.Hour+40*24*time.Hour)/3, results.AverageHoldingTime())
+
+	var counts []int
+	for _, bucket := range results.HoldingTimeDistribution() {
+		counts = append(counts, bucket.Count)
+	}
+	assert.Equal(t, []int{1, 0, 1, 0, 0, 0, 1}, counts)
+
+	monthly, err := results.PeriodReturns(vespyr.BacktestPeriodMonth)
+	assert.NoError(t, err)
+	if assert.Len(t, monthly, 2) {
+		assert.True(t, monthly[0].Start.Equal(time.Date(2018, time.January, 1, 0, 0, 0, 0, time.UTC)))
+		// The value sampled on February 1st closed January.
+		assert.InDelta(t, .05, monthly[0].Return, 1e-9)
+		assert.InDelta(t, 0, monthly[0].BenchmarkReturn, 1e-9)
+		assert.InDelta(t, 90.0/105-1, monthly[1].Return, 1e-9)
+		assert.InDelta(t, .25, monthly[1].BenchmarkReturn, 1e-9)
+	}
+
+	weekly, err := results.PeriodReturns(vespyr.BacktestPeriodWeek)
+	assert.NoError(t, err)
+	if assert.Len(t, weekly, 2) {
+		assert.True(t, weekly[0].Start.Equal(time.Date(2018, time.January, 29, 0, 0, 0, 0, time.UTC)))
+		assert.InDelta(t, .2, weekly[0].Return, 1e-9)
+		assert.True(t, weekly[1].Start.Equal(time.Date(2018, time.February, 5, 0, 0, 0, 0, time.UTC)))
+		assert.InDelta(t, -.25, weekly[1].Return, 1e-9)
+	}
+
+	daily, err := results.PeriodReturns(vespyr.BacktestPeriodDay)
+	assert.NoError(t, err)
+	if assert.Len(t, daily, 5) {
+		assert.True(t, daily[0].Start.Equal(time.Date(2018, time.January, 29, 0, 0, 0, 0, time.UTC)))
+		assert.True(t, daily[4].Start.Equal(time.Date(2018, time.February, 5, 0, 0, 0, 0, time.UTC)))
+	}
+
+	_, err = results.PeriodReturns("year")
+	assert.Error(t, err)
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, vespyr.WriteBacktestReport(buf, results))
+	assert.Contains(t, buf.String(), "Monthly returns:")
+	assert.Contains(t, buf.String(), "Feb 2018")
+	assert.Contains(t, buf.String(), "29 Jan 2018")
+	assert.Contains(t, buf.String(), "1d - 3d")
+	assert.Contains(t, buf.String(), "> 4w")
+}
+
+func TestBacktestResultsAnalyticsWithoutData(t *testing.T) {
+	results := &vespyr.BacktestResults{InitialBudget: 100}
+
+	assert.True(t, math.IsNaN(results.SharpeRatio()))
+	assert.Equal(t, 0, results.MaxDrawdownDuration())
+	assert.Equal(t, float64(0), results.TimeInMarket())
+	assert.Equal(t, time.Duration(0), results.AverageHoldingTime())
+	assert.Equal(t, float64(0), results.AverageWin())
+	assert.Equal(t, float64(0), results.AverageLoss())
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, vespyr.WriteBacktestResults(buf, vespyr.BacktestOutputText, results))
+	assert.NoError(t, vespyr.WriteBacktestReport(buf, results))
+}
+
+func TestBacktesterResultsAnalytics(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(7 * 24 * time.Hour)
+	closes := []float64{
+		100, 101, 103, 106, 104, 101, 98, 97, 99, 102, 106, 110, 108, 104, 100,
+		98, 99, 103, 107, 111, 115, 112, 108, 104, 103, 105, 108, 112, 116, 118,
+	}
+
+	backend := new(vespyr.MockBackend)
+	backend.On("FindCandlesticks", mock.Anything, end, vespyr.ProductBTCUSD, int64(360)).
+		Return(portfolioTestCandles(vespyr.ProductBTCUSD, start, closes), nil)
+
+	model := portfolioTestModel(t, vespyr.ProductBTCUSD)
+	model.InitialBudget = 1000
+	model.Budget = 1000
+
+	backtester, err := vespyr.NewBacktester(start, end, model, backend, rand.NewSource(1))
+	assert.NoError(t, err)
+	assert.NoError(t, backtester.Backtest())
+	results := backtester.Results()
+
+	// The first candles warm up the EMAs.
+	assert.Equal(t, uint(len(closes)-2), results.Ticks)
+	assert.True(t, results.TicksInMarket > 0)
+	assert.True(t, results.TicksInMarket < results.Ticks)
+	assert.Len(t, results.HoldingTimes, int(results.ProfitTrades+results.LossTrades))
+	for _, d := range results.HoldingTimes {
+		assert.True(t, d > 0)
+	}
+
+	// The final value includes the position held at the last
+	// close.
+	assert.InDelta(t, model.Budget+model.Invested*closes[len(closes)-1]*(1-.0025),
+		results.FinalPortfolioValue, 1e-6)
+
+	assert.NotEmpty(t, results.Days)
+	assert.Len(t, results.PortfolioValuePerDay, len(results.Days))
+	assert.Len(t, results.BenchmarkValuePerDay, len(results.Days))
+
+	// Buying and holding tracks the price of the trade currency.
+	first := int(results.Days[0].Sub(start) / (6 * time.Hour))
+	for i, day := range results.Days {
+		index := int(day.Sub(start) / (6 * time.Hour))
+		assert.InDelta(t, closes[index]/closes[first],
+			results.BenchmarkValuePerDay[i]/results.BenchmarkValuePerDay[0], 1e-9)
+	}
+	assert.InDelta(t, 1000*(1-.0025)/closes[2]*closes[first]*(1-.0025),
+		results.BenchmarkValuePerDay[0], 1e-6)
+}
@@ -0,0 +1,131 @@
+package vespyr
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// BacktestOutputFormat is a format that backtest results can be
+// written in.
+type BacktestOutputFormat string
+
+const (
+	// BacktestOutputText writes results as human readable text.
+	BacktestOutputText BacktestOutputFormat = "text"
+	// BacktestOutputJSON writes results as a JSON object.
+	BacktestOutputJSON BacktestOutputFormat = "json"
+	// BacktestOutputCSV writes results as a CSV header and row.
+	BacktestOutputCSV BacktestOutputFormat = "csv"
+)
+
+// ParseBacktestOutputFormat parses an output format name.
+func ParseBacktestOutputFormat(name string) (BacktestOutputFormat, error) {
+	switch BacktestOutputFormat(strings.ToLower(name)) {
+	case BacktestOutputText:
+		return BacktestOutputText, nil
+	case BacktestOutputJSON:
+		return BacktestOutputJSON, nil
+	case BacktestOutputCSV:
+		return BacktestOutputCSV, nil
+	}
+	return "", errors.Errorf("error: unknown output format %q", name)
+}
+
+type backtestSummaryField struct {
+	name  string
+	label string
+	value interface{}
+}
+
+func (b *BacktestResults) summaryFields() []*backtestSummaryField {
+	return []*backtestSummaryField{
+		{"total_trades", "Total trades", b.LossTrades + b.ProfitTrades},
+		{"profit_trades", "Profit trades", b.ProfitTrades},
+		{"loss_trades", "Loss trades", b.LossTrades},
+		{"gross_profit", "Gross profit", b.GrossProfit},
+		{"gross_loss", "Gross loss", b.GrossLoss},
+		{"net_profit", "Net profit", b.NetProfit()},
+		{"profit_factor", "Profit factor", b.ProfitFactor()},
+		{"expected_payoff", "Expected payoff", b.ExpectedPayoff()},
+		{"budget_currency", "Budget currency", b.BudgetCurrency},
+		{"initial_budget", "Starting budget", b.InitialBudget},
+		{"final_budget", "Ending budget", b.FinalBudget},
+		{"trade_currency", "Trade currency", b.TradeCurrency},
+		{"initial_currency_price", "Initial trade currency price", b.InitialCurrencyPrice},
+		{"final_currency_price", "Final trade currency price", b.FinalCurrencyPrice},
+		{"currency_gains_percent", "Currency gains (%)", 100 * (b.FinalCurrencyPrice - b.InitialCurrencyPrice) / b.InitialCurrencyPrice},
+		{"trading_gains_percent", "Trading gains (%)", 100 * b.NetProfit() / b.InitialBudget},
+		{"days_traded", "Total days traded", len(b.PortfolioValuePerDay)},
+		{"sharpe_ratio", "Sharpe ratio", b.SharpeRatio()},
+		{"sortino_ratio", "Sortino ratio", b.SortinoRatio()},
+		{"calmar_ratio", "Calmar ratio", b.CalmarRatio()},
+		{"cagr_percent", "CAGR (%)", 100 * b.AnnualizedReturn()},
+		{"max_drawdown_percent", "Max drawdown (%)", 100 * b.MaxDrawdown()},
+		{"max_drawdown_days", "Max drawdown duration (days)", b.MaxDrawdownDuration()},
+		{"time_in_market_percent", "Time in market (%)", 100 * b.TimeInMarket()},
+		{"average_win", "Average win", b.AverageWin()},
+		{"average_loss", "Average loss", b.AverageLoss()},
+		{"average_holding_hours", "Average holding time (hours)", b.AverageHoldingTime().Hours()},
+		{"total_return_percent", "Total return (%)", 100 * b.TotalReturn()},
+		{"buy_and_hold_return_percent", "Buy and hold return (%)", 100 * b.BenchmarkReturn()},
+		{"excess_return_percent", "Excess return (%)", 100 * b.ExcessReturn()},
+		{"fill_model", "Fill model", b.FillModel},
+	}
+}
+
+func formatSummaryValue(v interface{}) string {
+	if f, ok := v.(float64); ok {
+		return fmt.Sprintf("%f", f)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// WriteBacktestResults writes a summary of backtest results in an
+// output format. Ratios that are undefined, such as the profit factor
+// of a backtest without losses, are written as null in JSON.
+func WriteBacktestResults(w io.Writer, format BacktestOutputFormat, results *BacktestResults) error {
+	fields := results.summaryFields()
+
+	switch format {
+	case BacktestOutputText:
+		for _, f := range fields {
+			if _, err := fmt.Fprintf(w, "%s: %s\n", f.label, formatSummaryValue(f.value)); err != nil {
+				return errors.Wrapf(err, "error writing results")
+			}
+		}
+	case BacktestOutputJSON:
+		object := make(map[string]interface{})
+		for _, f := range fields {
+			value := f.value
+			if v, ok := value.(float64); ok && (math.IsNaN(v) || math.IsInf(v, 0)) {
+				value = nil
+			}
+			object[f.name] = value
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(object); err != nil {
+			return errors.Wrapf(err, "error encoding results")
+		}
+	case BacktestOutputCSV:
+		var header, row []string
+		for _, f := range fields {
+			header = append(header, f.name)
+			row = append(row, formatSummaryValue(f.value))
+		}
+		writer := csv.NewWriter(w)
+		if err := writer.WriteAll([][]string{header, row}); err != nil {
+			return errors.Wrapf(err, "error writing to csv")
+		}
+	default:
+		return errors.Errorf("error: unknown output format %q", format)
+	}
+
+	return nil
+}
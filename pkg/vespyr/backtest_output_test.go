@@ -0,0 +1,47 @@
+package vespyr_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/DavidHuie/vespyr/pkg/vespyr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteBacktestResults(t *testing.T) {
+	results := &vespyr.BacktestResults{
+		BudgetCurrency:       "USD",
+		InitialBudget:        100,
+		FinalBudget:          110,
+		TradeCurrency:        "BTC",
+		InitialCurrencyPrice: 10,
+		FinalCurrencyPrice:   20,
+		GrossProfit:          10,
+		ProfitTrades:         2,
+		PortfolioValuePerDay: []float64{100, 105, 110},
+	}
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, vespyr.WriteBacktestResults(buf, vespyr.BacktestOutputJSON, results))
+	var decoded map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, float64(2), decoded["total_trades"])
+	assert.Equal(t, float64(10), decoded["net_profit"])
+	assert.Nil(t, decoded["profit_factor"])
+
+	buf.Reset()
+	assert.NoError(t, vespyr.WriteBacktestResults(buf, vespyr.BacktestOutputCSV, results))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], "total_trades,profit_trades"))
+	assert.True(t, strings.HasPrefix(lines[1], "2,2,0"))
+
+	buf.Reset()
+	assert.NoError(t, vespyr.WriteBacktestResults(buf, vespyr.BacktestOutputText, results))
+	assert.Contains(t, buf.String(), "Ending budget: 110.000000\n")
+
+	_, err := vespyr.ParseBacktestOutputFormat("xml")
+	assert.Error(t, err)
+}
@@ -0,0 +1,202 @@
+package vespyr
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// NewBacktestRunModel returns the model to persist for a backtest of a
+// trading strategy model. Undefined ratios are stored as 0.
+func NewBacktestRunModel(model *TradingStrategyModel, startTime, endTime time.Time,
+	seed int64, objective Objective, results *BacktestResults) *BacktestRunModel {
+	if objective == nil {
+		objective = defaultObjective()
+	}
+
+	return &BacktestRunModel{
+		Product:             model.Product,
+		TickSizeMinutes:     model.TickSizeMinutes,
+		HistoryTicks:        model.HistoryTicks,
+		TradingStrategy:     model.TradingStrategy,
+		TradingStrategyData: model.TradingStrategyData,
+		StartTime:           startTime,
+		EndTime:             endTime,
+		Seed:                seed,
+		Objective:           objective.String(),
+		Score:               finiteOrZero(objective.Score(results)),
+		InitialBudget:       results.InitialBudget,
+		FinalBudget:         results.FinalBudget,
+		NetProfit:           finiteOrZero(results.NetProfit()),
+		ProfitFactor:        finiteOrZero(results.ProfitFactor()),
+		SharpeRatio:         finiteOrZero(results.SharpeRatio()),
+		MaxDrawdown:         finiteOrZero(results.MaxDrawdown()),
+		ProfitTrades:        results.ProfitTrades,
+		LossTrades:          results.LossTrades,
+		Equity:              results.PortfolioValuePerDay,
+		FillModel:           results.FillModel,
+	}
+}
+
+// WriteBacktestRuns writes a table summarizing backtest runs.
+func WriteBacktestRuns(w io.Writer, runs []*BacktestRunModel) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "id\tcreated_at\tsweep\tstrategy\tproduct\tstart_time\tend_time\tscore\ttrades\tnet_profit\tsharpe_ratio\tmax_drawdown")
+	for _, r := range runs {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%f\t%d\t%f\t%f\t%f\n",
+			r.ID,
+			r.CreatedAt.Format(time.RFC822),
+			r.SweepName,
+			r.TradingStrategy,
+			r.Product,
+			r.StartTime.Format(time.RFC822),
+			r.EndTime.Format(time.RFC822),
+			r.Score,
+			r.ProfitTrades+r.LossTrades,
+			r.NetProfit,
+			r.SharpeRatio,
+			r.MaxDrawdown,
+		)
+	}
+	return errors.Wrapf(tw.Flush(), "error writing backtest runs")
+}
+
+// WriteBacktestRun writes the details of a backtest run, including its
+// strategy YAML and trades.
+func WriteBacktestRun(w io.Writer, run *BacktestRunModel, trades []*BacktestTradeModel) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "ID:\t%d\n", run.ID)
+	fmt.Fprintf(tw, "Created at:\t%s\n", run.CreatedAt.Format(time.RFC822))
+	if run.SweepName != "" {
+		fmt.Fprintf(tw, "Sweep:\t%s\n", run.SweepName)
+	}
+	fmt.Fprintf(tw, "Product:\t%s\n", run.Product)
+	fmt.Fprintf(tw, "Tick size minutes:\t%d\n", run.TickSizeMinutes)
+	fmt.Fprintf(tw, "History ticks:\t%d\n", run.HistoryTicks)
+	fmt.Fprintf(tw, "Start time:\t%s\n", run.StartTime.Format(time.RFC822))
+	fmt.Fprintf(tw, "End time:\t%s\n", run.EndTime.Format(time.RFC822))
+	fmt.Fprintf(tw, "Seed:\t%d\n", run.Seed)
+	if run.FillModel != "" {
+		fmt.Fprintf(tw, "Fill model:\t%s\n", run.FillModel)
+	}
+	fmt.Fprintf(tw, "Objective:\t%s\n", run.Objective)
+	fmt.Fprintf(tw, "Score:\t%f\n", run.Score)
+	fmt.Fprintf(tw, "Starting budget:\t%f\n", run.InitialBudget)
+	fmt.Fprintf(tw, "Ending budget:\t%f\n", run.FinalBudget)
+	fmt.Fprintf(tw, "Net profit:\t%f\n", run.NetProfit)
+	fmt.Fprintf(tw, "Profit factor:\t%f\n", run.ProfitFactor)
+	fmt.Fprintf(tw, "Sharpe ratio:\t%f\n", run.SharpeRatio)
+	fmt.Fprintf(tw, "Max drawdown:\t%f\n", run.MaxDrawdown)
+	fmt.Fprintf(tw, "Profit trades:\t%d\n", run.ProfitTrades)
+	fmt.Fprintf(tw, "Loss trades:\t%d\n", run.LossTrades)
+	if err := tw.Flush(); err != nil {
+		return errors.Wrapf(err, "error writing backtest run")
+	}
+
+	fmt.Fprintf(w, "\nTrading strategy: %s\n", run.TradingStrategy)
+	fmt.Fprintf(w, "%s\n", strings.TrimSpace(string(run.TradingStrategyData)))
+
+	if len(trades) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(w, "\nTrades:")
+	tw = tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "time\tside\tprice\tcost\tfilled_size\tfees")
+	for _, t := range trades {
+		fmt.Fprintf(tw, "%s\t%s\t%f\t%f %s\t%f %s\t%f %s\n",
+			t.Time.Format(time.RFC822),
+			t.Side,
+			t.Price,
+			t.Cost, t.CostCurrency,
+			t.FilledSize, t.SizeCurrency,
+			t.Fees, t.FeesCurrency,
+		)
+	}
+	return errors.Wrapf(tw.Flush(), "error writing backtest trades")
+}
+
+// WriteBacktestRunComparison writes the metrics of backtest runs side
+// by side, followed by the strategy parameters that differ between
+// them.
+func WriteBacktestRunComparison(w io.Writer, runs []*BacktestRunModel) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+
+	row := func(label string, value func(*BacktestRunModel) string) {
+		fields := []string{label}
+		for _, r := range runs {
+			fields = append(fields, value(r))
+		}
+		fmt.Fprintln(tw, strings.Join(fields, "\t"))
+	}
+
+	row("id", func(r *BacktestRunModel) string { return fmt.Sprintf("%d", r.ID) })
+	row("strategy", func(r *BacktestRunModel) string { return r.TradingStrategy })
+	row("product", func(r *BacktestRunModel) string { return string(r.Product) })
+	row("start_time", func(r *BacktestRunModel) string { return r.StartTime.Format(time.RFC822) })
+	row("end_time", func(r *BacktestRunModel) string { return r.EndTime.Format(time.RFC822) })
+	row("fill_model", func(r *BacktestRunModel) string { return r.FillModel })
+	row("objective", func(r *BacktestRunModel) string { return r.Objective })
+	row("score", func(r *BacktestRunModel) string { return fmt.Sprintf("%f", r.Score) })
+	row("trades", func(r *BacktestRunModel) string { return fmt.Sprintf("%d", r.ProfitTrades+r.LossTrades) })
+	row("final_budget", func(r *BacktestRunModel) string { return fmt.Sprintf("%f", r.FinalBudget) })
+	row("net_profit", func(r *BacktestRunModel) string { return fmt.Sprintf("%f", r.NetProfit) })
+	row("profit_factor", func(r *BacktestRunModel) string { return fmt.Sprintf("%f", r.ProfitFactor) })
+	row("sharpe_ratio", func(r *BacktestRunModel) string { return fmt.Sprintf("%f", r.SharpeRatio) })
+	row("max_drawdown", func(r *BacktestRunModel) string { return fmt.Sprintf("%f", r.MaxDrawdown) })
+
+	params, names, err := backtestRunParameters(runs)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		row(name, func(r *BacktestRunModel) string { return params[r.ID][name] })
+	}
+
+	return errors.Wrapf(tw.Flush(), "error writing backtest run comparison")
+}
+
+// backtestRunParameters decodes the strategy data of runs, returning
+// each run's parameters keyed by run ID and the sorted names of the
+// parameters whose values differ.
+func backtestRunParameters(runs []*BacktestRunModel) (map[int64]map[string]string, []string, error) {
+	params := make(map[int64]map[string]string)
+	values := make(map[string]map[string]bool)
+	for _, r := range runs {
+		var data map[string]interface{}
+		if err := yaml.Unmarshal(r.TradingStrategyData, &data); err != nil {
+			return nil, nil, errors.Wrapf(err, "error decoding strategy data of run %d", r.ID)
+		}
+		params[r.ID] = make(map[string]string)
+		for name, v := range data {
+			value := fmt.Sprintf("%v", v)
+			params[r.ID][name] = value
+			if values[name] == nil {
+				values[name] = make(map[string]bool)
+			}
+			values[name][value] = true
+		}
+	}
+
+	var names []string
+	for name, seen := range values {
+		differs := len(seen) > 1
+		for _, r := range runs {
+			if _, ok := params[r.ID][name]; !ok {
+				differs = true
+			}
+		}
+		if differs {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return params, names, nil
+}
@@ -0,0 +1,77 @@
+package vespyr_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DavidHuie/vespyr/pkg/vespyr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBacktestRunTradingStrategyModel(t *testing.T) {
+	run := &vespyr.BacktestRunModel{
+		Product:             vespyr.ProductBTCUSD,
+		TickSizeMinutes:     15,
+		HistoryTicks:        500,
+		TradingStrategy:     vespyr.TradingStrategyEMACrossover,
+		TradingStrategyData: []byte("short_period: 3\nlong_period: 9\n"),
+	}
+
+	now := time.Date(2018, 1, 1, 10, 7, 0, 0, time.UTC)
+	model, err := run.TradingStrategyModel(250, now)
+	assert.NoError(t, err)
+	assert.True(t, time.Date(2018, 1, 1, 10, 15, 0, 0, time.UTC).Equal(model.NextTickAt))
+	assert.Equal(t, float64(250), model.InitialBudget)
+	assert.Equal(t, float64(250), model.Budget)
+	assert.Equal(t, vespyr.StrategyStateTryingToBuy, model.State)
+	assert.Equal(t, uint(500), model.HistoryTicks)
+
+	strategy, err := model.Strategy()
+	assert.NoError(t, err)
+	assert.Equal(t, uint(3), strategy.(*vespyr.EMACrossoverStrategy).ShortPeriod)
+	assert.Equal(t, uint(9), strategy.(*vespyr.EMACrossoverStrategy).LongPeriod)
+
+	run.Product = vespyr.Product("unknown")
+	_, err = run.TradingStrategyModel(250, now)
+	assert.Error(t, err)
+}
+
+func TestWriteBacktestRuns(t *testing.T) {
+	runs := []*vespyr.BacktestRunModel{
+		{
+			ID:                  1,
+			TradingStrategy:     vespyr.TradingStrategyEMACrossover,
+			TradingStrategyData: []byte("short_period: 3\nlong_period: 9\n"),
+			Score:               1.5,
+			ProfitTrades:        2,
+			LossTrades:          1,
+		},
+		{
+			ID:                  2,
+			TradingStrategy:     vespyr.TradingStrategyEMACrossover,
+			TradingStrategyData: []byte("short_period: 3\nlong_period: 12\n"),
+			Score:               0.5,
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, vespyr.WriteBacktestRuns(buf, runs))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[1], "1 "))
+
+	buf.Reset()
+	assert.NoError(t, vespyr.WriteBacktestRunComparison(buf, runs))
+	assert.Contains(t, buf.String(), "long_period")
+	assert.NotContains(t, buf.String(), "short_period")
+
+	buf.Reset()
+	trades := []*vespyr.BacktestTradeModel{
+		{Side: vespyr.OrderBuy, Price: 100, Cost: 10, CostCurrency: vespyr.CurrencyUSD},
+	}
+	assert.NoError(t, vespyr.WriteBacktestRun(buf, runs[0], trades))
+	assert.Contains(t, buf.String(), "long_period: 9")
+	assert.Contains(t, buf.String(), "Trades:")
+}
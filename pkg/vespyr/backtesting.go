@@ -43,10 +43,10 @@ func (b *BacktesterBackend) FindCandlesticks(startTime, endTime time.Time,
 	return b.Backend.FindCandlesticks(startTime, endTime, p, tickSizeMinutes)
 }
 
-// CreateMarketOrder registers the market order as the current market
-// order.
+// CreateMarketOrder registers the market order as one of the current
+// tick's market orders.
 func (b *BacktesterBackend) CreateMarketOrder(m *MarketOrderModel) error {
-	b.resultCalculator.current.marketOrder = m
+	b.resultCalculator.current.marketOrders = append(b.resultCalculator.current.marketOrders, m)
 	return nil
 }
 
@@ -54,26 +54,66 @@ func (b *BacktesterBackend) CreateMarketOrder(m *MarketOrderModel) error {
 func (b *BacktesterBackend) UpdateTradingStrategy(m *TradingStrategyModel) error {
 	b.resultCalculator.current.budget = m.Budget
 	b.resultCalculator.current.invested = m.Invested
+	b.resultCalculator.current.investedProduct = m.HeldProduct()
 	return nil
 }
 
 // BacktesterExchange is a mock exchange that can perform mock trades.
 type BacktesterExchange struct {
 	candles             []*CandlestickModel
+	pairCandles         map[Product][]*CandlestickModel
 	marketOrderSlippage float64
 	current             int
 	randSource          rand.Source
+	fillModel           FillModel
+	fills               []*backtesterFill
+}
+
+// backtesterFill records the value of a filled order for fee tiers.
+type backtesterFill struct {
+	time  time.Time
+	value float64
 }
 
 // NewBacktesterExchange instantiates a new backtester exchange.
 func NewBacktesterExchange(candles []*CandlestickModel, marketOrderSlippage float64,
 	randSource rand.Source) *BacktesterExchange {
+	fillModel := DefaultFillModel()
+	fillModel.Slippage = marketOrderSlippage
 	return &BacktesterExchange{
 		marketOrderSlippage: marketOrderSlippage,
 		candles:             candles,
 		current:             -1,
 		randSource:          randSource,
+		fillModel:           fillModel,
+	}
+}
+
+// SetFillModel sets the model that orders are filled with.
+func (b *BacktesterExchange) SetFillModel(m FillModel) {
+	b.fillModel = m
+}
+
+// SetPairCandlesticks sets the candlesticks that orders for a second
+// product are filled with. They must be aligned with the exchange's
+// candlesticks.
+func (b *BacktesterExchange) SetPairCandlesticks(p Product, candles []*CandlestickModel) {
+	if b.pairCandles == nil {
+		b.pairCandles = make(map[Product][]*CandlestickModel)
 	}
+	b.pairCandles[p] = candles
+}
+
+// volume30Day returns the value of the orders filled in the 30 days
+// before a time.
+func (b *BacktesterExchange) volume30Day(t time.Time) float64 {
+	var volume float64
+	for _, f := range b.fills {
+		if t.Sub(f.time) <= feeVolumePeriod {
+			volume += f.value
+		}
+	}
+	return volume
 }
 
 // NextTick advances the exchange to the next candle.
@@ -99,6 +139,12 @@ func (k *BacktesterExchange) EmitsFullCandlesticks() bool {
 	panic("not implemented")
 }
 
+// SupportsHistoricalCandlesticks returns false since the backtester
+// can't fetch candlesticks.
+func (k *BacktesterExchange) SupportsHistoricalCandlesticks() bool {
+	return false
+}
+
 // CalculatePriceWithSlippage calculates a price taking into account
 // slippage.
 func CalculatePriceWithSlippage(source rand.Source, price, orderSlippage float64) float64 {
@@ -107,29 +153,50 @@ func CalculatePriceWithSlippage(source rand.Source, price, orderSlippage float64
 	return price * (1 - slippage)
 }
 
-// CreateMarketOrder creates a mock market order taking into account
-// a slippage percentage.
+// CreateMarketOrder creates a mock market order filled by the
+// exchange's fill model.
 func (b *BacktesterExchange) CreateMarketOrder(m *MarketOrder) (*CreateMarketOrderResponse, error) {
-	price := CalculatePriceWithSlippage(b.randSource, b.candles[b.current].Close,
-		b.marketOrderSlippage)
+	candles := b.candles
+	if pairCandles, ok := b.pairCandles[m.Product]; ok {
+		candles = pairCandles
+	}
+	candle := candles[b.current]
+	fill, err := b.fillModel.Fill(&FillRequest{
+		Order:       m,
+		Candles:     candles,
+		Current:     b.current,
+		Volume30Day: b.volume30Day(candle.StartTime),
+		Source:      b.randSource,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "error filling market order")
+	}
+	price := fill.Price
 
 	response := &CreateMarketOrderResponse{
 		ExchangeID: uuid.NewV4().String(),
 	}
 
-	logrus.Infof("exchange price: %f", b.candles[b.current].Close)
+	logrus.Infof("exchange price: %f", candle.Close)
+
+	tradeCurrency, budgetCurrency := CurrencyBTC, CurrencyUSD
+	if metadata, ok := ProductToMetadata[m.Product]; ok {
+		tradeCurrency, budgetCurrency = metadata.MarketOrderSellCurrency, metadata.MarketOrderBuyCurrency
+	}
 
 	if m.Side == OrderBuy {
-		response.FilledSize = m.Cost * (1 - exchangeFee) / price
-		response.FilledSizeCurrency = CurrencyBTC
-		response.Fees = m.Cost * exchangeFee
-		response.FeesCurrency = CurrencyUSD
+		response.FilledSize = m.Cost * (1 - fill.FeeRate) / price
+		response.FilledSizeCurrency = tradeCurrency
+		response.Fees = m.Cost * fill.FeeRate
+		response.FeesCurrency = budgetCurrency
+		b.fills = append(b.fills, &backtesterFill{candle.StartTime, m.Cost})
 	} else {
 		response.FilledSize = m.Cost * price
-		response.FilledSizeCurrency = CurrencyUSD
-		response.Fees = response.FilledSize * exchangeFee
-		response.FeesCurrency = CurrencyUSD
-		response.FilledSize = response.FilledSize * (1 - exchangeFee)
+		response.FilledSizeCurrency = budgetCurrency
+		response.Fees = response.FilledSize * fill.FeeRate
+		response.FeesCurrency = budgetCurrency
+		b.fills = append(b.fills, &backtesterFill{candle.StartTime, response.FilledSize})
+		response.FilledSize = response.FilledSize * (1 - fill.FeeRate)
 	}
 
 	return response, nil
@@ -144,6 +211,8 @@ type Backtester struct {
 	backend          *BacktesterBackend
 	resultCalculator *backtestResultCalculator
 	source           rand.Source
+	indicatorCache   *IndicatorCache
+	fillModel        FillModel
 }
 
 // NewBacktester creates a new Backtester.
@@ -166,10 +235,31 @@ func NewBacktester(startTime, endTime time.Time, model *TradingStrategyModel,
 	}, nil
 }
 
-func generateIndicatorSets(indicators []Indicator, candles []*CandlestickModel) ([]*IndicatorSet, []*CandlestickModel, error) {
+// generateIndicatorSets calculates the indicators over the
+// candlesticks, returning the indicator sets and candlesticks of the
+// ticks where every indicator has a value. PairIndicators are given
+// the candlesticks of their pair product from pairCandles.
+func generateIndicatorSets(indicators []Indicator, candles []*CandlestickModel,
+	pairCandles map[Product][]*CandlestickModel,
+	cache *IndicatorCache) ([]*IndicatorSet, []*CandlestickModel, error) {
+	series := make([][]indicatorPoint, len(indicators))
+	for i, indicator := range indicators {
+		var points []indicatorPoint
+		var err error
+		if pair, ok := indicator.(PairIndicator); ok {
+			points, err = cache.pairSeries(pair, candles, pairCandles[pair.PairProduct()])
+		} else {
+			points, err = cache.series(indicator, candles)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		series[i] = points
+	}
+
 	var indicatorSets []*IndicatorSet
 	var validCandles []*CandlestickModel
-	for _, c := range candles {
+	for i, c := range candles {
 		if c.Volume == 0 {
 			continue
 		}
@@ -177,19 +267,11 @@ func generateIndicatorSets(indicators []Indicator, candles []*CandlestickModel)
 		var set IndicatorSet
 		set.Time = c.StartTime
 		skipSet := false
-		for _, indicator := range indicators {
-			if err := indicator.AddCandlestick(c); err != nil {
-				return nil, nil, errors.Wrapf(err, "error adding candlestick to indicator: %s", indicator.Name())
+		for _, points := range series {
+			if !points[i].ok {
+				skipSet = true
 			}
-			value, err := indicator.Value()
-			if err != nil {
-				if errors.Cause(err) == ErrNotEnoughData {
-					skipSet = true
-				} else {
-					return nil, nil, errors.Wrapf(err, "error calculating value for indicator: %s", indicator.Name())
-				}
-			}
-			set.Values = append(set.Values, value)
+			set.Values = append(set.Values, points[i].value)
 		}
 		if !skipSet {
 			indicatorSets = append(indicatorSets, &set)
@@ -199,6 +281,19 @@ func generateIndicatorSets(indicators []Indicator, candles []*CandlestickModel)
 	return indicatorSets, validCandles, nil
 }
 
+// SetIndicatorCache sets a cache that indicator values are shared
+// through. Backtests that share a cache should use a CacheingBackend
+// so that they backtest the same candlesticks.
+func (b *Backtester) SetIndicatorCache(c *IndicatorCache) {
+	b.indicatorCache = c
+}
+
+// SetFillModel sets the model that orders are filled with. Backtests
+// fill at the candle close by default.
+func (b *Backtester) SetFillModel(m FillModel) {
+	b.fillModel = m
+}
+
 // Backtest performs the backtest.
 func (b *Backtester) Backtest() error {
 	logrus.Debugf("starting backtest")
@@ -214,12 +309,37 @@ func (b *Backtester) Backtest() error {
 		return errors.Wrapf(err, "error finding candlesticks")
 	}
 
+	pairCandles := make(map[Product][]*CandlestickModel)
+	pair, isPair := b.strategy.(PairStrategyInterface)
+	if isPair {
+		if pair.PairProduct() == b.model.Product {
+			return errors.Errorf("error: %s can't be traded against itself", b.model.Product)
+		}
+		pairCandles[pair.PairProduct()], err = b.backend.FindCandlesticks(actualStartTime, b.endTime,
+			pair.PairProduct(), int64(b.model.TickSizeMinutes))
+		if err != nil {
+			return errors.Wrapf(err, "error finding %s candlesticks", pair.PairProduct())
+		}
+	}
+
 	logrus.Debugf("generating indicator sets")
-	indicatorSets, validCandles, err := generateIndicatorSets(indicators, candles)
+	indicatorSets, validCandles, err := generateIndicatorSets(indicators, candles, pairCandles, b.indicatorCache)
 	if err != nil {
 		return errors.Wrapf(err, "error generating indicator sets")
 	}
 	exchange := NewBacktesterExchange(validCandles, exchangeSlippage, b.source)
+	if b.fillModel != nil {
+		exchange.SetFillModel(b.fillModel)
+	}
+
+	var validPairCandles []*CandlestickModel
+	if isPair {
+		validPairCandles, err = alignCandlesticks(validCandles, pairCandles[pair.PairProduct()])
+		if err != nil {
+			return errors.Wrapf(err, "error aligning %s candlesticks", pair.PairProduct())
+		}
+		exchange.SetPairCandlesticks(pair.PairProduct(), validPairCandles)
+	}
 
 	trader := NewTradingStrategy(b.backend,
 		exchange, b.strategy, clockwork.NewRealClock())
@@ -229,9 +349,13 @@ func (b *Backtester) Backtest() error {
 	for i, sets := range indicatorSets {
 		b.resultCalculator.current.indicatorSet = sets
 		b.resultCalculator.current.candle = validCandles[i]
+		if isPair {
+			b.resultCalculator.current.pairCandle = validPairCandles[i]
+		}
 		b.resultCalculator.current.time = validCandles[i].StartTime
 		b.resultCalculator.current.budget = b.model.Budget
 		b.resultCalculator.current.invested = b.model.Invested
+		b.resultCalculator.current.investedProduct = b.model.HeldProduct()
 
 		trader.nextTick()
 		exchange.NextTick()
@@ -255,6 +379,25 @@ func (b *Backtester) Backtest() error {
 	return nil
 }
 
+// alignCandlesticks returns the candlestick of another product with
+// the same start time as each candlestick.
+func alignCandlesticks(candles, others []*CandlestickModel) ([]*CandlestickModel, error) {
+	byTime := make(map[time.Time]*CandlestickModel)
+	for _, c := range others {
+		byTime[c.StartTime.UTC()] = c
+	}
+
+	aligned := make([]*CandlestickModel, len(candles))
+	for i, c := range candles {
+		other, ok := byTime[c.StartTime.UTC()]
+		if !ok {
+			return nil, errors.Errorf("error: missing candlestick at %s", c.StartTime)
+		}
+		aligned[i] = other
+	}
+	return aligned, nil
+}
+
 // BacktestResults contains information about the results of the
 // backtest.
 type BacktestResults struct {
@@ -269,6 +412,25 @@ type BacktestResults struct {
 	ProfitTrades         uint
 	LossTrades           uint
 	PortfolioValuePerDay []float64
+	// FinalPortfolioValue is the value of the budget and any held
+	// position at the last tick.
+	FinalPortfolioValue float64
+	FillModel           string
+	// Days is the time that each daily portfolio value was sampled
+	// at, the first tick of a day, so each value is the one that
+	// closed the previous day. BenchmarkValuePerDay is the value
+	// that buying and holding the trade currency had at the same
+	// times.
+	Days                 []time.Time
+	BenchmarkValuePerDay []float64
+	// Ticks is the number of candlesticks backtested, and
+	// TicksInMarket is how many of them ended with the budget
+	// invested.
+	Ticks         uint
+	TicksInMarket uint
+	// HoldingTimes is how long each trade was held, from buy to
+	// sell.
+	HoldingTimes []time.Duration
 }
 
 // https://www.mql5.com/en/articles/1486
@@ -296,22 +458,85 @@ func (b *BacktestResults) SharpeRatio() float64 {
 
 	mean, err := stats.Mean(increases)
 	if err != nil {
-		panic(err)
+		return math.NaN()
 	}
 	stddev, err := stats.StandardDeviation(increases)
 	if err != nil {
-		panic(err)
+		return math.NaN()
 	}
 
 	return math.Pow(365, .5) * mean / stddev
 }
 
+// SortinoRatio is like the Sharpe ratio but only penalizes downside
+// volatility.
+func (b *BacktestResults) SortinoRatio() float64 {
+	increases := []float64{0}
+	for i := 1; i < len(b.PortfolioValuePerDay); i++ {
+		increases = append(increases, b.PortfolioValuePerDay[i]-b.PortfolioValuePerDay[i-1])
+	}
+
+	mean, err := stats.Mean(increases)
+	if err != nil {
+		return math.NaN()
+	}
+
+	var downside float64
+	for _, i := range increases {
+		if i < 0 {
+			downside += i * i
+		}
+	}
+	downside = math.Sqrt(downside / float64(len(increases)))
+
+	return math.Pow(365, .5) * mean / downside
+}
+
+// MaxDrawdown returns the largest fractional decline of the daily
+// portfolio value from a previous peak.
+func (b *BacktestResults) MaxDrawdown() float64 {
+	peak := b.InitialBudget
+	var drawdown float64
+	for _, v := range b.PortfolioValuePerDay {
+		if v > peak {
+			peak = v
+		}
+		if peak > 0 && (peak-v)/peak > drawdown {
+			drawdown = (peak - v) / peak
+		}
+	}
+	return drawdown
+}
+
+// AnnualizedReturn returns the compound annual growth rate of the
+// daily portfolio value.
+func (b *BacktestResults) AnnualizedReturn() float64 {
+	days := len(b.PortfolioValuePerDay)
+	if days == 0 || b.InitialBudget == 0 {
+		return 0
+	}
+	final := b.PortfolioValuePerDay[days-1]
+	return math.Pow(final/b.InitialBudget, 365/float64(days)) - 1
+}
+
+// CalmarRatio returns the annualized return divided by the maximum
+// drawdown.
+func (b *BacktestResults) CalmarRatio() float64 {
+	return b.AnnualizedReturn() / b.MaxDrawdown()
+}
+
 // Results returns the results of the backtest.
 func (b *Backtester) Results() *BacktestResults {
 	results := &BacktestResults{
 		InitialBudget:  b.model.InitialBudget,
 		BudgetCurrency: b.model.BudgetCurrency,
 		TradeCurrency:  b.model.InvestedCurrency,
+		FillModel:      DefaultFillModel().String(),
+		// Nothing changes the budget without ticks.
+		FinalPortfolioValue: b.model.InitialBudget,
+	}
+	if b.fillModel != nil {
+		results.FillModel = b.fillModel.String()
 	}
 	if len(b.resultCalculator.results) == 0 {
 		return results
@@ -319,24 +544,75 @@ func (b *Backtester) Results() *BacktestResults {
 
 	currentDay := b.resultCalculator.results[0].candle.StartTime
 	portfolioValue := b.model.InitialBudget
+	benchmarkValue := b.model.InitialBudget
+	var benchmarkSize float64
+
+	// The position is tracked by its size and what it cost so that
+	// level strategies, which sell parts of it, are credited with the
+	// profit of each part. Strategies that invest everything at once
+	// sell the whole position, which cost the budget they bought it
+	// with.
+	cash := b.model.InitialBudget
 	currentBudget := b.model.InitialBudget
+	var positionSize, positionCost float64
+	var boughtAt time.Time
 	for _, result := range b.resultCalculator.results {
-		if result.budget != 0 {
-			portfolioValue = result.budget
-		} else {
-			portfolioValue = result.invested * result.candle.Close * (1 - exchangeFee - exchangeSlippage)
+		portfolioValue = result.budget
+		if result.invested > 0 {
+			portfolioValue += result.invested * result.price(result.investedProduct) *
+				(1 - exchangeFee - exchangeSlippage)
+		}
+
+		// The benchmark buys the trade currency with the whole
+		// budget on the first tick of the backtest.
+		if !result.candle.StartTime.Before(b.startTime) {
+			if benchmarkSize == 0 && result.candle.Close > 0 {
+				benchmarkSize = b.model.InitialBudget * (1 - exchangeFee) / result.candle.Close
+			}
+			benchmarkValue = benchmarkSize * result.candle.Close * (1 - exchangeFee - exchangeSlippage)
+
+			results.Ticks++
+			if result.invested > 0 {
+				results.TicksInMarket++
+			}
 		}
 
 		if currentDay.After(b.startTime) &&
 			result.candle.StartTime.After(currentDay) &&
 			result.candle.StartTime.Weekday() != currentDay.Weekday() {
 			results.PortfolioValuePerDay = append(results.PortfolioValuePerDay, portfolioValue)
+			results.BenchmarkValuePerDay = append(results.BenchmarkValuePerDay, benchmarkValue)
+			results.Days = append(results.Days, result.candle.StartTime)
 		}
 
 		currentDay = result.candle.StartTime
 
-		if result.marketOrder != nil && result.marketOrder.Side == OrderSell {
-			diff := result.marketOrder.FilledSize - currentBudget
+		for _, order := range result.marketOrders {
+			if order.Side == OrderBuy {
+				if positionSize == 0 {
+					boughtAt = result.time
+				}
+				positionCost += order.Cost
+				positionSize += TruncateFloat(order.FilledSize, tradeCurrencyPrecision)
+				cash -= order.Cost
+				if results.InitialCurrencyPrice == 0 {
+					results.InitialCurrencyPrice = result.price(order.Product)
+				}
+				continue
+			}
+
+			// A position that wasn't bought during the backtest
+			// cost the budget.
+			cost := currentBudget
+			fraction := float64(1)
+			if positionSize > 0 {
+				fraction = math.Min(order.Cost/positionSize, 1)
+				cost = positionCost * fraction
+			} else {
+				cash -= cost
+			}
+
+			diff := order.FilledSize - cost
 			if diff >= 0 {
 				results.GrossProfit += diff
 				results.ProfitTrades++
@@ -344,21 +620,35 @@ func (b *Backtester) Results() *BacktestResults {
 				results.GrossLoss += -diff
 				results.LossTrades++
 			}
-			currentBudget = TruncateFloat(result.marketOrder.FilledSize, tradeCurrencyPrecision)
-		}
-		if result.marketOrder != nil && result.marketOrder.Side == OrderBuy &&
-			results.InitialCurrencyPrice == 0 {
-			results.InitialCurrencyPrice = result.candle.Close
-		}
-		if result.marketOrder != nil && result.marketOrder.Side == OrderSell {
-			results.FinalBudget = TruncateFloat(result.marketOrder.FilledSize, tradeCurrencyPrecision)
-			results.FinalCurrencyPrice = result.candle.Close
+
+			positionCost -= cost
+			positionSize = TruncateFloat(positionSize-order.Cost, tradeCurrencyPrecision)
+			if fraction == 1 || positionSize <= 0 {
+				positionCost, positionSize = 0, 0
+			}
+			if !boughtAt.IsZero() {
+				results.HoldingTimes = append(results.HoldingTimes, result.time.Sub(boughtAt))
+				if positionSize == 0 {
+					boughtAt = time.Time{}
+				}
+			}
+
+			cash += TruncateFloat(order.FilledSize, tradeCurrencyPrecision)
+			currentBudget = TruncateFloat(order.FilledSize, tradeCurrencyPrecision)
+			results.FinalBudget = cash
+			results.FinalCurrencyPrice = result.price(order.Product)
 		}
 	}
+	results.FinalPortfolioValue = portfolioValue
 
 	return results
 }
 
+// Trades returns the market orders made during the backtest.
+func (b *Backtester) Trades() []*BacktestTradeModel {
+	return b.resultCalculator.trades()
+}
+
 // ResultsCSV returns a CSV with the granular details about the
 // backtest
 func (b *Backtester) ResultsCSV() (io.Reader, error) {
@@ -366,12 +656,24 @@ func (b *Backtester) ResultsCSV() (io.Reader, error) {
 }
 
 type backtestResult struct {
-	time         time.Time
-	candle       *CandlestickModel
-	indicatorSet *IndicatorSet
-	marketOrder  *MarketOrderModel
-	budget       float64
-	invested     float64
+	time   time.Time
+	candle *CandlestickModel
+	// pairCandle is the pair product's candlestick for pair
+	// strategies.
+	pairCandle      *CandlestickModel
+	indicatorSet    *IndicatorSet
+	marketOrders    []*MarketOrderModel
+	budget          float64
+	invested        float64
+	investedProduct Product
+}
+
+// price returns a product's closing price at the result's tick.
+func (r *backtestResult) price(p Product) float64 {
+	if r.pairCandle != nil && p == r.pairCandle.Product {
+		return r.pairCandle.Close
+	}
+	return r.candle.Close
 }
 
 type backtestResultCalculator struct {
@@ -405,8 +707,9 @@ func (b *backtestResultCalculator) resultsCSV() (io.Reader, error) {
 		"budget",
 		"invested",
 	}
-	for _, set := range firstRow.indicatorSet.Values {
-		columns = append(columns, set.IndicatorName)
+	for _, value := range firstRow.indicatorSet.Values {
+		names, _ := value.columns()
+		columns = append(columns, names...)
 	}
 
 	if err := writer.Write(columns); err != nil {
@@ -424,26 +727,24 @@ func (b *backtestResultCalculator) resultsCSV() (io.Reader, error) {
 			fmt.Sprintf("%f", result.candle.Volume),
 		}
 
-		if result.marketOrder != nil {
-			if result.marketOrder.Side == OrderBuy {
-				row = append(row, fmt.Sprintf("%f", result.marketOrder.FilledSize))
+		var boughtSize, soldSize string
+		for _, order := range result.marketOrders {
+			if order.Side == OrderBuy {
+				boughtSize = fmt.Sprintf("%f", order.FilledSize)
 			} else {
-				row = append(row, "")
+				soldSize = fmt.Sprintf("%f", order.FilledSize)
 			}
-			if result.marketOrder.Side == OrderSell {
-				row = append(row, fmt.Sprintf("%f", result.marketOrder.FilledSize))
-			} else {
-				row = append(row, "")
-			}
-		} else {
-			row = append(row, "", "")
 		}
+		row = append(row, boughtSize, soldSize)
 
 		row = append(row, fmt.Sprintf("%f", result.budget))
 		row = append(row, fmt.Sprintf("%f", result.invested))
 
-		for _, set := range result.indicatorSet.Values {
-			row = append(row, fmt.Sprintf("%f", set.Value))
+		for _, value := range result.indicatorSet.Values {
+			_, values := value.columns()
+			for _, v := range values {
+				row = append(row, fmt.Sprintf("%f", v))
+			}
 		}
 
 		if err := writer.Write(row); err != nil {
@@ -459,6 +760,44 @@ func (b *backtestResultCalculator) resultsCSV() (io.Reader, error) {
 	return buf, nil
 }
 
+// fillPrice returns the price that an order was filled at before
+// fees, which differs from the candle's close under most fill models.
+// Buys spend their cost on their filled size and fees, and sells
+// receive their filled size once fees are taken from the proceeds of
+// selling their cost.
+func fillPrice(order *MarketOrderModel) float64 {
+	if order.Side == OrderBuy {
+		if order.FilledSize == 0 {
+			return 0
+		}
+		return (order.Cost - order.Fees) / order.FilledSize
+	}
+	if order.Cost == 0 {
+		return 0
+	}
+	return (order.FilledSize + order.Fees) / order.Cost
+}
+
+func (b *backtestResultCalculator) trades() []*BacktestTradeModel {
+	var trades []*BacktestTradeModel
+	for _, result := range b.results {
+		for _, order := range result.marketOrders {
+			trades = append(trades, &BacktestTradeModel{
+				Time:         result.candle.StartTime,
+				Side:         order.Side,
+				Price:        fillPrice(order),
+				Cost:         order.Cost,
+				CostCurrency: order.CostCurrency,
+				FilledSize:   order.FilledSize,
+				SizeCurrency: order.SizeCurrency,
+				Fees:         order.Fees,
+				FeesCurrency: order.FeesCurrency,
+			})
+		}
+	}
+	return trades
+}
+
 func (b *backtestResultCalculator) next() {
 	b.results = append(b.results, b.current)
 	b.current = &backtestResult{}
@@ -1,6 +1,7 @@
 package vespyr_test
 
 import (
+	"encoding/csv"
 	"math/rand"
 	"testing"
 	"time"
@@ -35,8 +36,10 @@ func TestBacktesterExchangeCreateMarketOrder(t *testing.T) {
 			Side:    vespyr.OrderBuy,
 			Cost:    8800,
 		})
+		// Slippage raises the price, so less than 8778/4400 is
+		// bought.
 		if assert.NoError(t, err) {
-			assert.Equal(t, float64(2.057195212480436), response.FilledSize)
+			assert.Equal(t, float64(1.9364551243427646), response.FilledSize)
 			assert.Equal(t, float64(22), response.Fees)
 		}
 	})
@@ -117,3 +120,49 @@ func TestBacktest(t *testing.T) {
 		LossTrades:           1,
 	}, results)
 }
+
+func TestBacktesterResultsCSVComponents(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(2 * 24 * time.Hour)
+
+	backend := new(vespyr.MockBackend)
+	backend.On("FindCandlesticks", mock.Anything, end, vespyr.ProductBTCUSD, int64(360)).
+		Return(portfolioTestCandles(vespyr.ProductBTCUSD, start, []float64{
+			100, 101, 103, 106, 104, 101, 98, 97,
+		}), nil)
+
+	model := portfolioTestModel(t, vespyr.ProductBTCUSD)
+	assert.NoError(t, model.SetStrategy(&vespyr.RSIBollingerStrategy{
+		RSIPeriod:             2,
+		RSIBuyThreshold:       30,
+		RSISellThreshold:      70,
+		BollingerPeriod:       3,
+		BollingerDeviations:   2,
+		PercentBBuyThreshold:  0,
+		PercentBSellThreshold: 1,
+	}))
+	model.InitialBudget = 1000
+	model.Budget = 1000
+
+	backtester, err := vespyr.NewBacktester(start, end, model, backend, rand.NewSource(1))
+	assert.NoError(t, err)
+	assert.NoError(t, backtester.Backtest())
+
+	reader, err := backtester.ResultsCSV()
+	assert.NoError(t, err)
+	rows, err := csv.NewReader(reader).ReadAll()
+	assert.NoError(t, err)
+	if assert.True(t, len(rows) > 1) {
+		header := rows[0]
+		assert.Contains(t, header, "rsi-2")
+		for _, component := range []string{
+			vespyr.BollingerMiddle, vespyr.BollingerUpper, vespyr.BollingerLower,
+			vespyr.BollingerPercentB, vespyr.BollingerBandwidth,
+		} {
+			assert.Contains(t, header, "bollinger-3-2."+component)
+		}
+		for _, row := range rows[1:] {
+			assert.Len(t, row, len(header))
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package vespyr
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// BollingerMiddle is the simple moving average at the center
+	// of the bands, which is the indicator's primary value.
+	BollingerMiddle = "middle"
+	// BollingerUpper is the upper band.
+	BollingerUpper = "upper"
+	// BollingerLower is the lower band.
+	BollingerLower = "lower"
+	// BollingerPercentB is where the last price sits within the
+	// bands: 0 at the lower band and 1 at the upper band.
+	BollingerPercentB = "percent-b"
+	// BollingerBandwidth is the distance between the bands as a
+	// fraction of the middle band.
+	BollingerBandwidth = "bandwidth"
+)
+
+// BollingerBands are the values of the Bollinger indicator after a
+// candlestick.
+type BollingerBands struct {
+	Middle    float64
+	Upper     float64
+	Lower     float64
+	PercentB  float64
+	Bandwidth float64
+}
+
+// BollingerIndicator calculates Bollinger Bands: a simple moving
+// average surrounded by bands a number of standard deviations above
+// and below it. Every band is a component of the indicator's value.
+type BollingerIndicator struct {
+	period     uint
+	deviations float64
+	prices     []float64
+	lastTime   time.Time
+}
+
+// NewBollingerIndicator returns a new BollingerIndicator.
+func NewBollingerIndicator(period uint, deviations float64) *BollingerIndicator {
+	return &BollingerIndicator{
+		period:     period,
+		deviations: deviations,
+	}
+}
+
+// AddCandlestick adds a candlestick to the indicator.
+func (b *BollingerIndicator) AddCandlestick(c *CandlestickModel) error {
+	if c.Volume == 0 {
+		return nil
+	}
+
+	price, err := c.MeanPrice()
+	if err != nil {
+		return errors.Wrapf(err, "error calculating mean price")
+	}
+
+	b.prices = append(b.prices, price)
+	if uint(len(b.prices)) > b.period {
+		b.prices = b.prices[1:]
+	}
+	b.lastTime = c.StartTime
+
+	return nil
+}
+
+// Name returns the name of the indicator.
+func (b *BollingerIndicator) Name() string {
+	return fmt.Sprintf("%s-%d-%g", IndicatorBollinger, b.period, b.deviations)
+}
+
+// Bands returns every component of the last calculated bands.
+func (b *BollingerIndicator) Bands() (*BollingerBands, error) {
+	if b.period == 0 || uint(len(b.prices)) < b.period {
+		return nil, ErrNotEnoughData
+	}
+
+	var mean float64
+	for _, p := range b.prices {
+		mean += p
+	}
+	mean /= float64(b.period)
+
+	var variance float64
+	for _, p := range b.prices {
+		variance += (p - mean) * (p - mean)
+	}
+	stddev := math.Sqrt(variance / float64(b.period))
+
+	bands := &BollingerBands{
+		Middle: mean,
+		Upper:  mean + b.deviations*stddev,
+		Lower:  mean - b.deviations*stddev,
+	}
+
+	// Prices that haven't moved put the last price in the middle
+	// of bands with no width.
+	bands.PercentB = .5
+	if width := bands.Upper - bands.Lower; width > 0 {
+		bands.PercentB = (b.prices[len(b.prices)-1] - bands.Lower) / width
+	}
+	if mean != 0 {
+		bands.Bandwidth = (bands.Upper - bands.Lower) / mean
+	}
+
+	return bands, nil
+}
+
+// Value returns the last calculated bands.
+func (b *BollingerIndicator) Value() (*IndicatorValue, error) {
+	bands, err := b.Bands()
+	if err != nil {
+		return nil, err
+	}
+
+	return &IndicatorValue{
+		Time:          b.lastTime,
+		Value:         bands.Middle,
+		IndicatorName: b.Name(),
+		Components: []*IndicatorComponent{
+			{Name: BollingerMiddle, Value: bands.Middle},
+			{Name: BollingerUpper, Value: bands.Upper},
+			{Name: BollingerLower, Value: bands.Lower},
+			{Name: BollingerPercentB, Value: bands.PercentB},
+			{Name: BollingerBandwidth, Value: bands.Bandwidth},
+		},
+	}, nil
+}
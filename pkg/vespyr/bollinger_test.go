@@ -0,0 +1,78 @@
+package vespyr_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/DavidHuie/vespyr/pkg/vespyr"
+	"github.com/stretchr/testify/assert"
+)
+
+func priceCandlestick(price float64) *vespyr.CandlestickModel {
+	return &vespyr.CandlestickModel{
+		StartTime: time.Now(),
+		Open:      price,
+		High:      price,
+		Low:       price,
+		Close:     price,
+		Volume:    1,
+	}
+}
+
+func TestBollingerIndicator(t *testing.T) {
+	b := vespyr.NewBollingerIndicator(3, 2)
+	assert.Equal(t, "bollinger-3-2", b.Name())
+
+	for _, p := range []float64{1, 2} {
+		assert.NoError(t, b.AddCandlestick(priceCandlestick(p)))
+		_, err := b.Value()
+		assert.Equal(t, vespyr.ErrNotEnoughData, err)
+	}
+
+	// Candlesticks without volume are skipped.
+	assert.NoError(t, b.AddCandlestick(&vespyr.CandlestickModel{Close: 100}))
+	assert.NoError(t, b.AddCandlestick(priceCandlestick(3)))
+
+	stddev := math.Sqrt(2.0 / 3)
+	bands, err := b.Bands()
+	assert.NoError(t, err)
+	assert.InDelta(t, 2, bands.Middle, 1e-9)
+	assert.InDelta(t, 2+2*stddev, bands.Upper, 1e-9)
+	assert.InDelta(t, 2-2*stddev, bands.Lower, 1e-9)
+	assert.InDelta(t, (1+2*stddev)/(4*stddev), bands.PercentB, 1e-9)
+	assert.InDelta(t, 2*stddev, bands.Bandwidth, 1e-9)
+
+	value, err := b.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, bands.Middle, value.Value)
+	assert.Equal(t, b.Name(), value.IndicatorName)
+	for component, expected := range map[string]float64{
+		vespyr.BollingerMiddle:    bands.Middle,
+		vespyr.BollingerUpper:     bands.Upper,
+		vespyr.BollingerLower:     bands.Lower,
+		vespyr.BollingerPercentB:  bands.PercentB,
+		vespyr.BollingerBandwidth: bands.Bandwidth,
+	} {
+		actual, err := value.Component(component)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, actual, component)
+	}
+
+	// The window only keeps the last period's prices.
+	assert.NoError(t, b.AddCandlestick(priceCandlestick(4)))
+	bands, err = b.Bands()
+	assert.NoError(t, err)
+	assert.InDelta(t, 3, bands.Middle, 1e-9)
+}
+
+func TestBollingerIndicatorFlatPrices(t *testing.T) {
+	b := vespyr.NewBollingerIndicator(2, 2)
+	assert.NoError(t, b.AddCandlestick(priceCandlestick(5)))
+	assert.NoError(t, b.AddCandlestick(priceCandlestick(5)))
+
+	bands, err := b.Bands()
+	assert.NoError(t, err)
+	assert.Equal(t, .5, bands.PercentB)
+	assert.Equal(t, float64(0), bands.Bandwidth)
+}
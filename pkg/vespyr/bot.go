@@ -99,15 +99,8 @@ func (b *Bot) initializeStrategies(t time.Time) error {
 		historyStart := t.Add(-time.Duration(strategy.HistoryTicks) *
 			time.Duration(strategy.TickSizeMinutes) * time.Minute)
 
-		candles, err := b.backend.FindCandlesticks(historyStart, t,
-			strategy.Product, int64(strategy.TickSizeMinutes))
-		if err != nil {
-			return errors.Wrapf(err, "error finding candlesticks for strategy")
-		}
-		for _, candle := range candles {
-			if err := service.SeedIndicators(candle); err != nil {
-				return errors.Wrapf(err, "error seeding strategy indicators")
-			}
+		if err := b.seedIndicators(strategy, service, historyStart, t); err != nil {
+			return errors.Wrapf(err, "error seeding strategy")
 		}
 
 		b.tradingStrategyModels = append(b.tradingStrategyModels, strategy)
@@ -149,15 +142,8 @@ func (b *Bot) processTick(model *TradingStrategyModel, service *TradingStrategy,
 		t.After(service.LastCandlestickTime()) &&
 		// Ensure that we're processing the complete tick, not a partial one.
 		uint(t.Sub(service.LastCandlestickTime()).Minutes())%model.TickSizeMinutes == 0 {
-		candles, err := b.backend.FindCandlesticks(service.LastCandlestickTime(), t,
-			model.Product, int64(model.TickSizeMinutes))
-		if err != nil {
-			return errors.Wrapf(err, "error finding candlesticks")
-		}
-		for _, c := range candles {
-			if err := service.SeedIndicators(c); err != nil {
-				return errors.Wrapf(err, "error seeding strategy indicators")
-			}
+		if err := b.seedIndicators(model, service, service.LastCandlestickTime(), t); err != nil {
+			return err
 		}
 	}
 
@@ -197,3 +183,39 @@ Reason: not enough data`, model.ID, service.strategy)
 
 	return nil
 }
+
+// seedIndicators seeds a strategy's indicators with the candlesticks
+// between two times. Pair strategies are seeded with the pair
+// product's candlestick for each tick before the strategy product's.
+func (b *Bot) seedIndicators(model *TradingStrategyModel, service *TradingStrategy,
+	start, end time.Time) error {
+	candles, err := b.backend.FindCandlesticks(start, end,
+		model.Product, int64(model.TickSizeMinutes))
+	if err != nil {
+		return errors.Wrapf(err, "error finding candlesticks")
+	}
+
+	pairCandles := make(map[time.Time]*CandlestickModel)
+	if pair, ok := service.strategy.(PairStrategyInterface); ok {
+		candles, err := b.backend.FindCandlesticks(start, end,
+			pair.PairProduct(), int64(model.TickSizeMinutes))
+		if err != nil {
+			return errors.Wrapf(err, "error finding %s candlesticks", pair.PairProduct())
+		}
+		for _, c := range candles {
+			pairCandles[c.StartTime.UTC()] = c
+		}
+	}
+
+	for _, c := range candles {
+		if pairCandle, ok := pairCandles[c.StartTime.UTC()]; ok {
+			if err := service.SeedPairCandlestick(pairCandle); err != nil {
+				return errors.Wrapf(err, "error seeding strategy indicators")
+			}
+		}
+		if err := service.SeedIndicators(c); err != nil {
+			return errors.Wrapf(err, "error seeding strategy indicators")
+		}
+	}
+	return nil
+}
@@ -6,38 +6,46 @@ import (
 	"time"
 )
 
+// CacheingBackend caches candlestick lookups so that repeated
+// backtests over the same range only query the underlying backend
+// once. It's safe for concurrent use.
 type CacheingBackend struct {
 	Backend
 	sync.Mutex
-	cache map[string]interface{}
+	cache map[string]*cacheingBackendEntry
+}
+
+type cacheingBackendEntry struct {
+	once   sync.Once
+	models []*CandlestickModel
+	err    error
 }
 
 func NewCacheingBackend(b Backend) Backend {
 	return &CacheingBackend{
 		Backend: b,
-		cache:   make(map[string]interface{}),
+		cache:   make(map[string]*cacheingBackendEntry),
 	}
 }
 
+// FindCandlesticks returns cached candlesticks. Concurrent calls for
+// the same range wait for a single lookup, while lookups for different
+// ranges run in parallel.
 func (c *CacheingBackend) FindCandlesticks(s time.Time, e time.Time,
 	p Product, t int64) ([]*CandlestickModel, error) {
 	key := fmt.Sprintf("find-candlesticks-%s-%s-%s-%d", s, e, p, t)
-	type responseStruct struct {
-		models []*CandlestickModel
-		err    error
-	}
-
-	if value, ok := c.cache[key]; ok {
-		response := value.(*responseStruct)
-		return response.models, response.err
-	}
 
 	c.Lock()
-	defer c.Unlock()
+	entry, ok := c.cache[key]
+	if !ok {
+		entry = &cacheingBackendEntry{}
+		c.cache[key] = entry
+	}
+	c.Unlock()
 
-	candles, err := c.Backend.FindCandlesticks(s, e, p, t)
-	response := &responseStruct{candles, err}
-	c.cache[key] = response
+	entry.once.Do(func() {
+		entry.models, entry.err = c.Backend.FindCandlesticks(s, e, p, t)
+	})
 
-	return response.models, response.err
+	return entry.models, entry.err
 }
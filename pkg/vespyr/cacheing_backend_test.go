@@ -0,0 +1,36 @@
+package vespyr_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DavidHuie/vespyr/pkg/vespyr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCacheingBackendConcurrentLookups(t *testing.T) {
+	endTime := time.Now()
+	startTime := endTime.Add(-time.Hour)
+	candles := []*vespyr.CandlestickModel{{Close: 10, Volume: 1}}
+
+	backend := new(vespyr.MockBackend)
+	backend.On("FindCandlesticks", startTime, endTime, vespyr.ProductBTCUSD, int64(15)).
+		Return(candles, nil).Once()
+	defer mock.AssertExpectationsForObjects(t, backend)
+
+	cache := vespyr.NewCacheingBackend(backend)
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			found, err := cache.FindCandlesticks(startTime, endTime, vespyr.ProductBTCUSD, 15)
+			assert.NoError(t, err)
+			assert.Equal(t, candles, found)
+		}()
+	}
+	wg.Wait()
+}
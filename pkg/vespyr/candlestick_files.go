@@ -0,0 +1,277 @@
+package vespyr
+
+import (
+	"encoding/csv"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// CandlestickFileFormat is a file format that candlesticks can be
+// imported from and exported to.
+type CandlestickFileFormat string
+
+const (
+	// CandlestickFileFormatCSV stores candlesticks as CSV with a
+	// header row.
+	CandlestickFileFormatCSV CandlestickFileFormat = "csv"
+	// CandlestickFileFormatParquet stores candlesticks as a
+	// Parquet table.
+	CandlestickFileFormatParquet CandlestickFileFormat = "parquet"
+)
+
+var candlestickFileColumns = []string{
+	"start_time",
+	"end_time",
+	"product",
+	"low",
+	"high",
+	"open",
+	"close",
+	"volume",
+	"direction",
+}
+
+// ParseCandlestickFileFormat parses a format name. When the name is
+// empty the format is inferred from the path's extension.
+func ParseCandlestickFileFormat(name, path string) (CandlestickFileFormat, error) {
+	if name == "" {
+		name = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+	switch CandlestickFileFormat(strings.ToLower(name)) {
+	case CandlestickFileFormatCSV:
+		return CandlestickFileFormatCSV, nil
+	case CandlestickFileFormatParquet:
+		return CandlestickFileFormatParquet, nil
+	}
+	return "", errors.Errorf("error: unknown candlestick file format %q", name)
+}
+
+// WriteCandlesticks writes candlesticks in a file format.
+func WriteCandlesticks(w io.Writer, format CandlestickFileFormat, candles []*CandlestickModel) error {
+	switch format {
+	case CandlestickFileFormatCSV:
+		return WriteCandlesticksCSV(w, candles)
+	case CandlestickFileFormatParquet:
+		return WriteCandlesticksParquet(w, candles)
+	}
+	return errors.Errorf("error: unknown candlestick file format %q", format)
+}
+
+// ReadCandlesticks reads candlesticks in a file format.
+func ReadCandlesticks(r io.Reader, format CandlestickFileFormat) ([]*CandlestickModel, error) {
+	switch format {
+	case CandlestickFileFormatCSV:
+		return ReadCandlesticksCSV(r)
+	case CandlestickFileFormatParquet:
+		return ReadCandlesticksParquet(r)
+	}
+	return nil, errors.Errorf("error: unknown candlestick file format %q", format)
+}
+
+func formatCandlestickFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// WriteCandlesticksCSV writes candlesticks as CSV.
+func WriteCandlesticksCSV(w io.Writer, candles []*CandlestickModel) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(candlestickFileColumns); err != nil {
+		return errors.Wrapf(err, "error writing to csv writer")
+	}
+
+	for _, c := range candles {
+		row := []string{
+			c.StartTime.UTC().Format(time.RFC3339),
+			c.EndTime.UTC().Format(time.RFC3339),
+			string(c.Product),
+			formatCandlestickFloat(c.Low),
+			formatCandlestickFloat(c.High),
+			formatCandlestickFloat(c.Open),
+			formatCandlestickFloat(c.Close),
+			formatCandlestickFloat(c.Volume),
+			string(c.Direction),
+		}
+		if err := writer.Write(row); err != nil {
+			return errors.Wrapf(err, "error writing csv row")
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return errors.Wrapf(err, "error writing to csv")
+	}
+
+	return nil
+}
+
+// ReadCandlesticksCSV reads candlesticks written by
+// WriteCandlesticksCSV. Columns are matched by the header row, so
+// they may appear in any order.
+func ReadCandlesticksCSV(r io.Reader) ([]*CandlestickModel, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading csv header")
+	}
+
+	index := make(map[string]int)
+	for i, name := range header {
+		index[strings.TrimSpace(name)] = i
+	}
+	for _, name := range candlestickFileColumns {
+		if _, ok := index[name]; !ok {
+			return nil, errors.Errorf("error: csv is missing column %s", name)
+		}
+	}
+
+	var candles []*CandlestickModel
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading csv row")
+		}
+
+		c := &CandlestickModel{
+			Product:   Product(row[index["product"]]),
+			Direction: CandlestickDirection(row[index["direction"]]),
+		}
+		if c.StartTime, err = time.Parse(time.RFC3339, row[index["start_time"]]); err != nil {
+			return nil, errors.Wrapf(err, "error parsing start_time on line %d", line)
+		}
+		if c.EndTime, err = time.Parse(time.RFC3339, row[index["end_time"]]); err != nil {
+			return nil, errors.Wrapf(err, "error parsing end_time on line %d", line)
+		}
+		for name, f := range map[string]*float64{
+			"low":    &c.Low,
+			"high":   &c.High,
+			"open":   &c.Open,
+			"close":  &c.Close,
+			"volume": &c.Volume,
+		} {
+			if *f, err = strconv.ParseFloat(row[index[name]], 64); err != nil {
+				return nil, errors.Wrapf(err, "error parsing %s on line %d", name, line)
+			}
+		}
+
+		candles = append(candles, c)
+	}
+
+	return candles, nil
+}
+
+// WriteCandlesticksParquet writes candlesticks as a Parquet table.
+// Times are stored as millisecond timestamps.
+func WriteCandlesticksParquet(w io.Writer, candles []*CandlestickModel) error {
+	columns := []*parquetColumn{
+		{name: "start_time", physicalType: parquetTypeInt64, convertedType: parquetConvertedTimestampMillis},
+		{name: "end_time", physicalType: parquetTypeInt64, convertedType: parquetConvertedTimestampMillis},
+		{name: "product", physicalType: parquetTypeByteArray, convertedType: parquetConvertedUTF8},
+		{name: "low", physicalType: parquetTypeDouble, convertedType: -1},
+		{name: "high", physicalType: parquetTypeDouble, convertedType: -1},
+		{name: "open", physicalType: parquetTypeDouble, convertedType: -1},
+		{name: "close", physicalType: parquetTypeDouble, convertedType: -1},
+		{name: "volume", physicalType: parquetTypeDouble, convertedType: -1},
+		{name: "direction", physicalType: parquetTypeByteArray, convertedType: parquetConvertedUTF8},
+	}
+
+	for _, c := range candles {
+		columns[0].int64s = append(columns[0].int64s, c.StartTime.UnixNano()/int64(time.Millisecond))
+		columns[1].int64s = append(columns[1].int64s, c.EndTime.UnixNano()/int64(time.Millisecond))
+		columns[2].strings = append(columns[2].strings, string(c.Product))
+		columns[3].doubles = append(columns[3].doubles, c.Low)
+		columns[4].doubles = append(columns[4].doubles, c.High)
+		columns[5].doubles = append(columns[5].doubles, c.Open)
+		columns[6].doubles = append(columns[6].doubles, c.Close)
+		columns[7].doubles = append(columns[7].doubles, c.Volume)
+		columns[8].strings = append(columns[8].strings, string(c.Direction))
+	}
+
+	return writeParquet(w, columns)
+}
+
+// ReadCandlesticksParquet reads candlesticks written by
+// WriteCandlesticksParquet, or by other tools such as pyarrow and
+// pandas with the same columns. Times are milliseconds since the
+// epoch unless the columns are timestamps with another unit.
+func ReadCandlesticksParquet(r io.Reader) ([]*CandlestickModel, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading parquet file")
+	}
+
+	columns, err := readParquet(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error decoding parquet file")
+	}
+
+	byName := make(map[string]*parquetColumn)
+	for _, c := range columns {
+		byName[c.name] = c
+	}
+	expectedTypes := map[string]int32{
+		"start_time": parquetTypeInt64,
+		"end_time":   parquetTypeInt64,
+		"product":    parquetTypeByteArray,
+		"low":        parquetTypeDouble,
+		"high":       parquetTypeDouble,
+		"open":       parquetTypeDouble,
+		"close":      parquetTypeDouble,
+		"volume":     parquetTypeDouble,
+		"direction":  parquetTypeByteArray,
+	}
+	for _, name := range candlestickFileColumns {
+		c, ok := byName[name]
+		if !ok {
+			return nil, errors.Errorf("error: parquet file is missing column %s", name)
+		}
+		if c.physicalType != expectedTypes[name] {
+			return nil, errors.Errorf("error: parquet column %s has unexpected type %d", name, c.physicalType)
+		}
+		if c.len() != byName["start_time"].len() {
+			return nil, errors.Errorf("error: parquet column %s has %d rows, expected %d",
+				name, c.len(), byName["start_time"].len())
+		}
+	}
+
+	var candles []*CandlestickModel
+	for i := range byName["start_time"].int64s {
+		candles = append(candles, &CandlestickModel{
+			StartTime: time.Unix(0, byName["start_time"].int64s[i]*int64(byName["start_time"].timeUnit)).UTC(),
+			EndTime:   time.Unix(0, byName["end_time"].int64s[i]*int64(byName["end_time"].timeUnit)).UTC(),
+			Product:   Product(byName["product"].strings[i]),
+			Low:       byName["low"].doubles[i],
+			High:      byName["high"].doubles[i],
+			Open:      byName["open"].doubles[i],
+			Close:     byName["close"].doubles[i],
+			Volume:    byName["volume"].doubles[i],
+			Direction: CandlestickDirection(byName["direction"].strings[i]),
+		})
+	}
+
+	return candles, nil
+}
+
+// FilterCandlesticks returns the candlesticks for a product that
+// start within [start, end). An empty product matches every product.
+func FilterCandlesticks(candles []*CandlestickModel, product Product, start, end time.Time) []*CandlestickModel {
+	var filtered []*CandlestickModel
+	for _, c := range candles {
+		if product != "" && c.Product != product {
+			continue
+		}
+		if c.StartTime.Before(start) || !c.StartTime.Before(end) {
+			continue
+		}
+		filtered = append(filtered, c)
+	}
+	return filtered
+}
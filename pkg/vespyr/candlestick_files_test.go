@@ -0,0 +1,84 @@
+package vespyr_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/DavidHuie/vespyr/pkg/vespyr"
+	"github.com/stretchr/testify/assert"
+)
+
+func testFileCandlesticks() []*vespyr.CandlestickModel {
+	start := vespyr.CandlestickBucket(time.Now(), 1).UTC()
+	return []*vespyr.CandlestickModel{
+		{
+			StartTime: start,
+			EndTime:   start.Add(time.Minute),
+			Product:   vespyr.ProductBTCUSD,
+			Low:       10.5,
+			High:      20.25,
+			Open:      12,
+			Close:     15.125,
+			Volume:    0.00012345,
+			Direction: vespyr.CandlestickDirectionUp,
+		},
+		{
+			StartTime: start.Add(time.Minute),
+			EndTime:   start.Add(2 * time.Minute),
+			Product:   vespyr.ProductETHUSD,
+			Low:       1,
+			High:      2,
+			Open:      2,
+			Close:     1,
+			Volume:    100,
+			Direction: vespyr.CandlestickDirectionDown,
+		},
+	}
+}
+
+func TestCandlestickFilesRoundTrip(t *testing.T) {
+	candles := testFileCandlesticks()
+
+	for _, format := range []vespyr.CandlestickFileFormat{
+		vespyr.CandlestickFileFormatCSV,
+		vespyr.CandlestickFileFormatParquet,
+	} {
+		buf := &bytes.Buffer{}
+		assert.NoError(t, vespyr.WriteCandlesticks(buf, format, candles))
+
+		read, err := vespyr.ReadCandlesticks(buf, format)
+		assert.NoError(t, err)
+		assert.Equal(t, candles, read, string(format))
+	}
+}
+
+func TestReadCandlesticksParquetInvalid(t *testing.T) {
+	_, err := vespyr.ReadCandlesticksParquet(bytes.NewBufferString("start_time,end_time"))
+	assert.Error(t, err)
+}
+
+func TestParseCandlestickFileFormat(t *testing.T) {
+	format, err := vespyr.ParseCandlestickFileFormat("", "candles.parquet")
+	assert.NoError(t, err)
+	assert.Equal(t, vespyr.CandlestickFileFormatParquet, format)
+
+	format, err = vespyr.ParseCandlestickFileFormat("CSV", "candles.parquet")
+	assert.NoError(t, err)
+	assert.Equal(t, vespyr.CandlestickFileFormatCSV, format)
+
+	_, err = vespyr.ParseCandlestickFileFormat("", "candles.txt")
+	assert.Error(t, err)
+}
+
+func TestFilterCandlesticks(t *testing.T) {
+	candles := testFileCandlesticks()
+
+	filtered := vespyr.FilterCandlesticks(candles, vespyr.ProductETHUSD,
+		candles[0].StartTime, candles[1].EndTime)
+	assert.Equal(t, candles[1:], filtered)
+
+	filtered = vespyr.FilterCandlesticks(candles, "",
+		candles[0].StartTime, candles[1].StartTime)
+	assert.Equal(t, candles[:1], filtered)
+}
@@ -0,0 +1,137 @@
+package vespyr
+
+import (
+	"context"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// CandlestickGap is a contiguous range of minute buckets that are
+// missing from the backend.
+type CandlestickGap struct {
+	Product   Product
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+// Minutes returns the number of minute buckets covered by the gap.
+func (c *CandlestickGap) Minutes() int {
+	return int(c.EndTime.Sub(c.StartTime).Minutes())
+}
+
+// CandlestickGaps groups a sorted list of missing minute bucket start
+// times into contiguous gaps.
+func CandlestickGaps(product Product, missing []time.Time) []*CandlestickGap {
+	var gaps []*CandlestickGap
+	var current *CandlestickGap
+	for _, t := range missing {
+		if current != nil && current.EndTime.Equal(t) {
+			current.EndTime = t.Add(time.Minute)
+			continue
+		}
+		current = &CandlestickGap{
+			Product:   product,
+			StartTime: t,
+			EndTime:   t.Add(time.Minute),
+		}
+		gaps = append(gaps, current)
+	}
+	return gaps
+}
+
+// CandlestickRepairer locates missing candlesticks in the backend and
+// backfills them from an exchange using a HistoricalImporter.
+type CandlestickRepairer struct {
+	product  Product
+	backend  Backend
+	importer *HistoricalImporter
+	clock    clockwork.Clock
+}
+
+// NewCandlestickRepairer creates a new CandlestickRepairer.
+func NewCandlestickRepairer(product Product, b Backend, importer *HistoricalImporter,
+	clock clockwork.Clock) *CandlestickRepairer {
+	return &CandlestickRepairer{
+		product:  product,
+		backend:  b,
+		importer: importer,
+		clock:    clock,
+	}
+}
+
+// FindGaps returns the gaps in the backend's candlesticks within a
+// time range.
+func (c *CandlestickRepairer) FindGaps(start, end time.Time) ([]*CandlestickGap, error) {
+	missing, err := c.backend.FindMissingCandlestickTimes(c.product, start, end)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error finding missing candlestick times")
+	}
+	return CandlestickGaps(c.product, missing), nil
+}
+
+// Repair backfills all gaps within a time range, returning the gaps
+// that still exist afterwards. Gaps can remain when the exchange has
+// no trades for a period.
+func (c *CandlestickRepairer) Repair(start, end time.Time) ([]*CandlestickGap, error) {
+	gaps, err := c.FindGaps(start, end)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error finding gaps")
+	}
+	if len(gaps) == 0 {
+		return nil, nil
+	}
+
+	logrus.Infof("backfilling %d %s candlestick gaps between %s and %s",
+		len(gaps), c.product, start, end)
+
+	// Merge gaps that fit within a single import batch so that
+	// we don't request the same window from the exchange more
+	// than once.
+	batchStart := gaps[0].StartTime
+	batchEnd := gaps[0].EndTime
+	for _, gap := range gaps[1:] {
+		if gap.EndTime.Sub(batchStart) <= importerBatchSize*time.Minute {
+			batchEnd = gap.EndTime
+			continue
+		}
+		c.importer.Import(batchStart, batchEnd.Add(-time.Minute))
+		batchStart, batchEnd = gap.StartTime, gap.EndTime
+	}
+	c.importer.Import(batchStart, batchEnd.Add(-time.Minute))
+
+	remaining, err := c.FindGaps(start, end)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error finding gaps after backfill")
+	}
+
+	return remaining, nil
+}
+
+// Run periodically repairs the trailing lookback window until the
+// context is cancelled.
+func (c *CandlestickRepairer) Run(ctx context.Context, interval, lookback time.Duration) {
+	logrus.Printf("starting candlestick repairer: %s", c.product)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		// Skip the current minute since it's still being
+		// imported.
+		end := CandlestickBucket(c.clock.Now(), dbCandlestickBucketSize).Add(-time.Minute)
+		remaining, err := c.Repair(end.Add(-lookback), end)
+		if err != nil {
+			logrus.WithError(err).Errorf("error repairing %s candlesticks", c.product)
+		} else if len(remaining) > 0 {
+			logrus.Debugf("%d %s candlestick gaps remain after repair", len(remaining), c.product)
+		}
+
+		c.clock.Sleep(interval)
+	}
+}
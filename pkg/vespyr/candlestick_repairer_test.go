@@ -0,0 +1,85 @@
+package vespyr_test
+
+import (
+	"testing"
+	"time"
+
+	coinbase "github.com/DavidHuie/go-coinbase-exchange"
+	"github.com/DavidHuie/vespyr/pkg/vespyr"
+	"github.com/google/go-cmp/cmp"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCandlestickGaps(t *testing.T) {
+	start := vespyr.CandlestickBucket(time.Now(), 1)
+
+	gaps := vespyr.CandlestickGaps(vespyr.ProductBTCUSD, []time.Time{
+		start,
+		start.Add(time.Minute),
+		start.Add(2 * time.Minute),
+		start.Add(10 * time.Minute),
+	})
+
+	assert.Equal(t, []*vespyr.CandlestickGap{
+		{
+			Product:   vespyr.ProductBTCUSD,
+			StartTime: start,
+			EndTime:   start.Add(3 * time.Minute),
+		},
+		{
+			Product:   vespyr.ProductBTCUSD,
+			StartTime: start.Add(10 * time.Minute),
+			EndTime:   start.Add(11 * time.Minute),
+		},
+	}, gaps)
+	assert.Equal(t, 3, gaps[0].Minutes())
+	assert.Nil(t, vespyr.CandlestickGaps(vespyr.ProductBTCUSD, nil))
+}
+
+func TestCandlestickRepairerRepair(t *testing.T) {
+	gdaxClient := new(vespyr.MockGDAXClient)
+	gdax := vespyr.NewGDAXExchange(gdaxClient, clockwork.NewFakeClock())
+	backend := new(vespyr.MockBackend)
+	importer := vespyr.NewHistoricalImporter(vespyr.ProductBTCUSD, gdax, backend, 1)
+	repairer := vespyr.NewCandlestickRepairer(vespyr.ProductBTCUSD, backend,
+		importer, clockwork.NewFakeClock())
+
+	defer mock.AssertExpectationsForObjects(t, gdaxClient, backend)
+
+	start := vespyr.CandlestickBucket(time.Now().Add(-time.Hour), 1)
+	end := start.Add(time.Hour)
+	missing := start.Add(5 * time.Minute)
+
+	backend.On("FindMissingCandlestickTimes", vespyr.ProductBTCUSD, start, end).
+		Return([]time.Time{missing}, nil).Once()
+	backend.On("FindMissingCandlestickTimes", vespyr.ProductBTCUSD, start, end).
+		Return(nil, nil).Once()
+
+	gdaxClient.On("GetHistoricRates", string(vespyr.ProductBTCUSD), mock.MatchedBy(func(c coinbase.GetHistoricRatesParams) bool {
+		return cmp.Equal(c, coinbase.GetHistoricRatesParams{
+			Start:       missing,
+			End:         missing.Add(150 * time.Minute),
+			Granularity: 60,
+		})
+	})).Return([]coinbase.HistoricRate{
+		{Time: missing, Low: 10, High: 20, Open: 12, Close: 15, Volume: 1},
+	}, nil)
+
+	backend.On("UpsertCandlestick", &vespyr.CandlestickModel{
+		StartTime: missing,
+		EndTime:   missing.Add(time.Minute),
+		Low:       10,
+		High:      20,
+		Open:      12,
+		Close:     15,
+		Volume:    1,
+		Direction: vespyr.CandlestickDirectionUp,
+		Product:   vespyr.ProductBTCUSD,
+	}).Return(nil).Once()
+
+	remaining, err := repairer.Repair(start, end)
+	assert.NoError(t, err)
+	assert.Empty(t, remaining)
+}
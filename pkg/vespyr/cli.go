@@ -9,16 +9,19 @@ import (
 
 	coinbase "github.com/DavidHuie/go-coinbase-exchange"
 	"github.com/DavidHuie/kraken-go-api-client"
-	"github.com/MaxHalford/gago"
 	"github.com/go-pg/pg"
 	"github.com/heroku/rollrus"
 	_ "github.com/mattes/migrate/database/postgres"
 	"github.com/nlopes/slack"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
 	"io"
+	"io/ioutil"
 
 	"os/exec"
+	"runtime"
+	"strconv"
 
 	"math/rand"
 
@@ -40,6 +43,7 @@ var RootCmd = &cobra.Command{
 
 func defineCommands() {
 	func() {
+		var repairInterval, repairLookback time.Duration
 		realtimeImport := &cobra.Command{
 			Use:   "realtime-import",
 			Short: "import data in realtime",
@@ -53,27 +57,273 @@ func defineCommands() {
 				wg := &sync.WaitGroup{}
 
 				for product := range ProductToMetadata {
-					var exchange Exchange
-					if ProductToMetadata[product].ExchangeType == ExchangeKraken {
-						exchange = runner.KrakenExchange
-					} else {
-						exchange = runner.GDAXExchange
-					}
-					importer := NewRealtimeImporter(product, runner.Backend, exchange)
+					importer := NewRealtimeImporter(product, runner.Backend, runner.Exchange(product))
 
 					wg.Add(1)
 					go func() {
 						defer wg.Done()
 						importer.Start(time.Second)
 					}()
+
+					if repairInterval == 0 {
+						continue
+					}
+					historicalImporter, err := runner.HistoricalImporter(product)
+					if err != nil {
+						logrus.Infof("not repairing %s candlesticks: %s", product, err)
+						continue
+					}
+					repairer := NewCandlestickRepairer(product, runner.Backend,
+						historicalImporter, clockwork.NewRealClock())
+
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						repairer.Run(context.Background(), repairInterval, repairLookback)
+					}()
 				}
 
 				wg.Wait()
 			},
 		}
+		realtimeImport.Flags().DurationVar(&repairInterval, "repair-interval", 15*time.Minute, "how often to backfill missing candlesticks, 0 disables repairs")
+		realtimeImport.Flags().DurationVar(&repairLookback, "repair-lookback", 6*time.Hour, "how far back to look for missing candlesticks")
 		RootCmd.AddCommand(realtimeImport)
 	}()
 
+	func() {
+		var startTime, endTime string
+		var product string
+		repair := &cobra.Command{
+			Use:   "repair-candles",
+			Short: "backfill missing candlesticks",
+			Run: func(cmd *cobra.Command, _ []string) {
+				runner, err := GetRunner()
+				if err != nil {
+					fmt.Printf("error getting runner: %s", err)
+					os.Exit(1)
+				}
+
+				s, err := time.Parse(time.RFC822, startTime)
+				if err != nil {
+					fmt.Printf("error parsing start time: %s", err)
+					os.Exit(1)
+				}
+				e, err := time.Parse(time.RFC822, endTime)
+				if err != nil {
+					fmt.Printf("error parsing end time: %s", err)
+					os.Exit(1)
+				}
+
+				historicalImporter, err := runner.HistoricalImporter(Product(product))
+				if err != nil {
+					fmt.Printf("error getting historical importer: %s\n", err)
+					os.Exit(1)
+				}
+				repairer := NewCandlestickRepairer(Product(product), runner.Backend,
+					historicalImporter, clockwork.NewRealClock())
+
+				gaps, err := repairer.FindGaps(s, e)
+				if err != nil {
+					fmt.Printf("error finding gaps: %s\n", err)
+					os.Exit(1)
+				}
+				fmt.Printf("Gaps found: %d\n", len(gaps))
+
+				remaining, err := repairer.Repair(s, e)
+				if err != nil {
+					fmt.Printf("error repairing candlesticks: %s\n", err)
+					os.Exit(1)
+				}
+
+				missing := 0
+				for _, gap := range remaining {
+					missing += gap.Minutes()
+					fmt.Printf("  unfilled: %s - %s\n", gap.StartTime.Format(time.RFC3339), gap.EndTime.Format(time.RFC3339))
+				}
+				fmt.Printf("Gaps remaining: %d (%d minutes)\n", len(remaining), missing)
+			},
+		}
+
+		repair.Flags().StringVar(&startTime, "start-time", time.Now().Add(-24*time.Hour).Format(time.RFC822), "the start of the range to repair")
+		repair.Flags().StringVar(&endTime, "end-time", time.Now().Format(time.RFC822), "the end of the range to repair")
+		repair.Flags().StringVar(&product, "product", string(ProductBTCUSD), "the product to repair")
+		RootCmd.AddCommand(repair)
+	}()
+
+	func() {
+		candles := &cobra.Command{
+			Use:   "candles",
+			Short: "import and export candlesticks",
+		}
+
+		var startTime, endTime string
+		var product, format, file string
+		var tickSizeMinutes int64
+		export := &cobra.Command{
+			Use:   "export",
+			Short: "export candlesticks to a CSV or Parquet file",
+			Run: func(cmd *cobra.Command, _ []string) {
+				runner, err := GetRunner()
+				if err != nil {
+					fmt.Printf("error getting runner: %s", err)
+					os.Exit(1)
+				}
+
+				if _, ok := ProductToMetadata[Product(product)]; !ok {
+					fmt.Println("unknown product: ", product)
+					os.Exit(1)
+				}
+				s, err := time.Parse(time.RFC822, startTime)
+				if err != nil {
+					fmt.Printf("error parsing start time: %s", err)
+					os.Exit(1)
+				}
+				e, err := time.Parse(time.RFC822, endTime)
+				if err != nil {
+					fmt.Printf("error parsing end time: %s", err)
+					os.Exit(1)
+				}
+				f, err := ParseCandlestickFileFormat(format, file)
+				if err != nil {
+					fmt.Printf("error parsing format: %s\n", err)
+					os.Exit(1)
+				}
+
+				found, err := runner.Backend.FindCandlesticks(s, e, Product(product), tickSizeMinutes)
+				if err != nil {
+					fmt.Printf("error finding candlesticks: %s\n", err)
+					os.Exit(1)
+				}
+
+				// Reprojection fills empty buckets with zero
+				// valued candlesticks, which shouldn't be
+				// exported.
+				var export []*CandlestickModel
+				for _, c := range found {
+					if c.Volume == 0 && c.Open == 0 && c.Close == 0 {
+						continue
+					}
+					export = append(export, c)
+				}
+
+				out, err := os.Create(file)
+				if err != nil {
+					fmt.Printf("error creating file: %s\n", err)
+					os.Exit(1)
+				}
+				if err := WriteCandlesticks(out, f, export); err != nil {
+					fmt.Printf("error writing candlesticks: %s\n", err)
+					os.Exit(1)
+				}
+				if err := out.Close(); err != nil {
+					fmt.Printf("error closing file: %s\n", err)
+					os.Exit(1)
+				}
+
+				fmt.Printf("Exported %d candlesticks to %s\n", len(export), file)
+			},
+		}
+		export.Flags().StringVar(&startTime, "start-time", time.Now().Add(-24*time.Hour).Format(time.RFC822), "the start of the range to export")
+		export.Flags().StringVar(&endTime, "end-time", time.Now().Format(time.RFC822), "the end of the range to export")
+		export.Flags().StringVar(&product, "product", string(ProductBTCUSD), "the product to export")
+		export.Flags().StringVar(&format, "format", "", "the file format, csv or parquet (default inferred from the file extension)")
+		export.Flags().StringVar(&file, "file", "candlesticks.csv", "the file to write")
+		export.Flags().Int64Var(&tickSizeMinutes, "tick-size-minutes", 1, "the size of the exported candlesticks in minutes")
+		candles.AddCommand(export)
+
+		var importStartTime, importEndTime string
+		var importProduct, importFormat, importFile string
+		importCmd := &cobra.Command{
+			Use:   "import",
+			Short: "import one minute candlesticks from a CSV or Parquet file",
+			Long: `Imports one minute candlesticks from a CSV or Parquet file with the columns
+written by candles export. Parquet files written by other tools, such as
+pyarrow and pandas, can be read if their columns are flat and have no nulls,
+and they're PLAIN or dictionary encoded with no compression or snappy or gzip
+compression.`,
+			Run: func(cmd *cobra.Command, _ []string) {
+				runner, err := GetRunner()
+				if err != nil {
+					fmt.Printf("error getting runner: %s", err)
+					os.Exit(1)
+				}
+
+				if importProduct != "" {
+					if _, ok := ProductToMetadata[Product(importProduct)]; !ok {
+						fmt.Println("unknown product: ", importProduct)
+						os.Exit(1)
+					}
+				}
+				s := time.Time{}
+				if importStartTime != "" {
+					if s, err = time.Parse(time.RFC822, importStartTime); err != nil {
+						fmt.Printf("error parsing start time: %s", err)
+						os.Exit(1)
+					}
+				}
+				e := time.Now()
+				if importEndTime != "" {
+					if e, err = time.Parse(time.RFC822, importEndTime); err != nil {
+						fmt.Printf("error parsing end time: %s", err)
+						os.Exit(1)
+					}
+				}
+				f, err := ParseCandlestickFileFormat(importFormat, importFile)
+				if err != nil {
+					fmt.Printf("error parsing format: %s\n", err)
+					os.Exit(1)
+				}
+
+				in, err := os.Open(importFile)
+				if err != nil {
+					fmt.Printf("error opening file: %s\n", err)
+					os.Exit(1)
+				}
+				defer in.Close()
+
+				read, err := ReadCandlesticks(in, f)
+				if err != nil {
+					fmt.Printf("error reading candlesticks: %s\n", err)
+					os.Exit(1)
+				}
+				imported := FilterCandlesticks(read, Product(importProduct), s, e)
+
+				// The candlesticks table only stores one
+				// minute candlesticks.
+				for _, c := range imported {
+					if _, ok := ProductToMetadata[c.Product]; !ok {
+						fmt.Printf("error: invalid product in file: %s\n", c.Product)
+						os.Exit(1)
+					}
+					if c.EndTime.Sub(c.StartTime) != time.Minute ||
+						!CandlestickBucket(c.StartTime, dbCandlestickBucketSize).Equal(c.StartTime) {
+						fmt.Printf("error: candlestick at %s isn't a one minute candlestick\n",
+							c.StartTime.Format(time.RFC3339))
+						os.Exit(1)
+					}
+				}
+
+				for _, c := range imported {
+					if err := runner.Backend.UpsertCandlestick(c); err != nil {
+						fmt.Printf("error upserting candlestick: %s\n", err)
+						os.Exit(1)
+					}
+				}
+
+				fmt.Printf("Imported %d of %d candlesticks from %s\n", len(imported), len(read), importFile)
+			},
+		}
+		importCmd.Flags().StringVar(&importStartTime, "start-time", "", "only import candlesticks starting at or after this time")
+		importCmd.Flags().StringVar(&importEndTime, "end-time", "", "only import candlesticks starting before this time")
+		importCmd.Flags().StringVar(&importProduct, "product", "", "only import candlesticks for this product")
+		importCmd.Flags().StringVar(&importFormat, "format", "", "the file format, csv or parquet (default inferred from the file extension)")
+		importCmd.Flags().StringVar(&importFile, "file", "candlesticks.csv", "the file to read")
+		candles.AddCommand(importCmd)
+
+		RootCmd.AddCommand(candles)
+	}()
+
 	func() {
 		bot := &cobra.Command{
 			Use:   "bot",
@@ -107,6 +357,194 @@ func defineCommands() {
 		RootCmd.AddCommand(bot)
 	}()
 
+	func() {
+		var strategyFile, startTime, endTime, output, resultsFile string
+		var save, report bool
+		var seed int64
+		backtest := &cobra.Command{
+			Use:   "backtest",
+			Short: "backtest a strategy described by a YAML file",
+			Run: func(cmd *cobra.Command, _ []string) {
+				runner, err := GetRunner()
+				if err != nil {
+					fmt.Printf("error getting runner: %s", err)
+					os.Exit(1)
+				}
+
+				model, err := LoadStrategyFile(strategyFile)
+				if err != nil {
+					fmt.Printf("error loading strategy file: %s\n", err)
+					os.Exit(1)
+				}
+				format, err := ParseBacktestOutputFormat(output)
+				if err != nil {
+					fmt.Printf("error parsing output format: %s\n", err)
+					os.Exit(1)
+				}
+				if report && format != BacktestOutputText {
+					fmt.Printf("error: --report requires text output\n")
+					os.Exit(1)
+				}
+
+				s, err := time.Parse(time.RFC822, startTime)
+				if err != nil {
+					fmt.Printf("error parsing start time: %s", err)
+					os.Exit(1)
+				}
+				e, err := time.Parse(time.RFC822, endTime)
+				if err != nil {
+					fmt.Printf("error parsing end time: %s", err)
+					os.Exit(1)
+				}
+
+				if seed == 0 {
+					seed = time.Now().UnixNano()
+				}
+				backtester, err := NewBacktester(
+					s, e,
+					model,
+					runner.BacktestBackend,
+					rand.NewSource(seed),
+				)
+				if err != nil {
+					fmt.Printf("error creating backtester: %s", err)
+					os.Exit(1)
+				}
+				backtester.SetFillModel(runner.FillModel)
+				if err := backtester.Backtest(); err != nil {
+					fmt.Printf("error running backtest: %s", err)
+					os.Exit(1)
+				}
+
+				results := backtester.Results()
+				if err := WriteBacktestResults(os.Stdout, format, results); err != nil {
+					fmt.Printf("error writing results: %s", err)
+					os.Exit(1)
+				}
+				if report {
+					if err := WriteBacktestReport(os.Stdout, results); err != nil {
+						fmt.Printf("error writing report: %s", err)
+						os.Exit(1)
+					}
+				}
+
+				// Results have already been written, so a
+				// failed save doesn't lose them.
+				if save {
+					run := NewBacktestRunModel(model, s, e, seed, nil, results)
+					if err := runner.Backend.CreateBacktestRun(run, backtester.Trades()); err != nil {
+						logrus.Warnf("error saving backtest run: %s", err)
+					} else {
+						logrus.Infof("saved backtest run %d", run.ID)
+					}
+				}
+
+				if resultsFile == "" {
+					return
+				}
+
+				reader, err := backtester.ResultsCSV()
+				if err != nil {
+					fmt.Printf("error creating results CSV: %s", err)
+					os.Exit(1)
+				}
+
+				file, err := os.Create(resultsFile)
+				if err != nil {
+					fmt.Printf("error opening file: %s", err)
+					os.Exit(1)
+				}
+				defer file.Close()
+
+				if _, err := io.Copy(file, reader); err != nil {
+					fmt.Printf("error copying to file: %s", err)
+					os.Exit(1)
+				}
+			},
+		}
+
+		backtest.Flags().StringVar(&strategyFile, "strategy-file", "strategy.yml", "a YAML file describing the trading strategy")
+		backtest.Flags().StringVar(&startTime, "start-time", time.Now().Add(-30*24*time.Hour).Format(time.RFC822), "the start time of the backtest")
+		backtest.Flags().StringVar(&endTime, "end-time", time.Now().Format(time.RFC822), "the end time of the backtest")
+		backtest.Flags().StringVar(&output, "output", string(BacktestOutputText), "the output format: text, json or csv")
+		backtest.Flags().StringVar(&resultsFile, "results-file", "", "an optional file to store per candlestick results in")
+		backtest.Flags().BoolVar(&save, "save", false, "store the run and its trades in the backtest_runs table")
+		backtest.Flags().BoolVar(&report, "report", false, "also write monthly and weekly returns against buy and hold, and holding times")
+		backtest.Flags().Int64Var(&seed, "seed", 0, "the seed for the backtest's slippage (0 picks one from the clock)")
+		RootCmd.AddCommand(backtest)
+	}()
+
+	func() {
+		var strategyFiles []string
+		var startTime, endTime string
+		var capital float64
+		var rules PortfolioRiskRules
+		var seed int64
+		portfolio := &cobra.Command{
+			Use:   "backtest-portfolio",
+			Short: "backtest several strategies that share capital",
+			Run: func(cmd *cobra.Command, _ []string) {
+				runner, err := GetRunner()
+				if err != nil {
+					fmt.Printf("error getting runner: %s", err)
+					os.Exit(1)
+				}
+
+				var models []*TradingStrategyModel
+				for _, path := range strategyFiles {
+					model, err := LoadStrategyFile(path)
+					if err != nil {
+						fmt.Printf("error loading strategy file: %s\n", err)
+						os.Exit(1)
+					}
+					models = append(models, model)
+				}
+
+				s, err := time.Parse(time.RFC822, startTime)
+				if err != nil {
+					fmt.Printf("error parsing start time: %s", err)
+					os.Exit(1)
+				}
+				e, err := time.Parse(time.RFC822, endTime)
+				if err != nil {
+					fmt.Printf("error parsing end time: %s", err)
+					os.Exit(1)
+				}
+
+				if seed == 0 {
+					seed = time.Now().UnixNano()
+				}
+				backtester, err := NewPortfolioBacktester(s, e, models, runner.BacktestBackend,
+					capital, rules, seed)
+				if err != nil {
+					fmt.Printf("error creating portfolio backtester: %s\n", err)
+					os.Exit(1)
+				}
+				backtester.SetFillModel(runner.FillModel)
+				results, err := backtester.Backtest()
+				if err != nil {
+					fmt.Printf("error running portfolio backtest: %s\n", err)
+					os.Exit(1)
+				}
+
+				if err := WritePortfolioResults(os.Stdout, results); err != nil {
+					fmt.Printf("error writing results: %s\n", err)
+					os.Exit(1)
+				}
+			},
+		}
+
+		portfolio.Flags().StringArrayVar(&strategyFiles, "strategy-file", nil, "a YAML file describing a strategy in the portfolio (repeatable)")
+		portfolio.Flags().StringVar(&startTime, "start-time", time.Now().Add(-30*24*time.Hour).Format(time.RFC822), "the start time of the backtest")
+		portfolio.Flags().StringVar(&endTime, "end-time", time.Now().Format(time.RFC822), "the end time of the backtest")
+		portfolio.Flags().Float64Var(&capital, "capital", 1000, "the capital shared by the strategies")
+		portfolio.Flags().Float64Var(&rules.MaxStrategyAllocation, "max-allocation", 0, "the largest fraction of equity a single strategy may invest (defaults to an equal share)")
+		portfolio.Flags().Float64Var(&rules.MaxGrossExposure, "max-exposure", 1, "the largest fraction of equity that may be invested across strategies")
+		portfolio.Flags().Float64Var(&rules.MaxDrawdown, "max-drawdown", 0, "the drawdown at which every position is sold and trading stops (0 disables)")
+		portfolio.Flags().Int64Var(&seed, "seed", 0, "the seed for the backtest's slippage (0 picks one from the clock)")
+		RootCmd.AddCommand(portfolio)
+	}()
+
 	func() {
 		var startTime, endTime string
 		var longPeriod, shortPeriod uint
@@ -168,13 +606,14 @@ func defineCommands() {
 				backtester, err := NewBacktester(
 					s, e,
 					model,
-					runner.Backend,
+					runner.BacktestBackend,
 					rand.NewSource(time.Now().Unix()),
 				)
 				if err != nil {
 					fmt.Printf("error creating backtester: %s", err)
 					os.Exit(1)
 				}
+				backtester.SetFillModel(runner.FillModel)
 				if err := backtester.Backtest(); err != nil {
 					fmt.Printf("error running backtest: %s", err)
 					os.Exit(1)
@@ -245,6 +684,7 @@ func defineCommands() {
 		var emaLongPeriod, emaShortPeriod uint
 		var emaDownThreshold, emaUpThreshold float64
 		var rsiEntrance, rsiExit float64
+		var rsiMode, rsiPriceSource string
 		var granularity uint
 		var resultsFile string
 		var generatePlotlyGraph bool
@@ -282,6 +722,14 @@ func defineCommands() {
 					EMAUpThreshold:       emaUpThreshold,
 					RSIEntranceThreshold: rsiEntrance,
 					RSIExitThreshold:     rsiExit,
+					RSIOptions: RSIOptions{
+						RSIMode:        rsiMode,
+						RSIPriceSource: PriceSource(rsiPriceSource),
+					},
+				}
+				if err := strategy.Validate(); err != nil {
+					fmt.Printf("error validating RSI options: %s", err)
+					os.Exit(1)
 				}
 				if err := model.SetStrategy(strategy); err != nil {
 					fmt.Printf("error setting trading strategy")
@@ -302,13 +750,14 @@ func defineCommands() {
 				backtester, err := NewBacktester(
 					s, e,
 					model,
-					runner.Backend,
+					runner.BacktestBackend,
 					rand.NewSource(time.Now().Unix()),
 				)
 				if err != nil {
 					fmt.Printf("error creating backtester: %s", err)
 					os.Exit(1)
 				}
+				backtester.SetFillModel(runner.FillModel)
 				if err := backtester.Backtest(); err != nil {
 					fmt.Printf("error running backtest: %s", err)
 					os.Exit(1)
@@ -370,6 +819,8 @@ func defineCommands() {
 		backtest.Flags().Float64Var(&emaUpThreshold, "ema-up-threshold", 0, "the EMA up threshold")
 		backtest.Flags().Float64Var(&rsiEntrance, "rsi-entrance", 0, "the RSI entrance threshold")
 		backtest.Flags().Float64Var(&rsiExit, "rsi-exit", 100, "the RSI exit threshold")
+		backtest.Flags().StringVar(&rsiMode, "rsi-mode", "", "the RSI mode: legacy or wilder")
+		backtest.Flags().StringVar(&rsiPriceSource, "rsi-price-source", "", "the RSI price source: close, hl2, hlc3 or ohlc4")
 		backtest.Flags().UintVar(&granularity, "tick-size-minutes", 15, "the tick size in minutes")
 		backtest.Flags().StringVar(&resultsFile, "results-file", "results.csv", "where to store the results")
 		backtest.Flags().BoolVar(&generatePlotlyGraph, "graph", false, "generate plotly graph")
@@ -382,6 +833,7 @@ func defineCommands() {
 		var startTime, endTime string
 		var rsiPeriod int
 		var rsiEntrance, rsiExit float64
+		var rsiMode, rsiPriceSource string
 		var product string
 		backtest := &cobra.Command{
 			Use:   "backtest-rsi",
@@ -413,6 +865,14 @@ func defineCommands() {
 					Period:        uint(rsiPeriod),
 					BuyThreshold:  rsiEntrance,
 					SellThreshold: rsiExit,
+					RSIOptions: RSIOptions{
+						RSIMode:        rsiMode,
+						RSIPriceSource: PriceSource(rsiPriceSource),
+					},
+				}
+				if err := strategy.Validate(); err != nil {
+					fmt.Printf("error validating RSI options: %s", err)
+					os.Exit(1)
 				}
 				if err := model.SetStrategy(strategy); err != nil {
 					fmt.Printf("error setting trading strategy")
@@ -433,13 +893,14 @@ func defineCommands() {
 				backtester, err := NewBacktester(
 					s, e,
 					model,
-					runner.Backend,
+					runner.BacktestBackend,
 					rand.NewSource(time.Now().Unix()),
 				)
 				if err != nil {
 					fmt.Printf("error creating backtester: %s", err)
 					os.Exit(1)
 				}
+				backtester.SetFillModel(runner.FillModel)
 				if err := backtester.Backtest(); err != nil {
 					fmt.Printf("error running backtest: %s", err)
 					os.Exit(1)
@@ -463,27 +924,275 @@ func defineCommands() {
 			},
 		}
 
-		backtest.Flags().StringVar(&product, "product", string(ProductBTCUSD), "the product to import")
-		backtest.Flags().StringVar(&startTime, "start-time", time.Now().Add(-30*24*time.Hour).Format(time.RFC822), "the start time for the experiment")
-		backtest.Flags().StringVar(&endTime, "end-time", time.Now().Format(time.RFC822), "the end time for the experiment")
-		backtest.Flags().Float64Var(&rsiEntrance, "rsi-entrance", 0, "the RSI entrance threshold")
-		backtest.Flags().Float64Var(&rsiExit, "rsi-exit", 100, "the RSI exit threshold")
-		backtest.Flags().IntVar(&rsiPeriod, "rsi-period", 14, "the RSI period")
-		backtest.Flags().UintVar(&granularity, "tick-size-minutes", 15, "the tick size in minutes")
+		backtest.Flags().StringVar(&product, "product", string(ProductBTCUSD), "the product to import")
+		backtest.Flags().StringVar(&startTime, "start-time", time.Now().Add(-30*24*time.Hour).Format(time.RFC822), "the start time for the experiment")
+		backtest.Flags().StringVar(&endTime, "end-time", time.Now().Format(time.RFC822), "the end time for the experiment")
+		backtest.Flags().Float64Var(&rsiEntrance, "rsi-entrance", 0, "the RSI entrance threshold")
+		backtest.Flags().Float64Var(&rsiExit, "rsi-exit", 100, "the RSI exit threshold")
+		backtest.Flags().StringVar(&rsiMode, "rsi-mode", "", "the RSI mode: legacy or wilder")
+		backtest.Flags().StringVar(&rsiPriceSource, "rsi-price-source", "", "the RSI price source: close, hl2, hlc3 or ohlc4")
+		backtest.Flags().IntVar(&rsiPeriod, "rsi-period", 14, "the RSI period")
+		backtest.Flags().UintVar(&granularity, "tick-size-minutes", 15, "the tick size in minutes")
+
+		RootCmd.AddCommand(backtest)
+	}()
+
+	func() {
+		var startTime, endTime string
+		var granularity uint
+		var generations uint
+		var populationSize uint
+		var tradingStrategyType string
+		var product string
+		var trainPeriod, testPeriod time.Duration
+		var objectiveSpec string
+		var minTrades uint
+		var tradePenalty, maxDrawdown, drawdownPenalty float64
+		var save bool
+		var promoteBudget float64
+		var seed int64
+		var strategyFile string
+		optimizer := &cobra.Command{
+			Use:   "optimize-strategy",
+			Short: "optimizes a genetic algorithm",
+			Run: func(cmd *cobra.Command, _ []string) {
+				runner, err := GetRunner()
+				if err != nil {
+					fmt.Printf("error getting runner: %s", err)
+					os.Exit(1)
+				}
+
+				var model *TradingStrategyModel
+				if _, err := LookupStrategyType(tradingStrategyType); err != nil && strategyFile == "" {
+					fmt.Printf("error looking up strategy type: %s\n", err)
+					os.Exit(1)
+				}
+				if strategyFile != "" {
+					model, err = LoadStrategyFile(strategyFile)
+					if err != nil {
+						fmt.Printf("error loading strategy file: %s\n", err)
+						os.Exit(1)
+					}
+					tradingStrategyType = model.TradingStrategy
+				} else {
+					if _, ok := ProductToMetadata[Product(product)]; !ok {
+						fmt.Println("unknown product: ", product)
+						os.Exit(1)
+					}
+
+					model = &TradingStrategyModel{
+						Product:          Product(product),
+						HistoryTicks:     1000,
+						State:            StrategyStateTryingToBuy,
+						InitialBudget:    100,
+						Budget:           100,
+						BudgetCurrency:   ProductToMetadata[Product(product)].MarketOrderBuyCurrency,
+						InvestedCurrency: ProductToMetadata[Product(product)].MarketOrderSellCurrency,
+						TickSizeMinutes:  granularity,
+						TradingStrategy:  tradingStrategyType,
+					}
+				}
+
+				s, err := time.Parse(time.RFC822, startTime)
+				if err != nil {
+					fmt.Printf("error parsing start time: %s", err)
+					os.Exit(1)
+				}
+				e, err := time.Parse(time.RFC822, endTime)
+				if err != nil {
+					fmt.Printf("error parsing end time: %s", err)
+					os.Exit(1)
+				}
+
+				parsedObjective, err := ParseObjective(objectiveSpec)
+				if err != nil {
+					fmt.Printf("error parsing objective: %s\n", err)
+					os.Exit(1)
+				}
+				objective := &PenalizedObjective{
+					Objective:       parsedObjective,
+					MinTrades:       minTrades,
+					TradePenalty:    tradePenalty,
+					MaxDrawdown:     maxDrawdown,
+					DrawdownPenalty: drawdownPenalty,
+				}
+
+				if seed == 0 {
+					seed = time.Now().UnixNano()
+				}
+				fmt.Printf("Seed: %d\n", seed)
+
+				// Promoted runs must be stored so that the
+				// live strategy can be traced back to them,
+				// so runs that couldn't be saved aren't
+				// promoted.
+				saveRun := func(run *BacktestRunModel, trades []*BacktestTradeModel) bool {
+					if !save && promoteBudget <= 0 {
+						return false
+					}
+					if err := runner.Backend.CreateBacktestRun(run, trades); err != nil {
+						logrus.Warnf("error saving backtest run: %s", err)
+						return false
+					}
+					fmt.Printf("Saved backtest run: %d\n", run.ID)
+					return true
+				}
+				promoteRun := func(run *BacktestRunModel, saved bool) {
+					if promoteBudget <= 0 || run == nil {
+						return
+					}
+					if !saved {
+						logrus.Warnf("not promoting a backtest run that wasn't saved")
+						return
+					}
+					ts, err := run.TradingStrategyModel(promoteBudget, time.Now())
+					if err != nil {
+						fmt.Printf("error creating trading strategy: %s\n", err)
+						os.Exit(1)
+					}
+					if err := runner.Backend.CreateTradingStrategy(ts); err != nil {
+						fmt.Printf("error creating trading strategy: %s\n", err)
+						os.Exit(1)
+					}
+					fmt.Printf("successfully created trading strategy: %d\n", ts.ID)
+				}
+
+				if testPeriod > 0 {
+					windows, err := WalkForwardWindows(s, e, trainPeriod, testPeriod)
+					if err != nil {
+						fmt.Printf("error creating walk forward windows: %s\n", err)
+						os.Exit(1)
+					}
+
+					optimizer := NewWalkForwardOptimizer(model, runner.BacktestBackend,
+						int(generations), int(populationSize))
+					optimizer.SetObjective(objective)
+					optimizer.SetSeed(seed)
+					optimizer.SetFillModel(runner.FillModel)
+					results, err := optimizer.Run(windows)
+					if err != nil {
+						fmt.Printf("error running walk forward optimization: %s\n", err)
+						os.Exit(1)
+					}
+
+					for i, step := range results.Steps {
+						fmt.Printf("Window %d: train %s - %s, test %s - %s\n", i+1,
+							step.Window.TrainStart.Format(time.RFC822), step.Window.TrainEnd.Format(time.RFC822),
+							step.Window.TestStart.Format(time.RFC822), step.Window.TestEnd.Format(time.RFC822))
+						fmt.Printf("  %s\n", step.Strategy)
+						fmt.Printf("  In-sample value: %f\n", step.InSampleScore)
+						fmt.Printf("  Out-of-sample return: %f%%\n", 100*(step.OutOfSampleReturn()-1))
+					}
+
+					stability, err := results.ParameterStability()
+					if err != nil {
+						fmt.Printf("error calculating parameter stability: %s\n", err)
+						os.Exit(1)
+					}
+					fmt.Printf("Parameter stability:\n")
+					for _, name := range ParameterNames(stability) {
+						p := stability[name]
+						fmt.Printf("  %s: mean %f, stddev %f, min %f, max %f\n", name, p.Mean, p.StdDev, p.Min, p.Max)
+					}
+
+					fmt.Printf("Out-of-sample equity: %v\n", results.Equity())
+					fmt.Printf("Starting budget: %f\n", results.InitialBudget)
+					fmt.Printf("Out-of-sample ending budget: %f\n", results.FinalEquity())
+
+					name := fmt.Sprintf("walk-forward-%s-%s", tradingStrategyType, time.Now().Format("20060102150405"))
+					var last *BacktestRunModel
+					var saved bool
+					for _, step := range results.Steps {
+						last = NewBacktestRunModel(step.Model, step.Window.TestStart, step.Window.TestEnd,
+							step.Seed, objective, step.OutOfSample)
+						last.SweepName = name
+						saved = saveRun(last, step.Trades)
+					}
+					promoteRun(last, saved)
+					return
+				}
+
+				factory, err := NewBacktesterGenomeFactory(
+					s, e,
+					model,
+					runner.BacktestBackend,
+				)
+				if err != nil {
+					fmt.Printf("error creating genome factory: %s", err)
+					os.Exit(1)
+				}
+				factory.SetObjective(objective)
+				factory.SetFillModel(runner.FillModel)
+
+				ga := NewGeneticOptimizer(factory, int(populationSize), seed)
+				ga.Initialize()
+
+				for i := 1; i <= int(generations); i++ {
+					if err := ga.Enhance(); err != nil {
+						fmt.Printf("error enhancing generation: %s\n", err)
+						os.Exit(1)
+					}
+					genome := ga.Best.Genome.(*BacktesterGenome)
+					fmt.Printf("Best value at generation %d: $%f\n", i, -ga.Best.Fitness)
+					fmt.Printf("  %s\n", genome.strategy)
+				}
+
+				if !save && promoteBudget <= 0 {
+					return
+				}
+
+				best := model.Copy()
+				if err := best.SetStrategy(ga.Best.Genome.(*BacktesterGenome).strategy); err != nil {
+					fmt.Printf("error setting model strategy: %s\n", err)
+					os.Exit(1)
+				}
+				backtester, err := NewBacktester(s, e, best, runner.BacktestBackend, rand.NewSource(seed))
+				if err != nil {
+					fmt.Printf("error creating backtester: %s\n", err)
+					os.Exit(1)
+				}
+				backtester.SetFillModel(runner.FillModel)
+				if err := backtester.Backtest(); err != nil {
+					fmt.Printf("error running backtest: %s\n", err)
+					os.Exit(1)
+				}
+
+				run := NewBacktestRunModel(best, s, e, seed, objective, backtester.Results())
+				promoteRun(run, saveRun(run, backtester.Trades()))
+			},
+		}
+
+		optimizer.Flags().UintVar(&generations, "generations", 100, "the number of generations to run")
+		optimizer.Flags().UintVar(&populationSize, "population-size", 100, "the number of generations to run")
+		optimizer.Flags().StringVar(&product, "product", string(ProductBTCUSD), "the exchange product to use")
+		optimizer.Flags().StringVar(&startTime, "start-time", time.Now().Add(-30*24*time.Hour).Format(time.RFC822), "the start time for the experiment")
+		optimizer.Flags().StringVar(&endTime, "end-time", time.Now().Format(time.RFC822), "the end time for the experiment")
+		optimizer.Flags().StringVar(&tradingStrategyType, "strategy-type", TradingStrategyEMACrossover, "the type of strategy to use (see vespyr strategy types)")
+		optimizer.Flags().UintVar(&granularity, "tick-size-minutes", 15, "the tick size in minutes")
+		optimizer.Flags().StringVar(&strategyFile, "strategy-file", "", "a YAML file describing the trading strategy to optimize, which overrides the strategy type, product and tick size (required for rule strategies)")
+		optimizer.Flags().DurationVar(&trainPeriod, "train-period", 30*24*time.Hour, "the length of each walk forward training window")
+		optimizer.Flags().DurationVar(&testPeriod, "test-period", 0, "the length of each walk forward test window (0 disables walk forward optimization)")
+		optimizer.Flags().StringVar(&objectiveSpec, "objective", ObjectiveSharpe, "the objective to maximize: sharpe, sortino, calmar, net-profit, profit-factor or weighted metrics such as sharpe:0.7,net-profit:0.3")
+		optimizer.Flags().UintVar(&minTrades, "min-trades", 0, "the number of trades below which strategies are penalized")
+		optimizer.Flags().Float64Var(&tradePenalty, "min-trades-penalty", 1, "the penalty for strategies that make no trades, scaled by the fraction of missing trades")
+		optimizer.Flags().Float64Var(&maxDrawdown, "max-drawdown", 0, "the fractional drawdown above which strategies are penalized (0 disables)")
+		optimizer.Flags().Float64Var(&drawdownPenalty, "drawdown-penalty", 10, "the penalty per unit of drawdown above the maximum")
+		optimizer.Flags().BoolVar(&save, "save", false, "store the best strategy's backtest, or each out-of-sample backtest, in the backtest_runs table")
+		optimizer.Flags().Int64Var(&seed, "seed", 0, "the seed for the genetic algorithm and backtests (0 picks one from the clock)")
+		optimizer.Flags().Float64Var(&promoteBudget, "promote-budget", 0, "create a live trading strategy with this budget from the best strategy (0 disables)")
 
-		RootCmd.AddCommand(backtest)
+		RootCmd.AddCommand(optimizer)
 	}()
 
 	func() {
-		var startTime, endTime string
-		var granularity uint
-		var generations uint
-		var populationSize uint
-		var tradingStrategyType string
-		var product string
-		optimizer := &cobra.Command{
-			Use:   "optimize-strategy",
-			Short: "optimizes a genetic algorithm",
+		var strategyFile, startTime, endTime, mode, objectiveSpec, sweepName string
+		var params []string
+		var samples, concurrency int
+		var seed int64
+		var save bool
+		sweep := &cobra.Command{
+			Use:   "sweep",
+			Short: "backtests a grid or random sample of strategy parameters",
 			Run: func(cmd *cobra.Command, _ []string) {
 				runner, err := GetRunner()
 				if err != nil {
@@ -491,21 +1200,25 @@ func defineCommands() {
 					os.Exit(1)
 				}
 
-				if _, ok := ProductToMetadata[Product(product)]; !ok {
-					fmt.Println("unknown product: ", product)
+				model, err := LoadStrategyFile(strategyFile)
+				if err != nil {
+					fmt.Printf("error loading strategy file: %s\n", err)
+					os.Exit(1)
+				}
+				objective, err := ParseObjective(objectiveSpec)
+				if err != nil {
+					fmt.Printf("error parsing objective: %s\n", err)
 					os.Exit(1)
 				}
 
-				model := &TradingStrategyModel{
-					Product:          Product(product),
-					HistoryTicks:     1000,
-					State:            StrategyStateTryingToBuy,
-					InitialBudget:    100,
-					Budget:           100,
-					BudgetCurrency:   ProductToMetadata[Product(product)].MarketOrderBuyCurrency,
-					InvestedCurrency: ProductToMetadata[Product(product)].MarketOrderSellCurrency,
-					TickSizeMinutes:  granularity,
-					TradingStrategy:  tradingStrategyType,
+				var sweepParams []*SweepParameter
+				for _, p := range params {
+					param, err := ParseSweepParameter(p)
+					if err != nil {
+						fmt.Printf("error parsing parameter: %s\n", err)
+						os.Exit(1)
+					}
+					sweepParams = append(sweepParams, param)
 				}
 
 				s, err := time.Parse(time.RFC822, startTime)
@@ -519,46 +1232,218 @@ func defineCommands() {
 					os.Exit(1)
 				}
 
-				factory, err := NewBacktesterGenomeFactory(
-					s, e,
-					model,
-					runner.Backend,
-				)
-				if err != nil {
-					fmt.Printf("error creating genome factory: %s", err)
+				var combinations []map[string]float64
+				switch mode {
+				case SweepModeGrid:
+					combinations = GridSweep(sweepParams)
+				case SweepModeRandom:
+					combinations = RandomSweep(sweepParams, samples, rand.New(rand.NewSource(seed)))
+				default:
+					fmt.Printf("error: unknown sweep mode: %s\n", mode)
 					os.Exit(1)
 				}
 
-				ga := gago.Generational(factory.Generate)
-				ga.PopSize = int(populationSize)
-				ga.Initialize()
+				if sweepName == "" {
+					sweepName = fmt.Sprintf("%s-%s", model.TradingStrategy, time.Now().Format("20060102150405"))
+				}
 
-				for i := 1; i <= int(generations); i++ {
-					if err := ga.Enhance(); err != nil {
-						fmt.Printf("error enhancing generation: %s\n", err)
-						os.Exit(1)
+				sweeper := NewSweeper(s, e, model, runner.BacktestBackend, concurrency, seed)
+				sweeper.SetObjective(objective)
+				sweeper.SetFillModel(runner.FillModel)
+
+				fmt.Printf("Running %d backtests for sweep %s\n", len(combinations), sweepName)
+				results := sweeper.Run(combinations)
+
+				if err := WriteSweepResults(os.Stdout, sweepParams, results); err != nil {
+					fmt.Printf("error writing results: %s\n", err)
+					os.Exit(1)
+				}
+
+				if save {
+					for _, result := range results {
+						if result.Err != nil {
+							continue
+						}
+						if err := runner.Backend.CreateBacktestRun(sweeper.BacktestRun(sweepName, result), result.Trades); err != nil {
+							logrus.Warnf("error saving backtest run: %s", err)
+							break
+						}
 					}
-					genome := ga.Best.Genome.(*BacktesterGenome)
-					fmt.Printf("Best value at generation %d: $%f\n", i, -ga.Best.Genome.Evaluate())
-					fmt.Printf("  %s\n", genome.strategy)
 				}
 			},
 		}
 
-		optimizer.Flags().UintVar(&generations, "generations", 100, "the number of generations to run")
-		optimizer.Flags().UintVar(&populationSize, "population-size", 100, "the number of generations to run")
-		optimizer.Flags().StringVar(&product, "product", string(ProductBTCUSD), "the exchange product to use")
-		optimizer.Flags().StringVar(&startTime, "start-time", time.Now().Add(-30*24*time.Hour).Format(time.RFC822), "the start time for the experiment")
-		optimizer.Flags().StringVar(&endTime, "end-time", time.Now().Format(time.RFC822), "the end time for the experiment")
-		optimizer.Flags().StringVar(&tradingStrategyType, "strategy-type", TradingStrategyEMACrossover, "the type of strategy to use")
-		optimizer.Flags().UintVar(&granularity, "tick-size-minutes", 15, "the tick size in minutes")
+		sweep.Flags().StringVar(&strategyFile, "strategy-file", "strategy.yml", "a YAML file describing the trading strategy and the values of parameters that aren't swept")
+		sweep.Flags().StringArrayVar(&params, "param", nil, "a parameter range to sweep as name=min:max:step, where nested names are dotted paths (repeatable)")
+		sweep.Flags().StringVar(&mode, "mode", SweepModeGrid, "the sweep mode: grid or random")
+		sweep.Flags().IntVar(&samples, "samples", 100, "the number of combinations to sample in random mode")
+		sweep.Flags().Int64Var(&seed, "seed", 1, "the seed for random sampling and backtests")
+		sweep.Flags().IntVar(&concurrency, "concurrency", runtime.NumCPU(), "the number of backtests to run in parallel")
+		sweep.Flags().StringVar(&objectiveSpec, "objective", ObjectiveSharpe, "the objective to rank results by")
+		sweep.Flags().StringVar(&sweepName, "name", "", "the name to store the runs under (defaults to the strategy type and time)")
+		sweep.Flags().BoolVar(&save, "save", false, "store the runs in the backtest_runs table")
+		sweep.Flags().StringVar(&startTime, "start-time", time.Now().Add(-30*24*time.Hour).Format(time.RFC822), "the start time for the experiment")
+		sweep.Flags().StringVar(&endTime, "end-time", time.Now().Format(time.RFC822), "the end time for the experiment")
+
+		RootCmd.AddCommand(sweep)
+	}()
 
-		RootCmd.AddCommand(optimizer)
+	func() {
+		backtests := &cobra.Command{
+			Use:   "backtests",
+			Short: "inspect stored backtest runs",
+		}
+
+		parseIDs := func(args []string) []int64 {
+			var ids []int64
+			for _, arg := range args {
+				id, err := strconv.ParseInt(arg, 10, 64)
+				if err != nil {
+					fmt.Printf("error parsing backtest run ID: %s\n", err)
+					os.Exit(1)
+				}
+				ids = append(ids, id)
+			}
+			return ids
+		}
+
+		func() {
+			var sweepName string
+			var limit int
+			list := &cobra.Command{
+				Use:   "list",
+				Short: "lists the most recent backtest runs",
+				Run: func(cmd *cobra.Command, _ []string) {
+					runner, err := GetRunner()
+					if err != nil {
+						fmt.Printf("error getting runner: %s", err)
+						os.Exit(1)
+					}
+
+					runs, err := runner.Backend.FindBacktestRuns(sweepName, limit)
+					if err != nil {
+						fmt.Printf("error finding backtest runs: %s\n", err)
+						os.Exit(1)
+					}
+					if err := WriteBacktestRuns(os.Stdout, runs); err != nil {
+						fmt.Printf("error writing backtest runs: %s\n", err)
+						os.Exit(1)
+					}
+				},
+			}
+
+			list.Flags().StringVar(&sweepName, "sweep", "", "only list runs from this sweep")
+			list.Flags().IntVar(&limit, "limit", 50, "the maximum number of runs to list")
+			backtests.AddCommand(list)
+		}()
+
+		func() {
+			show := &cobra.Command{
+				Use:   "show ID",
+				Short: "shows a backtest run and its trades",
+				Args:  cobra.ExactArgs(1),
+				Run: func(cmd *cobra.Command, args []string) {
+					runner, err := GetRunner()
+					if err != nil {
+						fmt.Printf("error getting runner: %s", err)
+						os.Exit(1)
+					}
+
+					id := parseIDs(args)[0]
+					run, err := runner.Backend.FindBacktestRunByID(id)
+					if err != nil {
+						fmt.Printf("error finding backtest run: %s\n", err)
+						os.Exit(1)
+					}
+					trades, err := runner.Backend.FindBacktestTrades(id)
+					if err != nil {
+						fmt.Printf("error finding backtest trades: %s\n", err)
+						os.Exit(1)
+					}
+					if err := WriteBacktestRun(os.Stdout, run, trades); err != nil {
+						fmt.Printf("error writing backtest run: %s\n", err)
+						os.Exit(1)
+					}
+				},
+			}
+
+			backtests.AddCommand(show)
+		}()
+
+		func() {
+			compare := &cobra.Command{
+				Use:   "compare ID ID...",
+				Short: "compares the metrics and parameters of backtest runs",
+				Args:  cobra.MinimumNArgs(2),
+				Run: func(cmd *cobra.Command, args []string) {
+					runner, err := GetRunner()
+					if err != nil {
+						fmt.Printf("error getting runner: %s", err)
+						os.Exit(1)
+					}
+
+					var runs []*BacktestRunModel
+					for _, id := range parseIDs(args) {
+						run, err := runner.Backend.FindBacktestRunByID(id)
+						if err != nil {
+							fmt.Printf("error finding backtest run: %s\n", err)
+							os.Exit(1)
+						}
+						runs = append(runs, run)
+					}
+					if err := WriteBacktestRunComparison(os.Stdout, runs); err != nil {
+						fmt.Printf("error writing comparison: %s\n", err)
+						os.Exit(1)
+					}
+				},
+			}
+
+			backtests.AddCommand(compare)
+		}()
+
+		func() {
+			var budget float64
+			promote := &cobra.Command{
+				Use:   "promote ID",
+				Short: "creates a live trading strategy from a backtest run",
+				Args:  cobra.ExactArgs(1),
+				Run: func(cmd *cobra.Command, args []string) {
+					runner, err := GetRunner()
+					if err != nil {
+						fmt.Printf("error getting runner: %s", err)
+						os.Exit(1)
+					}
+
+					run, err := runner.Backend.FindBacktestRunByID(parseIDs(args)[0])
+					if err != nil {
+						fmt.Printf("error finding backtest run: %s\n", err)
+						os.Exit(1)
+					}
+					ts, err := run.TradingStrategyModel(budget, time.Now())
+					if err != nil {
+						fmt.Printf("error creating trading strategy: %s\n", err)
+						os.Exit(1)
+					}
+					if err := runner.Backend.CreateTradingStrategy(ts); err != nil {
+						fmt.Printf("error creating trading strategy: %s\n", err)
+						os.Exit(1)
+					}
+
+					fmt.Printf("successfully created trading strategy: %d\n", ts.ID)
+				},
+			}
+
+			promote.Flags().Float64Var(&budget, "budget", 100, "the budget of the trading strategy")
+			backtests.AddCommand(promote)
+		}()
+
+		RootCmd.AddCommand(backtests)
 	}()
 
 	func() {
 		var startTime, endTime string
 		var product string
+		var resumeJobID int64
 		historicalImport := &cobra.Command{
 			Use:   "import",
 			Short: "import historical data",
@@ -569,26 +1454,52 @@ func defineCommands() {
 					os.Exit(1)
 				}
 
-				s, err := time.Parse(time.RFC822, startTime)
-				if err != nil {
-					fmt.Printf("error parsing start time: %s", err)
-					os.Exit(1)
+				var job *ImportJobModel
+				if resumeJobID != 0 {
+					job, err = runner.Backend.FindImportJobByID(resumeJobID)
+					if err != nil {
+						fmt.Printf("error finding import job: %s\n", err)
+						os.Exit(1)
+					}
+					if !job.CompletedAt.IsZero() {
+						fmt.Printf("import job %d already completed\n", job.ID)
+						os.Exit(0)
+					}
 				}
-				e, err := time.Parse(time.RFC822, endTime)
+
+				p := Product(product)
+				if job != nil {
+					p = job.Product
+				}
+				importer, err := runner.HistoricalImporter(p)
 				if err != nil {
-					fmt.Printf("error parsing end time: %s", err)
+					fmt.Printf("error getting historical importer: %s\n", err)
 					os.Exit(1)
 				}
 
-				switch Product(product) {
-				case ProductBTCUSD:
-					runner.BTCUSDHistoricalImporter.Import(s, e)
-				case ProductETHUSD:
-					runner.ETHUSDHistoricalImporter.Import(s, e)
-				case ProductLTCUSD:
-					runner.LTCUSDHistoricalImporter.Import(s, e)
-				default:
-					fmt.Printf("unsupported product: %s", product)
+				if job == nil {
+					s, err := time.Parse(time.RFC822, startTime)
+					if err != nil {
+						fmt.Printf("error parsing start time: %s", err)
+						os.Exit(1)
+					}
+					e, err := time.Parse(time.RFC822, endTime)
+					if err != nil {
+						fmt.Printf("error parsing end time: %s", err)
+						os.Exit(1)
+					}
+
+					job, err = importer.CreateImportJob(s, e)
+					if err != nil {
+						fmt.Printf("error creating import job: %s\n", err)
+						os.Exit(1)
+					}
+					fmt.Printf("created import job: %d\n", job.ID)
+				}
+
+				if err := importer.RunImportJob(job); err != nil {
+					fmt.Printf("error running import job: %s\n", err)
+					fmt.Printf("resume with: vespyr import --resume-job %d\n", job.ID)
 					os.Exit(1)
 				}
 			},
@@ -597,6 +1508,7 @@ func defineCommands() {
 		historicalImport.Flags().StringVar(&startTime, "start-time", time.Now().Add(-30*24*time.Hour).Format(time.RFC822), "the start time for the experiment")
 		historicalImport.Flags().StringVar(&endTime, "end-time", time.Now().Format(time.RFC822), "the end time for the experiment")
 		historicalImport.Flags().StringVar(&product, "product", string(ProductBTCUSD), "the product to import")
+		historicalImport.Flags().Int64Var(&resumeJobID, "resume-job", 0, "the ID of an incomplete import job to resume")
 		RootCmd.AddCommand(historicalImport)
 	}()
 
@@ -758,6 +1670,7 @@ func defineCommands() {
 		var emaLongPeriod, emaShortPeriod uint
 		var emaDownThreshold, emaUpThreshold float64
 		var rsiEntrance, rsiExit float64
+		var rsiMode, rsiPriceSource string
 		var product string
 		ts := &cobra.Command{
 			Use:   "create-s1",
@@ -786,6 +1699,14 @@ func defineCommands() {
 					EMAUpThreshold:       emaUpThreshold,
 					RSIEntranceThreshold: rsiEntrance,
 					RSIExitThreshold:     rsiExit,
+					RSIOptions: RSIOptions{
+						RSIMode:        rsiMode,
+						RSIPriceSource: PriceSource(rsiPriceSource),
+					},
+				}
+				if err := strategy.Validate(); err != nil {
+					fmt.Printf("error validating RSI options: %s", err)
+					os.Exit(1)
 				}
 				s := &TradingStrategyModel{
 					NextTickAt:       CandlestickBucket(time.Now(), int64(tickSizeMinutes)).Add(time.Minute * time.Duration(tickSizeMinutes)),
@@ -826,39 +1747,287 @@ func defineCommands() {
 		ts.Flags().Float64Var(&emaUpThreshold, "ema-up-threshold", 0, "the EMA up threshold")
 		ts.Flags().Float64Var(&rsiEntrance, "rsi-entrance", 0, "the RSI entrance threshold")
 		ts.Flags().Float64Var(&rsiExit, "rsi-exit", 100, "the RSI exit threshold")
+		ts.Flags().StringVar(&rsiMode, "rsi-mode", "", "the RSI mode: legacy or wilder")
+		ts.Flags().StringVar(&rsiPriceSource, "rsi-price-source", "", "the RSI price source: close, hl2, hlc3 or ohlc4")
+		ts.Flags().StringVar(&product, "product", string(ProductBTCUSD), "the product to use")
+	}()
+
+	func() {
+		var budget float64
+		var tickSizeMinutes uint
+		var product string
+		strategy := &RSIBollingerStrategy{}
+		ts := &cobra.Command{
+			Use:   "create-rsi-bollinger",
+			Short: "creates an rsi and bollinger bands trading strategy",
+			Run: func(cmd *cobra.Command, _ []string) {
+				runner, err := GetRunner()
+				if err != nil {
+					fmt.Printf("error getting runner: %s", err)
+					os.Exit(1)
+				}
+
+				if _, ok := ProductToMetadata[Product(product)]; !ok {
+					fmt.Println("unknown product: ", product)
+					os.Exit(1)
+				}
+
+				if budget == 0 {
+					fmt.Println("budget must be specified")
+					os.Exit(1)
+				}
+				if err := strategy.Validate(); err != nil {
+					fmt.Printf("error validating RSI options: %s", err)
+					os.Exit(1)
+				}
+
+				s := &TradingStrategyModel{
+					NextTickAt:       CandlestickBucket(time.Now(), int64(tickSizeMinutes)).Add(time.Minute * time.Duration(tickSizeMinutes)),
+					Product:          Product(product),
+					HistoryTicks:     1000,
+					State:            StrategyStateTryingToBuy,
+					InitialBudget:    budget,
+					Budget:           budget,
+					BudgetCurrency:   ProductToMetadata[Product(product)].MarketOrderBuyCurrency,
+					InvestedCurrency: ProductToMetadata[Product(product)].MarketOrderSellCurrency,
+					TickSizeMinutes:  tickSizeMinutes,
+				}
+				if err := s.SetStrategy(strategy); err != nil {
+					fmt.Printf("error setting trading strategy: %s", err)
+					os.Exit(1)
+				}
+				if err := runner.Backend.CreateTradingStrategy(s); err != nil {
+					fmt.Printf("error creating trading strategy: %s", err)
+					os.Exit(1)
+				}
+
+				fmt.Printf("successfully created trading strategy: %d\n", s.ID)
+			},
+		}
+		RootCmd.AddCommand(ts)
+		ts.Flags().Float64VarP(&budget, "budget", "b", 0, "the initial budget in USD")
+		ts.Flags().UintVarP(&tickSizeMinutes, "tick-size-minutes", "t", 15, "the size of each tick")
+		ts.Flags().UintVar(&strategy.RSIPeriod, "rsi-period", 14, "the RSI period")
+		ts.Flags().Float64Var(&strategy.RSIBuyThreshold, "rsi-buy-threshold", 30, "the RSI at or below which to buy")
+		ts.Flags().Float64Var(&strategy.RSISellThreshold, "rsi-sell-threshold", 70, "the RSI at or above which to sell")
+		ts.Flags().UintVar(&strategy.BollingerPeriod, "bollinger-period", 20, "the Bollinger Bands period")
+		ts.Flags().Float64Var(&strategy.BollingerDeviations, "bollinger-deviations", 2, "the number of standard deviations between the middle and outer bands")
+		ts.Flags().Float64Var(&strategy.PercentBBuyThreshold, "percent-b-buy-threshold", 0, "the %B at or below which to buy")
+		ts.Flags().Float64Var(&strategy.PercentBSellThreshold, "percent-b-sell-threshold", 1, "the %B at or above which to sell")
+		ts.Flags().StringVar(&strategy.RSIMode, "rsi-mode", "", "the RSI mode: legacy or wilder")
+		ts.Flags().StringVar((*string)(&strategy.RSIPriceSource), "rsi-price-source", "", "the RSI price source: close, hl2, hlc3 or ohlc4")
 		ts.Flags().StringVar(&product, "product", string(ProductBTCUSD), "the product to use")
 	}()
+
+	func() {
+		strategy := &cobra.Command{
+			Use:   "strategy",
+			Short: "create trading strategies of any registered type",
+		}
+
+		func() {
+			types := &cobra.Command{
+				Use:   "types",
+				Short: "lists the registered strategy types and their parameters",
+				Run: func(cmd *cobra.Command, _ []string) {
+					for _, t := range StrategyTypes() {
+						fmt.Printf("%s: %s\n", t.Name, t.Description)
+						for _, p := range t.Parameters {
+							fmt.Printf("  %s: %s (default %g, genome range %g to %g)\n",
+								p.Name, p.Description, p.Default, p.Min, p.Max)
+						}
+					}
+				},
+			}
+			strategy.AddCommand(types)
+		}()
+
+		func() {
+			var strategyType, dataFile, product string
+			var params []string
+			var budget float64
+			var tickSizeMinutes uint
+			create := &cobra.Command{
+				Use:   "create",
+				Short: "creates a trading strategy",
+				Run: func(cmd *cobra.Command, _ []string) {
+					runner, err := GetRunner()
+					if err != nil {
+						fmt.Printf("error getting runner: %s", err)
+						os.Exit(1)
+					}
+
+					if _, ok := ProductToMetadata[Product(product)]; !ok {
+						fmt.Println("unknown product: ", product)
+						os.Exit(1)
+					}
+					if budget == 0 {
+						fmt.Println("budget must be specified")
+						os.Exit(1)
+					}
+
+					t, err := LookupStrategyType(strategyType)
+					if err != nil {
+						fmt.Printf("error looking up strategy type: %s\n", err)
+						os.Exit(1)
+					}
+
+					var base []byte
+					if dataFile != "" {
+						if base, err = ioutil.ReadFile(dataFile); err != nil {
+							fmt.Printf("error reading strategy data: %s\n", err)
+							os.Exit(1)
+						}
+					}
+					values := make(map[string]interface{})
+					for _, spec := range params {
+						name, value, err := ParseStrategyParameter(spec)
+						if err != nil {
+							fmt.Printf("error parsing parameter: %s\n", err)
+							os.Exit(1)
+						}
+						values[name] = value
+					}
+					data, err := t.Data(base, values)
+					if err != nil {
+						fmt.Printf("error creating strategy data: %s\n", err)
+						os.Exit(1)
+					}
+
+					s := &TradingStrategyModel{
+						NextTickAt:          CandlestickBucket(time.Now(), int64(tickSizeMinutes)).Add(time.Minute * time.Duration(tickSizeMinutes)),
+						Product:             Product(product),
+						HistoryTicks:        1000,
+						State:               StrategyStateTryingToBuy,
+						InitialBudget:       budget,
+						Budget:              budget,
+						BudgetCurrency:      ProductToMetadata[Product(product)].MarketOrderBuyCurrency,
+						InvestedCurrency:    ProductToMetadata[Product(product)].MarketOrderSellCurrency,
+						TickSizeMinutes:     tickSizeMinutes,
+						TradingStrategy:     t.Name,
+						TradingStrategyData: data,
+					}
+					if err := runner.Backend.CreateTradingStrategy(s); err != nil {
+						fmt.Printf("error creating trading strategy: %s", err)
+						os.Exit(1)
+					}
+
+					fmt.Printf("successfully created trading strategy: %d\n", s.ID)
+				},
+			}
+			create.Flags().StringVar(&strategyType, "type", "", "the strategy type (see vespyr strategy types)")
+			create.Flags().StringArrayVar(&params, "param", nil, "a strategy parameter of the form name=value (repeatable); see strategy types for defaults")
+			create.Flags().StringVar(&dataFile, "data-file", "", "a YAML file with the strategy's data, which parameters override")
+			create.Flags().Float64VarP(&budget, "budget", "b", 0, "the initial budget in USD")
+			create.Flags().UintVarP(&tickSizeMinutes, "tick-size-minutes", "t", 15, "the size of each tick")
+			create.Flags().StringVar(&product, "product", string(ProductBTCUSD), "the product to use")
+			strategy.AddCommand(create)
+		}()
+
+		func() {
+			var strategyFile, objectiveSpec, startTime, endTime string
+			var seed int64
+			weigh := &cobra.Command{
+				Use:   "weigh",
+				Short: "weighs an ensemble strategy's members by how each performed in a backtest and prints its data",
+				Run: func(cmd *cobra.Command, _ []string) {
+					runner, err := GetRunner()
+					if err != nil {
+						fmt.Printf("error getting runner: %s", err)
+						os.Exit(1)
+					}
+
+					model, err := LoadStrategyFile(strategyFile)
+					if err != nil {
+						fmt.Printf("error loading strategy file: %s\n", err)
+						os.Exit(1)
+					}
+					objective, err := ParseObjective(objectiveSpec)
+					if err != nil {
+						fmt.Printf("error parsing objective: %s\n", err)
+						os.Exit(1)
+					}
+
+					s, err := time.Parse(time.RFC822, startTime)
+					if err != nil {
+						fmt.Printf("error parsing start time: %s", err)
+						os.Exit(1)
+					}
+					e, err := time.Parse(time.RFC822, endTime)
+					if err != nil {
+						fmt.Printf("error parsing end time: %s", err)
+						os.Exit(1)
+					}
+
+					if _, err := WeighEnsemble(s, e, model, runner.BacktestBackend, objective, runner.FillModel, seed); err != nil {
+						fmt.Printf("error weighing ensemble: %s\n", err)
+						os.Exit(1)
+					}
+					fmt.Print(string(model.TradingStrategyData))
+				},
+			}
+			weigh.Flags().StringVar(&strategyFile, "strategy-file", "strategy.yml", "a YAML file describing the ensemble strategy")
+			weigh.Flags().StringVar(&objectiveSpec, "objective", ObjectiveSharpe, "the objective that weights are scored by")
+			weigh.Flags().StringVar(&startTime, "start-time", time.Now().Add(-30*24*time.Hour).Format(time.RFC822), "the start time of the backtests")
+			weigh.Flags().StringVar(&endTime, "end-time", time.Now().Format(time.RFC822), "the end time of the backtests")
+			weigh.Flags().Int64Var(&seed, "seed", 1, "the seed for the backtests' slippage")
+			strategy.AddCommand(weigh)
+		}()
+
+		RootCmd.AddCommand(strategy)
+	}()
 }
 
 type config struct {
-	configFile         string
-	postgresURI        string
-	gdaxPassphrase     string
-	gdaxAPIKey         string
-	gdaxAPISecret      string
-	useFakeExchange    bool
-	slackToken         string
-	slackTradesChannel string
-	slackDataChannel   string
-	rollbarToken       string
+	configFile              string
+	postgresURI             string
+	gdaxPassphrase          string
+	gdaxAPIKey              string
+	gdaxAPISecret           string
+	gdaxRequestsPerSecond   float64
+	krakenRequestsPerSecond float64
+	useFakeExchange         bool
+	slackToken              string
+	slackTradesChannel      string
+	slackDataChannel        string
+	rollbarToken            string
+	candlesFiles            []string
+	fillModel               string
 }
 
 var appConfig = new(config)
 
 // Runner contains singletons exported by the package.
 type Runner struct {
-	Backend                  Backend
-	BTCUSDBot                *Bot
-	ETHUSDBot                *Bot
-	LTCUSDBot                *Bot
-	BTCUSDRealtimeImporter   *RealtimeImporter
-	ETHUSDRealtimeImporter   *RealtimeImporter
-	LTCUSDRealtimeImporter   *RealtimeImporter
-	BTCUSDHistoricalImporter *HistoricalImporter
-	ETHUSDHistoricalImporter *HistoricalImporter
-	LTCUSDHistoricalImporter *HistoricalImporter
-	GDAXExchange             Exchange
-	KrakenExchange           Exchange
+	Backend                Backend
+	BacktestBackend        Backend
+	BTCUSDBot              *Bot
+	ETHUSDBot              *Bot
+	LTCUSDBot              *Bot
+	BTCUSDRealtimeImporter *RealtimeImporter
+	ETHUSDRealtimeImporter *RealtimeImporter
+	LTCUSDRealtimeImporter *RealtimeImporter
+	HistoricalImporters    map[Product]*HistoricalImporter
+	GDAXExchange           Exchange
+	KrakenExchange         Exchange
+	FillModel              FillModel
+}
+
+// Exchange returns the exchange that a product trades on.
+func (r *Runner) Exchange(p Product) Exchange {
+	if meta, ok := ProductToMetadata[p]; ok && meta.ExchangeType == ExchangeKraken {
+		return r.KrakenExchange
+	}
+	return r.GDAXExchange
+}
+
+// HistoricalImporter returns the historical importer for a product.
+func (r *Runner) HistoricalImporter(p Product) (*HistoricalImporter, error) {
+	importer, ok := r.HistoricalImporters[p]
+	if !ok {
+		return nil, errors.Errorf("error: historical import not supported for product: %s", p)
+	}
+	return importer, nil
 }
 
 var (
@@ -943,13 +2112,49 @@ func GetRunner() (*Runner, error) {
 		appRunner.BTCUSDRealtimeImporter = NewRealtimeImporter(ProductBTCUSD, backend, gdax)
 		appRunner.ETHUSDRealtimeImporter = NewRealtimeImporter(ProductETHUSD, backend, gdax)
 		appRunner.LTCUSDRealtimeImporter = NewRealtimeImporter(ProductLTCUSD, backend, gdax)
-		appRunner.BTCUSDHistoricalImporter = NewHistoricalImporter(ProductBTCUSD, gdax, backend, 6)
-		appRunner.LTCUSDHistoricalImporter = NewHistoricalImporter(ProductLTCUSD, gdax, backend, 6)
-		appRunner.ETHUSDHistoricalImporter = NewHistoricalImporter(ProductETHUSD, gdax, backend, 6)
 		appRunner.Backend = backend
+		appRunner.BacktestBackend = backend
+		if files := viper.GetStringSlice("candles_file"); len(files) > 0 {
+			fileBackend, err := LoadFileBackend(files...)
+			if err != nil {
+				return errors.Wrapf(err, "error loading candlestick files")
+			}
+			appRunner.BacktestBackend = fileBackend
+		}
+		fillModel, err := ParseFillModel(viper.GetString("fill_model"))
+		if err != nil {
+			return errors.Wrapf(err, "error parsing fill model")
+		}
+		appRunner.FillModel = fillModel
 		appRunner.GDAXExchange = gdax
 		appRunner.KrakenExchange = kraken
 
+		// Each exchange limits how often its public endpoints can
+		// be called, so all importers on an exchange share a
+		// single limiter.
+		gdaxLimiter, err := NewTokenBucket(viper.GetFloat64("gdax_requests_per_second"), 1, clockwork.NewRealClock())
+		if err != nil {
+			return errors.Wrapf(err, "error creating GDAX rate limiter")
+		}
+		krakenLimiter, err := NewTokenBucket(viper.GetFloat64("kraken_requests_per_second"), 1, clockwork.NewRealClock())
+		if err != nil {
+			return errors.Wrapf(err, "error creating Kraken rate limiter")
+		}
+		limiters := map[ExchangeType]*TokenBucket{
+			ExchangeGDAX:   gdaxLimiter,
+			ExchangeKraken: krakenLimiter,
+		}
+		appRunner.HistoricalImporters = make(map[Product]*HistoricalImporter)
+		for product, meta := range ProductToMetadata {
+			exchange := appRunner.Exchange(product)
+			if !exchange.SupportsHistoricalCandlesticks() {
+				continue
+			}
+			importer := NewHistoricalImporter(product, exchange, backend, 6)
+			importer.SetRateLimiter(limiters[meta.ExchangeType])
+			appRunner.HistoricalImporters[product] = importer
+		}
+
 		return nil
 	}
 	runnerOnce.Do(func() {
@@ -978,9 +2183,15 @@ func init() {
 	viper.BindPFlag("gdax_api_key", RootCmd.PersistentFlags().Lookup("gdax-api-key"))
 	RootCmd.PersistentFlags().StringVar(&appConfig.gdaxAPISecret, "gdax-api-secret", "", "the GDAX API secret")
 	viper.BindPFlag("gdax_api_secret", RootCmd.PersistentFlags().Lookup("gdax-api-secret"))
+	RootCmd.PersistentFlags().Float64Var(&appConfig.gdaxRequestsPerSecond, "gdax-requests-per-second", 3, "the maximum rate of GDAX historical data requests")
+	viper.BindPFlag("gdax_requests_per_second", RootCmd.PersistentFlags().Lookup("gdax-requests-per-second"))
 	RootCmd.PersistentFlags().BoolVar(&appConfig.useFakeExchange, "use-fake-exchange", false, "use a fake exchange")
 	viper.BindPFlag("use_fake_exchange", RootCmd.PersistentFlags().Lookup("use-fake-exchange"))
 
+	// Kraken
+	RootCmd.PersistentFlags().Float64Var(&appConfig.krakenRequestsPerSecond, "kraken-requests-per-second", 1, "the maximum rate of Kraken historical data requests")
+	viper.BindPFlag("kraken_requests_per_second", RootCmd.PersistentFlags().Lookup("kraken-requests-per-second"))
+
 	// Slack
 	RootCmd.PersistentFlags().StringVar(&appConfig.slackToken, "slack-token", "", "the Slack API token")
 	viper.BindPFlag("slack_token", RootCmd.PersistentFlags().Lookup("slack-token"))
@@ -999,6 +2210,14 @@ func init() {
 		"the postgres URI")
 	viper.BindPFlag("postgres", RootCmd.PersistentFlags().Lookup("postgres"))
 
+	// Backtesting
+	RootCmd.PersistentFlags().StringSliceVar(&appConfig.candlesFiles, "candles-file", nil,
+		"candlestick files to backtest against instead of the database")
+	viper.BindPFlag("candles_file", RootCmd.PersistentFlags().Lookup("candles-file"))
+	RootCmd.PersistentFlags().StringVar(&appConfig.fillModel, "fill-model", FillPriceClose,
+		"how backtests fill orders, e.g. next-open,participation=0.1,spread=0.001,impact=0.5,fees=gdax")
+	viper.BindPFlag("fill_model", RootCmd.PersistentFlags().Lookup("fill-model"))
+
 	if configFile := viper.GetString("config_file"); configFile != "" {
 		viper.SetConfigFile(configFile)
 		if err := viper.ReadInConfig(); err != nil {
@@ -0,0 +1,280 @@
+package vespyr
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/MaxHalford/gago"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	RegisterStrategyType(&StrategyType{
+		Name:        TradingStrategyDCA,
+		Description: "buys a fraction of the budget on a schedule, buying more on dips",
+		New:         func() StrategyInterface { return &DCAStrategy{} },
+		Parameters: []*StrategyParameter{
+			{Name: "interval_minutes", Description: "the minutes between buys", Default: 1440, Min: 60, Max: 10080, Integer: true},
+			{Name: "buy_fraction", Description: "the fraction of the initial budget to buy with", Default: .05, Min: .01, Max: .25},
+			{Name: "dip_period", Description: "the period of the close SMA that dips are measured from", Default: 20, Min: 5, Max: 200, Integer: true},
+			{Name: "take_profit", Description: "the profit at which everything is sold, or 0 to never sell", Default: 0, Min: 0, Max: .5},
+		},
+	})
+}
+
+// DCADip multiplies a DCA buy when the price is at least Drop, as a
+// fraction, below its average.
+type DCADip struct {
+	Drop       float64 `yaml:"drop"`
+	Multiplier float64 `yaml:"multiplier"`
+}
+
+// DCAStrategy dollar-cost averages into a product, buying
+// BuyFraction of the model's initial budget every IntervalMinutes, for
+// example:
+//
+//	interval_minutes: 1440
+//	buy_fraction: 0.05
+//	dip_period: 20
+//	dip_multipliers:
+//	- drop: 0.05
+//	  multiplier: 2
+//	- drop: 0.1
+//	  multiplier: 3
+//	take_profit: 0.2
+//
+// buys 5% of the budget a day, twice as much when the price is 5%
+// below its 20 tick average and three times as much when it's 10%
+// below, and sells everything once it's worth 20% more than it cost.
+// Each buy is a level, and the first buy after selling is made
+// straight away.
+type DCAStrategy struct {
+	IntervalMinutes uint      `yaml:"interval_minutes"`
+	BuyFraction     float64   `yaml:"buy_fraction"`
+	DipPeriod       uint      `yaml:"dip_period"`
+	DipMultipliers  []*DCADip `yaml:"dip_multipliers,omitempty"`
+	TakeProfit      float64   `yaml:"take_profit"`
+
+	strategy *TradingStrategyModel
+}
+
+// Validate validates the strategy's parameters.
+func (d *DCAStrategy) Validate() error {
+	if d.IntervalMinutes == 0 {
+		return errors.New("error: the DCA interval must be positive")
+	}
+	if d.BuyFraction <= 0 || d.BuyFraction > 1 {
+		return errors.Errorf("error: the DCA buy fraction must be between 0 and 1: %f", d.BuyFraction)
+	}
+	if d.TakeProfit < 0 {
+		return errors.Errorf("error: the DCA take profit can't be negative: %f", d.TakeProfit)
+	}
+	if len(d.DipMultipliers) > 0 && d.DipPeriod == 0 {
+		return errors.New("error: dip multipliers need a dip period")
+	}
+	for i, dip := range d.DipMultipliers {
+		if dip.Drop <= 0 || dip.Drop >= 1 || dip.Multiplier <= 0 {
+			return errors.Errorf("error: dip %d needs a drop between 0 and 1 and a positive multiplier", i+1)
+		}
+	}
+	return nil
+}
+
+// String returns the string representation of the strategy.
+func (d *DCAStrategy) String() string {
+	var dips []string
+	for _, dip := range d.DipMultipliers {
+		dips = append(dips, fmt.Sprintf("%f: %fx", dip.Drop, dip.Multiplier))
+	}
+	return fmt.Sprintf("DCA: interval: %dm, buy fraction: %f, dip period: %d, dips: [%s], take profit: %f",
+		d.IntervalMinutes, d.BuyFraction, d.DipPeriod, strings.Join(dips, ", "), d.TakeProfit)
+}
+
+// SetTradingStrategy sets the underlying trading strategy.
+func (d *DCAStrategy) SetTradingStrategy(t *TradingStrategyModel) {
+	d.strategy = t
+}
+
+func (d *DCAStrategy) dipIndicator() Indicator {
+	return NewSMAIndicator(d.DipPeriod, PriceSourceClose)
+}
+
+// Indicators returns the indicators used by the strategy. The average
+// is only calculated for dip multipliers.
+func (d *DCAStrategy) Indicators() []Indicator {
+	indicators := []Indicator{levelPriceIndicator()}
+	if len(d.DipMultipliers) > 0 && d.dipIndicator().Name() != indicators[0].Name() {
+		indicators = append(indicators, d.dipIndicator())
+	}
+	return indicators
+}
+
+// multiplier returns the multiplier of the largest dip that the price
+// is in.
+func (d *DCAStrategy) multiplier(history []*IndicatorSet, current int, price float64) (float64, error) {
+	multiplier := float64(1)
+	if len(d.DipMultipliers) == 0 {
+		return multiplier, nil
+	}
+
+	average, err := indicatorValue(history, current, d.dipIndicator().Name())
+	if err != nil {
+		return 0, err
+	}
+	if average.Value <= 0 {
+		return multiplier, nil
+	}
+
+	drop := 1 - price/average.Value
+	var largest float64
+	for _, dip := range d.DipMultipliers {
+		if drop >= dip.Drop && dip.Drop > largest {
+			largest = dip.Drop
+			multiplier = dip.Multiplier
+		}
+	}
+	return multiplier, nil
+}
+
+// Orders sells every level once they're worth TakeProfit more than
+// they cost, and otherwise buys a level when the interval has passed
+// since the last one.
+func (d *DCAStrategy) Orders(history []*IndicatorSet, current int, levels []*StrategyLevel,
+	budget float64) ([]*LevelOrder, error) {
+	price, now, err := levelPrice(history, current)
+	if err != nil {
+		return nil, err
+	}
+
+	var cost, size float64
+	var last time.Time
+	next := 0
+	for _, l := range levels {
+		cost += l.Cost
+		size += l.Size
+		if l.BoughtAt.After(last) {
+			last = l.BoughtAt
+		}
+		if l.Level >= next {
+			next = l.Level + 1
+		}
+	}
+
+	if d.TakeProfit > 0 && len(levels) > 0 && size*price >= cost*(1+d.TakeProfit) {
+		var orders []*LevelOrder
+		for _, l := range levels {
+			orders = append(orders, &LevelOrder{Side: OrderSell, Level: l})
+		}
+		return orders, nil
+	}
+
+	if len(levels) > 0 && now.Sub(last) < time.Duration(d.IntervalMinutes)*time.Minute {
+		return nil, nil
+	}
+
+	multiplier, err := d.multiplier(history, current, price)
+	if err != nil {
+		return nil, err
+	}
+	initialBudget := budget
+	if d.strategy != nil && d.strategy.InitialBudget > 0 {
+		initialBudget = d.strategy.InitialBudget
+	}
+
+	return []*LevelOrder{{
+		Side:  OrderBuy,
+		Level: &StrategyLevel{Level: next, Price: price, BoughtAt: now},
+		Cost:  d.BuyFraction * initialBudget * multiplier,
+	}}, nil
+}
+
+// Buy isn't supported by DCA strategies, which hold several
+// positions.
+func (d *DCAStrategy) Buy([]*IndicatorSet, int) (bool, error) {
+	return false, errLevelSignals
+}
+
+// Sell isn't supported by DCA strategies, which hold several
+// positions.
+func (d *DCAStrategy) Sell([]*IndicatorSet, int) (bool, error) {
+	return false, errLevelSignals
+}
+
+// Rand creates a random version of the strategy within its registered
+// parameters. Dip multipliers are kept.
+func (d *DCAStrategy) Rand(rng *rand.Rand) {
+	d.IntervalMinutes = uint(dcaParameter("interval_minutes").Rand(rng))
+	d.BuyFraction = dcaParameter("buy_fraction").Rand(rng)
+	d.DipPeriod = uint(dcaParameter("dip_period").Rand(rng))
+	d.TakeProfit = dcaParameter("take_profit").Rand(rng)
+}
+
+func dcaParameter(name string) *StrategyParameter {
+	return strategyParameter(TradingStrategyDCA, name)
+}
+
+func (d *DCAStrategy) dips() []*DCADip {
+	var dips []*DCADip
+	for _, dip := range d.DipMultipliers {
+		clone := *dip
+		dips = append(dips, &clone)
+	}
+	return dips
+}
+
+// Clone returns a clone of the current strategy.
+func (d *DCAStrategy) Clone() StrategyGenome {
+	return &DCAStrategy{
+		IntervalMinutes: d.IntervalMinutes,
+		BuyFraction:     d.BuyFraction,
+		DipPeriod:       d.DipPeriod,
+		DipMultipliers:  d.dips(),
+		TakeProfit:      d.TakeProfit,
+	}
+}
+
+// Mutate mutates the underlying strategy within its registered
+// parameters.
+func (d *DCAStrategy) Mutate(rng *rand.Rand) {
+	mutateProb := 0.8
+
+	if rng.Float64() < mutateProb {
+		d.IntervalMinutes = uint(dcaParameter("interval_minutes").Mutate(rng, float64(d.IntervalMinutes)))
+	}
+	if rng.Float64() < mutateProb {
+		d.BuyFraction = dcaParameter("buy_fraction").Mutate(rng, d.BuyFraction)
+	}
+	if rng.Float64() < mutateProb {
+		d.DipPeriod = uint(dcaParameter("dip_period").Mutate(rng, float64(d.DipPeriod)))
+	}
+	if rng.Float64() < mutateProb {
+		d.TakeProfit = dcaParameter("take_profit").Mutate(rng, d.TakeProfit)
+	}
+}
+
+// Crossover crosses over a DCAStrategy with a different one.
+func (d *DCAStrategy) Crossover(m StrategyGenome,
+	r *rand.Rand) (StrategyGenome, StrategyGenome) {
+	mate := m.(*DCAStrategy)
+
+	p1 := []float64{float64(d.IntervalMinutes), d.BuyFraction, float64(d.DipPeriod), d.TakeProfit}
+	p2 := []float64{float64(mate.IntervalMinutes), mate.BuyFraction, float64(mate.DipPeriod), mate.TakeProfit}
+
+	c1, c2 := gago.CrossUniformFloat64(p1, p2, r)
+
+	var children []StrategyGenome
+	for _, c := range [][]float64{c1, c2} {
+		children = append(children, &DCAStrategy{
+			IntervalMinutes: uint(math.Max(c[0], 1)),
+			BuyFraction:     c[1],
+			DipPeriod:       uint(math.Max(c[2], 1)),
+			DipMultipliers:  d.dips(),
+			TakeProfit:      c[3],
+		})
+	}
+
+	return children[0], children[1]
+}
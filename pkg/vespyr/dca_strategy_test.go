@@ -0,0 +1,154 @@
+package vespyr_test
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/DavidHuie/vespyr/pkg/vespyr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDCAStrategyOrders(t *testing.T) {
+	strategy := &vespyr.DCAStrategy{
+		IntervalMinutes: 60,
+		BuyFraction:     .1,
+		DipPeriod:       10,
+		DipMultipliers: []*vespyr.DCADip{
+			{Drop: .05, Multiplier: 2},
+			{Drop: .1, Multiplier: 3},
+		},
+		TakeProfit: .2,
+	}
+	assert.NoError(t, strategy.Validate())
+	strategy.SetTradingStrategy(&vespyr.TradingStrategyModel{InitialBudget: 1000})
+	assert.Len(t, strategy.Indicators(), 2)
+
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	history := func(price, average float64) []*vespyr.IndicatorSet {
+		return []*vespyr.IndicatorSet{{
+			Time: start,
+			Values: []*vespyr.IndicatorValue{
+				{IndicatorName: "sma-1-close", Value: price},
+				{IndicatorName: "sma-10-close", Value: average},
+			},
+		}}
+	}
+
+	// The first buy is made straight away, multiplied by the largest
+	// dip that the price is in.
+	for _, expected := range []struct {
+		price, cost float64
+	}{
+		{100, 100},
+		{94, 200},
+		{89, 300},
+	} {
+		orders, err := strategy.Orders(history(expected.price, 100), 0, nil, 1000)
+		assert.NoError(t, err)
+		if assert.Len(t, orders, 1) {
+			assert.Equal(t, vespyr.OrderBuy, orders[0].Side)
+			assert.Equal(t, 0, orders[0].Level.Level)
+			assert.Equal(t, start, orders[0].Level.BoughtAt)
+			assert.InDelta(t, expected.cost, orders[0].Cost, 1e-9)
+		}
+	}
+
+	// Later buys wait for the interval.
+	levels := []*vespyr.StrategyLevel{
+		{Level: 0, Cost: 100, Size: 1, BoughtAt: start.Add(-time.Hour)},
+		{Level: 1, Cost: 100, Size: 1, BoughtAt: start.Add(-30 * time.Minute)},
+	}
+	orders, err := strategy.Orders(history(100, 100), 0, levels, 800)
+	assert.NoError(t, err)
+	assert.Empty(t, orders)
+
+	levels[1].BoughtAt = start.Add(-time.Hour)
+	orders, err = strategy.Orders(history(100, 100), 0, levels, 800)
+	assert.NoError(t, err)
+	if assert.Len(t, orders, 1) {
+		assert.Equal(t, 2, orders[0].Level.Level)
+	}
+
+	// Everything is sold at the take profit.
+	orders, err = strategy.Orders(history(120, 100), 0, levels, 800)
+	assert.NoError(t, err)
+	if assert.Len(t, orders, 2) {
+		for i, o := range orders {
+			assert.Equal(t, vespyr.OrderSell, o.Side)
+			assert.Equal(t, levels[i], o.Level)
+		}
+	}
+
+	for _, invalid := range []*vespyr.DCAStrategy{
+		{BuyFraction: .1},
+		{IntervalMinutes: 60},
+		{IntervalMinutes: 60, BuyFraction: .1, TakeProfit: -1},
+		{IntervalMinutes: 60, BuyFraction: .1, DipMultipliers: []*vespyr.DCADip{{Drop: .1, Multiplier: 2}}},
+		{IntervalMinutes: 60, BuyFraction: .1, DipPeriod: 10, DipMultipliers: []*vespyr.DCADip{{Drop: 1, Multiplier: 2}}},
+	} {
+		assert.Error(t, invalid.Validate())
+	}
+}
+
+func TestDCAStrategyBacktest(t *testing.T) {
+	candles := timeframeTestCandles()
+	backend := new(vespyr.MockBackend)
+	backend.On("FindCandlesticks", mock.Anything, mock.Anything, vespyr.ProductBTCUSD, int64(15)).
+		Return(candles, nil)
+
+	model, err := vespyr.ParseStrategyFile([]byte(`
+product: BTC-USD
+tick_size_minutes: 15
+history_ticks: 10
+trading_strategy: dca
+trading_strategy_data:
+  interval_minutes: 120
+  buy_fraction: 0.1
+  dip_period: 10
+  dip_multipliers:
+  - drop: 0.03
+    multiplier: 2
+  take_profit: 0.05
+`))
+	assert.NoError(t, err)
+
+	backtester, err := vespyr.NewBacktester(candles[20].StartTime, candles[len(candles)-1].EndTime,
+		model, backend, rand.NewSource(1))
+	assert.NoError(t, err)
+	assert.NoError(t, backtester.Backtest())
+
+	// Buys are spaced by the interval, and bigger on dips. Every
+	// level is sold at once.
+	var buys, sells int
+	var lastBuy time.Time
+	costs := make(map[float64]bool)
+	for _, trade := range backtester.Trades() {
+		if trade.Side == vespyr.OrderSell {
+			sells++
+			lastBuy = time.Time{}
+			continue
+		}
+		buys++
+		costs[trade.Cost] = true
+		if !lastBuy.IsZero() {
+			assert.True(t, trade.Time.Sub(lastBuy) >= 2*time.Hour, "%s", trade.Time)
+		}
+		lastBuy = trade.Time
+	}
+	assert.True(t, buys > sells, "%d buys, %d sells", buys, sells)
+	assert.True(t, sells > 1, "%d sells", sells)
+	assert.True(t, costs[10])
+	assert.True(t, costs[20])
+
+	results := backtester.Results()
+	assert.True(t, results.ProfitTrades > 0)
+	assert.True(t, results.FinalBudget > 0)
+
+	var invested float64
+	for _, l := range model.Levels {
+		invested += l.Size
+	}
+	assert.InDelta(t, model.Invested, invested, 1e-6)
+}
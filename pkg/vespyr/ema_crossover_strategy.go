@@ -8,22 +8,41 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+func init() {
+	RegisterStrategyType(&StrategyType{
+		Name:        TradingStrategyEMACrossover,
+		Description: "buys and sells when the DEMA crosses its thresholds",
+		New:         func() StrategyInterface { return &EMACrossoverStrategy{} },
+		Parameters: []*StrategyParameter{
+			{Name: "short_period", Description: "the EMA short period", Default: 10, Min: 0, Max: 50, Integer: true},
+			{Name: "long_period", Description: "the EMA long period", Default: 25, Min: 0, Max: 100, Integer: true},
+			{Name: "up_threshold", Description: "the DEMA above which to buy", Default: 0, Min: 0, Max: .002},
+			{Name: "down_threshold", Description: "the DEMA below which to sell", Default: 0, Min: -.002, Max: 0},
+		},
+	})
+}
+
 // EMACrossoverStrategy is a strategy for that buys and sells based on
 // EMA crossovers.
 type EMACrossoverStrategy struct {
-	ShortPeriod   uint    `yaml:"short_period"`
-	LongPeriod    uint    `yaml:"long_period"`
-	UpThreshold   float64 `yaml:"up_threshold"`
-	DownThreshold float64 `yaml:"down_threshold"`
+	ShortPeriod      uint    `yaml:"short_period"`
+	LongPeriod       uint    `yaml:"long_period"`
+	UpThreshold      float64 `yaml:"up_threshold"`
+	DownThreshold    float64 `yaml:"down_threshold"`
+	TimeframeOptions `yaml:",inline"`
 
 	strategy *TradingStrategyModel
 }
 
 // String returns the string representation of the strategy.
 func (e *EMACrossoverStrategy) String() string {
-	return fmt.Sprintf("EMA Crossover: short period: %d, long period: %d, up threshold: %f, down threshold %f",
+	str := fmt.Sprintf("EMA Crossover: short period: %d, long period: %d, up threshold: %f, down threshold %f",
 		e.ShortPeriod, e.LongPeriod, e.UpThreshold, e.DownThreshold,
 	)
+	if e.TimeframeMinutes != 0 {
+		str += fmt.Sprintf(", timeframe: %dm", e.TimeframeMinutes)
+	}
+	return str
 }
 
 // SetTradingStrategy sets the underlying trading strategy.
@@ -34,34 +53,28 @@ func (e *EMACrossoverStrategy) SetTradingStrategy(t *TradingStrategyModel) {
 // Indicators returns the indicators returned by the strategy.
 func (e *EMACrossoverStrategy) Indicators() []Indicator {
 	var indicators []Indicator
-	indicators = append(indicators, NewDEMAIndicator(e.ShortPeriod, e.LongPeriod))
-	indicators = append(indicators, NewEMAIndicator(e.ShortPeriod))
-	indicators = append(indicators, NewEMAIndicator(e.LongPeriod))
+	indicators = append(indicators, e.demaIndicator())
+	indicators = append(indicators, e.timeframe(NewEMAIndicator(e.ShortPeriod)))
+	indicators = append(indicators, e.timeframe(NewEMAIndicator(e.LongPeriod)))
 	return indicators
 }
 
+func (e *EMACrossoverStrategy) demaIndicator() Indicator {
+	return e.timeframe(NewDEMAIndicator(e.ShortPeriod, e.LongPeriod))
+}
+
 // Buy determines whether the currency should be bought using the
 // indicator history.
 func (e *EMACrossoverStrategy) Buy(history []*IndicatorSet, current int) (bool, error) {
-	if len(history)-1 < current {
-		return false, ErrNotEnoughData
-	}
-
-	currentValues := history[current].Values
-
-	dema := currentValues[0]
-	if dema == nil {
-		return false, ErrNotEnoughData
+	dema, err := indicatorValue(history, current, e.demaIndicator().Name())
+	if err != nil {
+		return false, err
 	}
 
 	message := fmt.Sprintf("ema crossover (%d, %s) buy dema value: %f, up threshold: %f",
 		e.strategy.ID, e.strategy.Product, dema.Value, e.UpThreshold)
 	logrus.Debug(message)
-	PostStrategyDataToSlack(e, e.strategy, map[string]interface{}{
-		currentValues[0].IndicatorName: currentValues[0].Value,
-		currentValues[1].IndicatorName: currentValues[1].Value,
-		currentValues[2].IndicatorName: currentValues[2].Value,
-	})
+	PostStrategyDataToSlack(e, e.strategy, history[current].Map())
 
 	return (dema.Value > e.UpThreshold), nil
 }
@@ -69,69 +82,59 @@ func (e *EMACrossoverStrategy) Buy(history []*IndicatorSet, current int) (bool,
 // Sell determines whether the currency should be sold using the
 // indicator history.
 func (e *EMACrossoverStrategy) Sell(history []*IndicatorSet, current int) (bool, error) {
-	if len(history)-1 < current {
-		return false, ErrNotEnoughData
-	}
-
-	currentValues := history[current].Values
-
-	dema := currentValues[0]
-	if dema == nil {
-		return false, ErrNotEnoughData
+	dema, err := indicatorValue(history, current, e.demaIndicator().Name())
+	if err != nil {
+		return false, err
 	}
 
 	message := fmt.Sprintf("ema crossover (%d, %s) sell dema value: %f, down threshold: %f",
 		e.strategy.ID, e.strategy.Product, dema.Value, e.DownThreshold)
 	logrus.Debug(message)
-	PostStrategyDataToSlack(e, e.strategy, map[string]interface{}{
-		currentValues[0].IndicatorName: currentValues[0].Value,
-		currentValues[1].IndicatorName: currentValues[1].Value,
-		currentValues[2].IndicatorName: currentValues[2].Value,
-	})
+	PostStrategyDataToSlack(e, e.strategy, history[current].Map())
 
 	return (dema.Value < e.DownThreshold), nil
 }
 
-// Rand creates a random version of the strategy.
+// Rand creates a random version of the strategy within its registered
+// parameters.
 func (e *EMACrossoverStrategy) Rand(rng *rand.Rand) {
-	e.ShortPeriod = uint(rng.Float64() * 50)
-	e.LongPeriod = 2 * e.ShortPeriod
-	e.UpThreshold = rng.Float64() * .002
-	e.DownThreshold = -e.UpThreshold
+	e.ShortPeriod = uint(emaCrossoverParameter("short_period").Rand(rng))
+	e.LongPeriod = uint(emaCrossoverParameter("long_period").Clamp(2 * float64(e.ShortPeriod)))
+	e.UpThreshold = emaCrossoverParameter("up_threshold").Rand(rng)
+	e.DownThreshold = emaCrossoverParameter("down_threshold").Clamp(-e.UpThreshold)
+}
+
+func emaCrossoverParameter(name string) *StrategyParameter {
+	return strategyParameter(TradingStrategyEMACrossover, name)
 }
 
 // Clone returns a clone of the current strategy.
 func (e *EMACrossoverStrategy) Clone() StrategyGenome {
 	return &EMACrossoverStrategy{
-		ShortPeriod:   e.ShortPeriod,
-		LongPeriod:    e.LongPeriod,
-		UpThreshold:   e.UpThreshold,
-		DownThreshold: e.DownThreshold,
+		ShortPeriod:      e.ShortPeriod,
+		LongPeriod:       e.LongPeriod,
+		UpThreshold:      e.UpThreshold,
+		DownThreshold:    e.DownThreshold,
+		TimeframeOptions: e.TimeframeOptions,
 	}
 }
 
-// Mutate mutates the underlying strategy.
+// Mutate mutates the underlying strategy within its registered
+// parameters.
 func (e *EMACrossoverStrategy) Mutate(rng *rand.Rand) {
 	mutateProb := 0.8
 
-	thresholds := []float64{e.DownThreshold, e.UpThreshold}
-	gago.MutNormalFloat64(thresholds, mutateProb, rng)
-	e.DownThreshold, e.UpThreshold = thresholds[0], thresholds[1]
-
 	if rng.Float64() < mutateProb {
-		x := int(e.ShortPeriod)
-		x += int(float64(e.ShortPeriod) * rng.NormFloat64())
-		if x > 0 {
-			e.ShortPeriod = uint(x) % 100
-		}
+		e.DownThreshold = emaCrossoverParameter("down_threshold").Mutate(rng, e.DownThreshold)
+	}
+	if rng.Float64() < mutateProb {
+		e.UpThreshold = emaCrossoverParameter("up_threshold").Mutate(rng, e.UpThreshold)
+	}
+	if rng.Float64() < mutateProb {
+		e.ShortPeriod = uint(emaCrossoverParameter("short_period").Mutate(rng, float64(e.ShortPeriod)))
 	}
-
 	if rng.Float64() < mutateProb {
-		x := int(e.LongPeriod)
-		x += int(float64(e.LongPeriod) * rng.NormFloat64())
-		if x > 0 {
-			e.LongPeriod = uint(x) % 100
-		}
+		e.LongPeriod = uint(emaCrossoverParameter("long_period").Mutate(rng, float64(e.LongPeriod)))
 	}
 
 	if e.LongPeriod < e.ShortPeriod {
@@ -153,20 +156,22 @@ func (e *EMACrossoverStrategy) Crossover(m StrategyGenome,
 	c1, c2 := gago.CrossUniformFloat64(p1, p2, r)
 
 	s1 := &EMACrossoverStrategy{
-		ShortPeriod:   uint(c1[0]),
-		LongPeriod:    uint(c1[1]),
-		UpThreshold:   c1[2],
-		DownThreshold: c1[3],
+		ShortPeriod:      uint(c1[0]),
+		LongPeriod:       uint(c1[1]),
+		UpThreshold:      c1[2],
+		DownThreshold:    c1[3],
+		TimeframeOptions: e.TimeframeOptions,
 	}
 	if s1.LongPeriod < s1.ShortPeriod {
 		s1.ShortPeriod = s1.LongPeriod
 	}
 
 	s2 := &EMACrossoverStrategy{
-		ShortPeriod:   uint(c2[0]),
-		LongPeriod:    uint(c2[1]),
-		UpThreshold:   c2[2],
-		DownThreshold: c2[3],
+		ShortPeriod:      uint(c2[0]),
+		LongPeriod:       uint(c2[1]),
+		UpThreshold:      c2[2],
+		DownThreshold:    c2[3],
+		TimeframeOptions: e.TimeframeOptions,
 	}
 	if s2.LongPeriod < s2.ShortPeriod {
 		s2.ShortPeriod = s2.LongPeriod
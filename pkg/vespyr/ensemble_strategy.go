@@ -0,0 +1,338 @@
+package vespyr
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/MaxHalford/gago"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// EnsembleVoteMajority buys or sells when more than half of an
+	// ensemble's strategies do.
+	EnsembleVoteMajority = "majority"
+	// EnsembleVoteWeighted buys or sells when the strategies that
+	// do hold more than the threshold of the ensemble's weight.
+	EnsembleVoteWeighted = "weighted"
+	// EnsembleVoteUnanimous buys or sells when every strategy
+	// does.
+	EnsembleVoteUnanimous = "unanimous"
+
+	defaultEnsembleThreshold = .5
+)
+
+func init() {
+	RegisterStrategyType(&StrategyType{
+		Name:        TradingStrategyEnsemble,
+		Description: "buys and sells by a vote of several strategies",
+		New:         func() StrategyInterface { return &EnsembleStrategy{} },
+	})
+}
+
+// EnsembleMember is a strategy in an ensemble. Data is the strategy's
+// YAML data, decoded by the strategy type named by Type.
+type EnsembleMember struct {
+	Type   string        `yaml:"type"`
+	Weight float64       `yaml:"weight,omitempty"`
+	Data   yaml.MapSlice `yaml:"data"`
+
+	strategy StrategyGenome
+}
+
+// EnsembleStrategy combines the buy and sell signals of several
+// strategies by a vote, for example:
+//
+//	vote: weighted
+//	threshold: 0.5
+//	strategies:
+//	- type: rsi
+//	  weight: 2
+//	  data:
+//	    period: 14
+//	    buy_threshold: 30
+//	    sell_threshold: 70
+//	- type: ema-crossover
+//	  weight: 1
+//	  data:
+//	    short_period: 10
+//	    long_period: 25
+//
+// Vote defaults to majority, which ignores weights. Weights can be set
+// from each strategy's recent performance with WeighEnsemble, and are
+// what the ensemble's genome tunes; the strategies themselves aren't
+// changed.
+type EnsembleStrategy struct {
+	Vote      string            `yaml:"vote,omitempty"`
+	Threshold float64           `yaml:"threshold,omitempty"`
+	Members   []*EnsembleMember `yaml:"strategies"`
+
+	strategy *TradingStrategyModel
+}
+
+// Validate decodes and validates the ensemble's strategies.
+func (e *EnsembleStrategy) Validate() error {
+	switch e.vote() {
+	case EnsembleVoteMajority, EnsembleVoteWeighted, EnsembleVoteUnanimous:
+	default:
+		return errors.Errorf("error: unknown ensemble vote: %s", e.Vote)
+	}
+	if e.Threshold < 0 || e.Threshold >= 1 {
+		return errors.Errorf("error: ensemble threshold must be between 0 and 1: %f", e.Threshold)
+	}
+	if len(e.Members) == 0 {
+		return errors.New("error: an ensemble needs at least one strategy")
+	}
+
+	for i, m := range e.Members {
+		if m.Weight < 0 {
+			return errors.Errorf("error: strategy %d has a negative weight: %f", i+1, m.Weight)
+		}
+		if err := m.decode(); err != nil {
+			return errors.Wrapf(err, "error decoding strategy %d", i+1)
+		}
+	}
+	return nil
+}
+
+func (m *EnsembleMember) decode() error {
+	t, err := LookupStrategyType(m.Type)
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(m.Data)
+	if err != nil {
+		return errors.Wrapf(err, "error YAML marshalling strategy data")
+	}
+	strategy, err := t.Decode(data)
+	if err != nil {
+		return err
+	}
+
+	// Pair strategies pick a product and level strategies pick
+	// orders rather than buying and selling, so they can't vote.
+	if _, ok := strategy.(PairStrategyInterface); ok {
+		return errors.Errorf("error: %s strategies can't be part of an ensemble", m.Type)
+	}
+	if _, ok := strategy.(LevelStrategyInterface); ok {
+		return errors.Errorf("error: %s strategies can't be part of an ensemble", m.Type)
+	}
+	genome, ok := strategy.(StrategyGenome)
+	if !ok {
+		return errors.Errorf("error: %s strategies can't be optimized", m.Type)
+	}
+	m.strategy = genome
+	return nil
+}
+
+func (m *EnsembleMember) clone(weight float64) *EnsembleMember {
+	return &EnsembleMember{
+		Type:     m.Type,
+		Weight:   weight,
+		Data:     m.Data,
+		strategy: m.strategy.Clone(),
+	}
+}
+
+func (e *EnsembleStrategy) vote() string {
+	if e.Vote == "" {
+		return EnsembleVoteMajority
+	}
+	return e.Vote
+}
+
+func (e *EnsembleStrategy) threshold() float64 {
+	if e.Threshold == 0 {
+		return defaultEnsembleThreshold
+	}
+	return e.Threshold
+}
+
+// String returns the string representation of the strategy.
+func (e *EnsembleStrategy) String() string {
+	var members []string
+	for _, m := range e.Members {
+		members = append(members, fmt.Sprintf("%f x %s", m.Weight, m.strategy))
+	}
+	return fmt.Sprintf("Ensemble (%s): [%s]", e.vote(), strings.Join(members, "; "))
+}
+
+// SetTradingStrategy sets the underlying trading strategy of the
+// ensemble and its strategies.
+func (e *EnsembleStrategy) SetTradingStrategy(t *TradingStrategyModel) {
+	e.strategy = t
+	for _, m := range e.Members {
+		m.strategy.SetTradingStrategy(t)
+	}
+}
+
+// Indicators returns the indicators used by any of the strategies.
+// Strategies that share an indicator share its values.
+func (e *EnsembleStrategy) Indicators() []Indicator {
+	var indicators []Indicator
+	names := make(map[string]bool)
+	for _, m := range e.Members {
+		for _, indicator := range m.strategy.Indicators() {
+			if names[indicator.Name()] {
+				continue
+			}
+			names[indicator.Name()] = true
+			indicators = append(indicators, indicator)
+		}
+	}
+	return indicators
+}
+
+// count tallies the strategies and weight in favor of a signal.
+func (e *EnsembleStrategy) count(signal func(StrategyInterface) (bool, error)) (bool, error) {
+	var votes int
+	var weight, total float64
+	for _, m := range e.Members {
+		total += m.Weight
+		ok, err := signal(m.strategy)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			votes++
+			weight += m.Weight
+		}
+	}
+
+	switch e.vote() {
+	case EnsembleVoteWeighted:
+		return total > 0 && weight > e.threshold()*total, nil
+	case EnsembleVoteUnanimous:
+		return votes == len(e.Members), nil
+	default:
+		return 2*votes > len(e.Members), nil
+	}
+}
+
+// Buy determines whether the currency should be bought by a vote of
+// the strategies.
+func (e *EnsembleStrategy) Buy(history []*IndicatorSet, current int) (bool, error) {
+	return e.count(func(s StrategyInterface) (bool, error) {
+		return s.Buy(history, current)
+	})
+}
+
+// Sell determines whether the currency should be sold by a vote of
+// the strategies.
+func (e *EnsembleStrategy) Sell(history []*IndicatorSet, current int) (bool, error) {
+	return e.count(func(s StrategyInterface) (bool, error) {
+		return s.Sell(history, current)
+	})
+}
+
+func (e *EnsembleStrategy) weights() []float64 {
+	var weights []float64
+	for _, m := range e.Members {
+		weights = append(weights, m.Weight)
+	}
+	return weights
+}
+
+func (e *EnsembleStrategy) withWeights(weights []float64) *EnsembleStrategy {
+	clone := &EnsembleStrategy{
+		Vote:      e.Vote,
+		Threshold: e.Threshold,
+		strategy:  e.strategy,
+	}
+	for i, m := range e.Members {
+		clone.Members = append(clone.Members, m.clone(weights[i]))
+	}
+	if e.strategy != nil {
+		clone.SetTradingStrategy(e.strategy)
+	}
+	return clone
+}
+
+// Rand randomizes the strategies' weights.
+func (e *EnsembleStrategy) Rand(rng *rand.Rand) {
+	for _, m := range e.Members {
+		m.Weight = rng.Float64()
+	}
+}
+
+// Clone returns a clone of the current strategy.
+func (e *EnsembleStrategy) Clone() StrategyGenome {
+	return e.withWeights(e.weights())
+}
+
+// Mutate mutates the strategies' weights.
+func (e *EnsembleStrategy) Mutate(rng *rand.Rand) {
+	mutateProb := 0.8
+
+	for _, m := range e.Members {
+		if rng.Float64() < mutateProb {
+			// Weights that have fallen to zero can recover.
+			x := m.Weight
+			x += math.Max(x, .1) * rng.NormFloat64()
+			m.Weight = math.Max(x, 0)
+		}
+	}
+}
+
+// Crossover crosses over the weights of an EnsembleStrategy with a
+// different one with the same strategies.
+func (e *EnsembleStrategy) Crossover(m StrategyGenome,
+	r *rand.Rand) (StrategyGenome, StrategyGenome) {
+	mate := m.(*EnsembleStrategy)
+
+	c1, c2 := gago.CrossUniformFloat64(e.weights(), mate.weights(), r)
+
+	return e.withWeights(c1), e.withWeights(c2)
+}
+
+// WeighEnsemble backtests each of an ensemble model's strategies by
+// itself between two times and sets its weight to its objective score.
+// Strategies that score below zero or have no finite score get no
+// weight. Every backtest uses the same seed. The model's data is
+// updated with the weights.
+func WeighEnsemble(startTime, endTime time.Time, model *TradingStrategyModel,
+	backend Backend, objective Objective, fillModel FillModel, seed int64) (*EnsembleStrategy, error) {
+	strategy, err := model.Strategy()
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting model strategy")
+	}
+	ensemble, ok := strategy.(*EnsembleStrategy)
+	if !ok {
+		return nil, errors.Errorf("error: %s isn't an ensemble strategy", model.TradingStrategy)
+	}
+
+	backend = NewCacheingBackend(backend)
+	cache := NewIndicatorCache()
+	for i, m := range ensemble.Members {
+		memberModel := model.Copy()
+		if err := memberModel.SetStrategy(m.strategy); err != nil {
+			return nil, errors.Wrapf(err, "error setting strategy %d", i+1)
+		}
+
+		backtester, err := NewBacktester(startTime, endTime, memberModel, backend, rand.NewSource(seed))
+		if err != nil {
+			return nil, errors.Wrapf(err, "error creating backtester for strategy %d", i+1)
+		}
+		backtester.SetIndicatorCache(cache)
+		if fillModel != nil {
+			backtester.SetFillModel(fillModel)
+		}
+		if err := backtester.Backtest(); err != nil {
+			return nil, errors.Wrapf(err, "error backtesting strategy %d", i+1)
+		}
+
+		m.Weight = objective.Score(backtester.Results())
+		if m.Weight < 0 || math.IsInf(m.Weight, 0) || math.IsNaN(m.Weight) {
+			m.Weight = 0
+		}
+	}
+
+	if err := model.SetStrategy(ensemble); err != nil {
+		return nil, errors.Wrapf(err, "error setting ensemble strategy")
+	}
+	return ensemble, nil
+}
@@ -0,0 +1,195 @@
+package vespyr_test
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/DavidHuie/vespyr/pkg/vespyr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// ensembleTestModel returns an ensemble of three RSI strategies that
+// buy below 20, 30 and 40 and sell above 60, 70 and 80.
+func ensembleTestModel(t *testing.T, vote string) *vespyr.TradingStrategyModel {
+	var members string
+	for i, weight := range []float64{5, 1, 1} {
+		members += fmt.Sprintf(`
+  - type: rsi
+    weight: %f
+    data:
+      period: 14
+      buy_threshold: %d
+      sell_threshold: %d`, weight, 20+10*i, 60+10*i)
+	}
+
+	model, err := vespyr.ParseStrategyFile([]byte(fmt.Sprintf(`
+product: BTC-USD
+tick_size_minutes: 15
+history_ticks: 96
+trading_strategy: ensemble
+trading_strategy_data:
+  vote: %s
+  strategies:%s
+`, vote, members)))
+	assert.NoError(t, err)
+	return model
+}
+
+func TestEnsembleStrategy(t *testing.T) {
+	history := []*vespyr.IndicatorSet{
+		{Values: []*vespyr.IndicatorValue{{IndicatorName: "rsi-14", Value: 25}}},
+		{Values: []*vespyr.IndicatorValue{{IndicatorName: "rsi-14", Value: 15}}},
+		{Values: []*vespyr.IndicatorValue{{IndicatorName: "rsi-14", Value: 65}}},
+	}
+
+	for _, expected := range []struct {
+		vote string
+		buy  []bool
+		sell []bool
+	}{
+		{vespyr.EnsembleVoteMajority, []bool{true, true, false}, []bool{false, false, false}},
+		{vespyr.EnsembleVoteWeighted, []bool{false, true, false}, []bool{false, false, true}},
+		{vespyr.EnsembleVoteUnanimous, []bool{false, true, false}, []bool{false, false, false}},
+	} {
+		strategy, err := ensembleTestModel(t, expected.vote).Strategy()
+		if !assert.NoError(t, err) {
+			continue
+		}
+
+		// The strategies share their RSI.
+		indicators := strategy.Indicators()
+		if assert.Len(t, indicators, 1) {
+			assert.Equal(t, "rsi-14", indicators[0].Name())
+		}
+
+		for i := range history {
+			buy, err := strategy.Buy(history, i)
+			assert.NoError(t, err)
+			assert.Equal(t, expected.buy[i], buy, "%s buy %d", expected.vote, i)
+			sell, err := strategy.Sell(history, i)
+			assert.NoError(t, err)
+			assert.Equal(t, expected.sell[i], sell, "%s sell %d", expected.vote, i)
+		}
+	}
+}
+
+func TestEnsembleStrategyGenome(t *testing.T) {
+	model := ensembleTestModel(t, vespyr.EnsembleVoteWeighted)
+	strategy, err := model.Strategy()
+	assert.NoError(t, err)
+	ensemble := strategy.(*vespyr.EnsembleStrategy)
+
+	rng := rand.New(rand.NewSource(1))
+	ensemble.Rand(rng)
+	for i := 0; i < 100; i++ {
+		ensemble.Mutate(rng)
+		for _, m := range ensemble.Members {
+			assert.True(t, m.Weight >= 0)
+		}
+	}
+
+	clone := ensemble.Clone().(*vespyr.EnsembleStrategy)
+	clone.Members[0].Weight = 100
+	assert.NotEqual(t, float64(100), ensemble.Members[0].Weight)
+
+	c1, c2 := ensemble.Crossover(clone, rng)
+	for _, c := range []vespyr.StrategyGenome{c1, c2} {
+		child := c.(*vespyr.EnsembleStrategy)
+		if assert.Len(t, child.Members, 3) {
+			assert.Equal(t, "rsi", child.Members[0].Type)
+		}
+
+		// Weights survive being stored on the model.
+		assert.NoError(t, model.SetStrategy(child))
+		decoded, err := model.Strategy()
+		assert.NoError(t, err)
+		for i, m := range decoded.(*vespyr.EnsembleStrategy).Members {
+			assert.InDelta(t, child.Members[i].Weight, m.Weight, 1e-4)
+		}
+	}
+}
+
+func TestEnsembleStrategyValidate(t *testing.T) {
+	for _, data := range []string{
+		"strategies: []",
+		"vote: plurality\nstrategies:\n- type: rsi\n  data: {period: 14}",
+		"strategies:\n- type: unknown\n  data: {}",
+		"strategies:\n- type: rsi\n  weight: -1\n  data: {period: 14}",
+		"strategies:\n- type: spread\n  data: {period: 20, entry_z_score: 2, exit_z_score: 0}",
+		"strategies:\n- type: grid\n  data: {lower_price: 90, upper_price: 110, levels: 4}",
+		"strategies:\n- type: dca\n  data: {interval_minutes: 60, buy_fraction: 0.1}",
+	} {
+		model := &vespyr.TradingStrategyModel{
+			TradingStrategy:     vespyr.TradingStrategyEnsemble,
+			TradingStrategyData: []byte(data),
+		}
+		_, err := model.Strategy()
+		assert.Error(t, err, data)
+	}
+}
+
+// constantObjective scores every backtest the same.
+type constantObjective float64
+
+func (c constantObjective) Score(*vespyr.BacktestResults) float64 { return float64(c) }
+func (c constantObjective) String() string                        { return fmt.Sprint(float64(c)) }
+
+func TestWeighEnsemble(t *testing.T) {
+	candles := timeframeTestCandles()
+	backend := new(vespyr.MockBackend)
+	backend.On("FindCandlesticks", mock.Anything, mock.Anything, vespyr.ProductBTCUSD, int64(15)).
+		Return(candles, nil)
+
+	model := ensembleTestModel(t, vespyr.EnsembleVoteWeighted)
+	objective, err := vespyr.NewMetricObjective(vespyr.ObjectiveNetProfit)
+	assert.NoError(t, err)
+
+	ensemble, err := vespyr.WeighEnsemble(candles[20].StartTime, candles[len(candles)-1].EndTime,
+		model, backend, objective, nil, 7)
+	assert.NoError(t, err)
+
+	// Each weight is the net profit of its strategy by itself.
+	assert.True(t, ensemble.Members[1].Weight > 0)
+	for i, m := range ensemble.Members {
+		member := ensembleTestModel(t, vespyr.EnsembleVoteWeighted)
+		assert.NoError(t, member.SetStrategy(&vespyr.RSIStrategy{
+			Period:        14,
+			BuyThreshold:  float64(20 + 10*i),
+			SellThreshold: float64(60 + 10*i),
+		}))
+		backtester, err := vespyr.NewBacktester(candles[20].StartTime, candles[len(candles)-1].EndTime,
+			member, backend, rand.NewSource(7))
+		assert.NoError(t, err)
+		assert.NoError(t, backtester.Backtest())
+		expected := backtester.Results().NetProfit()
+		if expected < 0 {
+			expected = 0
+		}
+		assert.InDelta(t, expected, m.Weight, 1e-6, "%d", i)
+	}
+
+	// The model stores the weights.
+	strategy, err := model.Strategy()
+	assert.NoError(t, err)
+	for i, m := range strategy.(*vespyr.EnsembleStrategy).Members {
+		assert.InDelta(t, ensemble.Members[i].Weight, m.Weight, 1e-4)
+	}
+
+	// Undefined scores get no weight.
+	model = ensembleTestModel(t, vespyr.EnsembleVoteWeighted)
+	ensemble, err = vespyr.WeighEnsemble(candles[20].StartTime, candles[len(candles)-1].EndTime,
+		model, backend, constantObjective(math.NaN()), nil, 7)
+	assert.NoError(t, err)
+	for _, m := range ensemble.Members {
+		assert.Equal(t, float64(0), m.Weight)
+	}
+
+	model = ensembleTestModel(t, vespyr.EnsembleVoteWeighted)
+	assert.NoError(t, model.SetStrategy(&vespyr.RSIStrategy{Period: 14}))
+	_, err = vespyr.WeighEnsemble(candles[20].StartTime, candles[len(candles)-1].EndTime,
+		model, backend, objective, nil, 7)
+	assert.Error(t, err)
+}
@@ -175,6 +175,13 @@ type Exchange interface {
 	CreateMarketOrder(*MarketOrder) (*CreateMarketOrderResponse, error)
 	StreamCandlesticks(ctx context.Context, product Product) (<-chan *CandlestickModel, error)
 	EmitsFullCandlesticks() bool
+	SupportsHistoricalCandlesticks() bool
+}
+
+// RateLimitedExchange is an exchange that takes a token from a rate
+// limiter before each request it makes for historical candlesticks.
+type RateLimitedExchange interface {
+	SetRateLimiter(*TokenBucket)
 }
 
 // CreateMarketOrderResponse is the create market order response.
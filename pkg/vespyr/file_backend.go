@@ -0,0 +1,227 @@
+package vespyr
+
+import (
+	"os"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrReadOnlyBackend is returned by backends that can only be used to
+// read candlesticks.
+var ErrReadOnlyBackend = errors.New("error: operation not supported by read only backend")
+
+// FileBackend is a read only Backend that serves candlesticks loaded
+// from exported candlestick files. It allows backtests to run without
+// a database.
+type FileBackend struct {
+	candles map[Product][]*CandlestickModel
+}
+
+// NewFileBackend creates a FileBackend from candlesticks.
+func NewFileBackend(candles []*CandlestickModel) *FileBackend {
+	f := &FileBackend{
+		candles: make(map[Product][]*CandlestickModel),
+	}
+
+	for _, c := range candles {
+		f.candles[c.Product] = append(f.candles[c.Product], c)
+	}
+
+	// Sort each product and keep the last candlestick loaded for
+	// a start time, so later files take precedence.
+	for product, list := range f.candles {
+		sort.SliceStable(list, func(i, j int) bool {
+			return list[i].StartTime.Before(list[j].StartTime)
+		})
+
+		var deduped []*CandlestickModel
+		for _, c := range list {
+			if n := len(deduped); n > 0 && deduped[n-1].StartTime.Equal(c.StartTime) {
+				deduped[n-1] = c
+				continue
+			}
+			deduped = append(deduped, c)
+		}
+		f.candles[product] = deduped
+	}
+
+	return f
+}
+
+// LoadFileBackend creates a FileBackend from candlestick files. The
+// format of each file is inferred from its extension.
+func LoadFileBackend(paths ...string) (*FileBackend, error) {
+	var candles []*CandlestickModel
+	for _, path := range paths {
+		format, err := ParseCandlestickFileFormat("", path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error parsing format of %s", path)
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error opening %s", path)
+		}
+		read, err := ReadCandlesticks(file, format)
+		file.Close()
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading %s", path)
+		}
+
+		candles = append(candles, read...)
+	}
+
+	return NewFileBackend(candles), nil
+}
+
+// rangeCandlesticks returns the candlesticks for a product that start
+// at or after startTime and end at or before endTime.
+func (f *FileBackend) rangeCandlesticks(startTime, endTime time.Time, product Product) []*CandlestickModel {
+	list := f.candles[product]
+	first := sort.Search(len(list), func(i int) bool {
+		return !list[i].StartTime.Before(startTime)
+	})
+
+	var candles []*CandlestickModel
+	for _, c := range list[first:] {
+		if c.StartTime.After(endTime) {
+			break
+		}
+		if c.EndTime.After(endTime) {
+			continue
+		}
+		candles = append(candles, c)
+	}
+	return candles
+}
+
+// FindCandlesticks locates candlesticks within a range and reprojects
+// them.
+func (f *FileBackend) FindCandlesticks(startTime, endTime time.Time,
+	product Product, tickSizeMinutes int64) ([]*CandlestickModel, error) {
+	projections, err := ReprojectCandlesticks(f.rangeCandlesticks(startTime, endTime, product),
+		product, tickSizeMinutes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error projecting candlesticks")
+	}
+	return projections, nil
+}
+
+// FindMostRecentCandlestick returns the latest candlestick for a
+// product.
+func (f *FileBackend) FindMostRecentCandlestick(p Product) (*CandlestickModel, error) {
+	list := f.candles[p]
+	if len(list) == 0 {
+		return nil, errors.Errorf("error finding most recent candlestick: no candlesticks for %s", p)
+	}
+	return list[len(list)-1], nil
+}
+
+// FindMissingCandlestickTimes returns the start times of the minute
+// buckets within a range that don't have a candlestick.
+func (f *FileBackend) FindMissingCandlestickTimes(product Product,
+	startTime, endTime time.Time) ([]time.Time, error) {
+	start := CandlestickBucket(startTime, dbCandlestickBucketSize)
+	end := CandlestickBucket(endTime, dbCandlestickBucketSize)
+
+	seen := make(map[time.Time]bool)
+	for _, c := range f.rangeCandlesticks(start, end.Add(time.Minute), product) {
+		seen[c.StartTime.UTC()] = true
+	}
+
+	var missing []time.Time
+	for t := start; !t.After(end); t = t.Add(time.Minute) {
+		if !seen[t.UTC()] {
+			missing = append(missing, t)
+		}
+	}
+	return missing, nil
+}
+
+// UpsertCandlestick isn't supported.
+func (f *FileBackend) UpsertCandlestick(*CandlestickModel) error {
+	return ErrReadOnlyBackend
+}
+
+// FindCandlestickByID isn't supported since file candlesticks don't
+// have IDs.
+func (f *FileBackend) FindCandlestickByID(int64) (*CandlestickModel, error) {
+	return nil, ErrReadOnlyBackend
+}
+
+// CreateMarketOrder isn't supported.
+func (f *FileBackend) CreateMarketOrder(*MarketOrderModel) error {
+	return ErrReadOnlyBackend
+}
+
+// FindMarketOrderByID isn't supported.
+func (f *FileBackend) FindMarketOrderByID(int64) (*MarketOrderModel, error) {
+	return nil, ErrReadOnlyBackend
+}
+
+// FindTradingStrategyByID isn't supported.
+func (f *FileBackend) FindTradingStrategyByID(int64) (*TradingStrategyModel, error) {
+	return nil, ErrReadOnlyBackend
+}
+
+// CreateTradingStrategy isn't supported.
+func (f *FileBackend) CreateTradingStrategy(*TradingStrategyModel) error {
+	return ErrReadOnlyBackend
+}
+
+// UpdateTradingStrategy isn't supported.
+func (f *FileBackend) UpdateTradingStrategy(*TradingStrategyModel) error {
+	return ErrReadOnlyBackend
+}
+
+// FindActiveTradingStrategies returns no strategies.
+func (f *FileBackend) FindActiveTradingStrategies(Product) ([]*TradingStrategyModel, error) {
+	return nil, nil
+}
+
+// CreateImportJob isn't supported.
+func (f *FileBackend) CreateImportJob(*ImportJobModel, []*ImportBatchModel) error {
+	return ErrReadOnlyBackend
+}
+
+// FindImportJobByID isn't supported.
+func (f *FileBackend) FindImportJobByID(int64) (*ImportJobModel, error) {
+	return nil, ErrReadOnlyBackend
+}
+
+// UpdateImportJob isn't supported.
+func (f *FileBackend) UpdateImportJob(*ImportJobModel) error {
+	return ErrReadOnlyBackend
+}
+
+// FindImportBatches isn't supported.
+func (f *FileBackend) FindImportBatches(int64) ([]*ImportBatchModel, error) {
+	return nil, ErrReadOnlyBackend
+}
+
+// UpdateImportBatch isn't supported.
+func (f *FileBackend) UpdateImportBatch(*ImportBatchModel) error {
+	return ErrReadOnlyBackend
+}
+
+// CreateBacktestRun isn't supported.
+func (f *FileBackend) CreateBacktestRun(*BacktestRunModel, []*BacktestTradeModel) error {
+	return ErrReadOnlyBackend
+}
+
+// FindBacktestRunByID isn't supported.
+func (f *FileBackend) FindBacktestRunByID(int64) (*BacktestRunModel, error) {
+	return nil, ErrReadOnlyBackend
+}
+
+// FindBacktestRuns isn't supported.
+func (f *FileBackend) FindBacktestRuns(string, int) ([]*BacktestRunModel, error) {
+	return nil, ErrReadOnlyBackend
+}
+
+// FindBacktestTrades isn't supported.
+func (f *FileBackend) FindBacktestTrades(int64) ([]*BacktestTradeModel, error) {
+	return nil, ErrReadOnlyBackend
+}
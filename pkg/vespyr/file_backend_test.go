@@ -0,0 +1,71 @@
+package vespyr_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DavidHuie/vespyr/pkg/vespyr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileBackend(t *testing.T) {
+	start := vespyr.CandlestickBucket(time.Now(), 15).UTC()
+
+	var candles []*vespyr.CandlestickModel
+	for i := 0; i < 30; i++ {
+		if i == 5 {
+			continue
+		}
+		s := start.Add(time.Duration(i) * time.Minute)
+		candles = append(candles, &vespyr.CandlestickModel{
+			StartTime: s,
+			EndTime:   s.Add(time.Minute),
+			Product:   vespyr.ProductBTCUSD,
+			Low:       float64(100 + i),
+			High:      float64(102 + i),
+			Open:      float64(100 + i),
+			Close:     float64(101 + i),
+			Volume:    1,
+			Direction: vespyr.CandlestickDirectionUp,
+		})
+	}
+
+	dir, err := ioutil.TempDir("", "vespyr")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "candles.parquet")
+	file, err := os.Create(path)
+	assert.NoError(t, err)
+	assert.NoError(t, vespyr.WriteCandlesticksParquet(file, candles))
+	assert.NoError(t, file.Close())
+
+	backend, err := vespyr.LoadFileBackend(path)
+	assert.NoError(t, err)
+
+	found, err := backend.FindCandlesticks(start, start.Add(30*time.Minute), vespyr.ProductBTCUSD, 15)
+	assert.NoError(t, err)
+	assert.Len(t, found, 2)
+	assert.Equal(t, float64(100), found[0].Open)
+	assert.Equal(t, float64(115), found[0].Close)
+	assert.Equal(t, float64(14), found[0].Volume)
+	assert.Equal(t, float64(130), found[1].Close)
+
+	recent, err := backend.FindMostRecentCandlestick(vespyr.ProductBTCUSD)
+	assert.NoError(t, err)
+	assert.Equal(t, candles[len(candles)-1], recent)
+
+	_, err = backend.FindMostRecentCandlestick(vespyr.ProductETHUSD)
+	assert.Error(t, err)
+
+	missing, err := backend.FindMissingCandlestickTimes(vespyr.ProductBTCUSD,
+		start, start.Add(29*time.Minute))
+	assert.NoError(t, err)
+	assert.Len(t, missing, 1)
+	assert.True(t, missing[0].Equal(start.Add(5*time.Minute)))
+
+	assert.Equal(t, vespyr.ErrReadOnlyBackend, backend.UpsertCandlestick(candles[0]))
+}
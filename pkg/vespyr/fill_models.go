@@ -0,0 +1,305 @@
+package vespyr
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// FillPriceClose fills orders at the close of the candle that
+	// the order was placed on.
+	FillPriceClose = "close"
+	// FillPriceNextOpen fills orders at the open of the candle
+	// after the one that the order was placed on.
+	FillPriceNextOpen = "next-open"
+
+	// FeesGDAX refers to the GDAX fee tiers.
+	FeesGDAX = "gdax"
+
+	feeVolumePeriod = 30 * 24 * time.Hour
+)
+
+// FillRequest describes a market order for a FillModel to fill.
+type FillRequest struct {
+	Order *MarketOrder
+	// Candles are the candlesticks being backtested and Current is
+	// the index of the candlestick that the order was placed on.
+	Candles []*CandlestickModel
+	Current int
+	// Volume30Day is the value of the orders filled in the
+	// previous 30 days, in the budget currency.
+	Volume30Day float64
+	Source      rand.Source
+}
+
+// Fill is the simulated result of a market order.
+type Fill struct {
+	// Price is the average price that the order was filled at.
+	Price float64
+	// FeeRate is the fraction of the order's value paid in fees.
+	FeeRate float64
+}
+
+// FillModel simulates how the exchange fills market orders during a
+// backtest.
+type FillModel interface {
+	Fill(*FillRequest) (*Fill, error)
+	String() string
+}
+
+// FeeSchedule returns the fee rates for a 30 day trading volume.
+type FeeSchedule interface {
+	MakerFee(volume30Day float64) float64
+	TakerFee(volume30Day float64) float64
+	String() string
+}
+
+// FlatFees charges the same rate for every order.
+type FlatFees float64
+
+func (f FlatFees) MakerFee(float64) float64 { return float64(f) }
+func (f FlatFees) TakerFee(float64) float64 { return float64(f) }
+func (f FlatFees) String() string           { return strconv.FormatFloat(float64(f), 'g', -1, 64) }
+
+// FeeTier is the fee rates for traders whose 30 day volume is at least
+// MinVolume.
+type FeeTier struct {
+	MinVolume float64
+	MakerFee  float64
+	TakerFee  float64
+}
+
+// TieredFees charges rates that fall as the 30 day volume grows. Tiers
+// are ordered by increasing minimum volume.
+type TieredFees struct {
+	Name  string
+	Tiers []FeeTier
+}
+
+// GDAXFees are the GDAX fee tiers for BTC-USD.
+var GDAXFees = &TieredFees{
+	Name: FeesGDAX,
+	Tiers: []FeeTier{
+		{MinVolume: 0, MakerFee: 0, TakerFee: .0025},
+		{MinVolume: 10000000, MakerFee: 0, TakerFee: .002},
+		{MinVolume: 100000000, MakerFee: 0, TakerFee: .001},
+	},
+}
+
+func (t *TieredFees) tier(volume30Day float64) FeeTier {
+	var tier FeeTier
+	for _, candidate := range t.Tiers {
+		if volume30Day >= candidate.MinVolume {
+			tier = candidate
+		}
+	}
+	return tier
+}
+
+func (t *TieredFees) MakerFee(volume30Day float64) float64 { return t.tier(volume30Day).MakerFee }
+func (t *TieredFees) TakerFee(volume30Day float64) float64 { return t.tier(volume30Day).TakerFee }
+func (t *TieredFees) String() string                       { return t.Name }
+
+// SimulatedFillModel fills market orders using a configurable price,
+// liquidity and fee model. The zero value of each option disables it.
+type SimulatedFillModel struct {
+	// Price is the candle price that orders fill at, either
+	// FillPriceClose or FillPriceNextOpen.
+	Price string
+	// Slippage is the largest random fraction that the fill price
+	// is moved against the order by.
+	Slippage float64
+	// MaxParticipation is the largest fraction of a candle's
+	// volume that an order may fill. The rest of the order is
+	// worked over the following candles, and any remainder fills
+	// on the last candle.
+	MaxParticipation float64
+	// Spread is the fractional bid/ask spread. Buys pay half of it
+	// above the candle price and sells receive half of it below.
+	Spread float64
+	// Impact moves the price against the order by this multiple of
+	// the order's size as a fraction of the candle's volume.
+	Impact float64
+	// Fees is the fee schedule. Market orders pay the taker fee.
+	Fees FeeSchedule
+}
+
+// DefaultFillModel fills at the candle close with random slippage and
+// a flat fee. Slippage used to lower the price of buys, so buys now
+// fill at higher prices than in older backtests.
+func DefaultFillModel() *SimulatedFillModel {
+	return &SimulatedFillModel{
+		Price:    FillPriceClose,
+		Slippage: exchangeSlippage,
+		Fees:     FlatFees(exchangeFee),
+	}
+}
+
+// priceAt returns the index of the candle that fills at index i and
+// its price.
+func (s *SimulatedFillModel) priceAt(candles []*CandlestickModel, i int) (int, float64) {
+	if s.Price == FillPriceNextOpen && i+1 < len(candles) {
+		return i + 1, candles[i+1].Open
+	}
+	return i, candles[i].Close
+}
+
+// Fill fills the order, returning its average price.
+func (s *SimulatedFillModel) Fill(req *FillRequest) (*Fill, error) {
+	if req.Current < 0 || req.Current >= len(req.Candles) {
+		return nil, errors.Errorf("error: no candlestick to fill order at")
+	}
+
+	side := float64(1)
+	if req.Order.Side == OrderSell {
+		side = -1
+	}
+
+	// Buy orders are sized in the budget currency and sell orders
+	// in the traded currency, so both are tracked.
+	remaining := req.Order.Cost
+	var size, value, fillPrice float64
+	chunks := 0
+	for i := req.Current; remaining > 0; {
+		index, price := s.priceAt(req.Candles, i)
+		candle := req.Candles[index]
+		if price <= 0 {
+			return nil, errors.Errorf("error: invalid fill price %f at %s", price, candle.StartTime)
+		}
+
+		// The chunk of the order filled on this candle, in the
+		// traded currency.
+		chunk := remaining
+		if req.Order.Side == OrderBuy {
+			chunk = remaining / price
+		}
+		last := index == len(req.Candles)-1
+		if s.MaxParticipation > 0 && !last {
+			chunk = math.Min(chunk, s.MaxParticipation*candle.Volume)
+		}
+
+		adjustment := s.Spread / 2
+		if s.Impact > 0 && candle.Volume > 0 {
+			adjustment += s.Impact * chunk / candle.Volume
+		}
+		fillPrice = price * (1 + side*adjustment)
+		chunks++
+
+		if req.Order.Side == OrderBuy {
+			filled := math.Min(remaining, chunk*price)
+			size += filled / fillPrice
+			value += filled
+			remaining -= filled
+		} else {
+			size += chunk
+			value += chunk * fillPrice
+			remaining -= chunk
+		}
+		if remaining <= 1e-12*req.Order.Cost || last {
+			break
+		}
+		i = index + 1
+		if s.Price == FillPriceNextOpen {
+			i = index
+		}
+	}
+
+	if size == 0 {
+		return nil, errors.Errorf("error: order for %f wasn't filled", req.Order.Cost)
+	}
+
+	// Orders filled on a single candle use its price directly to
+	// avoid rounding.
+	if chunks > 1 {
+		fillPrice = value / size
+	}
+
+	// Slippage always works against the order, raising the price of
+	// buys and lowering the price of sells.
+	slippage := rand.New(req.Source).Float64() * s.Slippage
+
+	return &Fill{
+		Price:   fillPrice * (1 + side*slippage),
+		FeeRate: s.Fees.TakerFee(req.Volume30Day),
+	}, nil
+}
+
+// String returns the model in the format parsed by ParseFillModel.
+func (s *SimulatedFillModel) String() string {
+	parts := []string{s.Price}
+	for _, option := range []struct {
+		name  string
+		value float64
+	}{
+		{"slippage", s.Slippage},
+		{"participation", s.MaxParticipation},
+		{"spread", s.Spread},
+		{"impact", s.Impact},
+	} {
+		if option.value != 0 {
+			parts = append(parts, fmt.Sprintf("%s=%g", option.name, option.value))
+		}
+	}
+	return strings.Join(append(parts, fmt.Sprintf("fees=%s", s.Fees)), ",")
+}
+
+// ParseFillModel parses a comma separated fill model specification,
+// such as "next-open,participation=0.1,spread=0.001,impact=0.5,fees=gdax".
+// The price is one of close or next-open, and fees are either a flat
+// rate or gdax. Options that aren't given keep their defaults.
+func ParseFillModel(spec string) (*SimulatedFillModel, error) {
+	model := DefaultFillModel()
+	if strings.TrimSpace(spec) == "" {
+		return model, nil
+	}
+
+	for _, part := range strings.Split(spec, ",") {
+		fields := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		name := fields[0]
+
+		if len(fields) == 1 {
+			switch name {
+			case FillPriceClose, FillPriceNextOpen:
+				model.Price = name
+				continue
+			}
+			return nil, errors.Errorf("error: unknown fill model option: %s", part)
+		}
+
+		if name == "fees" && fields[1] == FeesGDAX {
+			model.Fees = GDAXFees
+			continue
+		}
+
+		value, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error parsing fill model option: %s", part)
+		}
+		if value < 0 {
+			return nil, errors.Errorf("error: fill model option must not be negative: %s", part)
+		}
+
+		switch name {
+		case "slippage":
+			model.Slippage = value
+		case "participation":
+			model.MaxParticipation = value
+		case "spread":
+			model.Spread = value
+		case "impact":
+			model.Impact = value
+		case "fees":
+			model.Fees = FlatFees(value)
+		default:
+			return nil, errors.Errorf("error: unknown fill model option: %s", part)
+		}
+	}
+
+	return model, nil
+}
@@ -0,0 +1,242 @@
+package vespyr_test
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/DavidHuie/vespyr/pkg/vespyr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func fillTestCandles(prices ...float64) []*vespyr.CandlestickModel {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	var candles []*vespyr.CandlestickModel
+	for i, p := range prices {
+		candles = append(candles, &vespyr.CandlestickModel{
+			StartTime: start.Add(time.Duration(i) * time.Hour),
+			EndTime:   start.Add(time.Duration(i+1) * time.Hour),
+			Open:      p - 1,
+			Close:     p,
+			Volume:    10,
+		})
+	}
+	return candles
+}
+
+func TestParseFillModel(t *testing.T) {
+	model, err := vespyr.ParseFillModel("")
+	assert.NoError(t, err)
+	assert.Equal(t, vespyr.DefaultFillModel(), model)
+	assert.Equal(t, "close,fees=0.0025", model.String())
+
+	model, err = vespyr.ParseFillModel("next-open, participation=0.1,spread=0.001,impact=0.5,fees=gdax")
+	assert.NoError(t, err)
+	assert.Equal(t, vespyr.FillPriceNextOpen, model.Price)
+	assert.Equal(t, 0.1, model.MaxParticipation)
+	assert.Equal(t, 0.001, model.Spread)
+	assert.Equal(t, 0.5, model.Impact)
+	assert.Equal(t, vespyr.GDAXFees, model.Fees)
+	assert.Equal(t, "next-open,participation=0.1,spread=0.001,impact=0.5,fees=gdax", model.String())
+
+	parsed, err := vespyr.ParseFillModel(model.String())
+	assert.NoError(t, err)
+	assert.Equal(t, model, parsed)
+
+	model, err = vespyr.ParseFillModel("fees=0.001,slippage=0.01")
+	assert.NoError(t, err)
+	assert.Equal(t, vespyr.FlatFees(0.001), model.Fees)
+	assert.Equal(t, 0.01, model.Slippage)
+
+	for _, spec := range []string{"mid", "spread=wide", "impact=-1", "latency=3"} {
+		_, err := vespyr.ParseFillModel(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestSimulatedFillModelPrice(t *testing.T) {
+	candles := fillTestCandles(100, 110)
+	buy := &vespyr.MarketOrder{Side: vespyr.OrderBuy, Cost: 100}
+
+	fill, err := vespyr.DefaultFillModel().Fill(&vespyr.FillRequest{
+		Order: buy, Candles: candles, Current: 0, Source: rand.NewSource(1),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &vespyr.Fill{Price: 100, FeeRate: 0.0025}, fill)
+
+	model, err := vespyr.ParseFillModel("next-open")
+	assert.NoError(t, err)
+	fill, err = model.Fill(&vespyr.FillRequest{
+		Order: buy, Candles: candles, Current: 0, Source: rand.NewSource(1),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, float64(109), fill.Price)
+
+	// There's no next candle at the end of a backtest, so the order
+	// fills at the close.
+	fill, err = model.Fill(&vespyr.FillRequest{
+		Order: buy, Candles: candles, Current: 1, Source: rand.NewSource(1),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, float64(110), fill.Price)
+}
+
+func TestSimulatedFillModelSlippage(t *testing.T) {
+	model, err := vespyr.ParseFillModel("slippage=0.01")
+	assert.NoError(t, err)
+	candles := fillTestCandles(100)
+
+	// Buys fill higher and sells fill lower.
+	for seed := int64(0); seed < 10; seed++ {
+		buy, err := model.Fill(&vespyr.FillRequest{
+			Order:   &vespyr.MarketOrder{Side: vespyr.OrderBuy, Cost: 100},
+			Candles: candles, Current: 0, Source: rand.NewSource(seed),
+		})
+		assert.NoError(t, err)
+		assert.True(t, buy.Price >= 100 && buy.Price <= 101, "%f", buy.Price)
+
+		sell, err := model.Fill(&vespyr.FillRequest{
+			Order:   &vespyr.MarketOrder{Side: vespyr.OrderSell, Cost: 1},
+			Candles: candles, Current: 0, Source: rand.NewSource(seed),
+		})
+		assert.NoError(t, err)
+		assert.True(t, sell.Price >= 99 && sell.Price <= 100, "%f", sell.Price)
+		assert.InDelta(t, 200, buy.Price+sell.Price, 1e-9)
+	}
+}
+
+func TestSimulatedFillModelParticipation(t *testing.T) {
+	candles := fillTestCandles(100, 110, 120)
+	model, err := vespyr.ParseFillModel("participation=0.5")
+	assert.NoError(t, err)
+
+	// 5 BTC fill at 100, 5 BTC at 110 and the remaining $150 fill
+	// on the last candle.
+	fill, err := model.Fill(&vespyr.FillRequest{
+		Order:   &vespyr.MarketOrder{Side: vespyr.OrderBuy, Cost: 1200},
+		Candles: candles,
+		Source:  rand.NewSource(1),
+	})
+	assert.NoError(t, err)
+	assert.InDelta(t, 1200/11.25, fill.Price, 1e-9)
+
+	fill, err = model.Fill(&vespyr.FillRequest{
+		Order:   &vespyr.MarketOrder{Side: vespyr.OrderSell, Cost: 8},
+		Candles: candles,
+		Source:  rand.NewSource(1),
+	})
+	assert.NoError(t, err)
+	assert.InDelta(t, (5*100+3*110)/8.0, fill.Price, 1e-9)
+
+	// Small orders fill on a single candle.
+	fill, err = model.Fill(&vespyr.FillRequest{
+		Order:   &vespyr.MarketOrder{Side: vespyr.OrderSell, Cost: 1},
+		Candles: candles,
+		Source:  rand.NewSource(1),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, float64(100), fill.Price)
+}
+
+func TestSimulatedFillModelSpreadAndImpact(t *testing.T) {
+	candles := fillTestCandles(100)
+	model, err := vespyr.ParseFillModel("spread=0.002,impact=1")
+	assert.NoError(t, err)
+
+	// A 1 BTC order is 10% of the candle's volume.
+	fill, err := model.Fill(&vespyr.FillRequest{
+		Order:   &vespyr.MarketOrder{Side: vespyr.OrderBuy, Cost: 100},
+		Candles: candles,
+		Source:  rand.NewSource(1),
+	})
+	assert.NoError(t, err)
+	assert.InDelta(t, 110.1, fill.Price, 1e-9)
+
+	fill, err = model.Fill(&vespyr.FillRequest{
+		Order:   &vespyr.MarketOrder{Side: vespyr.OrderSell, Cost: 1},
+		Candles: candles,
+		Source:  rand.NewSource(1),
+	})
+	assert.NoError(t, err)
+	assert.InDelta(t, 89.9, fill.Price, 1e-9)
+}
+
+func TestGDAXFees(t *testing.T) {
+	assert.Equal(t, .0025, vespyr.GDAXFees.TakerFee(0))
+	assert.Equal(t, .0025, vespyr.GDAXFees.TakerFee(9999999))
+	assert.Equal(t, .002, vespyr.GDAXFees.TakerFee(10000000))
+	assert.Equal(t, .001, vespyr.GDAXFees.TakerFee(200000000))
+	assert.Equal(t, float64(0), vespyr.GDAXFees.MakerFee(200000000))
+}
+
+func TestBacktesterExchangeFeeTiers(t *testing.T) {
+	model, err := vespyr.ParseFillModel("fees=gdax")
+	assert.NoError(t, err)
+
+	exchange := vespyr.NewBacktesterExchange(fillTestCandles(100, 100, 100), 0, rand.NewSource(1))
+	exchange.SetFillModel(model)
+
+	exchange.NextTick()
+	resp, err := exchange.CreateMarketOrder(&vespyr.MarketOrder{Side: vespyr.OrderBuy, Cost: 6000000})
+	assert.NoError(t, err)
+	assert.InDelta(t, 6000000*.0025, resp.Fees, 1e-6)
+	assert.InDelta(t, 6000000*(1-.0025)/100, resp.FilledSize, 1e-6)
+
+	exchange.NextTick()
+	resp, err = exchange.CreateMarketOrder(&vespyr.MarketOrder{Side: vespyr.OrderSell, Cost: 60000})
+	assert.NoError(t, err)
+	assert.InDelta(t, 6000000*.0025, resp.Fees, 1e-6)
+	assert.InDelta(t, 6000000*(1-.0025), resp.FilledSize, 1e-6)
+
+	// The previous orders moved the account into the next tier.
+	exchange.NextTick()
+	resp, err = exchange.CreateMarketOrder(&vespyr.MarketOrder{Side: vespyr.OrderBuy, Cost: 1000})
+	assert.NoError(t, err)
+	assert.InDelta(t, 1000*.002, resp.Fees, 1e-9)
+}
+
+func TestBacktestTradeFillPrices(t *testing.T) {
+	candles := timeframeTestCandles()
+	backend := new(vespyr.MockBackend)
+	backend.On("FindCandlesticks", mock.Anything, mock.Anything, vespyr.ProductBTCUSD, int64(15)).
+		Return(candles, nil)
+
+	model, err := vespyr.ParseStrategyFile([]byte(`
+product: BTC-USD
+tick_size_minutes: 15
+history_ticks: 2
+trading_strategy: grid
+trading_strategy_data:
+  lower_price: 90
+  upper_price: 110
+  levels: 4
+`))
+	assert.NoError(t, err)
+
+	backtester, err := vespyr.NewBacktester(candles[20].StartTime, candles[len(candles)-1].EndTime,
+		model, backend, rand.NewSource(1))
+	assert.NoError(t, err)
+	fillModel, err := vespyr.ParseFillModel("next-open")
+	assert.NoError(t, err)
+	backtester.SetFillModel(fillModel)
+	assert.NoError(t, backtester.Backtest())
+
+	// Trades are reported at the next traded candle's open that
+	// they were filled at rather than at their candle's close.
+	var traded []*vespyr.CandlestickModel
+	for _, c := range candles {
+		if c.Volume > 0 {
+			traded = append(traded, c)
+		}
+	}
+	trades := backtester.Trades()
+	assert.NotEmpty(t, trades)
+	for _, trade := range trades {
+		for i, c := range traded[:len(traded)-1] {
+			if c.StartTime.Equal(trade.Time) {
+				assert.InDelta(t, traded[i+1].Open, trade.Price, 1e-9, "%s", trade.Time)
+			}
+		}
+	}
+}
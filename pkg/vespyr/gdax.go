@@ -17,6 +17,10 @@ import (
 const (
 	gdaxOrderMarket = "market"
 	gdaxRetries     = 20
+
+	// gdaxMaxCandlesticksPerRequest is the largest number of
+	// candlesticks GDAX returns for a single request.
+	gdaxMaxCandlesticksPerRequest = 200
 )
 
 // GDAXClient is the interface for an underlying GDAX client.
@@ -28,8 +32,9 @@ type GDAXClient interface {
 
 // GDAXExchange is a client to the GDAX cryptocurrency exchange.
 type GDAXExchange struct {
-	client GDAXClient
-	clock  clockwork.Clock
+	client  GDAXClient
+	clock   clockwork.Clock
+	limiter *TokenBucket
 }
 
 // NewGDAXExchange returns a new instance of GDAXExchange.
@@ -44,6 +49,18 @@ func (k *GDAXExchange) EmitsFullCandlesticks() bool {
 	return false
 }
 
+// SetRateLimiter sets a rate limiter that's consulted before every
+// historic rates request.
+func (g *GDAXExchange) SetRateLimiter(t *TokenBucket) {
+	g.limiter = t
+}
+
+// SupportsHistoricalCandlesticks returns whether the exchange can
+// return candlesticks for past time ranges.
+func (g *GDAXExchange) SupportsHistoricalCandlesticks() bool {
+	return true
+}
+
 // GetMessageChan returns a channel that emits exchange messages.
 func (g *GDAXExchange) GetMessageChan(ctx context.Context, product Product) (<-chan *ExchangeMessage, error) {
 	var wsDialer websocket.Dialer
@@ -100,13 +117,37 @@ func (g *GDAXExchange) StreamCandlesticks(ctx context.Context, product Product)
 }
 
 // GetCandlesticks returns candlesticks for a specified period and
-// granularity from GDAX.
+// granularity from GDAX. Ranges larger than GDAX allows in a single
+// request are fetched in multiple pages.
 func (g *GDAXExchange) GetCandlesticks(product Product, start, end time.Time, granularitySeconds int) ([]*CandlestickModel, error) {
-	intervals := start.Sub(end).Seconds() / float64(granularitySeconds)
-	if intervals >= 200 {
-		return nil, errors.New("error: too many candlesticks requested of GDAX")
+	if granularitySeconds <= 0 {
+		return nil, errors.Errorf("error: invalid GDAX granularity: %d", granularitySeconds)
 	}
 
+	granularity := time.Duration(granularitySeconds) * time.Second
+	pageSize := (gdaxMaxCandlesticksPerRequest - 1) * granularity
+
+	var candles []*CandlestickModel
+	for pageStart := start; !pageStart.After(end); pageStart = pageStart.Add(pageSize + granularity) {
+		pageEnd := pageStart.Add(pageSize)
+		if pageEnd.After(end) {
+			pageEnd = end
+		}
+
+		page, err := g.getCandlesticksPage(product, pageStart, pageEnd, granularitySeconds)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error fetching GDAX candlesticks from %s to %s", pageStart, pageEnd)
+		}
+		candles = append(candles, page...)
+	}
+
+	return candles, nil
+}
+
+func (g *GDAXExchange) getCandlesticksPage(product Product, start, end time.Time, granularitySeconds int) ([]*CandlestickModel, error) {
+	if g.limiter != nil {
+		g.limiter.Wait()
+	}
 	historicRates, err := g.client.GetHistoricRates(string(product), coinbase.GetHistoricRatesParams{
 		Start:       start,
 		End:         end,
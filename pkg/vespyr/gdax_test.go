@@ -70,6 +70,37 @@ func TestGDAXGetCandlesticks(t *testing.T) {
 	}, c2)
 }
 
+func TestGDAXGetCandlesticksPaging(t *testing.T) {
+	gdaxClient := new(vespyr.MockGDAXClient)
+	gdax := vespyr.NewGDAXExchange(gdaxClient, clockwork.NewFakeClock())
+
+	defer mock.AssertExpectationsForObjects(t, gdaxClient)
+
+	start := vespyr.CandlestickBucket(time.Now().Add(-time.Hour*24), 1)
+	end := start.Add(250 * time.Minute)
+
+	gdaxClient.On("GetHistoricRates", string(vespyr.ProductBTCUSD), coinbase.GetHistoricRatesParams{
+		Start:       start,
+		End:         start.Add(199 * time.Minute),
+		Granularity: 60,
+	}).Return([]coinbase.HistoricRate{
+		{Time: start, Low: 10, High: 20, Open: 12, Close: 15, Volume: 1},
+	}, nil).Once()
+	gdaxClient.On("GetHistoricRates", string(vespyr.ProductBTCUSD), coinbase.GetHistoricRatesParams{
+		Start:       start.Add(200 * time.Minute),
+		End:         end,
+		Granularity: 60,
+	}).Return([]coinbase.HistoricRate{
+		{Time: end, Low: 11, High: 21, Open: 16, Close: 13, Volume: 2},
+	}, nil).Once()
+
+	candles, err := gdax.GetCandlesticks(vespyr.ProductBTCUSD, start, end, 60)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(candles))
+	assert.Equal(t, start, candles[0].StartTime)
+	assert.Equal(t, end, candles[1].StartTime)
+}
+
 func TestGDAXCreateMarketOrderLive(t *testing.T) {
 	t.Skip()
 
@@ -0,0 +1,198 @@
+package vespyr
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/MaxHalford/gago"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	RegisterStrategyType(&StrategyType{
+		Name:        TradingStrategyGrid,
+		Description: "buys a level each time the price falls through a line of a grid and sells it a line higher",
+		New:         func() StrategyInterface { return &GridStrategy{} },
+		// The band depends on the product's price, so it's
+		// unbounded and its defaults only make a valid grid.
+		Parameters: []*StrategyParameter{
+			{Name: "lower_price", Description: "the price of the grid's lowest line", Default: 90, Min: 0, Max: math.Inf(1)},
+			{Name: "upper_price", Description: "the price of the grid's highest line", Default: 110, Min: 0, Max: math.Inf(1)},
+			{Name: "levels", Description: "the number of lines in the grid", Default: 10, Min: 2, Max: 50, Integer: true},
+		},
+	})
+}
+
+// GridStrategy trades sideways markets with a grid of evenly spaced
+// lines between LowerPrice and UpperPrice, emulating a buy limit order
+// on each line below the price and a sell limit order a line above each
+// level that's held. When the price falls through a line, a level is
+// bought there with an even share of the remaining budget, and it's
+// sold once the price rises to the next line. Nothing is bought below
+// the band, and levels held when the price leaves it are kept until
+// they can be sold.
+type GridStrategy struct {
+	LowerPrice float64 `yaml:"lower_price"`
+	UpperPrice float64 `yaml:"upper_price"`
+	Levels     uint    `yaml:"levels"`
+
+	strategy *TradingStrategyModel
+}
+
+// Validate validates the strategy's parameters.
+func (g *GridStrategy) Validate() error {
+	if g.LowerPrice <= 0 || g.UpperPrice <= g.LowerPrice {
+		return errors.Errorf("error: grid band must be positive with a lower price below the upper price: %f to %f",
+			g.LowerPrice, g.UpperPrice)
+	}
+	if g.Levels < 1 {
+		return errors.New("error: a grid needs at least one level")
+	}
+	return nil
+}
+
+// String returns the string representation of the strategy.
+func (g *GridStrategy) String() string {
+	return fmt.Sprintf("Grid: lower price: %f, upper price: %f, levels: %d",
+		g.LowerPrice, g.UpperPrice, g.Levels)
+}
+
+// SetTradingStrategy sets the underlying trading strategy.
+func (g *GridStrategy) SetTradingStrategy(t *TradingStrategyModel) {
+	g.strategy = t
+}
+
+// Indicators returns the indicators used by the strategy.
+func (g *GridStrategy) Indicators() []Indicator {
+	return []Indicator{levelPriceIndicator()}
+}
+
+func (g *GridStrategy) step() float64 {
+	return (g.UpperPrice - g.LowerPrice) / float64(g.Levels)
+}
+
+// line returns the price of a line of the grid.
+func (g *GridStrategy) line(i int) float64 {
+	return g.LowerPrice + float64(i)*g.step()
+}
+
+// Orders returns a sell for every held level whose next line the price
+// has reached and a buy for every free line that the price fell
+// through since the previous tick.
+func (g *GridStrategy) Orders(history []*IndicatorSet, current int, levels []*StrategyLevel,
+	budget float64) ([]*LevelOrder, error) {
+	price, now, err := levelPrice(history, current)
+	if err != nil {
+		return nil, err
+	}
+	previous, _, err := levelPrice(history, current-1)
+	if err != nil {
+		return nil, err
+	}
+
+	var orders []*LevelOrder
+	held := make(map[int]bool)
+	for _, l := range levels {
+		if price >= g.line(l.Level+1) {
+			orders = append(orders, &LevelOrder{Side: OrderSell, Level: l})
+			continue
+		}
+		held[l.Level] = true
+	}
+
+	var crossed []int
+	for i := 0; i < int(g.Levels); i++ {
+		if line := g.line(i); previous > line && price <= line && !held[i] {
+			crossed = append(crossed, i)
+		}
+	}
+	free := int(g.Levels) - len(held)
+	for _, i := range crossed {
+		orders = append(orders, &LevelOrder{
+			Side:  OrderBuy,
+			Level: &StrategyLevel{Level: i, Price: g.line(i), BoughtAt: now},
+			Cost:  budget / float64(free),
+		})
+	}
+
+	return orders, nil
+}
+
+// Buy isn't supported by grid strategies, which hold several
+// positions.
+func (g *GridStrategy) Buy([]*IndicatorSet, int) (bool, error) {
+	return false, errLevelSignals
+}
+
+// Sell isn't supported by grid strategies, which hold several
+// positions.
+func (g *GridStrategy) Sell([]*IndicatorSet, int) (bool, error) {
+	return false, errLevelSignals
+}
+
+// Rand randomizes the number of levels within its registered
+// parameter. The band is kept since it depends on the product's price.
+func (g *GridStrategy) Rand(rng *rand.Rand) {
+	g.Levels = uint(gridParameter("levels").Rand(rng))
+}
+
+func gridParameter(name string) *StrategyParameter {
+	return strategyParameter(TradingStrategyGrid, name)
+}
+
+// Clone returns a clone of the current strategy.
+func (g *GridStrategy) Clone() StrategyGenome {
+	return &GridStrategy{
+		LowerPrice: g.LowerPrice,
+		UpperPrice: g.UpperPrice,
+		Levels:     g.Levels,
+	}
+}
+
+// Mutate mutates the number of levels within its registered parameter
+// and the band by a few percent.
+func (g *GridStrategy) Mutate(rng *rand.Rand) {
+	mutateProb := 0.8
+
+	if rng.Float64() < mutateProb {
+		g.Levels = uint(gridParameter("levels").Mutate(rng, float64(g.Levels)))
+	}
+
+	lower, upper := g.LowerPrice, g.UpperPrice
+	if rng.Float64() < mutateProb {
+		lower = gridParameter("lower_price").Clamp(lower * (1 + .05*rng.NormFloat64()))
+	}
+	if rng.Float64() < mutateProb {
+		upper = gridParameter("upper_price").Clamp(upper * (1 + .05*rng.NormFloat64()))
+	}
+	if lower > 0 && upper > lower {
+		g.LowerPrice, g.UpperPrice = lower, upper
+	}
+}
+
+// Crossover crosses over a GridStrategy with a different one.
+func (g *GridStrategy) Crossover(m StrategyGenome,
+	r *rand.Rand) (StrategyGenome, StrategyGenome) {
+	mate := m.(*GridStrategy)
+
+	p1 := []float64{float64(g.Levels), g.LowerPrice, g.UpperPrice}
+	p2 := []float64{float64(mate.Levels), mate.LowerPrice, mate.UpperPrice}
+
+	c1, c2 := gago.CrossUniformFloat64(p1, p2, r)
+
+	var children []StrategyGenome
+	for _, c := range [][]float64{c1, c2} {
+		child := &GridStrategy{
+			Levels:     uint(gridParameter("levels").Clamp(c[0])),
+			LowerPrice: c[1],
+			UpperPrice: c[2],
+		}
+		if child.UpperPrice <= child.LowerPrice {
+			child.LowerPrice, child.UpperPrice = g.LowerPrice, g.UpperPrice
+		}
+		children = append(children, child)
+	}
+
+	return children[0], children[1]
+}
@@ -0,0 +1,142 @@
+package vespyr_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/DavidHuie/vespyr/pkg/vespyr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func levelTestHistory(prices ...float64) []*vespyr.IndicatorSet {
+	var history []*vespyr.IndicatorSet
+	for _, price := range prices {
+		history = append(history, &vespyr.IndicatorSet{
+			Values: []*vespyr.IndicatorValue{{IndicatorName: "sma-1-close", Value: price}},
+		})
+	}
+	return history
+}
+
+func TestGridStrategyOrders(t *testing.T) {
+	strategy := &vespyr.GridStrategy{LowerPrice: 90, UpperPrice: 110, Levels: 4}
+	assert.NoError(t, strategy.Validate())
+	levels := []*vespyr.StrategyLevel{{Level: 0, Size: 1}, {Level: 1, Size: 1}}
+
+	// Falling through the 100 and 95 lines only buys at 100 since
+	// 95 is held, with half of the budget left for the free lines.
+	orders, err := strategy.Orders(levelTestHistory(101, 94), 1, levels, 50)
+	assert.NoError(t, err)
+	if assert.Len(t, orders, 1) {
+		assert.Equal(t, vespyr.OrderBuy, orders[0].Side)
+		assert.Equal(t, 2, orders[0].Level.Level)
+		assert.Equal(t, float64(100), orders[0].Level.Price)
+		assert.Equal(t, float64(25), orders[0].Cost)
+	}
+
+	// Rising to 101 sells the levels bought at 90 and 95.
+	orders, err = strategy.Orders(levelTestHistory(94, 101), 1, levels, 50)
+	assert.NoError(t, err)
+	if assert.Len(t, orders, 2) {
+		for i, o := range orders {
+			assert.Equal(t, vespyr.OrderSell, o.Side)
+			assert.Equal(t, levels[i], o.Level)
+		}
+	}
+
+	// Nothing is bought below the band.
+	orders, err = strategy.Orders(levelTestHistory(89, 80), 1, nil, 50)
+	assert.NoError(t, err)
+	assert.Empty(t, orders)
+
+	_, err = strategy.Buy(levelTestHistory(100), 0)
+	assert.Error(t, err)
+
+	for _, invalid := range []*vespyr.GridStrategy{
+		{LowerPrice: 0, UpperPrice: 110, Levels: 4},
+		{LowerPrice: 110, UpperPrice: 90, Levels: 4},
+		{LowerPrice: 90, UpperPrice: 110},
+	} {
+		assert.Error(t, invalid.Validate())
+	}
+}
+
+func TestGridStrategyGenome(t *testing.T) {
+	strategy := &vespyr.GridStrategy{LowerPrice: 90, UpperPrice: 110, Levels: 4}
+	rng := rand.New(rand.NewSource(1))
+	strategy.Rand(rng)
+	for i := 0; i < 100; i++ {
+		strategy.Mutate(rng)
+		assert.NoError(t, strategy.Validate())
+		assert.True(t, strategy.Levels >= 2 && strategy.Levels <= 50, "%d levels", strategy.Levels)
+	}
+
+	c1, c2 := strategy.Crossover(&vespyr.GridStrategy{LowerPrice: 80, UpperPrice: 120, Levels: 10}, rng)
+	for _, c := range []vespyr.StrategyGenome{c1, c2} {
+		assert.NoError(t, c.(*vespyr.GridStrategy).Validate())
+	}
+}
+
+func TestGridStrategyBacktest(t *testing.T) {
+	candles := timeframeTestCandles()
+	backend := new(vespyr.MockBackend)
+	backend.On("FindCandlesticks", mock.Anything, mock.Anything, vespyr.ProductBTCUSD, int64(15)).
+		Return(candles, nil)
+
+	model, err := vespyr.ParseStrategyFile([]byte(`
+product: BTC-USD
+tick_size_minutes: 15
+history_ticks: 2
+trading_strategy: grid
+trading_strategy_data:
+  lower_price: 90
+  upper_price: 110
+  levels: 4
+`))
+	assert.NoError(t, err)
+
+	backtester, err := vespyr.NewBacktester(candles[20].StartTime, candles[len(candles)-1].EndTime,
+		model, backend, rand.NewSource(1))
+	assert.NoError(t, err)
+	assert.NoError(t, backtester.Backtest())
+
+	// Several levels are held at once and sold separately.
+	var buys, sells, held, maxHeld int
+	for _, trade := range backtester.Trades() {
+		if trade.Side == vespyr.OrderBuy {
+			buys++
+			held++
+		} else {
+			sells++
+			held--
+		}
+		if held > maxHeld {
+			maxHeld = held
+		}
+	}
+	assert.True(t, buys > 3, "%d buys", buys)
+	assert.True(t, sells > 2, "%d sells", sells)
+	assert.True(t, maxHeld > 1, "%d levels held", maxHeld)
+	assert.Len(t, model.Levels, buys-sells)
+
+	results := backtester.Results()
+	assert.Equal(t, uint(sells), results.ProfitTrades+results.LossTrades)
+	assert.True(t, results.ProfitTrades > 0)
+	assert.True(t, results.TicksInMarket > 0)
+
+	// The model's levels add up to what's invested.
+	var invested float64
+	for _, l := range model.Levels {
+		invested += l.Size
+	}
+	assert.InDelta(t, model.Invested, invested, 1e-6)
+	assert.True(t, model.Budget >= 0)
+
+	// Level strategies can't be part of a portfolio.
+	portfolio, err := vespyr.NewPortfolioBacktester(candles[20].StartTime, candles[len(candles)-1].EndTime,
+		[]*vespyr.TradingStrategyModel{model}, backend, 1000, vespyr.PortfolioRiskRules{}, 1)
+	assert.NoError(t, err)
+	_, err = portfolio.Backtest()
+	assert.Error(t, err)
+}
@@ -1,9 +1,11 @@
 package vespyr
 
 import (
+	"fmt"
 	"sync"
 	"time"
 
+	"github.com/jonboulle/clockwork"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
@@ -11,7 +13,18 @@ import (
 const (
 	importerBatchSize                 = 150
 	importerMaxRetries                = 20
+	importerRequeuePasses             = 2
 	gdaxCandlestickGranularitySeconds = 60
+
+	// ImportBatchStatusPending is the status of a batch that
+	// hasn't been imported yet.
+	ImportBatchStatusPending = "pending"
+	// ImportBatchStatusDone is the status of a batch that was
+	// imported.
+	ImportBatchStatusDone = "done"
+	// ImportBatchStatusFailed is the status of a batch that
+	// couldn't be imported after retrying.
+	ImportBatchStatusFailed = "failed"
 )
 
 // HistoricalImporter is a type that can import candle stick
@@ -21,6 +34,8 @@ type HistoricalImporter struct {
 	exchange    Exchange
 	backend     Backend
 	concurrency int
+	limiter     *TokenBucket
+	clock       clockwork.Clock
 }
 
 // NewHistoricalImporter creates a new HistoricalImporter.
@@ -30,10 +45,28 @@ func NewHistoricalImporter(product Product, e Exchange, b Backend, concurrency i
 		exchange:    e,
 		backend:     b,
 		concurrency: concurrency,
+		clock:       clockwork.NewRealClock(),
+	}
+}
+
+// SetRateLimiter sets a rate limiter that's consulted before every
+// exchange request. The limiter should be shared by all importers
+// using the same exchange. Exchanges that page through a batch take a
+// token for each of their requests, and other exchanges take one for
+// each batch.
+func (h *HistoricalImporter) SetRateLimiter(t *TokenBucket) {
+	if e, ok := h.exchange.(RateLimitedExchange); ok {
+		e.SetRateLimiter(t)
+		return
 	}
+	h.limiter = t
 }
 
 func (h *HistoricalImporter) processBatch(start, end time.Time) error {
+	if h.limiter != nil {
+		h.limiter.Wait()
+	}
+
 	candlesticks, err := h.exchange.GetCandlesticks(h.product,
 		start, end, gdaxCandlestickGranularitySeconds)
 	if err != nil {
@@ -49,25 +82,29 @@ func (h *HistoricalImporter) processBatch(start, end time.Time) error {
 	return nil
 }
 
+// processBatchWithRetries processes a batch, returning the number of
+// attempts that were made and the last error.
+func (h *HistoricalImporter) processBatchWithRetries(start, end time.Time) (uint, error) {
+	var err error
+	retries := 0
+	for retries < importerMaxRetries {
+		if err = h.processBatch(start, end); err == nil {
+			return uint(retries + 1), nil
+		}
+		logrus.WithError(err).Warnf("error processing batch, retrying")
+		h.clock.Sleep(time.Second << uint(retries))
+		retries++
+	}
+	return uint(retries), err
+}
+
 func (h *HistoricalImporter) worker(c <-chan *importerMsg) {
 	for msg := range c {
 		logrus.Debugf("processing import message: %#v", msg)
 
-		retries := 0
-		for {
-			if retries >= importerMaxRetries {
-				logrus.WithField("HistoricalImporter", msg).
-					Errorf("error too many retries for importer")
-				break
-			}
-
-			if err := h.processBatch(msg.start, msg.end); err != nil {
-				logrus.WithError(err).Warnf("error processing batch, retrying")
-				time.Sleep(time.Second << uint(retries))
-				retries++
-			} else {
-				break
-			}
+		if _, err := h.processBatchWithRetries(msg.start, msg.end); err != nil {
+			logrus.WithField("HistoricalImporter", msg).WithError(err).
+				Errorf("error too many retries for importer")
 		}
 	}
 }
@@ -105,3 +142,224 @@ func (h *HistoricalImporter) Import(start, end time.Time) {
 	close(c)
 	wg.Wait()
 }
+
+// CreateImportJob persists an import job for a time range, split into
+// batches, without running it.
+func (h *HistoricalImporter) CreateImportJob(start, end time.Time) (*ImportJobModel, error) {
+	job := &ImportJobModel{
+		Product:   h.product,
+		StartTime: start,
+		EndTime:   end,
+	}
+
+	var batches []*ImportBatchModel
+	for i := start; i.Before(end) || i.Equal(end); i = i.Add(importerBatchSize * time.Minute) {
+		batches = append(batches, &ImportBatchModel{
+			StartTime: i,
+			EndTime:   i.Add(importerBatchSize * time.Minute),
+			Status:    ImportBatchStatusPending,
+		})
+	}
+
+	if err := h.backend.CreateImportJob(job, batches); err != nil {
+		return nil, errors.Wrapf(err, "error creating import job")
+	}
+
+	return job, nil
+}
+
+// RunImportJob imports every batch of a job that hasn't been imported
+// yet. Batches that fail are re-queued a limited number of times; if
+// any still fail an error is returned and the job can be resumed
+// later.
+func (h *HistoricalImporter) RunImportJob(job *ImportJobModel) error {
+	if job.Product != h.product {
+		return errors.Errorf("error: import job %d is for product %s, not %s",
+			job.ID, job.Product, h.product)
+	}
+
+	batches, err := h.backend.FindImportBatches(job.ID)
+	if err != nil {
+		return errors.Wrapf(err, "error finding import batches")
+	}
+
+	var remaining []*ImportBatchModel
+	for _, b := range batches {
+		if b.Status != ImportBatchStatusDone {
+			remaining = append(remaining, b)
+		}
+	}
+
+	progress := NewImportProgress(len(batches), len(batches)-len(remaining), h.clock)
+	logrus.Infof("running import job %d for %s from %s to %s: %s", job.ID,
+		job.Product, job.StartTime, job.EndTime, progress)
+
+	for pass := 0; pass <= importerRequeuePasses && len(remaining) > 0; pass++ {
+		if pass > 0 {
+			logrus.Infof("re-queueing %d failed batches for import job %d", len(remaining), job.ID)
+			progress.Requeue(len(remaining))
+		}
+
+		remaining, err = h.runImportBatches(job, remaining, progress)
+		if err != nil {
+			return errors.Wrapf(err, "error running import batches")
+		}
+	}
+
+	if len(remaining) > 0 {
+		return errors.Errorf("error: %d batches of import job %d failed", len(remaining), job.ID)
+	}
+
+	job.CompletedAt = h.clock.Now()
+	if err := h.backend.UpdateImportJob(job); err != nil {
+		return errors.Wrapf(err, "error updating import job")
+	}
+
+	logrus.Infof("completed import job %d", job.ID)
+
+	return nil
+}
+
+// runImportBatches processes the batches concurrently and returns the
+// ones that failed.
+func (h *HistoricalImporter) runImportBatches(job *ImportJobModel, batches []*ImportBatchModel,
+	progress *ImportProgress) ([]*ImportBatchModel, error) {
+	c := make(chan *ImportBatchModel)
+	wg := &sync.WaitGroup{}
+	mutex := &sync.Mutex{}
+	var failed []*ImportBatchModel
+	var updateErr error
+
+	for i := 0; i < h.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range c {
+				attempts, err := h.processBatchWithRetries(batch.StartTime, batch.EndTime)
+				batch.Attempts += attempts
+				if err != nil {
+					batch.Status = ImportBatchStatusFailed
+					batch.LastError = err.Error()
+				} else {
+					batch.Status = ImportBatchStatusDone
+					batch.LastError = ""
+				}
+
+				uerr := h.backend.UpdateImportBatch(batch)
+
+				mutex.Lock()
+				if err != nil {
+					failed = append(failed, batch)
+				}
+				if uerr != nil && updateErr == nil {
+					updateErr = uerr
+				}
+				mutex.Unlock()
+
+				progress.Add(err == nil)
+				logrus.Infof("import job %d batch at %s %s: %s", job.ID,
+					batch.StartTime, batch.Status, progress)
+			}
+		}()
+	}
+
+	for _, b := range batches {
+		c <- b
+	}
+	close(c)
+	wg.Wait()
+
+	if updateErr != nil {
+		return nil, errors.Wrapf(updateErr, "error updating import batch")
+	}
+
+	return failed, nil
+}
+
+// ImportProgress tracks how far along an import job is.
+type ImportProgress struct {
+	mutex     sync.Mutex
+	clock     clockwork.Clock
+	started   time.Time
+	total     int
+	completed int
+	failed    int
+	processed int
+}
+
+// NewImportProgress creates a new ImportProgress for a job with total
+// batches, of which completed were already imported.
+func NewImportProgress(total, completed int, clock clockwork.Clock) *ImportProgress {
+	return &ImportProgress{
+		clock:     clock,
+		started:   clock.Now(),
+		total:     total,
+		completed: completed,
+	}
+}
+
+// Add records the result of a processed batch.
+func (p *ImportProgress) Add(success bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.processed++
+	if success {
+		p.completed++
+	} else {
+		p.failed++
+	}
+}
+
+// Requeue records that failed batches are being retried.
+func (p *ImportProgress) Requeue(n int) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.failed -= n
+}
+
+// Completed returns the number of completed batches.
+func (p *ImportProgress) Completed() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.completed
+}
+
+// Failed returns the number of failed batches.
+func (p *ImportProgress) Failed() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.failed
+}
+
+// ETA estimates the time left using the rate of batches processed so
+// far.
+func (p *ImportProgress) ETA() time.Duration {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.processed == 0 {
+		return 0
+	}
+	remaining := p.total - p.completed - p.failed
+	perBatch := p.clock.Now().Sub(p.started) / time.Duration(p.processed)
+	return perBatch * time.Duration(remaining)
+}
+
+// String returns a human readable summary of the progress.
+func (p *ImportProgress) String() string {
+	eta := p.ETA()
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	percent := float64(100)
+	if p.total > 0 {
+		percent = 100 * float64(p.completed) / float64(p.total)
+	}
+	return fmt.Sprintf("%d/%d batches (%.1f%%), %d failed, ETA %s",
+		p.completed, p.total, percent, p.failed, eta)
+}
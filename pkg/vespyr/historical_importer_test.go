@@ -8,6 +8,7 @@ import (
 	"github.com/DavidHuie/vespyr/pkg/vespyr"
 	"github.com/google/go-cmp/cmp"
 	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -61,3 +62,72 @@ func TestHistoricalImporter(t *testing.T) {
 
 	importer.Import(start, end)
 }
+
+func TestHistoricalImporterRunImportJob(t *testing.T) {
+	gdaxClient := new(vespyr.MockGDAXClient)
+	gdax := vespyr.NewGDAXExchange(gdaxClient, clockwork.NewFakeClock())
+	backend := new(vespyr.MockBackend)
+	importer := vespyr.NewHistoricalImporter(vespyr.ProductBTCUSD, gdax, backend, 2)
+
+	defer mock.AssertExpectationsForObjects(t, gdaxClient, backend)
+
+	start := time.Now().Add(-300 * time.Minute)
+	job := &vespyr.ImportJobModel{
+		ID:        3,
+		Product:   vespyr.ProductBTCUSD,
+		StartTime: start,
+		EndTime:   start.Add(300 * time.Minute),
+	}
+
+	// The first batch was imported by a previous run so only the
+	// second one should be fetched.
+	done := &vespyr.ImportBatchModel{
+		ID:          1,
+		ImportJobID: job.ID,
+		StartTime:   start,
+		EndTime:     start.Add(150 * time.Minute),
+		Status:      vespyr.ImportBatchStatusDone,
+		Attempts:    1,
+	}
+	pending := &vespyr.ImportBatchModel{
+		ID:          2,
+		ImportJobID: job.ID,
+		StartTime:   start.Add(150 * time.Minute),
+		EndTime:     start.Add(300 * time.Minute),
+		Status:      vespyr.ImportBatchStatusPending,
+	}
+	backend.On("FindImportBatches", job.ID).
+		Return([]*vespyr.ImportBatchModel{done, pending}, nil)
+
+	gdaxClient.On("GetHistoricRates", string(vespyr.ProductBTCUSD), mock.MatchedBy(func(c coinbase.GetHistoricRatesParams) bool {
+		return cmp.Equal(c, coinbase.GetHistoricRatesParams{
+			Start:       pending.StartTime,
+			End:         pending.EndTime,
+			Granularity: 60,
+		})
+	})).Return([]coinbase.HistoricRate{}, nil).Once()
+
+	backend.On("UpdateImportBatch", mock.MatchedBy(func(b *vespyr.ImportBatchModel) bool {
+		return b.ID == pending.ID && b.Status == vespyr.ImportBatchStatusDone && b.Attempts == 1
+	})).Return(nil).Once()
+	backend.On("UpdateImportJob", mock.MatchedBy(func(j *vespyr.ImportJobModel) bool {
+		return j.ID == job.ID && !j.CompletedAt.IsZero()
+	})).Return(nil).Once()
+
+	assert.NoError(t, importer.RunImportJob(job))
+}
+
+func TestImportProgress(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	progress := vespyr.NewImportProgress(10, 4, clock)
+	assert.Equal(t, time.Duration(0), progress.ETA())
+
+	clock.Advance(10 * time.Second)
+	progress.Add(true)
+	progress.Add(false)
+
+	assert.Equal(t, 5, progress.Completed())
+	assert.Equal(t, 1, progress.Failed())
+	assert.Equal(t, 20*time.Second, progress.ETA())
+	assert.Equal(t, "5/10 batches (50.0%), 1 failed, ETA 20s", progress.String())
+}
@@ -0,0 +1,121 @@
+package vespyr
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// IchimokuConversion is the conversion line (tenkan-sen), which
+	// is the Ichimoku indicator's primary value.
+	IchimokuConversion = "conversion"
+	// IchimokuBase is the base line (kijun-sen).
+	IchimokuBase = "base"
+	// IchimokuSpanA is the first leading span (senkou span A) of the
+	// cloud under the current candlestick.
+	IchimokuSpanA = "span-a"
+	// IchimokuSpanB is the second leading span (senkou span B) of
+	// the cloud under the current candlestick.
+	IchimokuSpanB = "span-b"
+)
+
+type ichimokuSpans struct {
+	a float64
+	b float64
+}
+
+// IchimokuIndicator calculates the Ichimoku cloud. The conversion and
+// base lines are midpoints of the high and low over their periods. The
+// leading spans are plotted basePeriod candlesticks ahead, so the
+// spans reported for a candlestick are the ones calculated basePeriod
+// candlesticks earlier. The lagging span is just the close plotted
+// behind the price and isn't included.
+type IchimokuIndicator struct {
+	conversionPeriod uint
+	basePeriod       uint
+	spanBPeriod      uint
+	highs            *rollingWindow
+	lows             *rollingWindow
+	spans            []ichimokuSpans
+	lastTime         time.Time
+}
+
+// NewIchimokuIndicator returns a new IchimokuIndicator. The usual
+// periods are 9, 26 and 52.
+func NewIchimokuIndicator(conversionPeriod, basePeriod, spanBPeriod uint) *IchimokuIndicator {
+	size := conversionPeriod
+	if basePeriod > size {
+		size = basePeriod
+	}
+	if spanBPeriod > size {
+		size = spanBPeriod
+	}
+
+	return &IchimokuIndicator{
+		conversionPeriod: conversionPeriod,
+		basePeriod:       basePeriod,
+		spanBPeriod:      spanBPeriod,
+		highs:            newRollingWindow(size),
+		lows:             newRollingWindow(size),
+	}
+}
+
+func (i *IchimokuIndicator) midpoint(period uint) float64 {
+	return (i.highs.highest(period) + i.lows.lowest(period)) / 2
+}
+
+func (i *IchimokuIndicator) ready() bool {
+	return i.conversionPeriod > 0 && i.basePeriod > 0 && i.spanBPeriod > 0 &&
+		i.highs.full()
+}
+
+// AddCandlestick adds a candlestick to the indicator.
+func (i *IchimokuIndicator) AddCandlestick(c *CandlestickModel) error {
+	if c.Volume == 0 {
+		return nil
+	}
+
+	i.highs.add(c.High)
+	i.lows.add(c.Low)
+	i.lastTime = c.StartTime
+	if !i.ready() {
+		return nil
+	}
+
+	i.spans = append(i.spans, ichimokuSpans{
+		a: (i.midpoint(i.conversionPeriod) + i.midpoint(i.basePeriod)) / 2,
+		b: i.midpoint(i.spanBPeriod),
+	})
+	if uint(len(i.spans)) > i.basePeriod+1 {
+		i.spans = i.spans[1:]
+	}
+
+	return nil
+}
+
+// Name returns the name of the indicator.
+func (i *IchimokuIndicator) Name() string {
+	return fmt.Sprintf("%s-%d-%d-%d", IndicatorIchimoku, i.conversionPeriod, i.basePeriod, i.spanBPeriod)
+}
+
+// Value returns the last calculated lines and the cloud under the last
+// candlestick.
+func (i *IchimokuIndicator) Value() (*IndicatorValue, error) {
+	if uint(len(i.spans)) <= i.basePeriod {
+		return nil, ErrNotEnoughData
+	}
+
+	conversion := i.midpoint(i.conversionPeriod)
+	spans := i.spans[0]
+	return &IndicatorValue{
+		Time:          i.lastTime,
+		Value:         conversion,
+		IndicatorName: i.Name(),
+		Components: []*IndicatorComponent{
+			{Name: IchimokuConversion, Value: conversion},
+			{Name: IchimokuBase, Value: i.midpoint(i.basePeriod)},
+			{Name: IchimokuSpanA, Value: spans.a},
+			{Name: IchimokuSpanB, Value: spans.b},
+		},
+	}, nil
+}
@@ -0,0 +1,291 @@
+package vespyr
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// indicatorPoint is the value of an indicator after a candlestick. ok
+// is false when the indicator didn't have enough data.
+type indicatorPoint struct {
+	value *IndicatorValue
+	ok    bool
+}
+
+type indicatorCacheEntry struct {
+	once   sync.Once
+	series [][]*CandlestickModel
+	points []indicatorPoint
+	err    error
+}
+
+type reprojectionCacheEntry struct {
+	once        sync.Once
+	series      [][]*CandlestickModel
+	reprojected []*CandlestickModel
+	err         error
+}
+
+// IndicatorCache memoizes the values that indicators produce over a
+// series of candlesticks. Indicators are identified by name, which
+// includes their parameters, so strategies that share an indicator,
+// such as two genomes with the same EMA period, only calculate it
+// once. It's safe for concurrent use.
+//
+// Cached values are shared between backtests and must not be
+// modified. Entries are kept for the cache's lifetime, so a cache
+// should be scoped to a single run, such as an optimization.
+type IndicatorCache struct {
+	sync.Mutex
+	cache         map[string]*indicatorCacheEntry
+	reprojections map[string]*reprojectionCacheEntry
+}
+
+// NewIndicatorCache creates a new IndicatorCache.
+func NewIndicatorCache() *IndicatorCache {
+	return &IndicatorCache{
+		cache:         make(map[string]*indicatorCacheEntry),
+		reprojections: make(map[string]*reprojectionCacheEntry),
+	}
+}
+
+// candlesticksKey identifies a series of candlesticks by its product,
+// tick size and time range.
+func candlesticksKey(candles []*CandlestickModel) string {
+	first, last := candles[0], candles[len(candles)-1]
+	return fmt.Sprintf("%s-%s-%d-%d-%d", first.Product, first.EndTime.Sub(first.StartTime),
+		first.StartTime.UnixNano(), last.EndTime.UnixNano(), len(candles))
+}
+
+func sameCandlestick(a, b *CandlestickModel) bool {
+	return a == b || (a.Product == b.Product && a.StartTime.Equal(b.StartTime) &&
+		a.EndTime.Equal(b.EndTime) && a.Open == b.Open && a.High == b.High &&
+		a.Low == b.Low && a.Close == b.Close && a.Volume == b.Volume)
+}
+
+// copySeries copies lists of candlestick series so that entries can
+// be compared with later callers' series even if the slices they were
+// created with are reused.
+func copySeries(series ...[]*CandlestickModel) [][]*CandlestickModel {
+	copied := make([][]*CandlestickModel, len(series))
+	for i, candles := range series {
+		copied[i] = append([]*CandlestickModel(nil), candles...)
+	}
+	return copied
+}
+
+// sameSeries returns whether two lists of candlestick series hold the
+// same candlesticks.
+func sameSeries(a, b [][]*CandlestickModel) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if len(a[i]) != len(b[i]) {
+			return false
+		}
+		for j := range a[i] {
+			if !sameCandlestick(a[i][j], b[i][j]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// entry returns the entry for a key, creating it for the series if it
+// doesn't exist. Entries created for different candlesticks with the
+// same key aren't returned.
+func (c *IndicatorCache) entry(key string, series ...[]*CandlestickModel) (*indicatorCacheEntry, bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	entry, ok := c.cache[key]
+	if !ok {
+		entry = &indicatorCacheEntry{series: copySeries(series...)}
+		c.cache[key] = entry
+		return entry, true
+	}
+	return entry, sameSeries(entry.series, series)
+}
+
+// series returns an indicator's value after every candlestick. Series
+// are keyed by the product, tick size and time range of the
+// candlesticks, and an entry is only shared with callers passing the
+// same candlesticks. A nil cache calculates the series without
+// memoizing it.
+func (c *IndicatorCache) series(indicator Indicator, candles []*CandlestickModel) ([]indicatorPoint, error) {
+	if t, ok := indicator.(*TimeframeIndicator); ok && len(candles) > 0 {
+		return c.timeframeSeries(t, candles)
+	}
+	if c == nil || len(candles) == 0 {
+		return calculateIndicatorSeries(indicator, candles)
+	}
+
+	entry, ok := c.entry(fmt.Sprintf("%s-%s", indicator.Name(), candlesticksKey(candles)), candles)
+	if !ok {
+		return calculateIndicatorSeries(indicator, candles)
+	}
+
+	entry.once.Do(func() {
+		entry.points, entry.err = calculateIndicatorSeries(indicator, candles)
+	})
+
+	return entry.points, entry.err
+}
+
+// pairSeries returns a PairIndicator's value after every candlestick,
+// adding the pair product's candlestick with the same start time
+// before each one.
+func (c *IndicatorCache) pairSeries(indicator PairIndicator, candles,
+	pairCandles []*CandlestickModel) ([]indicatorPoint, error) {
+	if c == nil || len(candles) == 0 || len(pairCandles) == 0 {
+		return calculatePairIndicatorSeries(indicator, candles, pairCandles)
+	}
+
+	key := fmt.Sprintf("%s-%s-%s", indicator.Name(), candlesticksKey(candles), candlesticksKey(pairCandles))
+	entry, ok := c.entry(key, candles, pairCandles)
+	if !ok {
+		return calculatePairIndicatorSeries(indicator, candles, pairCandles)
+	}
+
+	entry.once.Do(func() {
+		entry.points, entry.err = calculatePairIndicatorSeries(indicator, candles, pairCandles)
+	})
+
+	return entry.points, entry.err
+}
+
+func calculatePairIndicatorSeries(indicator PairIndicator, candles,
+	pairCandles []*CandlestickModel) ([]indicatorPoint, error) {
+	byTime := make(map[time.Time]*CandlestickModel)
+	for _, c := range pairCandles {
+		byTime[c.StartTime.UTC()] = c
+	}
+
+	points := make([]indicatorPoint, len(candles))
+	for i, c := range candles {
+		if pairCandle, ok := byTime[c.StartTime.UTC()]; ok {
+			if err := indicator.AddPairCandlestick(pairCandle); err != nil {
+				return nil, errors.Wrapf(err, "error adding pair candlestick to indicator: %s", indicator.Name())
+			}
+		}
+		if err := indicator.AddCandlestick(c); err != nil {
+			return nil, errors.Wrapf(err, "error adding candlestick to indicator: %s", indicator.Name())
+		}
+		value, err := indicator.Value()
+		if err != nil {
+			if errors.Cause(err) != ErrNotEnoughData {
+				return nil, errors.Wrapf(err, "error calculating value for indicator: %s", indicator.Name())
+			}
+			continue
+		}
+		points[i] = indicatorPoint{value: value, ok: true}
+	}
+	return points, nil
+}
+
+// calculateIndicatorSeries adds every candlestick to an indicator,
+// recording its value after each one. Indicators decide whether to
+// skip candlesticks without volume.
+func calculateIndicatorSeries(indicator Indicator, candles []*CandlestickModel) ([]indicatorPoint, error) {
+	points := make([]indicatorPoint, len(candles))
+	for i, c := range candles {
+		if err := indicator.AddCandlestick(c); err != nil {
+			return nil, errors.Wrapf(err, "error adding candlestick to indicator: %s", indicator.Name())
+		}
+		value, err := indicator.Value()
+		if err != nil {
+			if errors.Cause(err) != ErrNotEnoughData {
+				return nil, errors.Wrapf(err, "error calculating value for indicator: %s", indicator.Name())
+			}
+			points[i] = indicatorPoint{value: value}
+			continue
+		}
+		points[i] = indicatorPoint{value: value, ok: true}
+	}
+	return points, nil
+}
+
+// reproject returns candlesticks reprojected into a longer tick size.
+func (c *IndicatorCache) reproject(candles []*CandlestickModel, tickSizeMinutes uint) ([]*CandlestickModel, error) {
+	if c == nil {
+		return reprojectTimeframe(candles, tickSizeMinutes)
+	}
+
+	key := fmt.Sprintf("%s-%d", candlesticksKey(candles), tickSizeMinutes)
+
+	c.Lock()
+	entry, ok := c.reprojections[key]
+	if !ok {
+		entry = &reprojectionCacheEntry{series: copySeries(candles)}
+		c.reprojections[key] = entry
+	}
+	c.Unlock()
+
+	if !sameSeries(entry.series, [][]*CandlestickModel{candles}) {
+		return reprojectTimeframe(candles, tickSizeMinutes)
+	}
+
+	entry.once.Do(func() {
+		entry.reprojected, entry.err = reprojectTimeframe(candles, tickSizeMinutes)
+	})
+
+	return entry.reprojected, entry.err
+}
+
+func reprojectTimeframe(candles []*CandlestickModel, tickSizeMinutes uint) ([]*CandlestickModel, error) {
+	var traded []*CandlestickModel
+	for _, c := range candles {
+		if _, err := timeframeBucket(c, tickSizeMinutes); err != nil {
+			return nil, err
+		}
+		if c.Volume != 0 {
+			traded = append(traded, c)
+		}
+	}
+	if len(traded) == 0 {
+		return nil, nil
+	}
+	return ReprojectCandlesticks(traded, traded[0].Product, int64(tickSizeMinutes))
+}
+
+// timeframeSeries calculates a TimeframeIndicator's wrapped indicator
+// on reprojected candlesticks and aligns its values with the original
+// ones. Each candlestick gets the value after the last reprojected
+// candlestick that ended by the time it did.
+func (c *IndicatorCache) timeframeSeries(t *TimeframeIndicator, candles []*CandlestickModel) ([]indicatorPoint, error) {
+	reprojected, err := c.reproject(candles, t.tickSizeMinutes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reprojecting candlesticks for indicator: %s", t.Name())
+	}
+	series, err := c.series(t.indicator, reprojected)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cached values are shared, so they're renamed in copies.
+	renamed := make([]*IndicatorValue, len(series))
+	for i, p := range series {
+		if p.ok {
+			v := *p.value
+			v.IndicatorName = t.Name()
+			renamed[i] = &v
+		}
+	}
+
+	points := make([]indicatorPoint, len(candles))
+	j := -1
+	for i, candle := range candles {
+		for j+1 < len(reprojected) && !reprojected[j+1].EndTime.After(candle.EndTime) {
+			j++
+		}
+		if j >= 0 && series[j].ok {
+			points[i] = indicatorPoint{value: renamed[j], ok: true}
+		}
+	}
+	return points, nil
+}
@@ -0,0 +1,118 @@
+package vespyr_test
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DavidHuie/vespyr/pkg/vespyr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestIndicatorCacheSharedBetweenBacktests(t *testing.T) {
+	endTime := time.Now()
+	startTime := endTime.Add(-time.Minute * 30)
+
+	var candles []*vespyr.CandlestickModel
+	for i, c := range []float64{10, 9, 11, 8, 12, 10, 9, 13, 11, 8, 12, 14} {
+		candles = append(candles, &vespyr.CandlestickModel{
+			StartTime: startTime.Add(time.Duration(i) * time.Hour),
+			EndTime:   startTime.Add(time.Duration(i+1) * time.Hour),
+			Close:     c,
+			Volume:    1,
+		})
+	}
+
+	backend := new(vespyr.MockBackend)
+	backend.On("FindCandlesticks", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(candles, nil)
+
+	backtest := func(longPeriod uint, cache *vespyr.IndicatorCache) *vespyr.BacktestResults {
+		model := &vespyr.TradingStrategyModel{
+			Product:          vespyr.ProductBTCUSD,
+			HistoryTicks:     1,
+			State:            vespyr.StrategyStateTryingToBuy,
+			InitialBudget:    500,
+			Budget:           500,
+			BudgetCurrency:   vespyr.CurrencyUSD,
+			InvestedCurrency: vespyr.CurrencyBTC,
+			TickSizeMinutes:  15,
+		}
+		assert.NoError(t, model.SetStrategy(&vespyr.EMACrossoverStrategy{ShortPeriod: 2, LongPeriod: longPeriod}))
+
+		backtester, err := vespyr.NewBacktester(startTime, endTime, model, backend, rand.NewSource(1))
+		assert.NoError(t, err)
+		backtester.SetIndicatorCache(cache)
+		assert.NoError(t, backtester.Backtest())
+		return backtester.Results()
+	}
+
+	var expected []*vespyr.BacktestResults
+	for period := uint(3); period <= 5; period++ {
+		expected = append(expected, backtest(period, nil))
+	}
+
+	cache := vespyr.NewIndicatorCache()
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 3; i++ {
+		for period := uint(3); period <= 5; period++ {
+			wg.Add(1)
+			go func(period uint) {
+				defer wg.Done()
+				assert.Equal(t, expected[period-3], backtest(period, cache))
+			}(period)
+		}
+	}
+	wg.Wait()
+}
+
+func TestIndicatorCacheComparesCandlesticks(t *testing.T) {
+	endTime := time.Now()
+	startTime := endTime.Add(-time.Minute * 30)
+
+	var candles []*vespyr.CandlestickModel
+	for i, c := range []float64{10, 9, 11, 8, 12, 10, 9, 13, 11, 8, 12, 14} {
+		candles = append(candles, &vespyr.CandlestickModel{
+			StartTime: startTime.Add(time.Duration(i) * time.Hour),
+			EndTime:   startTime.Add(time.Duration(i+1) * time.Hour),
+			Close:     c,
+			Volume:    1,
+		})
+	}
+
+	backend := new(vespyr.MockBackend)
+	backend.On("FindCandlesticks", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(candles, nil)
+
+	backtest := func(cache *vespyr.IndicatorCache) *vespyr.BacktestResults {
+		model := &vespyr.TradingStrategyModel{
+			Product:          vespyr.ProductBTCUSD,
+			HistoryTicks:     1,
+			State:            vespyr.StrategyStateTryingToBuy,
+			InitialBudget:    500,
+			Budget:           500,
+			BudgetCurrency:   vespyr.CurrencyUSD,
+			InvestedCurrency: vespyr.CurrencyBTC,
+			TickSizeMinutes:  15,
+		}
+		assert.NoError(t, model.SetStrategy(&vespyr.EMACrossoverStrategy{ShortPeriod: 2, LongPeriod: 4}))
+
+		backtester, err := vespyr.NewBacktester(startTime, endTime, model, backend, rand.NewSource(1))
+		assert.NoError(t, err)
+		backtester.SetIndicatorCache(cache)
+		assert.NoError(t, backtester.Backtest())
+		return backtester.Results()
+	}
+
+	cache := vespyr.NewIndicatorCache()
+	assert.Equal(t, backtest(nil), backtest(cache))
+
+	// Changing a candlestick in the same slice and time range
+	// isn't served from the cache.
+	changed := *candles[6]
+	changed.Close = 20
+	candles[6] = &changed
+	assert.Equal(t, backtest(nil), backtest(cache))
+}
@@ -0,0 +1,167 @@
+package vespyr_test
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/DavidHuie/vespyr/pkg/vespyr"
+	"github.com/stretchr/testify/assert"
+)
+
+// readGoldenIndicators reads testdata/indicators.csv, a reference
+// dataset of candlesticks followed by the expected value of each
+// indicator column after every candlestick. The expected values were
+// calculated from the whole series at each step rather than
+// incrementally. Empty cells mean that the indicator doesn't have
+// enough data yet.
+//
+// The reference drops candlesticks without volume, like the
+// indicators do, so every indicator but the Wilder RSI repeats its
+// previous value after the zero volume candlestick at 2018-01-02
+// 16:00. A reference that counted that candlestick, as charting
+// platforms do, wouldn't match.
+func readGoldenIndicators(t *testing.T) ([]string, []*vespyr.CandlestickModel, []map[string]string) {
+	f, err := os.Open("testdata/indicators.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := rows[0]
+	var candles []*vespyr.CandlestickModel
+	var expected []map[string]string
+	for _, row := range rows[1:] {
+		var fields [5]float64
+		for i := range fields {
+			fields[i], err = strconv.ParseFloat(row[i+1], 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		start, err := time.Parse(time.RFC3339, row[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		candles = append(candles, &vespyr.CandlestickModel{
+			StartTime: start,
+			EndTime:   start.Add(time.Hour),
+			Open:      fields[0],
+			High:      fields[1],
+			Low:       fields[2],
+			Close:     fields[3],
+			Volume:    fields[4],
+		})
+
+		values := make(map[string]string)
+		for i, column := range header[6:] {
+			values[column] = row[i+6]
+		}
+		expected = append(expected, values)
+	}
+
+	return header[6:], candles, expected
+}
+
+func TestIndicatorsGolden(t *testing.T) {
+	columns, candles, expected := readGoldenIndicators(t)
+
+	indicators := []vespyr.Indicator{
+		vespyr.NewSMAIndicator(10, vespyr.PriceSourceClose),
+		vespyr.NewSMAIndicator(10, vespyr.PriceSourceHL2),
+		vespyr.NewWMAIndicator(10, vespyr.PriceSourceHLC3),
+		vespyr.NewHMAIndicator(9, vespyr.PriceSourceClose),
+		vespyr.NewHMAIndicator(16, vespyr.PriceSourceOHLC4),
+		vespyr.NewStdDevIndicator(20, vespyr.PriceSourceClose),
+		vespyr.NewATRIndicator(14),
+		vespyr.NewADXIndicator(14),
+		vespyr.NewStochasticIndicator(14, 3, vespyr.PriceSourceClose),
+		vespyr.NewOBVIndicator(vespyr.PriceSourceClose),
+		vespyr.NewVWAPIndicator(20, vespyr.PriceSourceHLC3),
+		vespyr.NewIchimokuIndicator(9, 26, 52),
+		vespyr.NewKeltnerIndicator(20, 2, vespyr.PriceSourceClose),
+		vespyr.NewRSIIndicatorWithMode(14, vespyr.RSIModeWilder, ""),
+	}
+
+	covered := make(map[string]bool)
+	for i, c := range candles {
+		produced := make(map[string]bool)
+		for _, indicator := range indicators {
+			assert.NoError(t, indicator.AddCandlestick(c))
+
+			value, err := indicator.Value()
+			if err != nil {
+				assert.Equal(t, vespyr.ErrNotEnoughData, err, "%s at %d", indicator.Name(), i)
+				continue
+			}
+			assert.Equal(t, indicator.Name(), value.IndicatorName)
+
+			actual := map[string]float64{value.IndicatorName: value.Value}
+			if len(value.Components) > 0 {
+				actual = make(map[string]float64)
+				for _, component := range value.Components {
+					actual[fmt.Sprintf("%s.%s", value.IndicatorName, component.Name)] = component.Value
+				}
+			}
+
+			for column, v := range actual {
+				covered[column] = true
+				produced[column] = true
+				cell, ok := expected[i][column]
+				if !assert.True(t, ok, "missing golden column %s", column) {
+					continue
+				}
+				if !assert.NotEmpty(t, cell, "%s has a value before the reference at %d", column, i) {
+					continue
+				}
+				golden, err := strconv.ParseFloat(cell, 64)
+				assert.NoError(t, err)
+				assert.InDelta(t, golden, v, 1e-6, "%s at %d", column, i)
+			}
+		}
+
+		// Every reference value must have been produced by this
+		// candlestick.
+		for _, column := range columns {
+			if expected[i][column] != "" {
+				assert.True(t, produced[column], "%s has no value at %d", column, i)
+			}
+		}
+	}
+
+	for _, column := range columns {
+		assert.True(t, covered[column], column)
+	}
+}
+
+func TestPriceSource(t *testing.T) {
+	c := &vespyr.CandlestickModel{Open: 1, High: 4, Low: 2, Close: 3}
+
+	for source, expected := range map[string]float64{
+		"":      2.5,
+		"close": 3,
+		"hl2":   3,
+		"hlc3":  3,
+		"ohlc4": 2.5,
+	} {
+		parsed, err := vespyr.ParsePriceSource(source)
+		assert.NoError(t, err)
+		price, err := parsed.Price(c)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, price, source)
+	}
+
+	_, err := vespyr.ParsePriceSource("open")
+	assert.Error(t, err)
+	_, err = vespyr.PriceSource("open").Price(c)
+	assert.Error(t, err)
+}
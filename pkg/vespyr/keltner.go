@@ -0,0 +1,89 @@
+package vespyr
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// KeltnerMiddle is the EMA at the center of the channel, which
+	// is the indicator's primary value.
+	KeltnerMiddle = "middle"
+	// KeltnerUpper is the upper band.
+	KeltnerUpper = "upper"
+	// KeltnerLower is the lower band.
+	KeltnerLower = "lower"
+)
+
+// KeltnerIndicator calculates Keltner channels: an EMA of the price
+// surrounded by bands a multiple of the average true range above and
+// below it. The EMA and ATR share a period.
+type KeltnerIndicator struct {
+	period     uint
+	multiplier float64
+	source     PriceSource
+	ema        *exponentialAverage
+	atr        *ATRIndicator
+	lastTime   time.Time
+}
+
+// NewKeltnerIndicator returns a new KeltnerIndicator.
+func NewKeltnerIndicator(period uint, multiplier float64, source PriceSource) *KeltnerIndicator {
+	return &KeltnerIndicator{
+		period:     period,
+		multiplier: multiplier,
+		source:     source,
+		ema:        &exponentialAverage{period: period},
+		atr:        NewATRIndicator(period),
+	}
+}
+
+// AddCandlestick adds a candlestick to the indicator.
+func (k *KeltnerIndicator) AddCandlestick(c *CandlestickModel) error {
+	if c.Volume == 0 {
+		return nil
+	}
+
+	price, err := k.source.Price(c)
+	if err != nil {
+		return errors.Wrapf(err, "error calculating %s price", k.source)
+	}
+	if err := k.atr.AddCandlestick(c); err != nil {
+		return errors.Wrapf(err, "error adding candlestick to ATR")
+	}
+
+	k.ema.add(price)
+	k.lastTime = c.StartTime
+
+	return nil
+}
+
+// Name returns the name of the indicator.
+func (k *KeltnerIndicator) Name() string {
+	return fmt.Sprintf("%s-%d-%g-%s", IndicatorKeltner, k.period, k.multiplier, k.source)
+}
+
+// Value returns the last calculated channel.
+func (k *KeltnerIndicator) Value() (*IndicatorValue, error) {
+	atr, err := k.atr.Value()
+	if err != nil {
+		return nil, err
+	}
+	if !k.ema.ready() {
+		return nil, ErrNotEnoughData
+	}
+
+	middle := k.ema.value
+	return &IndicatorValue{
+		Time:          k.lastTime,
+		Value:         middle,
+		IndicatorName: k.Name(),
+		Components: []*IndicatorComponent{
+			{Name: KeltnerMiddle, Value: middle},
+			{Name: KeltnerUpper, Value: middle + k.multiplier*atr.Value},
+			{Name: KeltnerLower, Value: middle - k.multiplier*atr.Value},
+		},
+	}, nil
+}
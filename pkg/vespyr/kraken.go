@@ -12,6 +12,8 @@ import (
 
 const (
 	krakenSleepBetweenCandles = 15 * time.Second
+	krakenGranularitySeconds  = 60
+	krakenMaxOHLCEntries      = 720
 )
 
 // KrakenClient is the interface needed out of a Kraken client.
@@ -23,8 +25,9 @@ type KrakenClient interface {
 
 // KrakenExchange is a client to the Kraken cryptocurrency exchange.
 type KrakenExchange struct {
-	client KrakenClient
-	clock  clockwork.Clock
+	client  KrakenClient
+	clock   clockwork.Clock
+	limiter *TokenBucket
 }
 
 // NewKrakenExchange creates a new instance of KrakenExchange.
@@ -90,28 +93,7 @@ func (k *KrakenExchange) StreamCandlesticks(ctx context.Context, product Product
 			}
 
 			for i := 0; i < len(response.OHLC)-1; i++ {
-				candle := response.OHLC[i]
-
-				model := &CandlestickModel{
-					StartTime: candle.Time,
-					EndTime:   candle.Time.Add(time.Minute),
-					Low:       candle.Low,
-					High:      candle.High,
-					Open:      candle.Open,
-					Close:     candle.Close,
-					Volume:    candle.Volume,
-					Product:   product,
-				}
-
-				if candle.Volume > 0 {
-					if model.Close >= model.Open {
-						model.Direction = CandlestickDirectionUp
-					} else {
-						model.Direction = CandlestickDirectionDown
-					}
-				}
-
-				c <- model
+				c <- krakenCandlestick(product, response.OHLC[i])
 			}
 
 			lastID = response.Last
@@ -128,8 +110,96 @@ func (k *KrakenExchange) GetMessageChan(ctx context.Context, product Product) (<
 	panic("not implemented")
 }
 
+func krakenCandlestick(product Product, candle *krakenapi.OHLC) *CandlestickModel {
+	model := &CandlestickModel{
+		StartTime: candle.Time,
+		EndTime:   candle.Time.Add(time.Minute),
+		Low:       candle.Low,
+		High:      candle.High,
+		Open:      candle.Open,
+		Close:     candle.Close,
+		Volume:    candle.Volume,
+		Product:   product,
+	}
+
+	if candle.Volume > 0 {
+		if model.Close >= model.Open {
+			model.Direction = CandlestickDirectionUp
+		} else {
+			model.Direction = CandlestickDirectionDown
+		}
+	}
+
+	return model
+}
+
+// SetRateLimiter sets a rate limiter that's consulted before every
+// historical OHLC request.
+func (k *KrakenExchange) SetRateLimiter(t *TokenBucket) {
+	k.limiter = t
+}
+
+// SupportsHistoricalCandlesticks returns whether the exchange can
+// return candlesticks for past time ranges.
+func (k *KrakenExchange) SupportsHistoricalCandlesticks() bool {
+	return true
+}
+
+// GetCandlesticks returns one minute candlesticks for a time range
+// from Kraken, paging through OHLC responses. Kraken only serves the
+// most recent krakenMaxOHLCEntries intervals, so ranges starting
+// before then return an error.
 func (k *KrakenExchange) GetCandlesticks(product Product, start time.Time, end time.Time, granularity int) ([]*CandlestickModel, error) {
-	panic("not implemented")
+	if granularity != krakenGranularitySeconds {
+		return nil, errors.Errorf("error: unsupported Kraken granularity: %d", granularity)
+	}
+	krakenType, ok := productToKrakenType[product]
+	if !ok {
+		return nil, errors.Errorf("error: unsupported Kraken product: %s", product)
+	}
+
+	oldest := k.clock.Now().Add(-krakenMaxOHLCEntries * time.Minute)
+	if start.Before(oldest) {
+		return nil, errors.Errorf("error: Kraken only serves %s candlesticks after %s, not %s",
+			product, oldest, start)
+	}
+
+	var candles []*CandlestickModel
+	since := start.Unix() - 1
+	for {
+		if k.limiter != nil {
+			k.limiter.Wait()
+		}
+		response, err := k.client.OHLC(krakenType, since)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error making Kraken OHLC call")
+		}
+
+		// The last candlestick refers to the current time
+		// period and isn't complete.
+		if len(response.OHLC) <= 1 {
+			break
+		}
+		finished := false
+		for i := 0; i < len(response.OHLC)-1; i++ {
+			candle := response.OHLC[i]
+			if candle.Time.Before(start) {
+				continue
+			}
+			if candle.Time.After(end) {
+				finished = true
+				break
+			}
+			candles = append(candles, krakenCandlestick(product, candle))
+		}
+
+		if finished || response.Last <= since {
+			break
+		}
+		since = response.Last
+	}
+
+	return candles, nil
 }
 
 func (k *KrakenExchange) CreateMarketOrder(*MarketOrder) (*CreateMarketOrderResponse, error) {
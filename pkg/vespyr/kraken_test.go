@@ -0,0 +1,67 @@
+package vespyr_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DavidHuie/kraken-go-api-client"
+	"github.com/DavidHuie/vespyr/pkg/vespyr"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestKrakenGetCandlesticks(t *testing.T) {
+	client := new(vespyr.MockKrakenClient)
+	start := vespyr.CandlestickBucket(time.Now().Add(-time.Hour), 1)
+	end := start.Add(2 * time.Minute)
+	clock := clockwork.NewFakeClockAt(start.Add(time.Hour))
+	kraken := vespyr.NewKrakenExchange(client, clock)
+	limiter, err := vespyr.NewTokenBucket(1e-6, 2, clock)
+	assert.NoError(t, err)
+	kraken.SetRateLimiter(limiter)
+
+	defer mock.AssertExpectationsForObjects(t, client)
+
+	ohlc := func(t time.Time, price float64) *krakenapi.OHLC {
+		return &krakenapi.OHLC{Time: t, Open: price, High: price, Low: price, Close: price, Volume: 1}
+	}
+
+	client.On("OHLC", krakenapi.XXMRZUSD, start.Unix()-1).Return(&krakenapi.OHLCResponse{
+		OHLC: []*krakenapi.OHLC{
+			ohlc(start.Add(-time.Minute), 9),
+			ohlc(start, 10),
+			ohlc(start.Add(time.Minute), 11),
+		},
+		Last: start.Add(time.Minute).Unix(),
+	}, nil).Once()
+	client.On("OHLC", krakenapi.XXMRZUSD, start.Add(time.Minute).Unix()).Return(&krakenapi.OHLCResponse{
+		OHLC: []*krakenapi.OHLC{
+			ohlc(start.Add(time.Minute), 11),
+			ohlc(start.Add(2*time.Minute), 12),
+			ohlc(start.Add(3*time.Minute), 13),
+			ohlc(start.Add(4*time.Minute), 14),
+		},
+		Last: start.Add(3 * time.Minute).Unix(),
+	}, nil).Once()
+
+	candles, err := kraken.GetCandlesticks(vespyr.ProductXMRUSD, start, end, 60)
+	assert.NoError(t, err)
+
+	var closes []float64
+	for _, c := range candles {
+		assert.Equal(t, vespyr.ProductXMRUSD, c.Product)
+		closes = append(closes, c.Close)
+	}
+	assert.Equal(t, []float64{10, 11, 12}, closes)
+
+	// A token is taken for each request.
+	assert.False(t, limiter.Take())
+
+	// Kraken doesn't serve candlesticks older than 720 minutes.
+	_, err = kraken.GetCandlesticks(vespyr.ProductXMRUSD, start.Add(-12*time.Hour), end, 60)
+	assert.Error(t, err)
+
+	_, err = kraken.GetCandlesticks(vespyr.ProductXMRUSD, start, end, 300)
+	assert.Error(t, err)
+}
@@ -0,0 +1,45 @@
+package vespyr
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// LevelStrategyInterface is a strategy that splits its budget between
+// several positions, or levels, that are bought and sold separately
+// instead of investing all of it at once. The levels that are held are
+// stored on the model.
+type LevelStrategyInterface interface {
+	StrategyInterface
+	// Orders returns the orders to make on a tick given the held
+	// levels and the remaining budget.
+	Orders(history []*IndicatorSet, current int, levels []*StrategyLevel,
+		budget float64) ([]*LevelOrder, error)
+}
+
+// LevelOrder is an order made by a LevelStrategyInterface. A sell sells
+// a held level, and a buy spends Cost on a new one.
+type LevelOrder struct {
+	Side  string
+	Level *StrategyLevel
+	Cost  float64
+}
+
+// errLevelSignals is returned by the Buy and Sell methods of level
+// strategies, which hold several positions.
+var errLevelSignals = errors.New("error: level strategies don't have buy and sell signals")
+
+// levelPrice returns the closing price at a tick of the history, as
+// calculated by a single period SMA.
+func levelPrice(history []*IndicatorSet, current int) (float64, time.Time, error) {
+	value, err := indicatorValue(history, current, levelPriceIndicator().Name())
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return value.Value, history[current].Time, nil
+}
+
+func levelPriceIndicator() Indicator {
+	return NewSMAIndicator(1, PriceSourceClose)
+}
@@ -71,8 +71,18 @@ func (m *MACDIndicator) Value() (*IndicatorValue, error) {
 	}, nil
 }
 
+const (
+	// MACDLine is the MACD component of MACDWithSignal.
+	MACDLine = "macd"
+	// MACDSignal is the signal EMA component of MACDWithSignal.
+	MACDSignal = "signal"
+	// MACDHistogram is the MACD minus the signal, which is
+	// MACDWithSignal's primary value.
+	MACDHistogram = "histogram"
+)
+
 // MACDWithSignal is an indicator that tracks the MACD value minus the
-// signal EMA.
+// signal EMA. It also exposes the MACD and signal as components.
 type MACDWithSignal struct {
 	shortEMAPeriod uint
 	longEMAPeriod  uint
@@ -148,9 +158,15 @@ func (m *MACDWithSignal) Value() (*IndicatorValue, error) {
 		return nil, errors.Wrapf(err, "error getting MACD value")
 	}
 
+	histogram := macd.Value - signal.Value
 	return &IndicatorValue{
 		Time:          m.lastTime,
-		Value:         macd.Value - signal.Value,
+		Value:         histogram,
 		IndicatorName: m.Name(),
+		Components: []*IndicatorComponent{
+			{Name: MACDLine, Value: macd.Value},
+			{Name: MACDSignal, Value: signal.Value},
+			{Name: MACDHistogram, Value: histogram},
+		},
 	}, nil
 }
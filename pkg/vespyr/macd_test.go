@@ -62,6 +62,15 @@ func TestMACDIndicatorWithSignal(t *testing.T) {
 					assert.Equal(t, responses[i], value.Value)
 					assert.Equal(t, startTime, value.Time)
 					assert.Equal(t, "macd-with-signal-2-ema-1-2", value.IndicatorName)
+
+					macd, err := value.Component(vespyr.MACDLine)
+					assert.NoError(t, err)
+					signal, err := value.Component(vespyr.MACDSignal)
+					assert.NoError(t, err)
+					histogram, err := value.Component(vespyr.MACDHistogram)
+					assert.NoError(t, err)
+					assert.Equal(t, value.Value, histogram)
+					assert.InDelta(t, macd-signal, histogram, 1e-9)
 				}
 			}
 		}
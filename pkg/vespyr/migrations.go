@@ -102,6 +102,125 @@ COMMIT;
 `).SetDown(`
 BEGIN;
 ALTER TABLE candlesticks DROP CONSTRAINT candlesticks_pkey;
+COMMIT;`))
+
+	cm.AddMigration(new(Migration).SetUp(`
+BEGIN;
+CREATE TABLE import_jobs (
+  id serial PRIMARY KEY,
+  created_at timestamptz NOT NULL,
+  updated_at timestamptz,
+  completed_at timestamptz,
+  product text,
+  start_time timestamptz,
+  end_time timestamptz
+);
+CREATE TABLE import_batches (
+  id serial PRIMARY KEY,
+  created_at timestamptz NOT NULL,
+  updated_at timestamptz,
+  import_job_id integer REFERENCES import_jobs ON DELETE CASCADE,
+  start_time timestamptz,
+  end_time timestamptz,
+  status text,
+  attempts integer,
+  last_error text
+);
+CREATE INDEX import_batches_import_job_id_idx ON import_batches (import_job_id);
+COMMIT;
+`).SetDown(`
+BEGIN;
+DROP TABLE import_batches;
+DROP TABLE import_jobs;
+COMMIT;`))
+
+	cm.AddMigration(new(Migration).SetUp(`
+BEGIN;
+CREATE TABLE backtest_runs (
+  id serial PRIMARY KEY,
+  created_at timestamptz NOT NULL,
+  updated_at timestamptz,
+  sweep_name text,
+  product text,
+  tick_size_minutes integer,
+  history_ticks integer,
+  trading_strategy text,
+  trading_strategy_data bytea,
+  parameters jsonb,
+  start_time timestamptz,
+  end_time timestamptz,
+  objective text,
+  score double precision,
+  initial_budget double precision,
+  final_budget double precision,
+  net_profit double precision,
+  profit_factor double precision,
+  sharpe_ratio double precision,
+  max_drawdown double precision,
+  profit_trades integer,
+  loss_trades integer
+);
+CREATE INDEX backtest_runs_sweep_name_idx ON backtest_runs (sweep_name);
+COMMIT;
+`).SetDown(`
+BEGIN;
+DROP TABLE backtest_runs;
+COMMIT;`))
+
+	cm.AddMigration(new(Migration).SetUp(`
+BEGIN;
+ALTER TABLE backtest_runs ADD COLUMN seed bigint;
+ALTER TABLE backtest_runs ADD COLUMN equity jsonb;
+CREATE INDEX backtest_runs_created_at_idx ON backtest_runs (created_at);
+CREATE TABLE backtest_trades (
+  id serial PRIMARY KEY,
+  created_at timestamptz NOT NULL,
+  backtest_run_id integer REFERENCES backtest_runs ON DELETE CASCADE,
+  time timestamptz,
+  side text,
+  price double precision,
+  cost double precision,
+  cost_currency text,
+  filled_size double precision,
+  size_currency text,
+  fees double precision,
+  fees_currency text
+);
+CREATE INDEX backtest_trades_backtest_run_id_idx ON backtest_trades (backtest_run_id);
+COMMIT;
+`).SetDown(`
+BEGIN;
+DROP TABLE backtest_trades;
+DROP INDEX backtest_runs_created_at_idx;
+ALTER TABLE backtest_runs DROP COLUMN equity;
+ALTER TABLE backtest_runs DROP COLUMN seed;
+COMMIT;`))
+
+	cm.AddMigration(new(Migration).SetUp(`
+BEGIN;
+ALTER TABLE backtest_runs ADD COLUMN fill_model text;
+COMMIT;
+`).SetDown(`
+BEGIN;
+ALTER TABLE backtest_runs DROP COLUMN fill_model;
+COMMIT;`))
+
+	cm.AddMigration(new(Migration).SetUp(`
+BEGIN;
+ALTER TABLE trading_strategies ADD COLUMN invested_product text;
+COMMIT;
+`).SetDown(`
+BEGIN;
+ALTER TABLE trading_strategies DROP COLUMN invested_product;
+COMMIT;`))
+
+	cm.AddMigration(new(Migration).SetUp(`
+BEGIN;
+ALTER TABLE trading_strategies ADD COLUMN levels jsonb;
+COMMIT;
+`).SetDown(`
+BEGIN;
+ALTER TABLE trading_strategies DROP COLUMN levels;
 COMMIT;`))
 
 	source.Register("code", cm)
@@ -9,6 +9,34 @@ type MockBackend struct {
 	mock.Mock
 }
 
+// CreateBacktestRun provides a mock function with given fields: _a0, _a1
+func (_m *MockBackend) CreateBacktestRun(_a0 *BacktestRunModel, _a1 []*BacktestTradeModel) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*BacktestRunModel, []*BacktestTradeModel) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateImportJob provides a mock function with given fields: _a0, _a1
+func (_m *MockBackend) CreateImportJob(_a0 *ImportJobModel, _a1 []*ImportBatchModel) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*ImportJobModel, []*ImportBatchModel) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // CreateMarketOrder provides a mock function with given fields: _a0
 func (_m *MockBackend) CreateMarketOrder(_a0 *MarketOrderModel) error {
 	ret := _m.Called(_a0)
@@ -60,6 +88,75 @@ func (_m *MockBackend) FindActiveTradingStrategies(_a0 Product) ([]*TradingStrat
 	return r0, r1
 }
 
+// FindBacktestRunByID provides a mock function with given fields: _a0
+func (_m *MockBackend) FindBacktestRunByID(_a0 int64) (*BacktestRunModel, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *BacktestRunModel
+	if rf, ok := ret.Get(0).(func(int64) *BacktestRunModel); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*BacktestRunModel)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int64) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindBacktestRuns provides a mock function with given fields: _a0, _a1
+func (_m *MockBackend) FindBacktestRuns(_a0 string, _a1 int) ([]*BacktestRunModel, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 []*BacktestRunModel
+	if rf, ok := ret.Get(0).(func(string, int) []*BacktestRunModel); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*BacktestRunModel)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, int) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindBacktestTrades provides a mock function with given fields: _a0
+func (_m *MockBackend) FindBacktestTrades(_a0 int64) ([]*BacktestTradeModel, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []*BacktestTradeModel
+	if rf, ok := ret.Get(0).(func(int64) []*BacktestTradeModel); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*BacktestTradeModel)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int64) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // FindCandlestickByID provides a mock function with given fields: _a0
 func (_m *MockBackend) FindCandlestickByID(_a0 int64) (*CandlestickModel, error) {
 	ret := _m.Called(_a0)
@@ -106,6 +203,52 @@ func (_m *MockBackend) FindCandlesticks(_a0 time.Time, _a1 time.Time, _a2 Produc
 	return r0, r1
 }
 
+// FindImportBatches provides a mock function with given fields: _a0
+func (_m *MockBackend) FindImportBatches(_a0 int64) ([]*ImportBatchModel, error) {
+	ret := _m.Called(_a0)
+
+	var r0 []*ImportBatchModel
+	if rf, ok := ret.Get(0).(func(int64) []*ImportBatchModel); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*ImportBatchModel)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int64) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindImportJobByID provides a mock function with given fields: _a0
+func (_m *MockBackend) FindImportJobByID(_a0 int64) (*ImportJobModel, error) {
+	ret := _m.Called(_a0)
+
+	var r0 *ImportJobModel
+	if rf, ok := ret.Get(0).(func(int64) *ImportJobModel); ok {
+		r0 = rf(_a0)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*ImportJobModel)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int64) error); ok {
+		r1 = rf(_a0)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // FindMarketOrderByID provides a mock function with given fields: _a0
 func (_m *MockBackend) FindMarketOrderByID(_a0 int64) (*MarketOrderModel, error) {
 	ret := _m.Called(_a0)
@@ -129,6 +272,29 @@ func (_m *MockBackend) FindMarketOrderByID(_a0 int64) (*MarketOrderModel, error)
 	return r0, r1
 }
 
+// FindMissingCandlestickTimes provides a mock function with given fields: _a0, _a1, _a2
+func (_m *MockBackend) FindMissingCandlestickTimes(_a0 Product, _a1 time.Time, _a2 time.Time) ([]time.Time, error) {
+	ret := _m.Called(_a0, _a1, _a2)
+
+	var r0 []time.Time
+	if rf, ok := ret.Get(0).(func(Product, time.Time, time.Time) []time.Time); ok {
+		r0 = rf(_a0, _a1, _a2)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]time.Time)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(Product, time.Time, time.Time) error); ok {
+		r1 = rf(_a0, _a1, _a2)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // FindMostRecentCandlestick provides a mock function with given fields: _a0
 func (_m *MockBackend) FindMostRecentCandlestick(_a0 Product) (*CandlestickModel, error) {
 	ret := _m.Called(_a0)
@@ -175,6 +341,34 @@ func (_m *MockBackend) FindTradingStrategyByID(_a0 int64) (*TradingStrategyModel
 	return r0, r1
 }
 
+// UpdateImportBatch provides a mock function with given fields: _a0
+func (_m *MockBackend) UpdateImportBatch(_a0 *ImportBatchModel) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*ImportBatchModel) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateImportJob provides a mock function with given fields: _a0
+func (_m *MockBackend) UpdateImportJob(_a0 *ImportJobModel) error {
+	ret := _m.Called(_a0)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*ImportJobModel) error); ok {
+		r0 = rf(_a0)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateTradingStrategy provides a mock function with given fields: _a0
 func (_m *MockBackend) UpdateTradingStrategy(_a0 *TradingStrategyModel) error {
 	ret := _m.Called(_a0)
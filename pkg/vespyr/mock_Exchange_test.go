@@ -115,3 +115,17 @@ func (_m *MockExchange) StreamCandlesticks(ctx context.Context, product Product)
 
 	return r0, r1
 }
+
+// SupportsHistoricalCandlesticks provides a mock function with given fields:
+func (_m *MockExchange) SupportsHistoricalCandlesticks() bool {
+	ret := _m.Called()
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func() bool); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
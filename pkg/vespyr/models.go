@@ -79,6 +79,145 @@ func (m *MarketOrderModel) BeforeUpdate(db orm.DB) error {
 	return nil
 }
 
+// ImportJobModel tracks a historical import of a product over a time
+// range so that it can be resumed.
+type ImportJobModel struct {
+	tableName   struct{} `sql:"import_jobs"`
+	ID          int64
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	CompletedAt time.Time
+	Product     Product
+	StartTime   time.Time
+	EndTime     time.Time
+}
+
+func (m *ImportJobModel) BeforeInsert(db orm.DB) error {
+	m.CreatedAt = time.Now()
+	return nil
+}
+
+func (m *ImportJobModel) BeforeUpdate(db orm.DB) error {
+	m.UpdatedAt = time.Now()
+	return nil
+}
+
+// ImportBatchModel is a single batch of an import job.
+type ImportBatchModel struct {
+	tableName   struct{} `sql:"import_batches"`
+	ID          int64
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	ImportJobID int64
+	StartTime   time.Time
+	EndTime     time.Time
+	Status      string
+	Attempts    uint
+	LastError   string
+}
+
+func (m *ImportBatchModel) BeforeInsert(db orm.DB) error {
+	m.CreatedAt = time.Now()
+	return nil
+}
+
+func (m *ImportBatchModel) BeforeUpdate(db orm.DB) error {
+	m.UpdatedAt = time.Now()
+	return nil
+}
+
+// BacktestRunModel stores the results of a single backtest so that
+// sweeps can be analyzed after they've run.
+type BacktestRunModel struct {
+	tableName           struct{} `sql:"backtest_runs"`
+	ID                  int64
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+	SweepName           string
+	Product             Product
+	TickSizeMinutes     uint
+	HistoryTicks        uint
+	TradingStrategy     string
+	TradingStrategyData []byte
+	Parameters          map[string]interface{}
+	StartTime           time.Time
+	EndTime             time.Time
+	Objective           string
+	Score               float64
+	InitialBudget       float64
+	FinalBudget         float64
+	NetProfit           float64
+	ProfitFactor        float64
+	SharpeRatio         float64
+	MaxDrawdown         float64
+	ProfitTrades        uint
+	LossTrades          uint
+	Seed                int64
+	Equity              []float64
+	FillModel           string
+}
+
+func (m *BacktestRunModel) BeforeInsert(db orm.DB) error {
+	m.CreatedAt = time.Now()
+	return nil
+}
+
+func (m *BacktestRunModel) BeforeUpdate(db orm.DB) error {
+	m.UpdatedAt = time.Now()
+	return nil
+}
+
+// TradingStrategyModel returns a new live trading strategy that uses
+// the run's strategy with a budget.
+func (m *BacktestRunModel) TradingStrategyModel(budget float64, now time.Time) (*TradingStrategyModel, error) {
+	meta, ok := ProductToMetadata[m.Product]
+	if !ok {
+		return nil, errors.Errorf("error: unknown product: %s", m.Product)
+	}
+
+	model := &TradingStrategyModel{
+		NextTickAt:          CandlestickBucket(now, int64(m.TickSizeMinutes)).Add(time.Minute * time.Duration(m.TickSizeMinutes)),
+		Product:             m.Product,
+		HistoryTicks:        m.HistoryTicks,
+		State:               StrategyStateTryingToBuy,
+		InitialBudget:       budget,
+		Budget:              budget,
+		BudgetCurrency:      meta.MarketOrderBuyCurrency,
+		InvestedCurrency:    meta.MarketOrderSellCurrency,
+		TickSizeMinutes:     m.TickSizeMinutes,
+		TradingStrategy:     m.TradingStrategy,
+		TradingStrategyData: m.TradingStrategyData,
+	}
+	if _, err := model.Strategy(); err != nil {
+		return nil, errors.Wrapf(err, "error decoding trading strategy")
+	}
+
+	return model, nil
+}
+
+// BacktestTradeModel is a market order made during a stored backtest
+// run.
+type BacktestTradeModel struct {
+	tableName     struct{} `sql:"backtest_trades"`
+	ID            int64
+	CreatedAt     time.Time
+	BacktestRunID int64
+	Time          time.Time
+	Side          string
+	Price         float64
+	Cost          float64
+	CostCurrency  string
+	FilledSize    float64
+	SizeCurrency  string
+	Fees          float64
+	FeesCurrency  string
+}
+
+func (m *BacktestTradeModel) BeforeInsert(db orm.DB) error {
+	m.CreatedAt = time.Now()
+	return nil
+}
+
 // TradingStrategyModel contains metadata for a trading strategy.
 type TradingStrategyModel struct {
 	tableName           struct{} `sql:"trading_strategies"`
@@ -96,9 +235,23 @@ type TradingStrategyModel struct {
 	BudgetCurrency      string
 	Invested            float64
 	InvestedCurrency    string
+	InvestedProduct     Product
 	TickSizeMinutes     uint
 	TradingStrategy     string
 	TradingStrategyData []byte
+	Levels              []*StrategyLevel
+}
+
+// StrategyLevel is one of the positions held by a strategy that buys
+// and sells in parts, such as a level of a grid.
+type StrategyLevel struct {
+	// Level identifies the level within the strategy, such as the
+	// index of a grid line.
+	Level    int       `json:"level"`
+	Price    float64   `json:"price"`
+	Cost     float64   `json:"cost"`
+	Size     float64   `json:"size"`
+	BoughtAt time.Time `json:"bought_at"`
 }
 
 func (m *TradingStrategyModel) BeforeInsert(db orm.DB) error {
@@ -111,6 +264,25 @@ func (m *TradingStrategyModel) BeforeUpdate(db orm.DB) error {
 	return nil
 }
 
+// HeldProduct returns the product that the model's invested currency
+// was bought with. InvestedProduct is only set when that isn't
+// Product, such as when a pairs strategy holds its second leg.
+func (t *TradingStrategyModel) HeldProduct() Product {
+	if t.InvestedProduct != "" {
+		return t.InvestedProduct
+	}
+	return t.Product
+}
+
+func (t *TradingStrategyModel) copyLevels() []*StrategyLevel {
+	var levels []*StrategyLevel
+	for _, l := range t.Levels {
+		level := *l
+		levels = append(levels, &level)
+	}
+	return levels
+}
+
 // Copy returns a copy of the current model.
 func (t *TradingStrategyModel) Copy() *TradingStrategyModel {
 	return &TradingStrategyModel{
@@ -123,24 +295,21 @@ func (t *TradingStrategyModel) Copy() *TradingStrategyModel {
 		BudgetCurrency:      t.BudgetCurrency,
 		Invested:            t.Invested,
 		InvestedCurrency:    t.InvestedCurrency,
+		InvestedProduct:     t.InvestedProduct,
 		TickSizeMinutes:     t.TickSizeMinutes,
 		TradingStrategy:     t.TradingStrategy,
 		TradingStrategyData: t.TradingStrategyData,
+		Levels:              t.copyLevels(),
 	}
 }
 
 // SetStrategy sets the underlying trading strategy.
 func (t *TradingStrategyModel) SetStrategy(s StrategyInterface) error {
-	switch s.(type) {
-	case *EMACrossoverStrategy:
-		t.TradingStrategy = TradingStrategyEMACrossover
-	case *RSIStrategy:
-		t.TradingStrategy = TradingStrategyRSI
-	case *S1Strategy:
-		t.TradingStrategy = TradingStrategyS1
-	default:
-		return errors.Errorf("error: unknown trading strategy")
+	strategyType, err := strategyTypeOf(s)
+	if err != nil {
+		return err
 	}
+	t.TradingStrategy = strategyType.Name
 
 	s.SetTradingStrategy(t)
 
@@ -155,29 +324,15 @@ func (t *TradingStrategyModel) SetStrategy(s StrategyInterface) error {
 
 // Strategy returns the underlying trading strategy.
 func (t *TradingStrategyModel) Strategy() (StrategyInterface, error) {
-	switch t.TradingStrategy {
-	case TradingStrategyEMACrossover:
-		var strategy EMACrossoverStrategy
-		if err := yaml.Unmarshal(t.TradingStrategyData, &strategy); err != nil {
-			return nil, errors.Wrapf(err, "error YAML unmarshaling trading strategy data")
-		}
-		strategy.SetTradingStrategy(t)
-		return &strategy, nil
-	case TradingStrategyRSI:
-		var strategy RSIStrategy
-		if err := yaml.Unmarshal(t.TradingStrategyData, &strategy); err != nil {
-			return nil, errors.Wrapf(err, "error YAML unmarshaling trading strategy data")
-		}
-		strategy.SetTradingStrategy(t)
-		return &strategy, nil
-	case TradingStrategyS1:
-		var strategy S1Strategy
-		if err := yaml.Unmarshal(t.TradingStrategyData, &strategy); err != nil {
-			return nil, errors.Wrapf(err, "error YAML unmarshaling trading strategy data")
-		}
-		strategy.SetTradingStrategy(t)
-		return &strategy, nil
-	default:
-		return nil, errors.Errorf("error: unknown trading strategy: %s", t.TradingStrategy)
+	strategyType, err := LookupStrategyType(t.TradingStrategy)
+	if err != nil {
+		return nil, err
+	}
+
+	strategy, err := strategyType.Decode(t.TradingStrategyData)
+	if err != nil {
+		return nil, err
 	}
+	strategy.SetTradingStrategy(t)
+	return strategy, nil
 }
@@ -0,0 +1,293 @@
+package vespyr
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// rollingWindow holds the last size values of a series.
+type rollingWindow struct {
+	size   uint
+	values []float64
+}
+
+func newRollingWindow(size uint) *rollingWindow {
+	return &rollingWindow{size: size}
+}
+
+func (w *rollingWindow) add(v float64) {
+	w.values = append(w.values, v)
+	if uint(len(w.values)) > w.size {
+		w.values = w.values[1:]
+	}
+}
+
+func (w *rollingWindow) full() bool {
+	return w.size > 0 && uint(len(w.values)) == w.size
+}
+
+func (w *rollingWindow) sum() float64 {
+	var sum float64
+	for _, v := range w.values {
+		sum += v
+	}
+	return sum
+}
+
+func (w *rollingWindow) mean() float64 {
+	return w.sum() / float64(len(w.values))
+}
+
+// weightedMean weights the newest value by the window's length and
+// the oldest by one.
+func (w *rollingWindow) weightedMean() float64 {
+	var sum, weights float64
+	for i, v := range w.values {
+		sum += float64(i+1) * v
+		weights += float64(i + 1)
+	}
+	return sum / weights
+}
+
+// stddev returns the population standard deviation.
+func (w *rollingWindow) stddev() float64 {
+	mean := w.mean()
+	var variance float64
+	for _, v := range w.values {
+		variance += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(variance / float64(len(w.values)))
+}
+
+// highest returns the highest of the newest n values.
+func (w *rollingWindow) highest(n uint) float64 {
+	values := w.values[uint(len(w.values))-n:]
+	high := values[0]
+	for _, v := range values {
+		high = math.Max(high, v)
+	}
+	return high
+}
+
+// lowest returns the lowest of the newest n values.
+func (w *rollingWindow) lowest(n uint) float64 {
+	values := w.values[uint(len(w.values))-n:]
+	low := values[0]
+	for _, v := range values {
+		low = math.Min(low, v)
+	}
+	return low
+}
+
+// wilderAverage is Wilder's moving average: the mean of the first
+// period values, followed by an exponential average with a smoothing
+// factor of 1/period.
+type wilderAverage struct {
+	period uint
+	count  uint
+	sum    float64
+	value  float64
+}
+
+func (a *wilderAverage) add(v float64) {
+	a.count++
+	if a.count <= a.period {
+		a.sum += v
+		a.value = a.sum / float64(a.period)
+		return
+	}
+	a.value = (a.value*float64(a.period-1) + v) / float64(a.period)
+}
+
+func (a *wilderAverage) ready() bool {
+	return a.period > 0 && a.count >= a.period
+}
+
+// exponentialAverage is an EMA seeded with the mean of the first
+// period values.
+type exponentialAverage struct {
+	period uint
+	count  uint
+	sum    float64
+	value  float64
+}
+
+func (a *exponentialAverage) add(v float64) {
+	a.count++
+	if a.count <= a.period {
+		a.sum += v
+		a.value = a.sum / float64(a.period)
+		return
+	}
+	constant := 2 / (float64(a.period) + 1)
+	a.value = constant*(v-a.value) + a.value
+}
+
+func (a *exponentialAverage) ready() bool {
+	return a.period > 0 && a.count >= a.period
+}
+
+// windowIndicator is an indicator calculated from a rolling window of
+// prices.
+type windowIndicator struct {
+	name     string
+	source   PriceSource
+	window   *rollingWindow
+	value    func(*rollingWindow) float64
+	lastTime time.Time
+}
+
+// AddCandlestick adds a candlestick to the indicator.
+func (w *windowIndicator) AddCandlestick(c *CandlestickModel) error {
+	if c.Volume == 0 {
+		return nil
+	}
+
+	price, err := w.source.Price(c)
+	if err != nil {
+		return errors.Wrapf(err, "error calculating %s price", w.source)
+	}
+
+	w.window.add(price)
+	w.lastTime = c.StartTime
+
+	return nil
+}
+
+// Name returns the name of the indicator.
+func (w *windowIndicator) Name() string {
+	return fmt.Sprintf("%s-%d-%s", w.name, w.window.size, w.source)
+}
+
+// Value returns the indicator's value for the last window.
+func (w *windowIndicator) Value() (*IndicatorValue, error) {
+	if !w.window.full() {
+		return nil, ErrNotEnoughData
+	}
+	return &IndicatorValue{
+		Time:          w.lastTime,
+		Value:         w.value(w.window),
+		IndicatorName: w.Name(),
+	}, nil
+}
+
+// SMAIndicator calculates the simple moving average of a price.
+type SMAIndicator struct {
+	windowIndicator
+}
+
+// NewSMAIndicator returns a new SMAIndicator.
+func NewSMAIndicator(period uint, source PriceSource) *SMAIndicator {
+	return &SMAIndicator{windowIndicator{
+		name:   IndicatorSMA,
+		source: source,
+		window: newRollingWindow(period),
+		value:  (*rollingWindow).mean,
+	}}
+}
+
+// WMAIndicator calculates the linearly weighted moving average of a
+// price, weighting the newest price by the period and the oldest by
+// one.
+type WMAIndicator struct {
+	windowIndicator
+}
+
+// NewWMAIndicator returns a new WMAIndicator.
+func NewWMAIndicator(period uint, source PriceSource) *WMAIndicator {
+	return &WMAIndicator{windowIndicator{
+		name:   IndicatorWMA,
+		source: source,
+		window: newRollingWindow(period),
+		value:  (*rollingWindow).weightedMean,
+	}}
+}
+
+// StdDevIndicator calculates the rolling population standard
+// deviation of a price.
+type StdDevIndicator struct {
+	windowIndicator
+}
+
+// NewStdDevIndicator returns a new StdDevIndicator.
+func NewStdDevIndicator(period uint, source PriceSource) *StdDevIndicator {
+	return &StdDevIndicator{windowIndicator{
+		name:   IndicatorStdDev,
+		source: source,
+		window: newRollingWindow(period),
+		value:  (*rollingWindow).stddev,
+	}}
+}
+
+// HMAIndicator calculates the Hull moving average of a price: the WMA,
+// over the square root of the period, of twice the WMA over half the
+// period minus the WMA over the whole period.
+type HMAIndicator struct {
+	period   uint
+	source   PriceSource
+	half     *rollingWindow
+	full     *rollingWindow
+	hull     *rollingWindow
+	lastTime time.Time
+}
+
+// NewHMAIndicator returns a new HMAIndicator.
+func NewHMAIndicator(period uint, source PriceSource) *HMAIndicator {
+	half := period / 2
+	if half == 0 {
+		half = 1
+	}
+	sqrt := uint(math.Sqrt(float64(period)))
+	if sqrt == 0 {
+		sqrt = 1
+	}
+
+	return &HMAIndicator{
+		period: period,
+		source: source,
+		half:   newRollingWindow(half),
+		full:   newRollingWindow(period),
+		hull:   newRollingWindow(sqrt),
+	}
+}
+
+// AddCandlestick adds a candlestick to the indicator.
+func (h *HMAIndicator) AddCandlestick(c *CandlestickModel) error {
+	if c.Volume == 0 {
+		return nil
+	}
+
+	price, err := h.source.Price(c)
+	if err != nil {
+		return errors.Wrapf(err, "error calculating %s price", h.source)
+	}
+
+	h.half.add(price)
+	h.full.add(price)
+	if h.full.full() {
+		h.hull.add(2*h.half.weightedMean() - h.full.weightedMean())
+	}
+	h.lastTime = c.StartTime
+
+	return nil
+}
+
+// Name returns the name of the indicator.
+func (h *HMAIndicator) Name() string {
+	return fmt.Sprintf("%s-%d-%s", IndicatorHMA, h.period, h.source)
+}
+
+// Value returns the last calculated HMA value.
+func (h *HMAIndicator) Value() (*IndicatorValue, error) {
+	if !h.hull.full() {
+		return nil, ErrNotEnoughData
+	}
+	return &IndicatorValue{
+		Time:          h.lastTime,
+		Value:         h.hull.weightedMean(),
+		IndicatorName: h.Name(),
+	}, nil
+}
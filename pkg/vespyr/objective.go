@@ -0,0 +1,178 @@
+package vespyr
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// ObjectiveSharpe scores backtests by Sharpe ratio.
+	ObjectiveSharpe = "sharpe"
+	// ObjectiveSortino scores backtests by Sortino ratio.
+	ObjectiveSortino = "sortino"
+	// ObjectiveCalmar scores backtests by Calmar ratio.
+	ObjectiveCalmar = "calmar"
+	// ObjectiveNetProfit scores backtests by net profit.
+	ObjectiveNetProfit = "net-profit"
+	// ObjectiveProfitFactor scores backtests by profit factor.
+	ObjectiveProfitFactor = "profit-factor"
+
+	// maxMetricScore is the score of metrics that are infinitely
+	// good, such as the profit factor of a strategy that never
+	// lost. It's finite so that weighted objectives still add up.
+	maxMetricScore = 1e6
+)
+
+// Objective scores backtest results during optimization. Higher
+// scores are better.
+type Objective interface {
+	Score(*BacktestResults) float64
+	String() string
+}
+
+// MetricObjective scores results using a single metric.
+type MetricObjective struct {
+	name   string
+	metric func(*BacktestResults) float64
+}
+
+// Score returns the metric's value. Undefined values, such as the
+// Sharpe ratio of a strategy that never trades, and infinitely bad
+// ones score 0. Infinitely good values, such as the profit factor of
+// a strategy that never lost, score maxMetricScore so that they rank
+// above finite ones.
+func (m *MetricObjective) Score(r *BacktestResults) float64 {
+	score := m.metric(r)
+	switch {
+	case math.IsInf(score, 1):
+		return maxMetricScore
+	case math.IsNaN(score) || math.IsInf(score, -1):
+		return 0
+	}
+	return score
+}
+
+// String returns the name of the objective.
+func (m *MetricObjective) String() string {
+	return m.name
+}
+
+var metricObjectives = map[string]func(*BacktestResults) float64{
+	ObjectiveSharpe:       (*BacktestResults).SharpeRatio,
+	ObjectiveSortino:      (*BacktestResults).SortinoRatio,
+	ObjectiveCalmar:       (*BacktestResults).CalmarRatio,
+	ObjectiveNetProfit:    (*BacktestResults).NetProfit,
+	ObjectiveProfitFactor: (*BacktestResults).ProfitFactor,
+}
+
+// NewMetricObjective returns the objective for a metric name.
+func NewMetricObjective(name string) (*MetricObjective, error) {
+	metric, ok := metricObjectives[name]
+	if !ok {
+		return nil, errors.Errorf("error: unknown objective: %s", name)
+	}
+	return &MetricObjective{name: name, metric: metric}, nil
+}
+
+func defaultObjective() Objective {
+	return &MetricObjective{name: ObjectiveSharpe, metric: (*BacktestResults).SharpeRatio}
+}
+
+// WeightedObjective scores results with a weighted sum of other
+// objectives.
+type WeightedObjective struct {
+	Objectives []Objective
+	Weights    []float64
+}
+
+// Score returns the weighted sum of the objectives' scores.
+func (w *WeightedObjective) Score(r *BacktestResults) float64 {
+	var score float64
+	for i, o := range w.Objectives {
+		score += w.Weights[i] * o.Score(r)
+	}
+	return score
+}
+
+// String returns the weighted objectives.
+func (w *WeightedObjective) String() string {
+	var parts []string
+	for i, o := range w.Objectives {
+		parts = append(parts, fmt.Sprintf("%s:%g", o, w.Weights[i]))
+	}
+	return strings.Join(parts, ",")
+}
+
+// PenalizedObjective subtracts penalties from an objective's score
+// for strategies that trade too rarely or draw down too far.
+type PenalizedObjective struct {
+	Objective Objective
+
+	// MinTrades is the number of trades below which the score
+	// is reduced by TradePenalty times the fraction of missing
+	// trades.
+	MinTrades    uint
+	TradePenalty float64
+
+	// MaxDrawdown is the fractional drawdown above which the
+	// score is reduced by DrawdownPenalty per unit of excess
+	// drawdown.
+	MaxDrawdown     float64
+	DrawdownPenalty float64
+}
+
+// Score returns the penalized score.
+func (p *PenalizedObjective) Score(r *BacktestResults) float64 {
+	score := p.Objective.Score(r)
+
+	trades := r.ProfitTrades + r.LossTrades
+	if p.MinTrades > 0 && trades < p.MinTrades {
+		score -= p.TradePenalty * float64(p.MinTrades-trades) / float64(p.MinTrades)
+	}
+
+	if drawdown := r.MaxDrawdown(); p.MaxDrawdown > 0 && drawdown > p.MaxDrawdown {
+		score -= p.DrawdownPenalty * (drawdown - p.MaxDrawdown)
+	}
+
+	return score
+}
+
+// String returns the underlying objective.
+func (p *PenalizedObjective) String() string {
+	return p.Objective.String()
+}
+
+// ParseObjective parses an objective specification. A specification
+// is either a metric name, such as "sortino", or a comma separated
+// list of weighted metrics, such as "sharpe:0.7,net-profit:0.3".
+func ParseObjective(spec string) (Objective, error) {
+	if !strings.Contains(spec, ":") && !strings.Contains(spec, ",") {
+		return NewMetricObjective(strings.TrimSpace(spec))
+	}
+
+	weighted := &WeightedObjective{}
+	for _, part := range strings.Split(spec, ",") {
+		fields := strings.SplitN(part, ":", 2)
+		objective, err := NewMetricObjective(strings.TrimSpace(fields[0]))
+		if err != nil {
+			return nil, err
+		}
+
+		weight := float64(1)
+		if len(fields) == 2 {
+			weight, err = strconv.ParseFloat(strings.TrimSpace(fields[1]), 64)
+			if err != nil {
+				return nil, errors.Wrapf(err, "error parsing weight of objective %s", fields[0])
+			}
+		}
+
+		weighted.Objectives = append(weighted.Objectives, objective)
+		weighted.Weights = append(weighted.Weights, weight)
+	}
+
+	return weighted, nil
+}
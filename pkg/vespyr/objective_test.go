@@ -0,0 +1,99 @@
+package vespyr_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/DavidHuie/vespyr/pkg/vespyr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseObjective(t *testing.T) {
+	results := &vespyr.BacktestResults{
+		InitialBudget:        100,
+		GrossProfit:          30,
+		GrossLoss:            10,
+		ProfitTrades:         2,
+		LossTrades:           1,
+		PortfolioValuePerDay: []float64{100, 110, 99, 120},
+	}
+
+	objective, err := vespyr.ParseObjective("net-profit")
+	assert.NoError(t, err)
+	assert.Equal(t, float64(20), objective.Score(results))
+
+	objective, err = vespyr.ParseObjective("net-profit:0.5, profit-factor:2")
+	assert.NoError(t, err)
+	assert.Equal(t, float64(16), objective.Score(results))
+	assert.Equal(t, "net-profit:0.5,profit-factor:2", objective.String())
+
+	objective, err = vespyr.ParseObjective("sortino")
+	assert.NoError(t, err)
+	assert.True(t, objective.Score(results) > 0)
+
+	_, err = vespyr.ParseObjective("unknown")
+	assert.Error(t, err)
+	_, err = vespyr.ParseObjective("sharpe:abc")
+	assert.Error(t, err)
+}
+
+func TestMetricObjectiveUndefined(t *testing.T) {
+	objective, err := vespyr.NewMetricObjective(vespyr.ObjectiveSharpe)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(0), objective.Score(&vespyr.BacktestResults{InitialBudget: 100}))
+}
+
+func TestMetricObjectiveNoLosses(t *testing.T) {
+	results := &vespyr.BacktestResults{
+		InitialBudget:        100,
+		GrossProfit:          10,
+		ProfitTrades:         1,
+		PortfolioValuePerDay: []float64{100, 105, 110},
+	}
+	assert.True(t, math.IsInf(results.ProfitFactor(), 1))
+	assert.True(t, math.IsInf(results.SortinoRatio(), 1))
+	assert.True(t, math.IsInf(results.CalmarRatio(), 1))
+
+	losing := &vespyr.BacktestResults{
+		InitialBudget:        100,
+		GrossProfit:          5,
+		GrossLoss:            10,
+		ProfitTrades:         1,
+		LossTrades:           1,
+		PortfolioValuePerDay: []float64{100, 105, 95},
+	}
+
+	for _, name := range []string{
+		vespyr.ObjectiveProfitFactor,
+		vespyr.ObjectiveSortino,
+		vespyr.ObjectiveCalmar,
+	} {
+		objective, err := vespyr.NewMetricObjective(name)
+		assert.NoError(t, err)
+		score := objective.Score(results)
+		assert.False(t, math.IsInf(score, 0), name)
+		assert.True(t, score > objective.Score(losing), name)
+	}
+}
+
+func TestPenalizedObjective(t *testing.T) {
+	netProfit, err := vespyr.NewMetricObjective(vespyr.ObjectiveNetProfit)
+	assert.NoError(t, err)
+
+	results := &vespyr.BacktestResults{
+		InitialBudget:        100,
+		GrossProfit:          10,
+		ProfitTrades:         1,
+		PortfolioValuePerDay: []float64{100, 80, 110},
+	}
+	assert.InDelta(t, 0.2, results.MaxDrawdown(), 1e-9)
+
+	objective := &vespyr.PenalizedObjective{
+		Objective:       netProfit,
+		MinTrades:       4,
+		TradePenalty:    8,
+		MaxDrawdown:     0.1,
+		DrawdownPenalty: 10,
+	}
+	assert.InDelta(t, 10-6-1, objective.Score(results), 1e-9)
+}
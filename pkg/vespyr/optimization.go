@@ -1,10 +1,14 @@
 package vespyr
 
 import (
+	"math"
 	"math/rand"
+	"runtime"
+	"sync"
 	"time"
 
 	"github.com/MaxHalford/gago"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 )
 
@@ -21,10 +25,14 @@ type StrategyGenome interface {
 // BacktesterGenomeFactory generates strategy genomes to be tested by
 // gago.
 type BacktesterGenomeFactory struct {
-	startTime time.Time
-	endTime   time.Time
-	model     *TradingStrategyModel
-	backend   Backend
+	startTime  time.Time
+	endTime    time.Time
+	model      *TradingStrategyModel
+	backend    Backend
+	objective  Objective
+	seed       int64
+	indicators *IndicatorCache
+	fillModel  FillModel
 }
 
 // NewBacktesterGenomeFactory creates a new NewBacktesterGenomeFactory.
@@ -32,13 +40,34 @@ func NewBacktesterGenomeFactory(startTime, endTime time.Time, model *TradingStra
 	backend Backend) (*BacktesterGenomeFactory, error) {
 	cache := NewCacheingBackend(backend)
 	return &BacktesterGenomeFactory{
-		startTime: startTime,
-		endTime:   endTime,
-		model:     model,
-		backend:   cache,
+		startTime:  startTime,
+		endTime:    endTime,
+		model:      model,
+		backend:    cache,
+		objective:  defaultObjective(),
+		indicators: NewIndicatorCache(),
 	}, nil
 }
 
+// SetObjective sets the objective that genomes are scored with. The
+// Sharpe ratio is used by default.
+func (b *BacktesterGenomeFactory) SetObjective(o Objective) {
+	b.objective = o
+}
+
+// SetSeed sets the seed of the random source that every backtest's
+// slippage is drawn from. All genomes share the seed so that they're
+// compared against the same fills.
+func (b *BacktesterGenomeFactory) SetSeed(seed int64) {
+	b.seed = seed
+}
+
+// SetFillModel sets the model that every backtest's orders are filled
+// with.
+func (b *BacktesterGenomeFactory) SetFillModel(m FillModel) {
+	b.fillModel = m
+}
+
 // Generate creates a new Genome for backtesting.
 func (b *BacktesterGenomeFactory) Generate(rng *rand.Rand) gago.Genome {
 	strategy, err := b.model.Strategy()
@@ -51,55 +80,66 @@ func (b *BacktesterGenomeFactory) Generate(rng *rand.Rand) gago.Genome {
 	genome.Rand(rng)
 
 	return &BacktesterGenome{
-		startTime: b.startTime,
-		endTime:   b.endTime,
-		backend:   b.backend,
-		model:     b.model,
-		strategy:  genome,
+		startTime:  b.startTime,
+		endTime:    b.endTime,
+		backend:    b.backend,
+		model:      b.model,
+		objective:  b.objective,
+		seed:       b.seed,
+		indicators: b.indicators,
+		fillModel:  b.fillModel,
+		strategy:   genome,
 	}
 }
 
 // BacktesterGenome wraps a StrategyGenome. BacktesterGenome evaluates
 // a StrategyGenome in a backtest when it's evaluated.
 type BacktesterGenome struct {
-	startTime time.Time
-	endTime   time.Time
-	backend   Backend
-	logger    logrus.FieldLogger
-	model     *TradingStrategyModel
-	strategy  StrategyGenome
-	evaluated bool
+	startTime  time.Time
+	endTime    time.Time
+	backend    Backend
+	logger     logrus.FieldLogger
+	model      *TradingStrategyModel
+	objective  Objective
+	seed       int64
+	indicators *IndicatorCache
+	fillModel  FillModel
+	strategy   StrategyGenome
+}
+
+// Strategy returns the genome's strategy.
+func (b *BacktesterGenome) Strategy() StrategyGenome {
+	return b.strategy
 }
 
-// Evaluate runs a backtest and returns the score.
+// Evaluate runs a backtest and returns the negated objective score,
+// since gago minimizes fitness. Backtests that fail get the worst
+// possible fitness.
 func (b *BacktesterGenome) Evaluate() float64 {
 	logrus.Debugf("evaluating strategy: %#v", b.strategy)
 
 	model := b.model.Copy()
 	if err := model.SetStrategy(b.strategy); err != nil {
 		logrus.Errorf("error setting model strategy: %s", err)
-		return 0
+		return math.MaxFloat64
 	}
 
 	logrus.Debugf("evaluating model: %#v", model)
 
 	backtester, err := NewBacktester(b.startTime, b.endTime,
-		model, b.backend, rand.NewSource(time.Now().Unix()))
+		model, b.backend, rand.NewSource(b.seed))
 	if err != nil {
 		logrus.Errorf("error creating backtester: %s", err)
-		return 0
+		return math.MaxFloat64
 	}
+	backtester.SetIndicatorCache(b.indicators)
+	backtester.SetFillModel(b.fillModel)
 	if err := backtester.Backtest(); err != nil {
 		logrus.Errorf("error running backtest: %s", err)
-		return 0
-	}
-
-	results := backtester.Results()
-	if results.FinalBudget == 0 || results.FinalCurrencyPrice == 0 {
-		return 0
+		return math.MaxFloat64
 	}
 
-	return -results.SharpeRatio()
+	return -b.objective.Score(backtester.Results())
 }
 
 // Mutate mutates the underlying strategy.
@@ -114,19 +154,27 @@ func (b *BacktesterGenome) Crossover(genome gago.Genome, rng *rand.Rand) (gago.G
 	c1, c2 := b.strategy.Crossover(cross.strategy, rng)
 
 	c1Genome := &BacktesterGenome{
-		startTime: b.startTime,
-		endTime:   b.endTime,
-		backend:   b.backend,
-		model:     b.model,
-		strategy:  c1,
+		startTime:  b.startTime,
+		endTime:    b.endTime,
+		backend:    b.backend,
+		model:      b.model,
+		objective:  b.objective,
+		seed:       b.seed,
+		indicators: b.indicators,
+		fillModel:  b.fillModel,
+		strategy:   c1,
 	}
 
 	c2Genome := &BacktesterGenome{
-		startTime: b.startTime,
-		endTime:   b.endTime,
-		backend:   b.backend,
-		model:     b.model,
-		strategy:  c2,
+		startTime:  b.startTime,
+		endTime:    b.endTime,
+		backend:    b.backend,
+		model:      b.model,
+		objective:  b.objective,
+		seed:       b.seed,
+		indicators: b.indicators,
+		fillModel:  b.fillModel,
+		strategy:   c2,
 	}
 
 	return c1Genome, c2Genome
@@ -135,11 +183,143 @@ func (b *BacktesterGenome) Crossover(genome gago.Genome, rng *rand.Rand) (gago.G
 // Clone clones the BacktesterGenome.
 func (b *BacktesterGenome) Clone() gago.Genome {
 	return &BacktesterGenome{
-		startTime: b.startTime,
-		endTime:   b.endTime,
-		backend:   b.backend,
-		model:     b.model,
-		strategy:  b.strategy.Clone(),
+		startTime:  b.startTime,
+		endTime:    b.endTime,
+		backend:    b.backend,
+		model:      b.model,
+		objective:  b.objective,
+		seed:       b.seed,
+		indicators: b.indicators,
+		fillModel:  b.fillModel,
+		strategy:   b.strategy.Clone(),
 	}
 
 }
+
+const geneticOptimizerPopulations = 2
+
+// GeneticOptimizer evolves backtester genomes with a generational
+// genetic algorithm. It uses the same operators as gago's Generational
+// preset, but gago seeds its random number generators from the clock,
+// so the optimizer runs the generations itself with generators derived
+// from a single seed. Given the same candlesticks, two optimizers with
+// the same seed find the same strategies.
+type GeneticOptimizer struct {
+	// Best is the best individual found so far.
+	Best gago.Individual
+
+	factory     *BacktesterGenomeFactory
+	model       gago.ModGenerational
+	popSize     int
+	concurrency int
+	rngs        []*rand.Rand
+	populations []gago.Individuals
+}
+
+// NewGeneticOptimizer creates a new GeneticOptimizer. The seed is also
+// used for the factory's backtests.
+func NewGeneticOptimizer(factory *BacktesterGenomeFactory, popSize int, seed int64) *GeneticOptimizer {
+	factory.SetSeed(seed)
+
+	rng := rand.New(rand.NewSource(seed))
+	var rngs []*rand.Rand
+	for i := 0; i < geneticOptimizerPopulations; i++ {
+		rngs = append(rngs, rand.New(rand.NewSource(rng.Int63())))
+	}
+
+	return &GeneticOptimizer{
+		Best:    gago.Individual{Fitness: math.Inf(1)},
+		factory: factory,
+		model: gago.ModGenerational{
+			Selector: gago.SelTournament{NContestants: 3},
+			MutRate:  0.5,
+		},
+		popSize:     popSize,
+		concurrency: runtime.NumCPU(),
+		rngs:        rngs,
+	}
+}
+
+// SetConcurrency sets the number of genomes evaluated in parallel. It
+// defaults to the number of CPUs.
+func (g *GeneticOptimizer) SetConcurrency(concurrency int) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	g.concurrency = concurrency
+}
+
+// evaluate evaluates individuals in parallel. Evaluation doesn't draw
+// random numbers, so the order that genomes finish in doesn't affect
+// reproducibility.
+func (g *GeneticOptimizer) evaluate(indis gago.Individuals) {
+	c := make(chan int)
+	wg := &sync.WaitGroup{}
+	for i := 0; i < g.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range c {
+				indis[i].Evaluate()
+			}
+		}()
+	}
+
+	for i := range indis {
+		c <- i
+	}
+	close(c)
+	wg.Wait()
+}
+
+// Initialize generates and evaluates the initial populations.
+func (g *GeneticOptimizer) Initialize() {
+	g.populations = make([]gago.Individuals, len(g.rngs))
+	for i, rng := range g.rngs {
+		indis := make(gago.Individuals, g.popSize)
+		for j := range indis {
+			indis[j] = gago.NewIndividual(g.factory.Generate(rng), rng)
+		}
+		g.evaluate(indis)
+		indis.SortByFitness()
+		g.populations[i] = indis
+	}
+	g.findBest()
+}
+
+// Enhance evolves each population by a generation.
+func (g *GeneticOptimizer) Enhance() error {
+	for i, indis := range g.populations {
+		rng := g.rngs[i]
+
+		offsprings := make(gago.Individuals, 0, len(indis))
+		for len(offsprings) < len(indis) {
+			parents, _, err := g.model.Selector.Apply(2, indis, rng)
+			if err != nil {
+				return errors.Wrapf(err, "error selecting parents")
+			}
+			o1, o2 := parents[0].Crossover(parents[1], rng)
+			offsprings = append(offsprings, o1)
+			if len(offsprings) < len(indis) {
+				offsprings = append(offsprings, o2)
+			}
+		}
+		offsprings.Mutate(g.model.MutRate, rng)
+
+		g.evaluate(offsprings)
+		offsprings.SortByFitness()
+		g.populations[i] = offsprings
+	}
+	g.findBest()
+	return nil
+}
+
+// findBest updates the best individual using the first, and
+// therefore fittest, individual of each sorted population.
+func (g *GeneticOptimizer) findBest() {
+	for i, indis := range g.populations {
+		if len(indis) > 0 && indis[0].Fitness < g.Best.Fitness {
+			g.Best = indis[0].Clone(g.rngs[i])
+		}
+	}
+}
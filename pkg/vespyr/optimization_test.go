@@ -8,6 +8,7 @@ import (
 	"github.com/MaxHalford/gago"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"gopkg.in/yaml.v2"
 )
 
 func TestBacktesterGenome(t *testing.T) {
@@ -64,3 +65,58 @@ func TestBacktesterGenome(t *testing.T) {
 
 	assert.True(t, ga.Best.Fitness < 0)
 }
+
+func TestGeneticOptimizerIsReproducible(t *testing.T) {
+	model := &vespyr.TradingStrategyModel{
+		Product:          vespyr.ProductBTCUSD,
+		HistoryTicks:     1,
+		State:            vespyr.StrategyStateTryingToBuy,
+		InitialBudget:    500,
+		Budget:           500,
+		BudgetCurrency:   vespyr.CurrencyUSD,
+		InvestedCurrency: vespyr.CurrencyBTC,
+		TickSizeMinutes:  15,
+		TradingStrategy:  vespyr.TradingStrategyEMACrossover,
+	}
+
+	endTime := time.Now()
+	startTime := endTime.Add(-time.Minute * 30)
+
+	var candles []*vespyr.CandlestickModel
+	for i, c := range []float64{10, 9, 11, 8, 12, 10, 9, 13, 11, 8, 12, 14} {
+		candles = append(candles, &vespyr.CandlestickModel{
+			Close:   c,
+			Volume:  1,
+			EndTime: startTime.Add(time.Duration(i+1) * time.Hour),
+		})
+	}
+
+	run := func(seed int64) (float64, string) {
+		backend := new(vespyr.MockBackend)
+		backend.On("FindCandlesticks", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(candles, nil)
+
+		factory, err := vespyr.NewBacktesterGenomeFactory(startTime, endTime, model, backend)
+		assert.NoError(t, err)
+
+		ga := vespyr.NewGeneticOptimizer(factory, 6, seed)
+		ga.Initialize()
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, ga.Enhance())
+		}
+
+		b, err := yaml.Marshal(ga.Best.Genome.(*vespyr.BacktesterGenome).Strategy())
+		assert.NoError(t, err)
+		return ga.Best.Fitness, string(b)
+	}
+
+	fitness, strategy := run(42)
+	for i := 0; i < 3; i++ {
+		f, s := run(42)
+		assert.Equal(t, fitness, f)
+		assert.Equal(t, strategy, s)
+	}
+
+	_, other := run(43)
+	assert.NotEqual(t, strategy, other)
+}
@@ -104,6 +104,25 @@ func (o *MarketOrderStrategy) PerformOrder(args *PerformOrderArgs) (*PerformOrde
 	}, nil
 }
 
+// PerformOrders performs orders one after another, such as both legs
+// of a pairs trade. A buy without a cost spends what the previous order
+// filled. The responses of the orders that filled are returned even if
+// a later one fails, so that callers can record them.
+func PerformOrders(o OrderStrategy, orders []*PerformOrderArgs) ([]*PerformOrderResponse, error) {
+	var responses []*PerformOrderResponse
+	for i, args := range orders {
+		if args.Side == OrderBuy && args.Cost == 0 && i > 0 {
+			args.Cost = TruncateFloat(responses[i-1].FilledSize, tradeCurrencyPrecision)
+		}
+		response, err := o.PerformOrder(args)
+		if err != nil {
+			return responses, errors.Wrapf(err, "error performing order %d of %d", i+1, len(orders))
+		}
+		responses = append(responses, response)
+	}
+	return responses, nil
+}
+
 // // LimitOrderSelloffStrategy is a order strategy that locates the
 // // current market price and creates a limit order at that price. If
 // // after a timeout the currency has not been exchanged, then a market
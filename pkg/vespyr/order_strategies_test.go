@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/DavidHuie/vespyr/pkg/vespyr"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -109,3 +110,53 @@ func TestMarketOrderStrategyPerformOrder(t *testing.T) {
 		}, response)
 	})
 }
+
+func TestPerformOrders(t *testing.T) {
+	exchange := new(vespyr.MockExchange)
+	backend := new(vespyr.MockBackend)
+	defer mock.AssertExpectationsForObjects(t, exchange, backend)
+	backend.On("CreateMarketOrder", mock.Anything).Return(nil)
+
+	ts := &vespyr.TradingStrategyModel{ID: 69}
+	sellETH := &vespyr.MarketOrder{Product: vespyr.ProductETHUSD, Side: vespyr.OrderSell, Cost: 10}
+	buyBTC := &vespyr.MarketOrder{Product: vespyr.ProductBTCUSD, Side: vespyr.OrderBuy, Cost: 5000.12345678}
+	exchange.On("CreateMarketOrder", sellETH).Return(&vespyr.CreateMarketOrderResponse{
+		FilledSize:         5000.123456789,
+		FilledSizeCurrency: vespyr.CurrencyUSD,
+	}, nil).Once()
+
+	orders := func() []*vespyr.PerformOrderArgs {
+		return []*vespyr.PerformOrderArgs{
+			{Product: vespyr.ProductETHUSD, Side: vespyr.OrderSell, Cost: 10, TradingStrategy: ts},
+			{Product: vespyr.ProductBTCUSD, Side: vespyr.OrderBuy, TradingStrategy: ts},
+		}
+	}
+
+	t.Run("the buy spends what the sell filled", func(t *testing.T) {
+		exchange.On("CreateMarketOrder", buyBTC).Return(&vespyr.CreateMarketOrderResponse{
+			FilledSize:         .5,
+			FilledSizeCurrency: vespyr.CurrencyBTC,
+		}, nil).Once()
+
+		responses, err := vespyr.PerformOrders(vespyr.NewMarketOrderStrategy(exchange, backend), orders())
+		assert.NoError(t, err)
+		if assert.Len(t, responses, 2) {
+			assert.Equal(t, 5000.123456789, responses[0].FilledSize)
+			assert.Equal(t, .5, responses[1].FilledSize)
+		}
+	})
+
+	t.Run("filled orders are returned when a later one fails", func(t *testing.T) {
+		exchange.On("CreateMarketOrder", sellETH).Return(&vespyr.CreateMarketOrderResponse{
+			FilledSize:         5000.123456789,
+			FilledSizeCurrency: vespyr.CurrencyUSD,
+		}, nil).Once()
+		exchange.On("CreateMarketOrder", buyBTC).Return(nil, errors.New("insufficient funds")).Once()
+
+		responses, err := vespyr.PerformOrders(vespyr.NewMarketOrderStrategy(exchange, backend), orders())
+		assert.Error(t, err)
+		if assert.Len(t, responses, 1) {
+			assert.Equal(t, 5000.123456789, responses[0].FilledSize)
+		}
+	})
+}
@@ -0,0 +1,951 @@
+package vespyr
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// This file contains a minimal Parquet implementation that's just
+// enough to exchange flat tables. Files are written with a single row
+// group and one uncompressed PLAIN encoded data page per required
+// column. Files written with the defaults of other tools, such as
+// pyarrow, can be read back too, as described by readParquet.
+
+const (
+	parquetMagic = "PAR1"
+
+	parquetTypeInt64     = 2
+	parquetTypeDouble    = 5
+	parquetTypeByteArray = 6
+
+	parquetRepetitionRequired = 0
+	parquetRepetitionOptional = 1
+
+	parquetConvertedUTF8            = 0
+	parquetConvertedTimestampMillis = 9
+	parquetConvertedTimestampMicros = 10
+
+	parquetEncodingPlain           = 0
+	parquetEncodingPlainDictionary = 2
+	parquetEncodingRLE             = 3
+	parquetEncodingRLEDictionary   = 8
+
+	parquetCodecUncompressed = 0
+	parquetCodecSnappy       = 1
+	parquetCodecGzip         = 2
+
+	parquetPageData       = 0
+	parquetPageIndex      = 1
+	parquetPageDictionary = 2
+
+	thriftBoolTrue  = 1
+	thriftBoolFalse = 2
+	thriftByte      = 3
+	thriftI16       = 4
+	thriftI32       = 5
+	thriftI64       = 6
+	thriftDouble    = 7
+	thriftBinary    = 8
+	thriftList      = 9
+	thriftSet       = 10
+	thriftMap       = 11
+	thriftStruct    = 12
+)
+
+// parquetColumn is a single column of a flat Parquet table. Exactly
+// one of the value slices is populated depending on the type.
+type parquetColumn struct {
+	name          string
+	physicalType  int32
+	convertedType int32
+	optional      bool
+	// timeUnit is the unit of timestamp columns.
+	timeUnit time.Duration
+	int64s   []int64
+	doubles  []float64
+	strings  []string
+}
+
+func (p *parquetColumn) len() int {
+	switch p.physicalType {
+	case parquetTypeInt64:
+		return len(p.int64s)
+	case parquetTypeDouble:
+		return len(p.doubles)
+	default:
+		return len(p.strings)
+	}
+}
+
+// supported returns whether the column's values can be decoded.
+func (p *parquetColumn) supported() bool {
+	switch p.physicalType {
+	case parquetTypeInt64, parquetTypeDouble, parquetTypeByteArray:
+		return true
+	}
+	return false
+}
+
+// appendValue appends the value at an index of another column of the
+// same type, such as a dictionary.
+func (p *parquetColumn) appendValue(from *parquetColumn, i int) error {
+	if i < 0 || i >= from.len() {
+		return errors.Errorf("error: column %s has an invalid dictionary index %d", p.name, i)
+	}
+	switch p.physicalType {
+	case parquetTypeInt64:
+		p.int64s = append(p.int64s, from.int64s[i])
+	case parquetTypeDouble:
+		p.doubles = append(p.doubles, from.doubles[i])
+	default:
+		p.strings = append(p.strings, from.strings[i])
+	}
+	return nil
+}
+
+func (p *parquetColumn) encodePlain() []byte {
+	buf := &bytes.Buffer{}
+	b := make([]byte, 8)
+	switch p.physicalType {
+	case parquetTypeInt64:
+		for _, v := range p.int64s {
+			binary.LittleEndian.PutUint64(b, uint64(v))
+			buf.Write(b)
+		}
+	case parquetTypeDouble:
+		for _, v := range p.doubles {
+			binary.LittleEndian.PutUint64(b, math.Float64bits(v))
+			buf.Write(b)
+		}
+	default:
+		for _, v := range p.strings {
+			binary.LittleEndian.PutUint32(b, uint32(len(v)))
+			buf.Write(b[:4])
+			buf.WriteString(v)
+		}
+	}
+	return buf.Bytes()
+}
+
+func (p *parquetColumn) decodePlain(data []byte, n int) error {
+	switch p.physicalType {
+	case parquetTypeInt64:
+		if len(data) < 8*n {
+			return errors.Errorf("error: column %s is truncated", p.name)
+		}
+		for i := 0; i < n; i++ {
+			p.int64s = append(p.int64s, int64(binary.LittleEndian.Uint64(data[8*i:])))
+		}
+	case parquetTypeDouble:
+		if len(data) < 8*n {
+			return errors.Errorf("error: column %s is truncated", p.name)
+		}
+		for i := 0; i < n; i++ {
+			p.doubles = append(p.doubles, math.Float64frombits(binary.LittleEndian.Uint64(data[8*i:])))
+		}
+	case parquetTypeByteArray:
+		for i := 0; i < n; i++ {
+			if len(data) < 4 {
+				return errors.Errorf("error: column %s is truncated", p.name)
+			}
+			size := int(binary.LittleEndian.Uint32(data))
+			if len(data) < 4+size {
+				return errors.Errorf("error: column %s is truncated", p.name)
+			}
+			p.strings = append(p.strings, string(data[4:4+size]))
+			data = data[4+size:]
+		}
+	default:
+		return errors.Errorf("error: unsupported parquet type %d for column %s", p.physicalType, p.name)
+	}
+	return nil
+}
+
+// writeParquet writes the columns as a Parquet file.
+func writeParquet(w io.Writer, columns []*parquetColumn) error {
+	numRows := 0
+	if len(columns) > 0 {
+		numRows = columns[0].len()
+	}
+	for _, c := range columns {
+		if c.len() != numRows {
+			return errors.Errorf("error: column %s has %d rows, expected %d", c.name, c.len(), numRows)
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteString(parquetMagic)
+
+	chunks := &thriftWriter{}
+	totalSize := int64(0)
+	for _, c := range columns {
+		data := c.encodePlain()
+
+		header := &thriftWriter{}
+		header.i32Field(1, parquetPageData)
+		header.i32Field(2, int32(len(data)))
+		header.i32Field(3, int32(len(data)))
+		header.structField(5, func(t *thriftWriter) {
+			t.i32Field(1, int32(numRows))
+			t.i32Field(2, parquetEncodingPlain)
+			t.i32Field(3, parquetEncodingRLE)
+			t.i32Field(4, parquetEncodingRLE)
+		})
+		header.stop()
+
+		offset := int64(buf.Len())
+		buf.Write(header.Bytes())
+		buf.Write(data)
+		size := int64(buf.Len()) - offset
+		totalSize += size
+
+		col := c
+		chunks.listElement(func(t *thriftWriter) {
+			t.i64Field(2, offset)
+			t.structField(3, func(t *thriftWriter) {
+				t.i32Field(1, col.physicalType)
+				t.listField(2, thriftI32, 2, func(t *thriftWriter) {
+					t.varint(zigzag(parquetEncodingPlain))
+					t.varint(zigzag(parquetEncodingRLE))
+				})
+				t.listField(3, thriftBinary, 1, func(t *thriftWriter) {
+					t.binary(col.name)
+				})
+				t.i32Field(4, parquetCodecUncompressed)
+				t.i64Field(5, int64(numRows))
+				t.i64Field(6, size)
+				t.i64Field(7, size)
+				t.i64Field(9, offset)
+			})
+		})
+	}
+
+	meta := &thriftWriter{}
+	meta.i32Field(1, 1)
+	meta.listField(2, thriftStruct, len(columns)+1, func(t *thriftWriter) {
+		t.listElement(func(t *thriftWriter) {
+			t.binaryField(4, "schema")
+			t.i32Field(5, int32(len(columns)))
+		})
+		for _, c := range columns {
+			col := c
+			t.listElement(func(t *thriftWriter) {
+				t.i32Field(1, col.physicalType)
+				t.i32Field(3, parquetRepetitionRequired)
+				t.binaryField(4, col.name)
+				if col.convertedType >= 0 {
+					t.i32Field(6, col.convertedType)
+				}
+			})
+		}
+	})
+	meta.i64Field(3, int64(numRows))
+	meta.listField(4, thriftStruct, 1, func(t *thriftWriter) {
+		t.listElement(func(t *thriftWriter) {
+			t.listField(1, thriftStruct, len(columns), func(t *thriftWriter) {
+				t.Write(chunks.Bytes())
+			})
+			t.i64Field(2, totalSize)
+			t.i64Field(3, int64(numRows))
+		})
+	})
+	meta.binaryField(6, "vespyr")
+	meta.stop()
+
+	buf.Write(meta.Bytes())
+	size := make([]byte, 4)
+	binary.LittleEndian.PutUint32(size, uint32(meta.Len()))
+	buf.Write(size)
+	buf.WriteString(parquetMagic)
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return errors.Wrapf(err, "error writing parquet file")
+	}
+	return nil
+}
+
+// readParquet reads the flat columns of a Parquet file. Columns may be
+// required or optional, as long as they have no nulls, PLAIN or
+// dictionary encoded, and uncompressed, snappy or gzip compressed,
+// which covers files written by pyarrow and pandas with their
+// defaults. Columns of types other than INT64, DOUBLE and BYTE_ARRAY
+// are returned without values.
+func readParquet(data []byte) ([]*parquetColumn, error) {
+	if len(data) < 12 || string(data[:4]) != parquetMagic || string(data[len(data)-4:]) != parquetMagic {
+		return nil, errors.New("error: not a parquet file")
+	}
+	metaSize := int64(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	if metaSize == 0 || metaSize > int64(len(data)-12) {
+		return nil, errors.New("error: invalid parquet footer")
+	}
+	meta, err := newThriftReader(data[int64(len(data)-8)-metaSize : len(data)-8]).readStruct()
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading parquet metadata")
+	}
+
+	schema, err := thriftStructList(meta, 2, "schema")
+	if err != nil {
+		return nil, err
+	}
+	if len(schema) == 0 {
+		return nil, errors.New("error: parquet file has no schema")
+	}
+	if children, _ := thriftInt(schema[0], 5); children != int64(len(schema)-1) {
+		return nil, errors.New("error: nested parquet schemas are not supported")
+	}
+
+	var columns []*parquetColumn
+	for _, element := range schema[1:] {
+		column, err := parquetSchemaColumn(element)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+	}
+
+	rowGroups, err := thriftStructList(meta, 4, "row groups")
+	if err != nil {
+		return nil, err
+	}
+	for _, rg := range rowGroups {
+		chunks, err := thriftStructList(rg, 1, "column chunks")
+		if err != nil {
+			return nil, err
+		}
+		if len(chunks) != len(columns) {
+			return nil, errors.New("error: parquet row group doesn't match schema")
+		}
+		for i, ch := range chunks {
+			if err := readParquetChunk(data, ch, columns[i]); err != nil {
+				return nil, errors.Wrapf(err, "error reading parquet column %s", columns[i].name)
+			}
+		}
+	}
+
+	return columns, nil
+}
+
+// parquetSchemaColumn returns the column for a leaf schema element.
+func parquetSchemaColumn(element map[int16]interface{}) (*parquetColumn, error) {
+	name, ok := element[4].([]byte)
+	if !ok {
+		return nil, errors.New("error: parquet schema element has no name")
+	}
+	if _, ok := element[5]; ok {
+		return nil, errors.New("error: nested parquet schemas are not supported")
+	}
+	physicalType, ok := thriftInt(element, 1)
+	if !ok {
+		return nil, errors.Errorf("error: parquet column %s has no type", name)
+	}
+
+	column := &parquetColumn{
+		name:          string(name),
+		physicalType:  int32(physicalType),
+		convertedType: -1,
+		timeUnit:      time.Millisecond,
+	}
+	repetition, _ := thriftInt(element, 3)
+	switch repetition {
+	case parquetRepetitionRequired:
+	case parquetRepetitionOptional:
+		column.optional = true
+	default:
+		return nil, errors.Errorf("error: parquet column %s is repeated", name)
+	}
+
+	if convertedType, ok := thriftInt(element, 6); ok {
+		column.convertedType = int32(convertedType)
+		if convertedType == parquetConvertedTimestampMicros {
+			column.timeUnit = time.Microsecond
+		}
+	}
+	if logicalType, ok := element[10].(map[int16]interface{}); ok {
+		if timestamp, ok := logicalType[8].(map[int16]interface{}); ok {
+			unit, _ := timestamp[2].(map[int16]interface{})
+			switch {
+			case unit[2] != nil:
+				column.timeUnit = time.Microsecond
+			case unit[3] != nil:
+				column.timeUnit = time.Nanosecond
+			}
+		}
+	}
+	return column, nil
+}
+
+// readParquetChunk reads a column chunk's pages into the column.
+func readParquetChunk(data []byte, chunk map[int16]interface{}, column *parquetColumn) error {
+	meta, ok := chunk[3].(map[int16]interface{})
+	if !ok {
+		return errors.New("error: parquet column chunk has no metadata")
+	}
+	if !column.supported() {
+		return nil
+	}
+	codec, _ := thriftInt(meta, 4)
+	numValues, ok := thriftInt(meta, 5)
+	if !ok || numValues < 0 {
+		return errors.New("error: parquet column chunk has no value count")
+	}
+	offset, ok := thriftInt(meta, 9)
+	if !ok {
+		return errors.New("error: parquet column chunk has no data page offset")
+	}
+	if dictionaryOffset, ok := thriftInt(meta, 11); ok && dictionaryOffset > 0 && dictionaryOffset < offset {
+		offset = dictionaryOffset
+	}
+
+	var dictionary *parquetColumn
+	for numValues > 0 {
+		if offset < 0 || offset >= int64(len(data)) {
+			return errors.New("error: invalid parquet page offset")
+		}
+		reader := newThriftReader(data[offset:])
+		header, err := reader.readStruct()
+		if err != nil {
+			return errors.Wrapf(err, "error reading page header")
+		}
+		pageSize, ok := thriftInt(header, 3)
+		if !ok || pageSize < 0 {
+			return errors.New("error: parquet page has no size")
+		}
+		uncompressedSize, _ := thriftInt(header, 2)
+		start := offset + int64(reader.pos)
+		end := start + pageSize
+		if end > int64(len(data)) {
+			return errors.New("error: parquet page is truncated")
+		}
+		offset = end
+
+		pageType, _ := thriftInt(header, 1)
+		switch pageType {
+		case parquetPageData, parquetPageDictionary:
+		case parquetPageIndex:
+			continue
+		default:
+			return errors.Errorf("error: unsupported parquet page type %d", pageType)
+		}
+
+		page, err := decompressParquetPage(data[start:end], codec, uncompressedSize)
+		if err != nil {
+			return err
+		}
+
+		if pageType == parquetPageDictionary {
+			dictionaryHeader, ok := header[7].(map[int16]interface{})
+			if !ok {
+				return errors.New("error: parquet dictionary page has no header")
+			}
+			n, _ := thriftInt(dictionaryHeader, 1)
+			dictionary = &parquetColumn{name: column.name, physicalType: column.physicalType}
+			if err := dictionary.decodePlain(page, int(n)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		dataHeader, ok := header[5].(map[int16]interface{})
+		if !ok {
+			return errors.New("error: parquet data page has no header")
+		}
+		n, ok := thriftInt(dataHeader, 1)
+		if !ok || n < 0 || n > numValues {
+			return errors.New("error: parquet data page has an invalid value count")
+		}
+		encoding, _ := thriftInt(dataHeader, 2)
+		if err := column.decodePage(page, int(n), encoding, dictionary); err != nil {
+			return err
+		}
+		numValues -= n
+	}
+	return nil
+}
+
+// decodePage decodes a version 1 data page of n values, including
+// the definition levels of optional columns.
+func (p *parquetColumn) decodePage(page []byte, n int, encoding int64, dictionary *parquetColumn) error {
+	if p.optional {
+		if len(page) < 4 {
+			return errors.Errorf("error: column %s is truncated", p.name)
+		}
+		size := int64(binary.LittleEndian.Uint32(page))
+		if size > int64(len(page)-4) {
+			return errors.Errorf("error: column %s is truncated", p.name)
+		}
+		levels, err := decodeRLEHybrid(page[4:4+size], 1, n)
+		if err != nil {
+			return errors.Wrapf(err, "error decoding definition levels of column %s", p.name)
+		}
+		for _, l := range levels {
+			if l == 0 {
+				return errors.Errorf("error: column %s has null values", p.name)
+			}
+		}
+		page = page[4+size:]
+	}
+
+	switch encoding {
+	case parquetEncodingPlain:
+		return p.decodePlain(page, n)
+	case parquetEncodingPlainDictionary, parquetEncodingRLEDictionary:
+		if dictionary == nil {
+			return errors.Errorf("error: column %s has no dictionary", p.name)
+		}
+		if len(page) < 1 {
+			return errors.Errorf("error: column %s is truncated", p.name)
+		}
+		indexes, err := decodeRLEHybrid(page[1:], int(page[0]), n)
+		if err != nil {
+			return errors.Wrapf(err, "error decoding dictionary indexes of column %s", p.name)
+		}
+		for _, i := range indexes {
+			if err := p.appendValue(dictionary, i); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return errors.Errorf("error: unsupported parquet encoding %d for column %s", encoding, p.name)
+	}
+}
+
+// decompressParquetPage decompresses a page with a column chunk's
+// codec.
+func decompressParquetPage(page []byte, codec, uncompressedSize int64) ([]byte, error) {
+	var decompressed []byte
+	switch codec {
+	case parquetCodecUncompressed:
+		return page, nil
+	case parquetCodecSnappy:
+		var err error
+		if decompressed, err = snappyDecode(page); err != nil {
+			return nil, errors.Wrapf(err, "error decompressing snappy page")
+		}
+	case parquetCodecGzip:
+		r, err := gzip.NewReader(bytes.NewReader(page))
+		if err != nil {
+			return nil, errors.Wrapf(err, "error decompressing gzip page")
+		}
+		if decompressed, err = ioutil.ReadAll(io.LimitReader(r, uncompressedSize+1)); err != nil {
+			return nil, errors.Wrapf(err, "error decompressing gzip page")
+		}
+	default:
+		return nil, errors.Errorf("error: unsupported parquet compression codec %d", codec)
+	}
+	if int64(len(decompressed)) != uncompressedSize {
+		return nil, errors.New("error: parquet page has the wrong uncompressed size")
+	}
+	return decompressed, nil
+}
+
+// decodeRLEHybrid decodes n values of the RLE and bit-packing hybrid
+// encoding used for levels and dictionary indexes.
+func decodeRLEHybrid(data []byte, bitWidth, n int) ([]int, error) {
+	if bitWidth < 0 || bitWidth > 32 {
+		return nil, errors.Errorf("error: invalid bit width %d", bitWidth)
+	}
+	byteWidth := (bitWidth + 7) / 8
+
+	values := make([]int, 0, n)
+	for len(values) < n {
+		header, size := binary.Uvarint(data)
+		if size <= 0 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		data = data[size:]
+
+		if header&1 == 0 {
+			count := header >> 1
+			if len(data) < byteWidth {
+				return nil, io.ErrUnexpectedEOF
+			}
+			var value int
+			for i := 0; i < byteWidth; i++ {
+				value |= int(data[i]) << (8 * uint(i))
+			}
+			data = data[byteWidth:]
+			for i := uint64(0); i < count && len(values) < n; i++ {
+				values = append(values, value)
+			}
+			continue
+		}
+
+		groups := header >> 1
+		if groups > uint64(len(data)) || groups*uint64(bitWidth) > uint64(len(data)) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		packed := data[:groups*uint64(bitWidth)]
+		data = data[groups*uint64(bitWidth):]
+		for i := 0; i < int(groups)*8 && len(values) < n; i++ {
+			var value int
+			for b := 0; b < bitWidth; b++ {
+				bit := i*bitWidth + b
+				value |= int(packed[bit/8]>>(uint(bit)%8)&1) << uint(b)
+			}
+			values = append(values, value)
+		}
+	}
+	return values, nil
+}
+
+// snappyDecode decodes a snappy block, as used for Parquet pages.
+func snappyDecode(src []byte) ([]byte, error) {
+	length, n := binary.Uvarint(src)
+	if n <= 0 || length > uint64(math.MaxInt32) {
+		return nil, errors.New("error: invalid snappy length")
+	}
+	src = src[n:]
+
+	// Every byte of a block expands to at most 64 bytes, which
+	// bounds the allocation for corrupt lengths.
+	if length > 64*uint64(len(src)) {
+		return nil, errors.New("error: invalid snappy length")
+	}
+	dst := make([]byte, 0, length)
+	for len(src) > 0 {
+		tag := src[0]
+		var size, offset int
+		switch tag & 3 {
+		case 0:
+			size = int(tag>>2) + 1
+			src = src[1:]
+			if extra := size - 60; extra > 0 {
+				if len(src) < extra {
+					return nil, io.ErrUnexpectedEOF
+				}
+				size = 0
+				for i := 0; i < extra; i++ {
+					size |= int(src[i]) << (8 * uint(i))
+				}
+				size++
+				src = src[extra:]
+			}
+			if size <= 0 || size > len(src) || len(dst)+size > int(length) {
+				return nil, errors.New("error: invalid snappy literal")
+			}
+			dst = append(dst, src[:size]...)
+			src = src[size:]
+			continue
+		case 1:
+			if len(src) < 2 {
+				return nil, io.ErrUnexpectedEOF
+			}
+			size = 4 + int(tag>>2)&7
+			offset = int(tag&0xe0)<<3 | int(src[1])
+			src = src[2:]
+		case 2:
+			if len(src) < 3 {
+				return nil, io.ErrUnexpectedEOF
+			}
+			size = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint16(src[1:]))
+			src = src[3:]
+		case 3:
+			if len(src) < 5 {
+				return nil, io.ErrUnexpectedEOF
+			}
+			size = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint32(src[1:]))
+			src = src[5:]
+		}
+		if offset <= 0 || offset > len(dst) || len(dst)+size > int(length) {
+			return nil, errors.New("error: invalid snappy copy")
+		}
+		// Copies may overlap what they write.
+		for i := 0; i < size; i++ {
+			dst = append(dst, dst[len(dst)-offset])
+		}
+	}
+	if len(dst) != int(length) {
+		return nil, errors.New("error: snappy block is truncated")
+	}
+	return dst, nil
+}
+
+// thriftInt returns an integer field of a Thrift struct.
+func thriftInt(fields map[int16]interface{}, id int16) (int64, bool) {
+	v, ok := fields[id].(int64)
+	return v, ok
+}
+
+// thriftStructList returns a required field of a Thrift struct that's
+// a list of structs.
+func thriftStructList(fields map[int16]interface{}, id int16, name string) ([]map[int16]interface{}, error) {
+	list, ok := fields[id].([]interface{})
+	if !ok {
+		return nil, errors.Errorf("error: parquet %s are missing", name)
+	}
+	var structs []map[int16]interface{}
+	for _, e := range list {
+		s, ok := e.(map[int16]interface{})
+		if !ok {
+			return nil, errors.Errorf("error: parquet %s are invalid", name)
+		}
+		structs = append(structs, s)
+	}
+	return structs, nil
+}
+
+func zigzag(v int64) uint64 {
+	return uint64((v << 1) ^ (v >> 63))
+}
+
+// thriftWriter encodes structs with the Thrift compact protocol.
+type thriftWriter struct {
+	bytes.Buffer
+	lastField []int16
+}
+
+func (t *thriftWriter) varint(v uint64) {
+	b := make([]byte, binary.MaxVarintLen64)
+	t.Write(b[:binary.PutUvarint(b, v)])
+}
+
+func (t *thriftWriter) binary(s string) {
+	t.varint(uint64(len(s)))
+	t.WriteString(s)
+}
+
+func (t *thriftWriter) last() int16 {
+	if len(t.lastField) == 0 {
+		t.lastField = append(t.lastField, 0)
+	}
+	return t.lastField[len(t.lastField)-1]
+}
+
+func (t *thriftWriter) fieldHeader(id int16, typ byte) {
+	delta := id - t.last()
+	if delta > 0 && delta <= 15 {
+		t.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		t.WriteByte(typ)
+		t.varint(zigzag(int64(id)))
+	}
+	t.lastField[len(t.lastField)-1] = id
+}
+
+func (t *thriftWriter) i32Field(id int16, v int32) {
+	t.fieldHeader(id, thriftI32)
+	t.varint(zigzag(int64(v)))
+}
+
+func (t *thriftWriter) i64Field(id int16, v int64) {
+	t.fieldHeader(id, thriftI64)
+	t.varint(zigzag(v))
+}
+
+func (t *thriftWriter) binaryField(id int16, s string) {
+	t.fieldHeader(id, thriftBinary)
+	t.binary(s)
+}
+
+func (t *thriftWriter) structField(id int16, f func(*thriftWriter)) {
+	t.fieldHeader(id, thriftStruct)
+	t.listElement(f)
+}
+
+func (t *thriftWriter) listField(id int16, elemType byte, size int, f func(*thriftWriter)) {
+	t.fieldHeader(id, thriftList)
+	if size < 15 {
+		t.WriteByte(byte(size)<<4 | elemType)
+	} else {
+		t.WriteByte(0xf0 | elemType)
+		t.varint(uint64(size))
+	}
+	f(t)
+}
+
+// listElement writes a struct without a field header, as is done for
+// struct list elements.
+func (t *thriftWriter) listElement(f func(*thriftWriter)) {
+	t.last()
+	t.lastField = append(t.lastField, 0)
+	f(t)
+	t.stop()
+}
+
+func (t *thriftWriter) stop() {
+	t.WriteByte(0)
+	if len(t.lastField) > 0 {
+		t.lastField = t.lastField[:len(t.lastField)-1]
+	}
+}
+
+// thriftReader decodes Thrift compact protocol structs into maps
+// keyed by field ID. Integers are returned as int64 and strings as
+// []byte.
+type thriftReader struct {
+	data  []byte
+	pos   int
+	depth int
+}
+
+// thriftMaxDepth limits how deeply structs can be nested so that
+// corrupt files can't exhaust the stack.
+const thriftMaxDepth = 64
+
+func newThriftReader(data []byte) *thriftReader {
+	return &thriftReader{data: data}
+}
+
+func (t *thriftReader) readByte() (byte, error) {
+	if t.pos >= len(t.data) {
+		return 0, io.ErrUnexpectedEOF
+	}
+	b := t.data[t.pos]
+	t.pos++
+	return b, nil
+}
+
+func (t *thriftReader) readVarint() (uint64, error) {
+	v, n := binary.Uvarint(t.data[t.pos:])
+	if n <= 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	t.pos += n
+	return v, nil
+}
+
+func (t *thriftReader) readZigzag() (int64, error) {
+	v, err := t.readVarint()
+	if err != nil {
+		return 0, err
+	}
+	return int64(v>>1) ^ -int64(v&1), nil
+}
+
+func (t *thriftReader) readStruct() (map[int16]interface{}, error) {
+	fields := make(map[int16]interface{})
+	last := int16(0)
+	for {
+		b, err := t.readByte()
+		if err != nil {
+			return nil, err
+		}
+		if b == 0 {
+			return fields, nil
+		}
+
+		typ := b & 0x0f
+		id := last + int16(b>>4)
+		if b>>4 == 0 {
+			v, err := t.readZigzag()
+			if err != nil {
+				return nil, err
+			}
+			id = int16(v)
+		}
+		last = id
+
+		value, err := t.readValue(typ)
+		if err != nil {
+			return nil, err
+		}
+		fields[id] = value
+	}
+}
+
+func (t *thriftReader) readValue(typ byte) (interface{}, error) {
+	switch typ {
+	case thriftBoolTrue:
+		return true, nil
+	case thriftBoolFalse:
+		return false, nil
+	case thriftByte:
+		b, err := t.readByte()
+		return int64(int8(b)), err
+	case thriftI16, thriftI32, thriftI64:
+		return t.readZigzag()
+	case thriftDouble:
+		if t.pos+8 > len(t.data) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		v := math.Float64frombits(binary.LittleEndian.Uint64(t.data[t.pos:]))
+		t.pos += 8
+		return v, nil
+	case thriftBinary:
+		size, err := t.readVarint()
+		if err != nil {
+			return nil, err
+		}
+		if size > uint64(len(t.data)-t.pos) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		v := t.data[t.pos : t.pos+int(size)]
+		t.pos += int(size)
+		return v, nil
+	case thriftList, thriftSet:
+		b, err := t.readByte()
+		if err != nil {
+			return nil, err
+		}
+		size := uint64(b >> 4)
+		if size == 15 {
+			if size, err = t.readVarint(); err != nil {
+				return nil, err
+			}
+		}
+		// Every element takes at least a byte.
+		if size > uint64(len(t.data)-t.pos) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		elemType := b & 0x0f
+		var values []interface{}
+		for i := uint64(0); i < size; i++ {
+			// Booleans in lists are a byte each rather
+			// than part of a field header.
+			if elemType == thriftBoolTrue || elemType == thriftBoolFalse {
+				b, err := t.readByte()
+				if err != nil {
+					return nil, err
+				}
+				values = append(values, b == thriftBoolTrue)
+				continue
+			}
+			v, err := t.readValue(elemType)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+	case thriftMap:
+		size, err := t.readVarint()
+		if err != nil || size == 0 {
+			return nil, err
+		}
+		types, err := t.readByte()
+		if err != nil {
+			return nil, err
+		}
+		if size > uint64(len(t.data)-t.pos) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		for i := uint64(0); i < size; i++ {
+			if _, err := t.readValue(types >> 4); err != nil {
+				return nil, err
+			}
+			if _, err := t.readValue(types & 0x0f); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	case thriftStruct:
+		if t.depth >= thriftMaxDepth {
+			return nil, errors.New("error: thrift structs are nested too deeply")
+		}
+		t.depth++
+		defer func() { t.depth-- }()
+		return t.readStruct()
+	default:
+		return nil, errors.Errorf("error: unknown thrift type %d", typ)
+	}
+}
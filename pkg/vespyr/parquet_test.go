@@ -0,0 +1,297 @@
+package vespyr
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testParquetChunk is a column of a Parquet file built by
+// testParquetFile.
+type testParquetChunk struct {
+	element      func(*thriftWriter)
+	physicalType int32
+	codec        int32
+	numValues    int64
+	// dictionary is an optional dictionary page, written before
+	// the data pages.
+	dictionary []byte
+	pages      [][]byte
+}
+
+// testParquetPage returns a page header followed by its compressed
+// body.
+func testParquetPage(pageType int32, uncompressedSize int, body []byte, header func(*thriftWriter)) []byte {
+	t := &thriftWriter{}
+	t.i32Field(1, pageType)
+	t.i32Field(2, int32(uncompressedSize))
+	t.i32Field(3, int32(len(body)))
+	header(t)
+	t.stop()
+	return append(t.Bytes(), body...)
+}
+
+// testParquetFile writes a file with one row group of the chunks, the
+// way other Parquet writers lay them out.
+func testParquetFile(chunks []*testParquetChunk) []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString(parquetMagic)
+
+	meta := &thriftWriter{}
+	meta.i32Field(1, 1)
+	meta.listField(2, thriftStruct, len(chunks)+1, func(t *thriftWriter) {
+		t.listElement(func(t *thriftWriter) {
+			t.binaryField(4, "schema")
+			t.i32Field(5, int32(len(chunks)))
+		})
+		for _, c := range chunks {
+			t.listElement(c.element)
+		}
+	})
+	meta.i64Field(3, chunks[0].numValues)
+
+	columnChunks := &thriftWriter{}
+	for _, c := range chunks {
+		dictionaryOffset := int64(buf.Len())
+		buf.Write(c.dictionary)
+		dataOffset := int64(buf.Len())
+		for _, p := range c.pages {
+			buf.Write(p)
+		}
+
+		chunk := c
+		columnChunks.listElement(func(t *thriftWriter) {
+			t.i64Field(2, dictionaryOffset)
+			t.structField(3, func(t *thriftWriter) {
+				t.i32Field(1, chunk.physicalType)
+				t.listField(2, thriftI32, 1, func(t *thriftWriter) {
+					t.varint(zigzag(parquetEncodingPlain))
+				})
+				t.listField(3, thriftBinary, 1, func(t *thriftWriter) {
+					t.binary("column")
+				})
+				t.i32Field(4, chunk.codec)
+				t.i64Field(5, chunk.numValues)
+				t.i64Field(6, 0)
+				t.i64Field(7, 0)
+				t.i64Field(9, dataOffset)
+				if len(chunk.dictionary) > 0 {
+					t.i64Field(11, dictionaryOffset)
+				}
+			})
+		})
+	}
+	meta.listField(4, thriftStruct, 1, func(t *thriftWriter) {
+		t.listElement(func(t *thriftWriter) {
+			t.listField(1, thriftStruct, len(chunks), func(t *thriftWriter) {
+				t.Write(columnChunks.Bytes())
+			})
+			t.i64Field(2, 0)
+			t.i64Field(3, chunks[0].numValues)
+		})
+	})
+	meta.stop()
+
+	buf.Write(meta.Bytes())
+	size := make([]byte, 4)
+	binary.LittleEndian.PutUint32(size, uint32(meta.Len()))
+	buf.Write(size)
+	buf.WriteString(parquetMagic)
+	return buf.Bytes()
+}
+
+// testSnappyLiteral encodes data as a snappy block of literals.
+func testSnappyLiteral(data []byte) []byte {
+	b := make([]byte, binary.MaxVarintLen64)
+	out := append([]byte(nil), b[:binary.PutUvarint(b, uint64(len(data)))]...)
+	for len(data) > 0 {
+		n := len(data)
+		if n > 60 {
+			n = 60
+		}
+		out = append(out, byte(n-1)<<2)
+		out = append(out, data[:n]...)
+		data = data[n:]
+	}
+	return out
+}
+
+func testGzip(t *testing.T, data []byte) []byte {
+	buf := &bytes.Buffer{}
+	w := gzip.NewWriter(buf)
+	_, err := w.Write(data)
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+// testDictionaryChunk returns an optional, dictionary encoded and
+// snappy compressed DOUBLE column, as pyarrow writes by default, with
+// the values 2.5, 1.5 and 2.5. The definition levels are the given
+// RLE run.
+func testDictionaryChunk(definitionLevel byte) *testParquetChunk {
+	dictionary := make([]byte, 16)
+	binary.LittleEndian.PutUint64(dictionary, math.Float64bits(1.5))
+	binary.LittleEndian.PutUint64(dictionary[8:], math.Float64bits(2.5))
+
+	// Definition levels are a run of three, and the indexes are
+	// bit-packed with a width of one.
+	data := []byte{2, 0, 0, 0, 3 << 1, definitionLevel, 1, 1<<1 | 1, 0x05}
+
+	return &testParquetChunk{
+		element: func(t *thriftWriter) {
+			t.i32Field(1, parquetTypeDouble)
+			t.i32Field(3, parquetRepetitionOptional)
+			t.binaryField(4, "close")
+		},
+		physicalType: parquetTypeDouble,
+		codec:        parquetCodecSnappy,
+		numValues:    3,
+		dictionary: testParquetPage(parquetPageDictionary, len(dictionary), testSnappyLiteral(dictionary),
+			func(t *thriftWriter) {
+				t.structField(7, func(t *thriftWriter) {
+					t.i32Field(1, 2)
+					t.i32Field(2, parquetEncodingPlain)
+				})
+			}),
+		pages: [][]byte{
+			testParquetPage(parquetPageData, len(data), testSnappyLiteral(data), func(t *thriftWriter) {
+				t.structField(5, func(t *thriftWriter) {
+					t.i32Field(1, 3)
+					t.i32Field(2, parquetEncodingRLEDictionary)
+					t.i32Field(3, parquetEncodingRLE)
+					t.i32Field(4, parquetEncodingRLE)
+				})
+			}),
+		},
+	}
+}
+
+// testTimestampChunk returns a required, gzip compressed INT64 column
+// of microsecond timestamps split over two pages.
+func testTimestampChunk(t *testing.T, times []time.Time) *testParquetChunk {
+	chunk := &testParquetChunk{
+		element: func(t *thriftWriter) {
+			t.i32Field(1, parquetTypeInt64)
+			t.i32Field(3, parquetRepetitionRequired)
+			t.binaryField(4, "start_time")
+			t.structField(10, func(t *thriftWriter) {
+				t.structField(8, func(t *thriftWriter) {
+					t.structField(2, func(t *thriftWriter) {
+						t.structField(2, func(*thriftWriter) {})
+					})
+				})
+			})
+		},
+		physicalType: parquetTypeInt64,
+		codec:        parquetCodecGzip,
+		numValues:    int64(len(times)),
+	}
+	for _, page := range [][]time.Time{times[:1], times[1:]} {
+		data := make([]byte, 8*len(page))
+		for i, ts := range page {
+			binary.LittleEndian.PutUint64(data[8*i:], uint64(ts.UnixNano()/int64(time.Microsecond)))
+		}
+		n := len(page)
+		chunk.pages = append(chunk.pages, testParquetPage(parquetPageData, len(data), testGzip(t, data),
+			func(t *thriftWriter) {
+				t.structField(5, func(t *thriftWriter) {
+					t.i32Field(1, int32(n))
+					t.i32Field(2, parquetEncodingPlain)
+					t.i32Field(3, parquetEncodingRLE)
+					t.i32Field(4, parquetEncodingRLE)
+				})
+			}))
+	}
+	return chunk
+}
+
+func TestReadParquetEncodings(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	times := []time.Time{start, start.Add(time.Minute), start.Add(2 * time.Minute)}
+
+	columns, err := readParquet(testParquetFile([]*testParquetChunk{
+		testTimestampChunk(t, times),
+		testDictionaryChunk(1),
+	}))
+	if !assert.NoError(t, err) || !assert.Len(t, columns, 2) {
+		return
+	}
+
+	assert.Equal(t, "start_time", columns[0].name)
+	assert.Equal(t, time.Microsecond, columns[0].timeUnit)
+	for i, ts := range times {
+		assert.Equal(t, ts.UnixNano()/int64(time.Microsecond), columns[0].int64s[i])
+	}
+	assert.Equal(t, "close", columns[1].name)
+	assert.Equal(t, []float64{2.5, 1.5, 2.5}, columns[1].doubles)
+
+	// Nulls aren't supported.
+	_, err = readParquet(testParquetFile([]*testParquetChunk{testDictionaryChunk(0)}))
+	assert.Error(t, err)
+
+	// Neither are other codecs, such as zstd.
+	chunk := testDictionaryChunk(1)
+	chunk.codec = 6
+	_, err = readParquet(testParquetFile([]*testParquetChunk{chunk}))
+	assert.Error(t, err)
+}
+
+func TestReadParquetInvalid(t *testing.T) {
+	_, err := readParquet([]byte("PAR1PAR1"))
+	assert.Error(t, err)
+	_, err = readParquet([]byte("PAR1\x00\x00\x00\x00PAR1"))
+	assert.Error(t, err)
+	_, err = readParquet([]byte("PAR1\x00\x04\x00\x00\x00PAR1"))
+	assert.Error(t, err)
+	// An empty metadata struct.
+	_, err = readParquet([]byte("PAR1\x00\x01\x00\x00\x00PAR1"))
+	assert.Error(t, err)
+
+	valid := &bytes.Buffer{}
+	assert.NoError(t, writeParquet(valid, []*parquetColumn{
+		{name: "a", physicalType: parquetTypeInt64, convertedType: -1, int64s: []int64{1, 2, 3}},
+		{name: "b", physicalType: parquetTypeByteArray, convertedType: parquetConvertedUTF8,
+			strings: []string{"x", "y", "z"}},
+	}))
+
+	// Corrupt and truncated files return errors rather than
+	// panicking.
+	data := valid.Bytes()
+	metaStart := len(data) - 8 - int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	for i := 4; i < len(data)-8; i++ {
+		for _, b := range []byte{0x00, 0x15, 0xff} {
+			corrupt := append([]byte(nil), data...)
+			corrupt[i] = b
+			assert.NotPanics(t, func() { readParquet(corrupt) }, "byte %d", i)
+		}
+		if i >= metaStart {
+			truncated := append(append([]byte(nil), data[:i]...), data[len(data)-8:]...)
+			binary.LittleEndian.PutUint32(truncated[len(truncated)-8:], uint32(i-metaStart))
+			_, err := readParquet(truncated)
+			assert.Error(t, err, "truncated at %d", i)
+		}
+	}
+}
+
+func TestSnappyDecode(t *testing.T) {
+	// A literal of "abc" followed by a copy of nine bytes from
+	// three bytes back.
+	decoded, err := snappyDecode([]byte{12, 0x08, 'a', 'b', 'c', 0x15, 0x03})
+	assert.NoError(t, err)
+	assert.Equal(t, "abcabcabcabc", string(decoded))
+
+	for _, invalid := range [][]byte{
+		{12, 0x08, 'a', 'b', 'c'},
+		{12, 0x08, 'a', 'b', 'c', 0x15, 0x04},
+		{0xff, 0xff, 0xff, 0xff, 0x0f},
+	} {
+		_, err := snappyDecode(invalid)
+		assert.Error(t, err)
+	}
+}
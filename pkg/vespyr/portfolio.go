@@ -0,0 +1,539 @@
+package vespyr
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/montanaflynn/stats"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// PortfolioRiskRules limit how a portfolio backtest allocates its
+// shared capital.
+type PortfolioRiskRules struct {
+	// MaxStrategyAllocation is the largest fraction of portfolio
+	// equity that a single strategy may invest when it buys. It
+	// defaults to an equal share for every strategy.
+	MaxStrategyAllocation float64
+	// MaxGrossExposure is the largest fraction of portfolio
+	// equity that may be invested across all strategies. It
+	// defaults to 1.
+	MaxGrossExposure float64
+	// MaxDrawdown is the fractional drop from the portfolio's peak
+	// equity at which every position is sold and trading stops. 0
+	// disables the rule.
+	MaxDrawdown float64
+}
+
+// portfolioLeg is a single strategy within a portfolio backtest.
+type portfolioLeg struct {
+	model         *TradingStrategyModel
+	trader        *TradingStrategy
+	exchange      *BacktesterExchange
+	calc          *backtestResultCalculator
+	indicatorSets []*IndicatorSet
+	candles       []*CandlestickModel
+	startBucket   time.Time
+
+	price     float64
+	costBasis float64
+	results   *PortfolioStrategyResults
+}
+
+// value returns the liquidation value of the leg's position.
+func (l *portfolioLeg) value() float64 {
+	return l.model.Invested * l.price * (1 - exchangeFee - exchangeSlippage)
+}
+
+// pnl returns the leg's realized and unrealized profit.
+func (l *portfolioLeg) pnl() float64 {
+	pnl := l.results.GrossProfit - l.results.GrossLoss
+	if l.model.State == StrategyStateTryingToSell {
+		pnl += l.value() - l.costBasis
+	}
+	return pnl
+}
+
+// portfolioTick is a candlestick of a single leg.
+type portfolioTick struct {
+	time  time.Time
+	leg   *portfolioLeg
+	index int
+}
+
+// portfolioSnapshot is the value of a portfolio at a point in time.
+type portfolioSnapshot struct {
+	equity float64
+	pnl    []float64
+}
+
+// PortfolioBacktester backtests several trading strategy models that
+// trade from a shared pool of capital. The strategies' ticks are
+// merged by time so that every allocation decision sees the current
+// value of the whole portfolio.
+type PortfolioBacktester struct {
+	startTime      time.Time
+	endTime        time.Time
+	models         []*TradingStrategyModel
+	backend        Backend
+	initialCapital float64
+	rules          PortfolioRiskRules
+	seed           int64
+	indicators     *IndicatorCache
+	fillModel      FillModel
+}
+
+// NewPortfolioBacktester creates a new PortfolioBacktester. The
+// models' budgets are ignored in favor of the shared capital, and the
+// models must all use the same budget currency.
+func NewPortfolioBacktester(startTime, endTime time.Time, models []*TradingStrategyModel,
+	backend Backend, initialCapital float64, rules PortfolioRiskRules, seed int64) (*PortfolioBacktester, error) {
+	if len(models) == 0 {
+		return nil, errors.New("error: a portfolio needs at least one strategy")
+	}
+	if initialCapital <= 0 {
+		return nil, errors.New("error: initial capital must be positive")
+	}
+	for _, m := range models[1:] {
+		if m.BudgetCurrency != models[0].BudgetCurrency {
+			return nil, errors.Errorf("error: strategies use different budget currencies: %s and %s",
+				models[0].BudgetCurrency, m.BudgetCurrency)
+		}
+	}
+
+	if rules.MaxStrategyAllocation <= 0 {
+		rules.MaxStrategyAllocation = 1 / float64(len(models))
+	}
+	if rules.MaxGrossExposure <= 0 {
+		rules.MaxGrossExposure = 1
+	}
+
+	return &PortfolioBacktester{
+		startTime:      startTime,
+		endTime:        endTime,
+		models:         models,
+		backend:        NewCacheingBackend(backend),
+		initialCapital: initialCapital,
+		rules:          rules,
+		seed:           seed,
+		indicators:     NewIndicatorCache(),
+		fillModel:      DefaultFillModel(),
+	}, nil
+}
+
+// SetFillModel sets the model that every strategy's orders are filled
+// with.
+func (p *PortfolioBacktester) SetFillModel(m FillModel) {
+	p.fillModel = m
+}
+
+func (p *PortfolioBacktester) newLeg(model *TradingStrategyModel) (*portfolioLeg, error) {
+	model = model.Copy()
+	model.State = StrategyStateTryingToBuy
+	model.InitialBudget = p.initialCapital * p.rules.MaxStrategyAllocation
+	model.Budget = 0
+	model.Invested = 0
+
+	strategy, err := model.Strategy()
+	if err != nil {
+		return nil, errors.Wrapf(err, "error getting model strategy")
+	}
+	if _, ok := strategy.(PairStrategyInterface); ok {
+		return nil, errors.Errorf("error: %s strategies trade two products and can't be part of a portfolio",
+			model.TradingStrategy)
+	}
+	if _, ok := strategy.(LevelStrategyInterface); ok {
+		return nil, errors.Errorf("error: %s strategies hold several positions and can't be part of a portfolio",
+			model.TradingStrategy)
+	}
+
+	actualStartTime := p.startTime.Add(-time.Duration(model.TickSizeMinutes) *
+		time.Duration(model.HistoryTicks) * time.Minute)
+	candles, err := p.backend.FindCandlesticks(actualStartTime, p.endTime,
+		model.Product, int64(model.TickSizeMinutes))
+	if err != nil {
+		return nil, errors.Wrapf(err, "error finding candlesticks")
+	}
+
+	indicatorSets, validCandles, err := generateIndicatorSets(strategy.Indicators(), candles, nil, p.indicators)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error generating indicator sets")
+	}
+
+	calc := newBacktestResultCalculator()
+	backend := &BacktesterBackend{p.backend, calc}
+	exchange := NewBacktesterExchange(validCandles, exchangeSlippage, rand.NewSource(p.seed))
+	exchange.SetFillModel(p.fillModel)
+	trader := NewTradingStrategy(backend, exchange, strategy, clockwork.NewRealClock())
+	trader.setIndicatorSets(indicatorSets)
+
+	return &portfolioLeg{
+		model:         model,
+		trader:        trader,
+		exchange:      exchange,
+		calc:          calc,
+		indicatorSets: indicatorSets,
+		candles:       validCandles,
+		startBucket:   CandlestickBucket(p.startTime, int64(model.TickSizeMinutes)),
+		results: &PortfolioStrategyResults{
+			Name:    fmt.Sprintf("%s %s", model.Product, strategy),
+			Product: model.Product,
+		},
+	}, nil
+}
+
+// Backtest runs the portfolio backtest.
+func (p *PortfolioBacktester) Backtest() (*PortfolioResults, error) {
+	var legs []*portfolioLeg
+	var ticks []*portfolioTick
+	for i, model := range p.models {
+		leg, err := p.newLeg(model)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error preparing strategy %d", i+1)
+		}
+		legs = append(legs, leg)
+		for j, c := range leg.candles {
+			ticks = append(ticks, &portfolioTick{time: c.EndTime, leg: leg, index: j})
+		}
+	}
+
+	// Ticks at the same time are processed in the order that the
+	// strategies were given.
+	sort.SliceStable(ticks, func(i, j int) bool {
+		return ticks[i].time.Before(ticks[j].time)
+	})
+
+	results := &PortfolioResults{
+		InitialCapital: p.initialCapital,
+		FillModel:      p.fillModel.String(),
+	}
+	for _, leg := range legs {
+		results.Strategies = append(results.Strategies, leg.results)
+	}
+
+	cash := p.initialCapital
+	peak := p.initialCapital
+	equity := func() float64 {
+		e := cash
+		for _, leg := range legs {
+			e += leg.value()
+		}
+		return e
+	}
+	invested := func() float64 {
+		var v float64
+		for _, leg := range legs {
+			v += leg.value()
+		}
+		return v
+	}
+
+	var last *portfolioSnapshot
+	var lastDay time.Time
+	for i, tick := range ticks {
+		leg := tick.leg
+		candle := leg.candles[tick.index]
+		leg.price = candle.Close
+
+		leg.calc.current.indicatorSet = leg.indicatorSets[tick.index]
+		leg.calc.current.candle = candle
+		leg.calc.current.time = candle.StartTime
+		leg.trader.nextTick()
+		leg.exchange.NextTick()
+
+		if !results.Halted && candle.EndTime.After(leg.startBucket) {
+			if err := p.processTick(leg, &cash, equity(), invested()); err != nil {
+				return nil, err
+			}
+		}
+		leg.calc.next()
+
+		if !candle.EndTime.After(p.startTime) {
+			continue
+		}
+
+		// Record the portfolio once every tick at the same time
+		// has been processed.
+		if i+1 < len(ticks) && ticks[i+1].time.Equal(tick.time) {
+			continue
+		}
+
+		current := equity()
+		if current > peak {
+			peak = current
+		}
+		if drawdown := (peak - current) / peak; drawdown > results.MaxDrawdown {
+			results.MaxDrawdown = drawdown
+		}
+
+		if !results.Halted && p.rules.MaxDrawdown > 0 && (peak-current)/peak >= p.rules.MaxDrawdown {
+			logrus.Infof("portfolio drew down more than %f at %s, liquidating", p.rules.MaxDrawdown, tick.time)
+			for _, l := range legs {
+				if err := p.liquidate(l, &cash); err != nil {
+					return nil, err
+				}
+			}
+			results.Halted = true
+			results.HaltedAt = tick.time
+			current = equity()
+		}
+
+		results.Times = append(results.Times, tick.time)
+		results.Equity = append(results.Equity, current)
+
+		// Daily values are recorded when the day changes, using
+		// the last values of the previous day.
+		day := tick.time.UTC().Truncate(24 * time.Hour)
+		if last != nil && day.After(lastDay) {
+			results.recordDay(last)
+		}
+		last = p.snapshot(legs, current)
+		lastDay = day
+	}
+
+	results.FinalEquity = p.initialCapital
+	if last != nil {
+		results.recordDay(last)
+		results.FinalEquity = last.equity
+	}
+
+	for _, leg := range legs {
+		leg.results.PnL = leg.pnl()
+		leg.results.Trades = leg.calc.trades()
+	}
+
+	return results, nil
+}
+
+func (p *PortfolioBacktester) snapshot(legs []*portfolioLeg, equity float64) *portfolioSnapshot {
+	s := &portfolioSnapshot{equity: equity}
+	for _, leg := range legs {
+		s.pnl = append(s.pnl, leg.pnl())
+	}
+	return s
+}
+
+// processTick lets a leg trade. A leg that's trying to buy is given a
+// budget from the shared cash according to the risk rules.
+func (p *PortfolioBacktester) processTick(leg *portfolioLeg, cash *float64, equity, invested float64) error {
+	m := leg.model
+	state := m.State
+
+	var budget float64
+	if state == StrategyStateTryingToBuy {
+		budget = math.Min(*cash, p.rules.MaxStrategyAllocation*equity)
+		budget = math.Min(budget, p.rules.MaxGrossExposure*equity-invested)
+		budget = TruncateFloat(budget, tradeCurrencyPrecision)
+		if budget <= 0 {
+			return nil
+		}
+		m.Budget = budget
+	}
+
+	if err := leg.trader.ProcessTick(m); err != nil {
+		if errors.Cause(err) == ErrNotEnoughData {
+			if state == StrategyStateTryingToBuy {
+				m.Budget = 0
+			}
+			return nil
+		}
+		return errors.Wrapf(err, "error processing tick of %s", leg.results.Name)
+	}
+
+	switch {
+	case state == StrategyStateTryingToBuy && m.State == StrategyStateTryingToSell:
+		leg.costBasis = budget
+		*cash -= budget
+	case state == StrategyStateTryingToBuy:
+		m.Budget = 0
+	case state == StrategyStateTryingToSell && m.State == StrategyStateTryingToBuy:
+		leg.realize(m.Budget)
+		*cash += m.Budget
+		m.Budget = 0
+	}
+
+	return nil
+}
+
+// liquidate sells a leg's position regardless of its strategy.
+func (p *PortfolioBacktester) liquidate(leg *portfolioLeg, cash *float64) error {
+	m := leg.model
+	if m.State != StrategyStateTryingToSell {
+		return nil
+	}
+
+	// The liquidation is recorded as an extra result for the
+	// leg's last candlestick.
+	last := leg.calc.results[len(leg.calc.results)-1]
+	leg.calc.current.time = last.time
+	leg.calc.current.candle = last.candle
+	leg.calc.current.indicatorSet = last.indicatorSet
+	response, err := leg.trader.orderStrategy.PerformOrder(&PerformOrderArgs{
+		Product:         m.Product,
+		Side:            OrderSell,
+		Cost:            m.Invested,
+		TradingStrategy: m,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "error liquidating %s", leg.results.Name)
+	}
+	leg.calc.next()
+
+	proceeds := TruncateFloat(response.FilledSize, tradeCurrencyPrecision)
+	leg.realize(proceeds)
+	*cash += proceeds
+	m.Invested = 0
+	m.State = StrategyStateTryingToBuy
+	return nil
+}
+
+func (l *portfolioLeg) realize(proceeds float64) {
+	diff := proceeds - l.costBasis
+	if diff >= 0 {
+		l.results.GrossProfit += diff
+		l.results.ProfitTrades++
+	} else {
+		l.results.GrossLoss += -diff
+		l.results.LossTrades++
+	}
+	l.costBasis = 0
+}
+
+// PortfolioStrategyResults contains the results of a single strategy
+// within a portfolio backtest.
+type PortfolioStrategyResults struct {
+	Name         string
+	Product      Product
+	ProfitTrades uint
+	LossTrades   uint
+	GrossProfit  float64
+	GrossLoss    float64
+	// PnL is the strategy's realized and unrealized profit at the
+	// end of the backtest.
+	PnL float64
+	// DailyPnL is the strategy's cumulative profit at the end of
+	// each day.
+	DailyPnL []float64
+	Trades   []*BacktestTradeModel
+}
+
+// PortfolioResults contains the results of a portfolio backtest.
+type PortfolioResults struct {
+	InitialCapital float64
+	FinalEquity    float64
+	// Times and Equity describe the portfolio's value after every
+	// tick within the backtest's time range.
+	Times       []time.Time
+	Equity      []float64
+	DailyEquity []float64
+	MaxDrawdown float64
+	// Halted is true when the drawdown rule liquidated the
+	// portfolio.
+	Halted     bool
+	HaltedAt   time.Time
+	Strategies []*PortfolioStrategyResults
+	FillModel  string
+}
+
+func (p *PortfolioResults) recordDay(s *portfolioSnapshot) {
+	p.DailyEquity = append(p.DailyEquity, s.equity)
+	for i, pnl := range s.pnl {
+		p.Strategies[i].DailyPnL = append(p.Strategies[i].DailyPnL, pnl)
+	}
+}
+
+// Return returns the portfolio's fractional return.
+func (p *PortfolioResults) Return() float64 {
+	return (p.FinalEquity - p.InitialCapital) / p.InitialCapital
+}
+
+// SharpeRatio returns the annualized Sharpe ratio of the portfolio's
+// daily equity.
+func (p *PortfolioResults) SharpeRatio() float64 {
+	if len(p.DailyEquity) == 0 {
+		return math.NaN()
+	}
+	results := &BacktestResults{PortfolioValuePerDay: p.DailyEquity}
+	return results.SharpeRatio()
+}
+
+// Contribution returns the fraction of the initial capital that a
+// strategy gained or lost. Contributions sum to the portfolio's
+// return.
+func (p *PortfolioResults) Contribution(i int) float64 {
+	return p.Strategies[i].PnL / p.InitialCapital
+}
+
+// Correlation returns the correlation matrix of the strategies' daily
+// profit changes. Correlations with a strategy whose profit never
+// changes are NaN.
+func (p *PortfolioResults) Correlation() [][]float64 {
+	changes := make([][]float64, len(p.Strategies))
+	for i, s := range p.Strategies {
+		previous := float64(0)
+		for _, v := range s.DailyPnL {
+			changes[i] = append(changes[i], v-previous)
+			previous = v
+		}
+	}
+
+	matrix := make([][]float64, len(changes))
+	for i := range changes {
+		matrix[i] = make([]float64, len(changes))
+		for j := range changes {
+			c, err := stats.Correlation(changes[i], changes[j])
+			if err != nil {
+				c = math.NaN()
+			}
+			matrix[i][j] = c
+		}
+	}
+	return matrix
+}
+
+// WritePortfolioResults writes a summary of the portfolio, each
+// strategy's contribution and the correlation between strategies.
+func WritePortfolioResults(w io.Writer, results *PortfolioResults) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "Starting capital:\t%f\n", results.InitialCapital)
+	fmt.Fprintf(tw, "Ending equity:\t%f\n", results.FinalEquity)
+	fmt.Fprintf(tw, "Return (%%):\t%f\n", 100*results.Return())
+	fmt.Fprintf(tw, "Max drawdown (%%):\t%f\n", 100*results.MaxDrawdown)
+	fmt.Fprintf(tw, "Sharpe ratio:\t%f\n", results.SharpeRatio())
+	fmt.Fprintf(tw, "Fill model:\t%s\n", results.FillModel)
+	if results.Halted {
+		fmt.Fprintf(tw, "Halted at:\t%s\n", results.HaltedAt.Format(time.RFC822))
+	}
+	fmt.Fprintln(tw)
+
+	fmt.Fprintln(tw, "#\tstrategy\ttrades\tprofit_trades\tloss_trades\tpnl\tcontribution_%")
+	for i, s := range results.Strategies {
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%d\t%d\t%f\t%f\n", i+1, s.Name,
+			s.ProfitTrades+s.LossTrades, s.ProfitTrades, s.LossTrades,
+			s.PnL, 100*results.Contribution(i))
+	}
+	fmt.Fprintln(tw)
+
+	header := []string{"correlation"}
+	for i := range results.Strategies {
+		header = append(header, fmt.Sprintf("%d", i+1))
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for i, row := range results.Correlation() {
+		fields := []string{fmt.Sprintf("%d", i+1)}
+		for _, c := range row {
+			fields = append(fields, fmt.Sprintf("%.3f", c))
+		}
+		fmt.Fprintln(tw, strings.Join(fields, "\t"))
+	}
+
+	return errors.Wrapf(tw.Flush(), "error writing portfolio results")
+}
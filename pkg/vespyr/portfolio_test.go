@@ -0,0 +1,130 @@
+package vespyr_test
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/DavidHuie/vespyr/pkg/vespyr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func portfolioTestCandles(product vespyr.Product, start time.Time, closes []float64) []*vespyr.CandlestickModel {
+	var candles []*vespyr.CandlestickModel
+	for i, c := range closes {
+		candles = append(candles, &vespyr.CandlestickModel{
+			StartTime: start.Add(time.Duration(i) * 6 * time.Hour),
+			EndTime:   start.Add(time.Duration(i+1) * 6 * time.Hour),
+			Product:   product,
+			Open:      c,
+			Close:     c,
+			Low:       c,
+			High:      c,
+			Volume:    1,
+		})
+	}
+	return candles
+}
+
+func portfolioTestModel(t *testing.T, product vespyr.Product) *vespyr.TradingStrategyModel {
+	model := &vespyr.TradingStrategyModel{
+		Product:          product,
+		HistoryTicks:     1,
+		State:            vespyr.StrategyStateTryingToBuy,
+		BudgetCurrency:   vespyr.CurrencyUSD,
+		InvestedCurrency: vespyr.CurrencyBTC,
+		TickSizeMinutes:  360,
+	}
+	assert.NoError(t, model.SetStrategy(&vespyr.EMACrossoverStrategy{ShortPeriod: 1, LongPeriod: 3}))
+	return model
+}
+
+func TestPortfolioBacktester(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(10 * 24 * time.Hour)
+
+	backend := new(vespyr.MockBackend)
+	backend.On("FindCandlesticks", mock.Anything, end, vespyr.ProductBTCUSD, int64(360)).
+		Return(portfolioTestCandles(vespyr.ProductBTCUSD, start, []float64{
+			100, 101, 103, 106, 104, 101, 98, 97, 99, 102, 106, 110, 108, 104, 100,
+			98, 99, 103, 107, 111, 115, 112, 108, 104, 103, 105, 108, 112, 116, 118,
+		}), nil)
+	backend.On("FindCandlesticks", mock.Anything, end, vespyr.ProductETHUSD, int64(360)).
+		Return(portfolioTestCandles(vespyr.ProductETHUSD, start, []float64{
+			50, 49, 48, 50, 53, 55, 54, 51, 49, 48, 50, 52, 55, 57, 56,
+			53, 50, 48, 47, 49, 52, 54, 53, 50, 47, 45, 46, 48, 51, 53,
+		}), nil)
+
+	models := []*vespyr.TradingStrategyModel{
+		portfolioTestModel(t, vespyr.ProductBTCUSD),
+		portfolioTestModel(t, vespyr.ProductETHUSD),
+	}
+
+	backtester, err := vespyr.NewPortfolioBacktester(start, end, models, backend, 1000,
+		vespyr.PortfolioRiskRules{}, 1)
+	assert.NoError(t, err)
+	results, err := backtester.Backtest()
+	assert.NoError(t, err)
+
+	assert.Len(t, results.Strategies, 2)
+	assert.False(t, results.Halted)
+
+	peak := results.InitialCapital
+	for _, e := range results.Equity {
+		peak = math.Max(peak, e)
+	}
+	for _, s := range results.Strategies {
+		assert.True(t, s.ProfitTrades+s.LossTrades > 0)
+		assert.Len(t, s.DailyPnL, len(results.DailyEquity))
+
+		// Each strategy may only invest half of the portfolio.
+		for _, trade := range s.Trades {
+			if trade.Side == vespyr.OrderBuy {
+				assert.True(t, trade.Cost <= peak/2+1e-6)
+			}
+		}
+	}
+
+	// Contributions add up to the portfolio's return since the
+	// strategies share the same cash.
+	assert.InDelta(t, results.Return(), results.Contribution(0)+results.Contribution(1), 1e-6)
+	assert.Equal(t, len(results.Times), len(results.Equity))
+	assert.True(t, results.MaxDrawdown > 0)
+
+	correlation := results.Correlation()
+	assert.InDelta(t, 1, correlation[0][0], 1e-9)
+	assert.InDelta(t, correlation[0][1], correlation[1][0], 1e-9)
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, vespyr.WritePortfolioResults(buf, results))
+	assert.Contains(t, buf.String(), "contribution_%")
+
+	// A tight drawdown limit liquidates the portfolio on the first
+	// loss and stops trading.
+	backtester, err = vespyr.NewPortfolioBacktester(start, end, models, backend, 1000,
+		vespyr.PortfolioRiskRules{MaxDrawdown: 0.001}, 1)
+	assert.NoError(t, err)
+	halted, err := backtester.Backtest()
+	assert.NoError(t, err)
+	assert.True(t, halted.Halted)
+	for _, s := range halted.Strategies {
+		for _, trade := range s.Trades {
+			assert.False(t, trade.Time.After(halted.HaltedAt))
+		}
+	}
+	for i, e := range halted.Equity {
+		if halted.Times[i].After(halted.HaltedAt) {
+			assert.InDelta(t, halted.FinalEquity, e, 1e-9)
+		}
+	}
+}
+
+func TestPortfolioBacktesterRequiresSharedCurrency(t *testing.T) {
+	a := &vespyr.TradingStrategyModel{BudgetCurrency: vespyr.CurrencyUSD}
+	b := &vespyr.TradingStrategyModel{BudgetCurrency: vespyr.CurrencyBTC}
+	_, err := vespyr.NewPortfolioBacktester(time.Now(), time.Now(), []*vespyr.TradingStrategyModel{a, b},
+		new(vespyr.MockBackend), 100, vespyr.PortfolioRiskRules{}, 1)
+	assert.Error(t, err)
+}
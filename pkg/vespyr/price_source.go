@@ -0,0 +1,48 @@
+package vespyr
+
+import (
+	"github.com/pkg/errors"
+)
+
+// PriceSource selects the price that an indicator reads from each
+// candlestick.
+type PriceSource string
+
+const (
+	// PriceSourceClose is the candlestick's close.
+	PriceSourceClose PriceSource = "close"
+	// PriceSourceHL2 is the mean of the high and low.
+	PriceSourceHL2 PriceSource = "hl2"
+	// PriceSourceHLC3 is the mean of the high, low and close, also
+	// known as the typical price.
+	PriceSourceHLC3 PriceSource = "hlc3"
+	// PriceSourceOHLC4 is the mean of the open, high, low and
+	// close. It's the price that the original indicators use.
+	PriceSourceOHLC4 PriceSource = "ohlc4"
+)
+
+// ParsePriceSource parses a price source, defaulting to OHLC4.
+func ParsePriceSource(s string) (PriceSource, error) {
+	switch source := PriceSource(s); source {
+	case "":
+		return PriceSourceOHLC4, nil
+	case PriceSourceClose, PriceSourceHL2, PriceSourceHLC3, PriceSourceOHLC4:
+		return source, nil
+	}
+	return "", errors.Errorf("error: unknown price source: %s", s)
+}
+
+// Price returns the candlestick's price.
+func (p PriceSource) Price(c *CandlestickModel) (float64, error) {
+	switch p {
+	case PriceSourceClose:
+		return c.Close, nil
+	case PriceSourceHL2:
+		return (c.High + c.Low) / 2, nil
+	case PriceSourceHLC3:
+		return (c.High + c.Low + c.Close) / 3, nil
+	case PriceSourceOHLC4, "":
+		return c.MeanPrice()
+	}
+	return 0, errors.Errorf("error: unknown price source: %s", p)
+}
@@ -2,13 +2,60 @@ package vespyr
 
 import (
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/pkg/errors"
 )
 
+const (
+	// RSIModeLegacy is the original RSI calculation. It skips
+	// candlesticks without volume and its smoothing assumes a
+	// period of 14.
+	RSIModeLegacy = "legacy"
+	// RSIModeWilder is the standard RSI that charting platforms
+	// use: gains and losses on every candlestick, including those
+	// without volume, are smoothed with Wilder's moving average.
+	RSIModeWilder = "wilder"
+)
+
+// RSIOptions selects how a strategy calculates its RSI. The zero value
+// is the legacy RSI on the OHLC4 price. The Wilder RSI defaults to the
+// close.
+type RSIOptions struct {
+	RSIMode        string      `yaml:"rsi_mode,omitempty"`
+	RSIPriceSource PriceSource `yaml:"rsi_price_source,omitempty"`
+}
+
+// Validate returns an error for unknown modes and price sources.
+func (o RSIOptions) Validate() error {
+	switch o.RSIMode {
+	case "", RSIModeLegacy, RSIModeWilder:
+	default:
+		return errors.Errorf("error: unknown RSI mode: %s", o.RSIMode)
+	}
+	_, err := ParsePriceSource(string(o.RSIPriceSource))
+	return err
+}
+
+func (o RSIOptions) newRSIIndicator(periods uint) *RSIIndicator {
+	return NewRSIIndicatorWithMode(periods, o.RSIMode, o.RSIPriceSource)
+}
+
+// String returns the options, or an empty string for the defaults.
+func (o RSIOptions) String() string {
+	if o.RSIMode == "" && o.RSIPriceSource == "" {
+		return ""
+	}
+	return fmt.Sprintf("-rsi(%s)-(%s)", o.RSIMode, o.RSIPriceSource)
+}
+
 // RSIIndicator calculates the RSI indicator.
 type RSIIndicator struct {
+	mode            string
+	source          PriceSource
+	averageGain     *wilderAverage
+	averageLoss     *wilderAverage
 	iterations      uint
 	periods         uint
 	lastValue       float64
@@ -20,10 +67,30 @@ type RSIIndicator struct {
 	lastTime        time.Time
 }
 
-// NewRSIIndicator returns a new RSIIndicator.
+// NewRSIIndicator returns a new legacy RSIIndicator.
 func NewRSIIndicator(periods uint) *RSIIndicator {
+	return NewRSIIndicatorWithMode(periods, RSIModeLegacy, "")
+}
+
+// NewRSIIndicatorWithMode returns a new RSIIndicator that uses an RSI
+// mode and price source. An empty mode is the legacy mode.
+func NewRSIIndicatorWithMode(periods uint, mode string, source PriceSource) *RSIIndicator {
+	if mode == "" {
+		mode = RSIModeLegacy
+	}
+	if source == "" {
+		source = PriceSourceOHLC4
+		if mode == RSIModeWilder {
+			source = PriceSourceClose
+		}
+	}
+
 	return &RSIIndicator{
-		periods: periods,
+		mode:        mode,
+		source:      source,
+		periods:     periods,
+		averageGain: &wilderAverage{period: periods},
+		averageLoss: &wilderAverage{period: periods},
 	}
 }
 
@@ -33,13 +100,65 @@ func rsiFromRS(rs float64) float64 {
 
 // AddCandlestick adds a candlestick to the indicator.
 func (r *RSIIndicator) AddCandlestick(c *CandlestickModel) error {
+	switch r.mode {
+	case RSIModeLegacy:
+		return r.addLegacyCandlestick(c)
+	case RSIModeWilder:
+		return r.addWilderCandlestick(c)
+	}
+	return errors.Errorf("error: unknown RSI mode: %s", r.mode)
+}
+
+func (r *RSIIndicator) addWilderCandlestick(c *CandlestickModel) error {
+	// Reprojection fills empty buckets with zero valued
+	// candlesticks, which don't appear on charts. Candlesticks
+	// that have prices but no volume do.
+	if c.Volume == 0 && c.Close == 0 {
+		return nil
+	}
+
+	price, err := r.source.Price(c)
+	if err != nil {
+		return errors.Wrapf(err, "error calculating %s price", r.source)
+	}
+
+	defer func() {
+		r.iterations++
+		r.lastValue = price
+		r.lastTime = c.StartTime
+	}()
+
+	if r.iterations == 0 {
+		return nil
+	}
+
+	diff := price - r.lastValue
+	r.averageGain.add(math.Max(diff, 0))
+	r.averageLoss.add(math.Max(-diff, 0))
+	if !r.averageLoss.ready() {
+		return nil
+	}
+
+	switch {
+	case r.averageLoss.value == 0:
+		r.currentRSI = 100
+	case r.averageGain.value == 0:
+		r.currentRSI = 0
+	default:
+		r.currentRSI = rsiFromRS(r.averageGain.value / r.averageLoss.value)
+	}
+
+	return nil
+}
+
+func (r *RSIIndicator) addLegacyCandlestick(c *CandlestickModel) error {
 	if c.Volume == 0 {
 		return nil
 	}
 
-	price, err := c.MeanPrice()
+	price, err := r.source.Price(c)
 	if err != nil {
-		return errors.Wrapf(err, "error calculating mean price")
+		return errors.Wrapf(err, "error calculating %s price", r.source)
 	}
 
 	defer func() {
@@ -120,9 +239,13 @@ func (r *RSIIndicator) AddCandlestick(c *CandlestickModel) error {
 	return nil
 }
 
-// Name returns the name of the indicator
+// Name returns the name of the indicator. The legacy RSI on the OHLC4
+// price keeps its original name.
 func (r *RSIIndicator) Name() string {
-	return fmt.Sprintf("%s-%d", IndicatorRSI, r.periods)
+	if r.mode == RSIModeLegacy && r.source == PriceSourceOHLC4 {
+		return fmt.Sprintf("%s-%d", IndicatorRSI, r.periods)
+	}
+	return fmt.Sprintf("%s-%s-%d-%s", IndicatorRSI, r.mode, r.periods, r.source)
 }
 
 // Value returns the last calculated RSI value.
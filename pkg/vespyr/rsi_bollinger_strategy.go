@@ -0,0 +1,196 @@
+package vespyr
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/MaxHalford/gago"
+)
+
+func init() {
+	RegisterStrategyType(&StrategyType{
+		Name:        TradingStrategyRSIBollinger,
+		Description: "buys and sells when the RSI and Bollinger Bands agree",
+		New:         func() StrategyInterface { return &RSIBollingerStrategy{} },
+		Parameters: []*StrategyParameter{
+			{Name: "rsi_period", Description: "the RSI period", Default: 14, Min: 2, Max: 50, Integer: true},
+			{Name: "rsi_buy_threshold", Description: "the RSI at or below which to buy", Default: 30, Min: 0, Max: 50},
+			{Name: "rsi_sell_threshold", Description: "the RSI at or above which to sell", Default: 70, Min: 50, Max: 100},
+			{Name: "bollinger_period", Description: "the Bollinger Bands period", Default: 20, Min: 2, Max: 50, Integer: true},
+			{Name: "bollinger_deviations", Description: "the number of standard deviations between the middle and outer bands", Default: 2, Min: 1, Max: 3},
+			{Name: "percent_b_buy_threshold", Description: "the %B at or below which to buy", Default: 0, Min: -.2, Max: .5},
+			{Name: "percent_b_sell_threshold", Description: "the %B at or above which to sell", Default: 1, Min: .5, Max: 1.2},
+		},
+	})
+}
+
+// RSIBollingerStrategy is a mean reversion strategy that buys when the
+// RSI is oversold and the price is near the lower Bollinger Band, and
+// sells when the RSI is overbought and the price is near the upper
+// band. The band thresholds are %B values, so 0 is the lower band and
+// 1 is the upper band.
+type RSIBollingerStrategy struct {
+	RSIPeriod             uint    `yaml:"rsi_period"`
+	RSIBuyThreshold       float64 `yaml:"rsi_buy_threshold"`
+	RSISellThreshold      float64 `yaml:"rsi_sell_threshold"`
+	BollingerPeriod       uint    `yaml:"bollinger_period"`
+	BollingerDeviations   float64 `yaml:"bollinger_deviations"`
+	PercentBBuyThreshold  float64 `yaml:"percent_b_buy_threshold"`
+	PercentBSellThreshold float64 `yaml:"percent_b_sell_threshold"`
+	RSIOptions            `yaml:",inline"`
+	TimeframeOptions      `yaml:",inline"`
+
+	strategy *TradingStrategyModel
+}
+
+// String returns the string representation of the strategy.
+func (e *RSIBollingerStrategy) String() string {
+	return fmt.Sprintf("RSIBollingerStrategy-rsi(%d)-(%f)-(%f)-bollinger(%d)-(%f)-(%f)-(%f)%s%s",
+		e.RSIPeriod, e.RSIBuyThreshold, e.RSISellThreshold,
+		e.BollingerPeriod, e.BollingerDeviations, e.PercentBBuyThreshold, e.PercentBSellThreshold,
+		e.RSIOptions, e.TimeframeOptions,
+	)
+}
+
+// SetTradingStrategy sets the underlying trading strategy.
+func (e *RSIBollingerStrategy) SetTradingStrategy(t *TradingStrategyModel) {
+	e.strategy = t
+}
+
+func (e *RSIBollingerStrategy) rsiIndicator() Indicator {
+	return e.timeframe(e.newRSIIndicator(e.RSIPeriod))
+}
+
+func (e *RSIBollingerStrategy) bollingerIndicator() Indicator {
+	return e.timeframe(NewBollingerIndicator(e.BollingerPeriod, e.BollingerDeviations))
+}
+
+// Indicators returns the indicators returned by the strategy.
+func (e *RSIBollingerStrategy) Indicators() []Indicator {
+	var indicators []Indicator
+	indicators = append(indicators, e.rsiIndicator())
+	indicators = append(indicators, e.bollingerIndicator())
+	return indicators
+}
+
+func (e *RSIBollingerStrategy) values(history []*IndicatorSet, current int) (float64, float64, error) {
+	rsi, err := indicatorValue(history, current, e.rsiIndicator().Name())
+	if err != nil {
+		return 0, 0, err
+	}
+	percentB, err := history[current].Component(e.bollingerIndicator().Name(), BollingerPercentB)
+	if err != nil {
+		return 0, 0, err
+	}
+	return rsi.Value, percentB, nil
+}
+
+// Buy determines whether the currency should be bought using the
+// indicator history.
+func (e *RSIBollingerStrategy) Buy(history []*IndicatorSet, current int) (bool, error) {
+	rsi, percentB, err := e.values(history, current)
+	if err != nil {
+		return false, err
+	}
+
+	return rsi <= e.RSIBuyThreshold && percentB <= e.PercentBBuyThreshold, nil
+}
+
+// Sell determines whether the currency should be sold using the
+// indicator history.
+func (e *RSIBollingerStrategy) Sell(history []*IndicatorSet, current int) (bool, error) {
+	rsi, percentB, err := e.values(history, current)
+	if err != nil {
+		return false, err
+	}
+
+	return rsi >= e.RSISellThreshold && percentB >= e.PercentBSellThreshold, nil
+}
+
+// Rand creates a random version of the strategy within its registered
+// parameters.
+func (e *RSIBollingerStrategy) Rand(rng *rand.Rand) {
+	e.RSIPeriod = uint(rsiBollingerParameter("rsi_period").Rand(rng))
+	e.RSISellThreshold = rsiBollingerParameter("rsi_sell_threshold").Rand(rng)
+	e.RSIBuyThreshold = rsiBollingerParameter("rsi_buy_threshold").Clamp(100 - e.RSISellThreshold)
+	e.BollingerPeriod = uint(rsiBollingerParameter("bollinger_period").Rand(rng))
+	e.BollingerDeviations = rsiBollingerParameter("bollinger_deviations").Rand(rng)
+	e.PercentBBuyThreshold = rsiBollingerParameter("percent_b_buy_threshold").Rand(rng)
+	e.PercentBSellThreshold = rsiBollingerParameter("percent_b_sell_threshold").Clamp(1 - e.PercentBBuyThreshold)
+}
+
+func rsiBollingerParameter(name string) *StrategyParameter {
+	return strategyParameter(TradingStrategyRSIBollinger, name)
+}
+
+// Clone returns a clone of the current strategy.
+func (e *RSIBollingerStrategy) Clone() StrategyGenome {
+	return &RSIBollingerStrategy{
+		RSIPeriod:             e.RSIPeriod,
+		RSIBuyThreshold:       e.RSIBuyThreshold,
+		RSISellThreshold:      e.RSISellThreshold,
+		BollingerPeriod:       e.BollingerPeriod,
+		BollingerDeviations:   e.BollingerDeviations,
+		PercentBBuyThreshold:  e.PercentBBuyThreshold,
+		PercentBSellThreshold: e.PercentBSellThreshold,
+		RSIOptions:            e.RSIOptions,
+		TimeframeOptions:      e.TimeframeOptions,
+	}
+}
+
+// Mutate mutates the underlying strategy within its registered
+// parameters.
+func (e *RSIBollingerStrategy) Mutate(rng *rand.Rand) {
+	mutateProb := 0.8
+
+	if rng.Float64() < mutateProb {
+		e.RSIPeriod = uint(rsiBollingerParameter("rsi_period").Mutate(rng, float64(e.RSIPeriod)))
+	}
+	if rng.Float64() < mutateProb {
+		e.RSIBuyThreshold = rsiBollingerParameter("rsi_buy_threshold").Mutate(rng, e.RSIBuyThreshold)
+	}
+	if rng.Float64() < mutateProb {
+		e.RSISellThreshold = rsiBollingerParameter("rsi_sell_threshold").Mutate(rng, e.RSISellThreshold)
+	}
+	if rng.Float64() < mutateProb {
+		e.BollingerPeriod = uint(rsiBollingerParameter("bollinger_period").Mutate(rng, float64(e.BollingerPeriod)))
+	}
+	if rng.Float64() < mutateProb {
+		e.BollingerDeviations = rsiBollingerParameter("bollinger_deviations").Mutate(rng, e.BollingerDeviations)
+	}
+	if rng.Float64() < mutateProb {
+		e.PercentBBuyThreshold = rsiBollingerParameter("percent_b_buy_threshold").Mutate(rng, e.PercentBBuyThreshold)
+	}
+	if rng.Float64() < mutateProb {
+		e.PercentBSellThreshold = rsiBollingerParameter("percent_b_sell_threshold").Mutate(rng, e.PercentBSellThreshold)
+	}
+}
+
+// Crossover crosses over an RSIBollingerStrategy with a different one.
+func (e *RSIBollingerStrategy) Crossover(m StrategyGenome,
+	r *rand.Rand) (StrategyGenome, StrategyGenome) {
+	mate := m.(*RSIBollingerStrategy)
+
+	p1 := []float64{float64(e.RSIPeriod), e.RSIBuyThreshold, e.RSISellThreshold,
+		float64(e.BollingerPeriod), e.BollingerDeviations, e.PercentBBuyThreshold, e.PercentBSellThreshold}
+	p2 := []float64{float64(mate.RSIPeriod), mate.RSIBuyThreshold, mate.RSISellThreshold,
+		float64(mate.BollingerPeriod), mate.BollingerDeviations, mate.PercentBBuyThreshold, mate.PercentBSellThreshold}
+
+	c1, c2 := gago.CrossUniformFloat64(p1, p2, r)
+
+	child := func(c []float64) *RSIBollingerStrategy {
+		return &RSIBollingerStrategy{
+			RSIPeriod:             uint(c[0]),
+			RSIBuyThreshold:       c[1],
+			RSISellThreshold:      c[2],
+			BollingerPeriod:       uint(c[3]),
+			BollingerDeviations:   c[4],
+			PercentBBuyThreshold:  c[5],
+			PercentBSellThreshold: c[6],
+			RSIOptions:            e.RSIOptions,
+			TimeframeOptions:      e.TimeframeOptions,
+		}
+	}
+
+	return child(c1), child(c2)
+}
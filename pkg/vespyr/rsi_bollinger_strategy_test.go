@@ -0,0 +1,123 @@
+package vespyr_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/DavidHuie/vespyr/pkg/vespyr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRSIBollingerStrategy(t *testing.T) {
+	strategy := &vespyr.RSIBollingerStrategy{
+		RSIPeriod:             14,
+		RSIBuyThreshold:       30,
+		RSISellThreshold:      70,
+		BollingerPeriod:       20,
+		BollingerDeviations:   2,
+		PercentBBuyThreshold:  0,
+		PercentBSellThreshold: 1,
+	}
+
+	indicators := strategy.Indicators()
+	if assert.Len(t, indicators, 2) {
+		assert.Equal(t, "rsi-14", indicators[0].Name())
+		assert.Equal(t, "bollinger-20-2", indicators[1].Name())
+	}
+
+	set := func(rsi, percentB float64) *vespyr.IndicatorSet {
+		return &vespyr.IndicatorSet{Values: []*vespyr.IndicatorValue{
+			{IndicatorName: "rsi-14", Value: rsi},
+			{IndicatorName: "bollinger-20-2", Components: []*vespyr.IndicatorComponent{
+				{Name: vespyr.BollingerPercentB, Value: percentB},
+			}},
+		}}
+	}
+	history := []*vespyr.IndicatorSet{
+		set(25, -.1),
+		set(25, .5),
+		set(50, -.1),
+		set(75, 1.1),
+		set(75, .5),
+		{Values: []*vespyr.IndicatorValue{nil, {IndicatorName: "bollinger-20-2"}}},
+	}
+
+	for i, expected := range []struct{ buy, sell bool }{
+		{true, false},
+		{false, false},
+		{false, false},
+		{false, true},
+		{false, false},
+	} {
+		buy, err := strategy.Buy(history, i)
+		assert.NoError(t, err)
+		assert.Equal(t, expected.buy, buy, "buy %d", i)
+		sell, err := strategy.Sell(history, i)
+		assert.NoError(t, err)
+		assert.Equal(t, expected.sell, sell, "sell %d", i)
+	}
+
+	_, err := strategy.Buy(history, 5)
+	assert.Equal(t, vespyr.ErrNotEnoughData, err)
+	_, err = strategy.Sell(history, 6)
+	assert.Equal(t, vespyr.ErrNotEnoughData, err)
+}
+
+func TestRSIBollingerStrategyModel(t *testing.T) {
+	strategy := &vespyr.RSIBollingerStrategy{
+		RSIPeriod:             10,
+		RSIBuyThreshold:       25,
+		RSISellThreshold:      75,
+		BollingerPeriod:       15,
+		BollingerDeviations:   2.5,
+		PercentBBuyThreshold:  .1,
+		PercentBSellThreshold: .9,
+	}
+
+	model := &vespyr.TradingStrategyModel{}
+	assert.NoError(t, model.SetStrategy(strategy))
+	assert.Equal(t, vespyr.TradingStrategyRSIBollinger, model.TradingStrategy)
+
+	decoded, err := model.Strategy()
+	assert.NoError(t, err)
+	assert.Equal(t, strategy.String(), decoded.String())
+}
+
+func TestRSIBollingerStrategyGenome(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	var genome vespyr.StrategyGenome = &vespyr.RSIBollingerStrategy{}
+	genome.Rand(rng)
+	s := genome.(*vespyr.RSIBollingerStrategy)
+	assert.True(t, s.RSIPeriod >= 2)
+	assert.True(t, s.BollingerPeriod >= 2)
+	assert.True(t, s.RSIBuyThreshold <= s.RSISellThreshold)
+	assert.True(t, s.PercentBBuyThreshold <= s.PercentBSellThreshold)
+
+	clone := genome.Clone()
+	assert.Equal(t, genome.String(), clone.String())
+
+	for i := 0; i < 100; i++ {
+		clone.Mutate(rng)
+		c := clone.(*vespyr.RSIBollingerStrategy)
+		assert.True(t, c.RSIPeriod >= 2)
+		assert.True(t, c.BollingerPeriod >= 2)
+		assert.True(t, c.RSIBuyThreshold >= 0 && c.RSIBuyThreshold <= 50)
+		assert.True(t, c.BollingerDeviations >= 1 && c.BollingerDeviations <= 3)
+		assert.True(t, c.PercentBBuyThreshold >= -.2 && c.PercentBBuyThreshold <= .5)
+	}
+
+	// Thresholds that default to 0 still mutate.
+	zero := &vespyr.RSIBollingerStrategy{RSIPeriod: 14, BollingerPeriod: 20, BollingerDeviations: 2,
+		PercentBSellThreshold: 1}
+	mutated := false
+	for i := 0; i < 10; i++ {
+		zero.Mutate(rng)
+		mutated = mutated || zero.PercentBBuyThreshold != 0
+	}
+	assert.True(t, mutated)
+
+	c1, c2 := genome.Crossover(clone, rng)
+	assert.IsType(t, &vespyr.RSIBollingerStrategy{}, c1)
+	assert.IsType(t, &vespyr.RSIBollingerStrategy{}, c2)
+}
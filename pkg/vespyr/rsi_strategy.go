@@ -7,20 +7,35 @@ import (
 	"github.com/MaxHalford/gago"
 )
 
+func init() {
+	RegisterStrategyType(&StrategyType{
+		Name:        TradingStrategyRSI,
+		Description: "buys when the RSI is oversold and sells when it's overbought",
+		New:         func() StrategyInterface { return &RSIStrategy{} },
+		Parameters: []*StrategyParameter{
+			{Name: "period", Description: "the RSI period", Default: 14, Min: 0, Max: 50, Integer: true},
+			{Name: "buy_threshold", Description: "the RSI at or below which to buy", Default: 30, Min: 0, Max: 50},
+			{Name: "sell_threshold", Description: "the RSI at or above which to sell", Default: 70, Min: 0, Max: 100},
+		},
+	})
+}
+
 // RSIStrategy is a strategy for that buys and sells based on the RSI
 // values.
 type RSIStrategy struct {
-	Period        uint    `yaml:"period"`
-	BuyThreshold  float64 `yaml:"buy_threshold"`
-	SellThreshold float64 `yaml:"sell_threshold"`
+	Period           uint    `yaml:"period"`
+	BuyThreshold     float64 `yaml:"buy_threshold"`
+	SellThreshold    float64 `yaml:"sell_threshold"`
+	RSIOptions       `yaml:",inline"`
+	TimeframeOptions `yaml:",inline"`
 
 	strategy *TradingStrategyModel
 }
 
 // String returns the string representation of the strategy.
 func (e *RSIStrategy) String() string {
-	return fmt.Sprintf("RSIStrategy-p(%d)-(%f)-(%f)",
-		e.Period, e.BuyThreshold, e.SellThreshold,
+	return fmt.Sprintf("RSIStrategy-p(%d)-(%f)-(%f)%s%s",
+		e.Period, e.BuyThreshold, e.SellThreshold, e.RSIOptions, e.TimeframeOptions,
 	)
 }
 
@@ -32,22 +47,20 @@ func (e *RSIStrategy) SetTradingStrategy(t *TradingStrategyModel) {
 // Indicators returns the indicators returned by the strategy.
 func (e *RSIStrategy) Indicators() []Indicator {
 	var indicators []Indicator
-	indicators = append(indicators, NewRSIIndicator(e.Period))
+	indicators = append(indicators, e.rsiIndicator())
 	return indicators
 }
 
+func (e *RSIStrategy) rsiIndicator() Indicator {
+	return e.timeframe(e.newRSIIndicator(e.Period))
+}
+
 // Buy determines whether the currency should be bought using the
 // indicator history.
 func (e *RSIStrategy) Buy(history []*IndicatorSet, current int) (bool, error) {
-	if len(history)-1 < current {
-		return false, ErrNotEnoughData
-	}
-
-	currentValues := history[current].Values
-
-	rsi := currentValues[0]
-	if rsi == nil {
-		return false, ErrNotEnoughData
+	rsi, err := indicatorValue(history, current, e.rsiIndicator().Name())
+	if err != nil {
+		return false, err
 	}
 
 	return rsi.Value <= e.BuyThreshold, nil
@@ -56,72 +69,50 @@ func (e *RSIStrategy) Buy(history []*IndicatorSet, current int) (bool, error) {
 // Sell determines whether the currency should be sold using the
 // indicator history.
 func (e *RSIStrategy) Sell(history []*IndicatorSet, current int) (bool, error) {
-	if len(history)-1 < current {
-		return false, ErrNotEnoughData
-	}
-
-	currentValues := history[current].Values
-
-	rsi := currentValues[0]
-	if rsi == nil {
-		return false, ErrNotEnoughData
+	rsi, err := indicatorValue(history, current, e.rsiIndicator().Name())
+	if err != nil {
+		return false, err
 	}
 
 	return rsi.Value >= e.SellThreshold, nil
 }
 
-// Rand creates a random version of the strategy.
+// Rand creates a random version of the strategy within its registered
+// parameters.
 func (e *RSIStrategy) Rand(rng *rand.Rand) {
-	e.Period = uint(rng.Float64() * 50)
-	e.SellThreshold = rng.Float64() * 100
-	e.BuyThreshold = e.SellThreshold / 2
+	e.Period = uint(rsiParameter("period").Rand(rng))
+	e.SellThreshold = rsiParameter("sell_threshold").Rand(rng)
+	e.BuyThreshold = rsiParameter("buy_threshold").Clamp(e.SellThreshold / 2)
+}
+
+func rsiParameter(name string) *StrategyParameter {
+	return strategyParameter(TradingStrategyRSI, name)
 }
 
 // Clone returns a clone of the current strategy.
 func (e *RSIStrategy) Clone() StrategyGenome {
 	return &RSIStrategy{
-		Period:        e.Period,
-		BuyThreshold:  e.BuyThreshold,
-		SellThreshold: e.SellThreshold,
+		Period:           e.Period,
+		BuyThreshold:     e.BuyThreshold,
+		SellThreshold:    e.SellThreshold,
+		RSIOptions:       e.RSIOptions,
+		TimeframeOptions: e.TimeframeOptions,
 	}
 }
 
-// Mutate mutates the underlying strategy.
+// Mutate mutates the underlying strategy within its registered
+// parameters.
 func (e *RSIStrategy) Mutate(rng *rand.Rand) {
 	mutateProb := 0.8
 
 	if rng.Float64() < mutateProb {
-		x := float64(e.Period)
-		x += x * rng.NormFloat64()
-		if x < 0 {
-			e.Period = 0
-		} else {
-			e.Period = uint(x)
-		}
+		e.Period = uint(rsiParameter("period").Mutate(rng, float64(e.Period)))
 	}
-
 	if rng.Float64() < mutateProb {
-		x := e.BuyThreshold
-		x += x * rng.NormFloat64()
-		e.BuyThreshold = x
-		if x > 100 {
-			e.BuyThreshold = 100
-		}
-		if x < 0 {
-			e.BuyThreshold = 0
-		}
+		e.BuyThreshold = rsiParameter("buy_threshold").Mutate(rng, e.BuyThreshold)
 	}
-
 	if rng.Float64() < mutateProb {
-		x := e.SellThreshold
-		x += x * rng.NormFloat64()
-		e.SellThreshold = x
-		if x > 100 {
-			e.SellThreshold = 100
-		}
-		if x < 0 {
-			e.SellThreshold = 0
-		}
+		e.SellThreshold = rsiParameter("sell_threshold").Mutate(rng, e.SellThreshold)
 	}
 }
 
@@ -136,14 +127,18 @@ func (e *RSIStrategy) Crossover(m StrategyGenome,
 	c1, c2 := gago.CrossUniformFloat64(p1, p2, r)
 
 	s1 := &RSIStrategy{
-		Period:        uint(c1[0]),
-		BuyThreshold:  c1[1],
-		SellThreshold: c1[2],
+		Period:           uint(c1[0]),
+		BuyThreshold:     c1[1],
+		SellThreshold:    c1[2],
+		RSIOptions:       e.RSIOptions,
+		TimeframeOptions: e.TimeframeOptions,
 	}
 	s2 := &RSIStrategy{
-		Period:        uint(c2[0]),
-		BuyThreshold:  c2[1],
-		SellThreshold: c2[2],
+		Period:           uint(c2[0]),
+		BuyThreshold:     c2[1],
+		SellThreshold:    c2[2],
+		RSIOptions:       e.RSIOptions,
+		TimeframeOptions: e.TimeframeOptions,
 	}
 
 	return s1, s2
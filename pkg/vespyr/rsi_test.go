@@ -39,58 +39,83 @@ func TestRSIIndicator(t *testing.T) {
 	})
 
 	// http://cns.bu.edu/~gsc/CN710/fincast/Technical%20_indicators/Relative%20Strength%20Index%20(RSI).htm
+	values := []float64{
+		46.1250,
+		47.1250,
+		46.4375,
+		46.9375,
+		44.9375,
+		44.25,
+		44.6250,
+		45.7500,
+		47.8125,
+		47.5625,
+		47,
+		44.5625,
+		46.3125,
+		47.6875,
+		46.6875,
+		45.6875,
+		43.0625,
+		43.5625,
+		44.8750,
+		43.6875,
+	}
+	responses := []*float64{
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		f(51.77865612648221),
+		f(48.47708511243952),
+		f(41.073449472180485),
+		f(42.863429113074524),
+		f(47.38184957507224),
+		f(43.992110594000444),
+	}
+
 	t.Run("rsi-complex", func(t *testing.T) {
 		r := vespyr.NewRSIIndicator(14)
 
-		values := []float64{
-			46.1250,
-			47.1250,
-			46.4375,
-			46.9375,
-			44.9375,
-			44.25,
-			44.6250,
-			45.7500,
-			47.8125,
-			47.5625,
-			47,
-			44.5625,
-			46.3125,
-			47.6875,
-			46.6875,
-			45.6875,
-			43.0625,
-			43.5625,
-			44.8750,
-			43.6875,
-		}
-		responses := []*float64{
-			nil,
-			nil,
-			nil,
-			nil,
-			nil,
-			nil,
-			nil,
-			nil,
-			nil,
-			nil,
-			nil,
-			nil,
-			nil,
-			nil,
-			f(51.77865612648221),
-			f(48.47708511243952),
-			f(41.073449472180485),
-			f(42.863429113074524),
-			f(47.38184957507224),
-			f(43.992110594000444),
+		for i, v := range values {
+			assert.NoError(t, r.AddCandlestick(&vespyr.CandlestickModel{
+				Volume:    1,
+				StartTime: time.Now(),
+				Close:     v,
+			}))
+
+			value, err := r.Value()
+
+			response := responses[i]
+			if response == nil {
+				assert.EqualError(t, err, vespyr.ErrNotEnoughData.Error())
+			} else {
+				assert.Equal(t, *response, value.Value)
+				assert.NoError(t, err)
+			}
 		}
+	})
+	t.Run("rsi-wilder", func(t *testing.T) {
+		r := vespyr.NewRSIIndicatorWithMode(14, vespyr.RSIModeWilder, "")
+		assert.Equal(t, "rsi-wilder-14-close", r.Name())
 
 		for i, v := range values {
 			assert.NoError(t, r.AddCandlestick(&vespyr.CandlestickModel{
 				Volume:    1,
 				StartTime: time.Now(),
+				Open:      v - 1,
+				High:      v + 1,
+				Low:       v - 2,
 				Close:     v,
 			}))
 
@@ -100,9 +125,63 @@ func TestRSIIndicator(t *testing.T) {
 			if response == nil {
 				assert.EqualError(t, err, vespyr.ErrNotEnoughData.Error())
 			} else {
-				assert.Equal(t, *response, value.Value)
 				assert.NoError(t, err)
+				assert.InDelta(t, *response, value.Value, 1e-9)
+				assert.Equal(t, r.Name(), value.IndicatorName)
 			}
 		}
 	})
+
+	t.Run("rsi-wilder-zero-volume", func(t *testing.T) {
+		r := vespyr.NewRSIIndicatorWithMode(2, vespyr.RSIModeWilder, vespyr.PriceSourceClose)
+
+		// Empty buckets are skipped but candlesticks with prices
+		// and no volume are counted.
+		for _, c := range []*vespyr.CandlestickModel{
+			{Volume: 1, Close: 1},
+			{},
+			{Close: 3},
+			{Volume: 1, Close: 2},
+		} {
+			assert.NoError(t, r.AddCandlestick(c))
+		}
+
+		value, err := r.Value()
+		assert.NoError(t, err)
+		assert.InDelta(t, 100-100/(1+1.0/.5), value.Value, 1e-9)
+	})
+}
+
+func TestRSIOptions(t *testing.T) {
+	assert.Equal(t, "rsi-14", vespyr.NewRSIIndicator(14).Name())
+	assert.Equal(t, "rsi-14", vespyr.NewRSIIndicatorWithMode(14, "", "").Name())
+	assert.Equal(t, "rsi-legacy-14-close", vespyr.NewRSIIndicatorWithMode(14, vespyr.RSIModeLegacy, vespyr.PriceSourceClose).Name())
+	assert.Equal(t, "rsi-wilder-14-hlc3", vespyr.NewRSIIndicatorWithMode(14, vespyr.RSIModeWilder, vespyr.PriceSourceHLC3).Name())
+
+	assert.NoError(t, vespyr.RSIOptions{}.Validate())
+	assert.NoError(t, vespyr.RSIOptions{RSIMode: vespyr.RSIModeWilder, RSIPriceSource: vespyr.PriceSourceHL2}.Validate())
+	assert.Error(t, vespyr.RSIOptions{RSIMode: "cutler"}.Validate())
+	assert.Error(t, vespyr.RSIOptions{RSIPriceSource: "open"}.Validate())
+
+	assert.Error(t, vespyr.NewRSIIndicatorWithMode(14, "cutler", "").AddCandlestick(&vespyr.CandlestickModel{Volume: 1}))
+
+	// Strategies keep their original YAML unless a mode is chosen.
+	model := &vespyr.TradingStrategyModel{}
+	assert.NoError(t, model.SetStrategy(&vespyr.RSIStrategy{Period: 14, BuyThreshold: 30, SellThreshold: 70}))
+	assert.NotContains(t, string(model.TradingStrategyData), "rsi_mode")
+
+	model.TradingStrategyData = []byte("period: 14\nbuy_threshold: 30\nsell_threshold: 70\nrsi_mode: wilder\nrsi_price_source: hl2\n")
+	strategy, err := model.Strategy()
+	assert.NoError(t, err)
+	if assert.Len(t, strategy.Indicators(), 1) {
+		assert.Equal(t, "rsi-wilder-14-hl2", strategy.Indicators()[0].Name())
+	}
+
+	s1 := &vespyr.S1Strategy{RSIOptions: vespyr.RSIOptions{RSIMode: vespyr.RSIModeWilder}}
+	assert.NoError(t, model.SetStrategy(s1))
+	assert.Contains(t, string(model.TradingStrategyData), "rsi_mode: wilder")
+	strategy, err = model.Strategy()
+	assert.NoError(t, err)
+	assert.Equal(t, s1.String(), strategy.String())
+	assert.Equal(t, s1.RSIOptions, s1.Clone().(*vespyr.S1Strategy).RSIOptions)
 }
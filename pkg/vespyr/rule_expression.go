@@ -0,0 +1,488 @@
+package vespyr
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// ruleEnv resolves the identifiers in a rule expression. Booleans are
+// represented as 1 and 0.
+type ruleEnv interface {
+	// lookup returns the value of an identifier lookback ticks
+	// before the current one.
+	lookup(ident *ruleIdent, lookback int) (float64, error)
+}
+
+type ruleNode interface {
+	eval(env ruleEnv, lookback int) (float64, error)
+}
+
+type ruleNumber float64
+
+func (n ruleNumber) eval(ruleEnv, int) (float64, error) {
+	return float64(n), nil
+}
+
+// ruleIdent is a parameter, an indicator's value or one of its
+// components, optionally indexed by how many ticks to look back, such
+// as bollinger.percent-b[1].
+type ruleIdent struct {
+	name      string
+	component string
+	lookback  int
+}
+
+func (i *ruleIdent) eval(env ruleEnv, lookback int) (float64, error) {
+	return env.lookup(i, lookback+i.lookback)
+}
+
+func (i *ruleIdent) String() string {
+	if i.component == "" {
+		return i.name
+	}
+	return i.name + "." + i.component
+}
+
+type ruleUnary struct {
+	op string
+	x  ruleNode
+}
+
+func (u *ruleUnary) eval(env ruleEnv, lookback int) (float64, error) {
+	x, err := u.x.eval(env, lookback)
+	if err != nil {
+		return 0, err
+	}
+	if u.op == "not" {
+		return ruleBool(x == 0), nil
+	}
+	return -x, nil
+}
+
+type ruleBinary struct {
+	op   string
+	x, y ruleNode
+}
+
+func ruleBool(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (b *ruleBinary) eval(env ruleEnv, lookback int) (float64, error) {
+	x, err := b.x.eval(env, lookback)
+	if err != nil {
+		return 0, err
+	}
+
+	// The right side of and and or is only evaluated when needed.
+	switch {
+	case b.op == "and" && x == 0:
+		return 0, nil
+	case b.op == "or" && x != 0:
+		return 1, nil
+	}
+
+	y, err := b.y.eval(env, lookback)
+	if err != nil {
+		return 0, err
+	}
+
+	switch b.op {
+	case "and", "or":
+		return ruleBool(y != 0), nil
+	case "+":
+		return x + y, nil
+	case "-":
+		return x - y, nil
+	case "*":
+		return x * y, nil
+	case "/":
+		return x / y, nil
+	case "<":
+		return ruleBool(x < y), nil
+	case "<=":
+		return ruleBool(x <= y), nil
+	case ">":
+		return ruleBool(x > y), nil
+	case ">=":
+		return ruleBool(x >= y), nil
+	case "==":
+		return ruleBool(x == y), nil
+	case "!=":
+		return ruleBool(x != y), nil
+	}
+	return 0, errors.Errorf("error: unknown operator: %s", b.op)
+}
+
+// ruleFunctions are the functions that expressions may call, by their
+// number of arguments.
+var ruleFunctions = map[string]int{
+	"abs":           1,
+	"min":           2,
+	"max":           2,
+	"crosses_above": 2,
+	"crosses_below": 2,
+}
+
+type ruleCall struct {
+	fn   string
+	args []ruleNode
+}
+
+func (c *ruleCall) eval(env ruleEnv, lookback int) (float64, error) {
+	values := func(lookback int) ([]float64, error) {
+		var values []float64
+		for _, arg := range c.args {
+			v, err := arg.eval(env, lookback)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+	}
+
+	current, err := values(lookback)
+	if err != nil {
+		return 0, err
+	}
+
+	switch c.fn {
+	case "abs":
+		return math.Abs(current[0]), nil
+	case "min":
+		return math.Min(current[0], current[1]), nil
+	case "max":
+		return math.Max(current[0], current[1]), nil
+	}
+
+	// Crosses compare the arguments with their values on the
+	// previous tick.
+	previous, err := values(lookback + 1)
+	if err != nil {
+		return 0, err
+	}
+	if c.fn == "crosses_above" {
+		return ruleBool(current[0] > current[1] && previous[0] <= previous[1]), nil
+	}
+	return ruleBool(current[0] < current[1] && previous[0] >= previous[1]), nil
+}
+
+// ruleExpression is a parsed rule expression.
+type ruleExpression struct {
+	source string
+	root   ruleNode
+	idents []*ruleIdent
+}
+
+func (e *ruleExpression) eval(env ruleEnv) (float64, error) {
+	return e.root.eval(env, 0)
+}
+
+type ruleToken struct {
+	kind  string
+	text  string
+	value float64
+}
+
+const (
+	ruleTokenNumber = "number"
+	ruleTokenIdent  = "identifier"
+	ruleTokenOp     = "operator"
+	ruleTokenEOF    = "end of expression"
+)
+
+func isRuleIdentStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func isRuleIdentPart(r rune) bool {
+	return isRuleIdentStart(r) || unicode.IsDigit(r)
+}
+
+// lexRuleExpression splits an expression into tokens. Components may
+// contain dashes, such as percent-b, so subtraction from a component
+// needs spaces around the minus sign.
+func lexRuleExpression(s string) ([]ruleToken, error) {
+	var tokens []ruleToken
+	r := []rune(s)
+	for i := 0; i < len(r); {
+		switch c := r[i]; {
+		case unicode.IsSpace(c):
+			i++
+		case unicode.IsDigit(c) || c == '.':
+			j := i
+			for j < len(r) && (unicode.IsDigit(r[j]) || r[j] == '.') {
+				j++
+			}
+			if j < len(r) && (r[j] == 'e' || r[j] == 'E') {
+				j++
+				if j < len(r) && (r[j] == '+' || r[j] == '-') {
+					j++
+				}
+				for j < len(r) && unicode.IsDigit(r[j]) {
+					j++
+				}
+			}
+			value, err := strconv.ParseFloat(string(r[i:j]), 64)
+			if err != nil {
+				return nil, errors.Errorf("error: invalid number: %s", string(r[i:j]))
+			}
+			tokens = append(tokens, ruleToken{kind: ruleTokenNumber, text: string(r[i:j]), value: value})
+			i = j
+		case isRuleIdentStart(c):
+			j := i
+			for j < len(r) && isRuleIdentPart(r[j]) {
+				j++
+			}
+			if j+1 < len(r) && r[j] == '.' && isRuleIdentStart(r[j+1]) {
+				j++
+				for j < len(r) && (isRuleIdentPart(r[j]) || r[j] == '-') {
+					j++
+				}
+			}
+			text := string(r[i:j])
+			kind := ruleTokenIdent
+			if text == "and" || text == "or" || text == "not" {
+				kind = ruleTokenOp
+			}
+			tokens = append(tokens, ruleToken{kind: kind, text: text})
+			i = j
+		default:
+			op := string(c)
+			if i+1 < len(r) {
+				switch two := string(r[i : i+2]); two {
+				case "<=", ">=", "==", "!=":
+					op = two
+				}
+			}
+			if !strings.Contains("+-*/<>()[],", op) && len(op) == 1 {
+				return nil, errors.Errorf("error: unexpected character: %q", c)
+			}
+			tokens = append(tokens, ruleToken{kind: ruleTokenOp, text: op})
+			i += len(op)
+		}
+	}
+	return append(tokens, ruleToken{kind: ruleTokenEOF}), nil
+}
+
+type ruleParser struct {
+	tokens []ruleToken
+	pos    int
+	idents []*ruleIdent
+}
+
+// parseRuleExpression parses an expression such as
+//
+//	dema > 0.001 and rsi < 30
+//
+// Expressions combine numbers, identifiers, arithmetic (+ - * /),
+// comparisons (< <= > >= == !=), and, or, not, parentheses and the
+// functions abs, min, max, crosses_above and crosses_below. An
+// identifier followed by [n] is its value n ticks ago.
+func parseRuleExpression(s string) (*ruleExpression, error) {
+	tokens, err := lexRuleExpression(s)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing expression: %s", s)
+	}
+
+	p := &ruleParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err == nil && p.peek().kind != ruleTokenEOF {
+		err = errors.Errorf("error: unexpected %s", p.peek().text)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "error parsing expression: %s", s)
+	}
+
+	return &ruleExpression{source: s, root: root, idents: p.idents}, nil
+}
+
+func (p *ruleParser) peek() ruleToken {
+	return p.tokens[p.pos]
+}
+
+func (p *ruleParser) next() ruleToken {
+	t := p.tokens[p.pos]
+	if t.kind != ruleTokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *ruleParser) accept(ops ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != ruleTokenOp {
+		return "", false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *ruleParser) expect(op string) error {
+	if _, ok := p.accept(op); !ok {
+		t := p.peek()
+		text := t.text
+		if t.kind == ruleTokenEOF {
+			text = t.kind
+		}
+		return errors.Errorf("error: expected %s, found %s", op, text)
+	}
+	return nil
+}
+
+func (p *ruleParser) parseBinary(next func() (ruleNode, error), ops ...string) (ruleNode, error) {
+	x, err := next()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept(ops...)
+		if !ok {
+			return x, nil
+		}
+		y, err := next()
+		if err != nil {
+			return nil, err
+		}
+		x = &ruleBinary{op: op, x: x, y: y}
+	}
+}
+
+func (p *ruleParser) parseOr() (ruleNode, error) {
+	return p.parseBinary(p.parseAnd, "or")
+}
+
+func (p *ruleParser) parseAnd() (ruleNode, error) {
+	return p.parseBinary(p.parseNot, "and")
+}
+
+func (p *ruleParser) parseNot() (ruleNode, error) {
+	if _, ok := p.accept("not"); ok {
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &ruleUnary{op: "not", x: x}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *ruleParser) parseComparison() (ruleNode, error) {
+	x, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	op, ok := p.accept("<", "<=", ">", ">=", "==", "!=")
+	if !ok {
+		return x, nil
+	}
+	y, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	return &ruleBinary{op: op, x: x, y: y}, nil
+}
+
+func (p *ruleParser) parseSum() (ruleNode, error) {
+	return p.parseBinary(p.parseProduct, "+", "-")
+}
+
+func (p *ruleParser) parseProduct() (ruleNode, error) {
+	return p.parseBinary(p.parseUnary, "*", "/")
+}
+
+func (p *ruleParser) parseUnary() (ruleNode, error) {
+	if _, ok := p.accept("-"); ok {
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &ruleUnary{op: "-", x: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *ruleParser) parsePrimary() (ruleNode, error) {
+	t := p.next()
+	switch t.kind {
+	case ruleTokenNumber:
+		return ruleNumber(t.value), nil
+	case ruleTokenIdent:
+		if _, ok := p.accept("("); ok {
+			return p.parseCall(t.text)
+		}
+		return p.parseIdent(t.text)
+	case ruleTokenOp:
+		if t.text == "(" {
+			x, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			return x, p.expect(")")
+		}
+	}
+
+	text := t.text
+	if t.kind == ruleTokenEOF {
+		text = t.kind
+	}
+	return nil, errors.Errorf("error: unexpected %s", text)
+}
+
+func (p *ruleParser) parseCall(fn string) (ruleNode, error) {
+	arity, ok := ruleFunctions[fn]
+	if !ok {
+		return nil, errors.Errorf("error: unknown function: %s", fn)
+	}
+
+	call := &ruleCall{fn: fn}
+	for len(call.args) < arity {
+		if len(call.args) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		arg, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		call.args = append(call.args, arg)
+	}
+
+	return call, p.expect(")")
+}
+
+func (p *ruleParser) parseIdent(text string) (ruleNode, error) {
+	ident := &ruleIdent{name: text}
+	if i := strings.Index(text, "."); i >= 0 {
+		ident.name, ident.component = text[:i], text[i+1:]
+	}
+
+	if _, ok := p.accept("["); ok {
+		t := p.next()
+		lookback, err := strconv.Atoi(t.text)
+		if t.kind != ruleTokenNumber || err != nil || lookback < 0 {
+			return nil, errors.Errorf("error: invalid lookback for %s: %s", text, t.text)
+		}
+		ident.lookback = lookback
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+	}
+
+	p.idents = append(p.idents, ident)
+	return ident, nil
+}
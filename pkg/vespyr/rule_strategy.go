@@ -0,0 +1,557 @@
+package vespyr
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/MaxHalford/gago"
+	"github.com/pkg/errors"
+)
+
+// ruleIndicatorPrice is the rule indicator type for the candlestick
+// price itself.
+const ruleIndicatorPrice = "price"
+
+var ruleNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ruleIndicatorType describes the arguments of a rule indicator type
+// and how to create it.
+type ruleIndicatorType struct {
+	args   []string
+	source bool
+	new    func(args map[string]float64, r *RuleIndicator) Indicator
+}
+
+func ruleUint(v float64) uint {
+	return uint(math.Round(v))
+}
+
+var ruleIndicatorTypes = map[string]*ruleIndicatorType{
+	IndicatorEMA: {
+		args: []string{"period"},
+		new: func(a map[string]float64, _ *RuleIndicator) Indicator {
+			return NewEMAIndicator(ruleUint(a["period"]))
+		},
+	},
+	IndicatorDEMA: {
+		args: []string{"short_period", "long_period"},
+		new: func(a map[string]float64, _ *RuleIndicator) Indicator {
+			return NewDEMAIndicator(ruleUint(a["short_period"]), ruleUint(a["long_period"]))
+		},
+	},
+	IndicatorRSI: {
+		args:   []string{"period"},
+		source: true,
+		new: func(a map[string]float64, r *RuleIndicator) Indicator {
+			return NewRSIIndicatorWithMode(ruleUint(a["period"]), r.Mode, r.Source)
+		},
+	},
+	IndicatorMACD: {
+		args: []string{"short_period", "long_period"},
+		new: func(a map[string]float64, _ *RuleIndicator) Indicator {
+			return NewMACDIndicator(ruleUint(a["short_period"]), ruleUint(a["long_period"]))
+		},
+	},
+	IndicatorMACDWithSignal: {
+		args: []string{"short_period", "long_period", "signal_period"},
+		new: func(a map[string]float64, _ *RuleIndicator) Indicator {
+			return NewMACDWithSignal(ruleUint(a["short_period"]), ruleUint(a["long_period"]),
+				ruleUint(a["signal_period"]))
+		},
+	},
+	IndicatorBollinger: {
+		args: []string{"period", "deviations"},
+		new: func(a map[string]float64, _ *RuleIndicator) Indicator {
+			return NewBollingerIndicator(ruleUint(a["period"]), a["deviations"])
+		},
+	},
+	IndicatorSMA: {
+		args:   []string{"period"},
+		source: true,
+		new: func(a map[string]float64, r *RuleIndicator) Indicator {
+			return NewSMAIndicator(ruleUint(a["period"]), r.source())
+		},
+	},
+	IndicatorWMA: {
+		args:   []string{"period"},
+		source: true,
+		new: func(a map[string]float64, r *RuleIndicator) Indicator {
+			return NewWMAIndicator(ruleUint(a["period"]), r.source())
+		},
+	},
+	IndicatorHMA: {
+		args:   []string{"period"},
+		source: true,
+		new: func(a map[string]float64, r *RuleIndicator) Indicator {
+			return NewHMAIndicator(ruleUint(a["period"]), r.source())
+		},
+	},
+	IndicatorStdDev: {
+		args:   []string{"period"},
+		source: true,
+		new: func(a map[string]float64, r *RuleIndicator) Indicator {
+			return NewStdDevIndicator(ruleUint(a["period"]), r.source())
+		},
+	},
+	IndicatorATR: {
+		args: []string{"period"},
+		new: func(a map[string]float64, _ *RuleIndicator) Indicator {
+			return NewATRIndicator(ruleUint(a["period"]))
+		},
+	},
+	IndicatorADX: {
+		args: []string{"period"},
+		new: func(a map[string]float64, _ *RuleIndicator) Indicator {
+			return NewADXIndicator(ruleUint(a["period"]))
+		},
+	},
+	IndicatorStochastic: {
+		args:   []string{"k_period", "d_period"},
+		source: true,
+		new: func(a map[string]float64, r *RuleIndicator) Indicator {
+			return NewStochasticIndicator(ruleUint(a["k_period"]), ruleUint(a["d_period"]), r.source())
+		},
+	},
+	IndicatorOBV: {
+		source: true,
+		new: func(_ map[string]float64, r *RuleIndicator) Indicator {
+			return NewOBVIndicator(r.source())
+		},
+	},
+	IndicatorVWAP: {
+		args:   []string{"period"},
+		source: true,
+		new: func(a map[string]float64, r *RuleIndicator) Indicator {
+			return NewVWAPIndicator(ruleUint(a["period"]), r.source())
+		},
+	},
+	IndicatorIchimoku: {
+		args: []string{"conversion_period", "base_period", "span_b_period"},
+		new: func(a map[string]float64, _ *RuleIndicator) Indicator {
+			return NewIchimokuIndicator(ruleUint(a["conversion_period"]), ruleUint(a["base_period"]),
+				ruleUint(a["span_b_period"]))
+		},
+	},
+	IndicatorKeltner: {
+		args:   []string{"period", "multiplier"},
+		source: true,
+		new: func(a map[string]float64, r *RuleIndicator) Indicator {
+			return NewKeltnerIndicator(ruleUint(a["period"]), a["multiplier"], r.source())
+		},
+	},
+	ruleIndicatorPrice: {
+		source: true,
+		new: func(_ map[string]float64, r *RuleIndicator) Indicator {
+			return NewSMAIndicator(1, r.source())
+		},
+	},
+}
+
+func init() {
+	// Rule strategies define their own parameters and ranges.
+	RegisterStrategyType(&StrategyType{
+		Name:        TradingStrategyRule,
+		Description: "buys and sells using rules defined in YAML",
+		New:         func() StrategyInterface { return &RuleStrategy{} },
+	})
+}
+
+// RuleIndicator is an indicator used by a RuleStrategy. Its arguments
+// depend on its type, for example period for an RSI, and may be
+// numbers or expressions of the strategy's parameters. Mode only
+// applies to RSIs. Indicators with a tick size are calculated on
+// candlesticks of that size instead of the strategy's. Like every
+// Indicator, they skip candlesticks without volume.
+type RuleIndicator struct {
+	Name            string            `yaml:"name"`
+	Type            string            `yaml:"type"`
+	Mode            string            `yaml:"mode,omitempty"`
+	Source          PriceSource       `yaml:"source,omitempty"`
+	TickSizeMinutes uint              `yaml:"tick_size_minutes,omitempty"`
+	Args            map[string]string `yaml:",inline"`
+}
+
+func (r *RuleIndicator) source() PriceSource {
+	if r.Source == "" {
+		return PriceSourceOHLC4
+	}
+	return r.Source
+}
+
+func (r *RuleIndicator) clone() *RuleIndicator {
+	c := *r
+	c.Args = make(map[string]string)
+	for k, v := range r.Args {
+		c.Args[k] = v
+	}
+	return &c
+}
+
+// ruleParameterEnv resolves parameters in indicator arguments.
+type ruleParameterEnv map[string]float64
+
+func (e ruleParameterEnv) lookup(ident *ruleIdent, _ int) (float64, error) {
+	return e[ident.name], nil
+}
+
+// ruleHistoryEnv resolves parameters and indicator values in buy and
+// sell rules.
+type ruleHistoryEnv struct {
+	parameters map[string]float64
+	indicators map[string]string
+	history    []*IndicatorSet
+	current    int
+}
+
+func (e *ruleHistoryEnv) lookup(ident *ruleIdent, lookback int) (float64, error) {
+	if v, ok := e.parameters[ident.name]; ok {
+		return v, nil
+	}
+
+	value, err := indicatorValue(e.history, e.current-lookback, e.indicators[ident.name])
+	if err != nil {
+		return 0, err
+	}
+	if ident.component != "" {
+		return value.Component(ident.component)
+	}
+	return value.Value, nil
+}
+
+type compiledRule struct {
+	buy        *ruleExpression
+	sell       *ruleExpression
+	indicators []Indicator
+	names      map[string]string
+}
+
+// RuleStrategy is a strategy whose indicators and buy and sell rules
+// are defined in YAML instead of Go, for example:
+//
+//	indicators:
+//	- name: dema
+//	  type: dema
+//	  short_period: 10
+//	  long_period: 25
+//	- name: rsi
+//	  type: rsi
+//	  period: rsi_period
+//	- name: trend
+//	  type: ema
+//	  period: 50
+//	  tick_size_minutes: 240
+//	- name: price
+//	  type: price
+//	buy: dema > 0.001 and rsi < rsi_buy and price > trend
+//	sell: dema < -0.001 or rsi > 70
+//	rsi_period: 14
+//	rsi_buy: 30
+//	ranges:
+//	  rsi_buy: [20, 40]
+//
+// Rules refer to indicators by name, to their components as
+// name.component, and to the values of previous ticks as name[n].
+// Every other top level number is a parameter, and parameters with a
+// range are optimized.
+type RuleStrategy struct {
+	RuleIndicators []*RuleIndicator     `yaml:"indicators"`
+	BuyRule        string               `yaml:"buy"`
+	SellRule       string               `yaml:"sell"`
+	Ranges         map[string][]float64 `yaml:"ranges,omitempty"`
+	Parameters     map[string]float64   `yaml:",inline"`
+
+	strategy *TradingStrategyModel
+	compiled *compiledRule
+}
+
+// String returns the string representation of the strategy.
+func (e *RuleStrategy) String() string {
+	var params []string
+	for _, name := range e.parameterNames() {
+		params = append(params, fmt.Sprintf("%s=%g", name, e.Parameters[name]))
+	}
+	return fmt.Sprintf("Rule: buy: %s, sell: %s, parameters: %s",
+		e.BuyRule, e.SellRule, strings.Join(params, ", "))
+}
+
+// SetTradingStrategy sets the underlying trading strategy.
+func (e *RuleStrategy) SetTradingStrategy(t *TradingStrategyModel) {
+	e.strategy = t
+}
+
+func (e *RuleStrategy) parameterNames() []string {
+	var names []string
+	for name := range e.Parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate parses the strategy's rules and checks that every name they
+// use is defined.
+func (e *RuleStrategy) Validate() error {
+	e.compiled = nil
+	_, err := e.compile()
+	return err
+}
+
+func (e *RuleStrategy) compile() (*compiledRule, error) {
+	if e.compiled != nil {
+		return e.compiled, nil
+	}
+
+	used := make(map[string]bool)
+	for name, r := range e.Ranges {
+		if _, ok := e.Parameters[name]; !ok {
+			return nil, errors.Errorf("error: range for unknown parameter: %s", name)
+		}
+		if len(r) != 2 || r[0] > r[1] {
+			return nil, errors.Errorf("error: range for %s must be [min, max]", name)
+		}
+	}
+
+	c := &compiledRule{names: make(map[string]string)}
+	for _, r := range e.RuleIndicators {
+		if !ruleNamePattern.MatchString(r.Name) {
+			return nil, errors.Errorf("error: invalid indicator name: %q", r.Name)
+		}
+		if _, ok := e.Parameters[r.Name]; ok {
+			return nil, errors.Errorf("error: %s is both a parameter and an indicator", r.Name)
+		}
+		if _, ok := c.names[r.Name]; ok {
+			return nil, errors.Errorf("error: duplicate indicator: %s", r.Name)
+		}
+
+		indicator, err := e.newIndicator(r, used)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error creating indicator: %s", r.Name)
+		}
+		c.indicators = append(c.indicators, indicator)
+		c.names[r.Name] = indicator.Name()
+	}
+
+	var err error
+	for _, rule := range []struct {
+		name   string
+		source string
+		expr   **ruleExpression
+	}{
+		{"buy", e.BuyRule, &c.buy},
+		{"sell", e.SellRule, &c.sell},
+	} {
+		if strings.TrimSpace(rule.source) == "" {
+			return nil, errors.Errorf("error: the %s rule must be set", rule.name)
+		}
+		if *rule.expr, err = parseRuleExpression(rule.source); err != nil {
+			return nil, errors.Wrapf(err, "error parsing %s rule", rule.name)
+		}
+
+		for _, ident := range (*rule.expr).idents {
+			_, isParameter := e.Parameters[ident.name]
+			_, isIndicator := c.names[ident.name]
+			switch {
+			case isParameter && (ident.component != "" || ident.lookback != 0):
+				return nil, errors.Errorf("error: parameter %s has no components or history", ident)
+			case !isParameter && !isIndicator:
+				return nil, errors.Errorf("error: unknown name in %s rule: %s", rule.name, ident)
+			}
+			used[ident.name] = true
+		}
+	}
+
+	for _, name := range e.parameterNames() {
+		if !used[name] {
+			return nil, errors.Errorf("error: unused parameter: %s", name)
+		}
+	}
+
+	e.compiled = c
+	return c, nil
+}
+
+func (e *RuleStrategy) newIndicator(r *RuleIndicator, used map[string]bool) (Indicator, error) {
+	t, ok := ruleIndicatorTypes[r.Type]
+	if !ok {
+		return nil, errors.Errorf("error: unknown indicator type: %s", r.Type)
+	}
+	if r.Mode != "" && r.Type != IndicatorRSI {
+		return nil, errors.Errorf("error: %s indicators don't have a mode", r.Type)
+	}
+	if r.Source != "" {
+		if !t.source {
+			return nil, errors.Errorf("error: %s indicators don't have a price source", r.Type)
+		}
+		if _, err := ParsePriceSource(string(r.Source)); err != nil {
+			return nil, err
+		}
+	}
+	if err := (RSIOptions{RSIMode: r.Mode}).Validate(); err != nil {
+		return nil, err
+	}
+
+	args := make(map[string]float64)
+	for _, name := range t.args {
+		source, ok := r.Args[name]
+		if !ok {
+			return nil, errors.Errorf("error: missing argument: %s", name)
+		}
+		expr, err := parseRuleExpression(source)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error parsing argument: %s", name)
+		}
+		for _, ident := range expr.idents {
+			if _, ok := e.Parameters[ident.name]; !ok || ident.component != "" || ident.lookback != 0 {
+				return nil, errors.Errorf("error: argument %s uses an unknown parameter: %s", name, ident)
+			}
+			used[ident.name] = true
+		}
+		if args[name], err = expr.eval(ruleParameterEnv(e.Parameters)); err != nil {
+			return nil, errors.Wrapf(err, "error evaluating argument: %s", name)
+		}
+		if strings.HasSuffix(name, "period") && ruleUint(args[name]) < 1 {
+			return nil, errors.Errorf("error: %s must be at least 1", name)
+		}
+	}
+	for name := range r.Args {
+		if _, ok := args[name]; !ok {
+			return nil, errors.Errorf("error: unknown argument: %s", name)
+		}
+	}
+
+	if r.TickSizeMinutes > 0 {
+		return NewTimeframeIndicator(t.new(args, r), r.TickSizeMinutes), nil
+	}
+	return t.new(args, r), nil
+}
+
+// Indicators returns the indicators returned by the strategy.
+func (e *RuleStrategy) Indicators() []Indicator {
+	if _, err := e.compile(); err != nil {
+		return nil
+	}
+
+	// Indicators hold state, so every call gets new ones.
+	var indicators []Indicator
+	for _, r := range e.RuleIndicators {
+		indicator, err := e.newIndicator(r, make(map[string]bool))
+		if err != nil {
+			return nil
+		}
+		indicators = append(indicators, indicator)
+	}
+	return indicators
+}
+
+func (e *RuleStrategy) eval(rule func(*compiledRule) *ruleExpression,
+	history []*IndicatorSet, current int) (bool, error) {
+	c, err := e.compile()
+	if err != nil {
+		return false, err
+	}
+
+	v, err := rule(c).eval(&ruleHistoryEnv{
+		parameters: e.Parameters,
+		indicators: c.names,
+		history:    history,
+		current:    current,
+	})
+	if err != nil {
+		return false, err
+	}
+	return v != 0, nil
+}
+
+// Buy determines whether the currency should be bought using the
+// indicator history.
+func (e *RuleStrategy) Buy(history []*IndicatorSet, current int) (bool, error) {
+	return e.eval(func(c *compiledRule) *ruleExpression { return c.buy }, history, current)
+}
+
+// Sell determines whether the currency should be sold using the
+// indicator history.
+func (e *RuleStrategy) Sell(history []*IndicatorSet, current int) (bool, error) {
+	return e.eval(func(c *compiledRule) *ruleExpression { return c.sell }, history, current)
+}
+
+// rangedParameters returns the names of the optimized parameters.
+func (e *RuleStrategy) rangedParameters() []string {
+	var names []string
+	for name := range e.Ranges {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Rand creates a random version of the strategy.
+func (e *RuleStrategy) Rand(rng *rand.Rand) {
+	for _, name := range e.rangedParameters() {
+		r := e.Ranges[name]
+		e.Parameters[name] = r[0] + rng.Float64()*(r[1]-r[0])
+	}
+	e.compiled = nil
+}
+
+// Clone returns a clone of the current strategy.
+func (e *RuleStrategy) Clone() StrategyGenome {
+	c := &RuleStrategy{
+		BuyRule:    e.BuyRule,
+		SellRule:   e.SellRule,
+		Ranges:     make(map[string][]float64),
+		Parameters: make(map[string]float64),
+	}
+	for _, r := range e.RuleIndicators {
+		c.RuleIndicators = append(c.RuleIndicators, r.clone())
+	}
+	for name, r := range e.Ranges {
+		c.Ranges[name] = append([]float64(nil), r...)
+	}
+	for name, v := range e.Parameters {
+		c.Parameters[name] = v
+	}
+	return c
+}
+
+// Mutate mutates the underlying strategy.
+func (e *RuleStrategy) Mutate(rng *rand.Rand) {
+	mutateProb := 0.8
+
+	for _, name := range e.rangedParameters() {
+		if rng.Float64() < mutateProb {
+			r := e.Ranges[name]
+			x := e.Parameters[name] + rng.NormFloat64()*(r[1]-r[0])/10
+			e.Parameters[name] = math.Max(r[0], math.Min(r[1], x))
+		}
+	}
+	e.compiled = nil
+}
+
+// Crossover crosses over a RuleStrategy with a different one.
+func (e *RuleStrategy) Crossover(m StrategyGenome,
+	r *rand.Rand) (StrategyGenome, StrategyGenome) {
+	mate := m.(*RuleStrategy)
+
+	names := e.rangedParameters()
+	var p1, p2 []float64
+	for _, name := range names {
+		p1 = append(p1, e.Parameters[name])
+		p2 = append(p2, mate.Parameters[name])
+	}
+
+	c1, c2 := gago.CrossUniformFloat64(p1, p2, r)
+
+	child := func(c []float64) *RuleStrategy {
+		s := e.Clone().(*RuleStrategy)
+		for i, name := range names {
+			s.Parameters[name] = c[i]
+		}
+		return s
+	}
+
+	return child(c1), child(c2)
+}
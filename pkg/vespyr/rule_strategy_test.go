@@ -0,0 +1,190 @@
+package vespyr_test
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/DavidHuie/vespyr/pkg/vespyr"
+	"github.com/stretchr/testify/assert"
+)
+
+const ruleStrategyFile = `
+product: BTC-USD
+tick_size_minutes: 15
+trading_strategy: rule
+trading_strategy_data:
+  indicators:
+  - name: dema
+    type: dema
+    short_period: 10
+    long_period: 25
+  - name: rsi
+    type: rsi
+    period: rsi_period
+    mode: wilder
+  - name: bands
+    type: bollinger
+    period: rsi_period + 6
+    deviations: 2
+  buy: dema > 0.001 and rsi < rsi_buy and bands.percent-b < 0.2
+  sell: crosses_below(dema, 0) or rsi[1] > 100 - rsi_buy
+  rsi_period: 14
+  rsi_buy: 30
+  ranges:
+    rsi_buy: [20, 40]
+`
+
+func TestRuleStrategy(t *testing.T) {
+	model, err := vespyr.ParseStrategyFile([]byte(ruleStrategyFile))
+	assert.NoError(t, err)
+	assert.Equal(t, vespyr.TradingStrategyRule, model.TradingStrategy)
+
+	s, err := model.Strategy()
+	assert.NoError(t, err)
+	strategy := s.(*vespyr.RuleStrategy)
+	assert.Equal(t, map[string]float64{"rsi_period": 14, "rsi_buy": 30}, strategy.Parameters)
+	assert.Equal(t, "Rule: buy: dema > 0.001 and rsi < rsi_buy and bands.percent-b < 0.2, "+
+		"sell: crosses_below(dema, 0) or rsi[1] > 100 - rsi_buy, parameters: rsi_buy=30, rsi_period=14",
+		strategy.String())
+
+	var names []string
+	for _, indicator := range strategy.Indicators() {
+		names = append(names, indicator.Name())
+	}
+	assert.Equal(t, []string{"dema-10-25", "rsi-wilder-14-close", "bollinger-20-2"}, names)
+
+	set := func(dema, rsi, percentB float64) *vespyr.IndicatorSet {
+		return &vespyr.IndicatorSet{Values: []*vespyr.IndicatorValue{
+			{IndicatorName: "dema-10-25", Value: dema},
+			{IndicatorName: "rsi-wilder-14-close", Value: rsi},
+			{IndicatorName: "bollinger-20-2", Components: []*vespyr.IndicatorComponent{
+				{Name: vespyr.BollingerPercentB, Value: percentB},
+			}},
+		}}
+	}
+	history := []*vespyr.IndicatorSet{
+		set(.002, 25, .1),
+		set(.002, 25, .5),
+		set(.001, 75, .1),
+		set(-.001, 50, .5),
+		set(-.002, 50, .5),
+	}
+
+	for i, expected := range []struct{ buy, sell bool }{
+		{true, false},
+		{false, false},
+		{false, false},
+		{false, true},
+		{false, false},
+	} {
+		buy, err := strategy.Buy(history, i)
+		assert.NoError(t, err)
+		assert.Equal(t, expected.buy, buy, "buy %d", i)
+		if i == 0 {
+			continue
+		}
+		sell, err := strategy.Sell(history, i)
+		assert.NoError(t, err)
+		assert.Equal(t, expected.sell, sell, "sell %d", i)
+	}
+
+	// The sell rule looks at the previous tick.
+	_, err = strategy.Sell(history, 0)
+	assert.Equal(t, vespyr.ErrNotEnoughData, err)
+
+	// Parameters are swept like any other strategy's.
+	sweeper := vespyr.NewSweeper(time.Now(), time.Now(), model, new(vespyr.MockBackend), 1, 1)
+	swept, err := sweeper.Model(map[string]float64{"rsi_period": 7})
+	assert.NoError(t, err)
+	s, err = swept.Strategy()
+	assert.NoError(t, err)
+	assert.Equal(t, "rsi-wilder-7-close", s.Indicators()[1].Name())
+	assert.Equal(t, "bollinger-13-2", s.Indicators()[2].Name())
+	_, err = sweeper.Model(map[string]float64{"unknown": 1})
+	assert.Error(t, err)
+}
+
+func TestRuleStrategyGenome(t *testing.T) {
+	model, err := vespyr.ParseStrategyFile([]byte(ruleStrategyFile))
+	assert.NoError(t, err)
+	s, err := model.Strategy()
+	assert.NoError(t, err)
+	strategy := s.(*vespyr.RuleStrategy)
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		strategy.Rand(rng)
+		assert.True(t, strategy.Parameters["rsi_buy"] >= 20 && strategy.Parameters["rsi_buy"] <= 40)
+		strategy.Mutate(rng)
+		assert.True(t, strategy.Parameters["rsi_buy"] >= 20 && strategy.Parameters["rsi_buy"] <= 40)
+		assert.Equal(t, float64(14), strategy.Parameters["rsi_period"])
+	}
+
+	clone := strategy.Clone().(*vespyr.RuleStrategy)
+	assert.Equal(t, strategy.String(), clone.String())
+	clone.Parameters["rsi_buy"] = 21
+	clone.RuleIndicators[0].Args["long_period"] = "30"
+	assert.NotEqual(t, float64(21), strategy.Parameters["rsi_buy"])
+	assert.Equal(t, "25", strategy.RuleIndicators[0].Args["long_period"])
+
+	c1, c2 := strategy.Crossover(clone, rng)
+	for _, c := range []vespyr.StrategyGenome{c1, c2} {
+		child := c.(*vespyr.RuleStrategy)
+		assert.True(t, child.Parameters["rsi_buy"] >= 21 &&
+			child.Parameters["rsi_buy"] <= strategy.Parameters["rsi_buy"])
+		assert.NoError(t, child.Validate())
+	}
+
+	assert.NoError(t, model.SetStrategy(clone))
+	s, err = model.Strategy()
+	assert.NoError(t, err)
+	assert.Equal(t, clone.String(), s.String())
+	assert.Equal(t, "dema-10-30", s.Indicators()[0].Name())
+}
+
+func TestRuleStrategyValidate(t *testing.T) {
+	indicators := []*vespyr.RuleIndicator{
+		{Name: "rsi", Type: vespyr.IndicatorRSI, Args: map[string]string{"period": "14"}},
+	}
+
+	for name, strategy := range map[string]*vespyr.RuleStrategy{
+		"missing rule":     {RuleIndicators: indicators, BuyRule: "rsi < 30"},
+		"syntax":           {RuleIndicators: indicators, BuyRule: "rsi <", SellRule: "rsi > 70"},
+		"unknown name":     {RuleIndicators: indicators, BuyRule: "ema < 30", SellRule: "rsi > 70"},
+		"unused parameter": {RuleIndicators: indicators, BuyRule: "rsi < 30", SellRule: "rsi > 70", Parameters: map[string]float64{"x": 1}},
+		"parameter history": {RuleIndicators: indicators, BuyRule: "rsi < x[1]", SellRule: "rsi > 70",
+			Parameters: map[string]float64{"x": 1}},
+		"bad range": {RuleIndicators: indicators, BuyRule: "rsi < x", SellRule: "rsi > 70",
+			Parameters: map[string]float64{"x": 1}, Ranges: map[string][]float64{"x": {2, 1}}},
+		"unknown type": {RuleIndicators: []*vespyr.RuleIndicator{{Name: "rsi", Type: "unknown"}},
+			BuyRule: "rsi < 30", SellRule: "rsi > 70"},
+		"missing argument": {RuleIndicators: []*vespyr.RuleIndicator{{Name: "rsi", Type: vespyr.IndicatorRSI}},
+			BuyRule: "rsi < 30", SellRule: "rsi > 70"},
+		"zero period": {RuleIndicators: []*vespyr.RuleIndicator{
+			{Name: "rsi", Type: vespyr.IndicatorRSI, Args: map[string]string{"period": "0"}}},
+			BuyRule: "rsi < 30", SellRule: "rsi > 70"},
+		"unknown argument": {RuleIndicators: []*vespyr.RuleIndicator{
+			{Name: "rsi", Type: vespyr.IndicatorRSI, Args: map[string]string{"period": "14", "length": "2"}}},
+			BuyRule: "rsi < 30", SellRule: "rsi > 70"},
+		"mode": {RuleIndicators: []*vespyr.RuleIndicator{
+			{Name: "ema", Type: vespyr.IndicatorEMA, Mode: vespyr.RSIModeWilder, Args: map[string]string{"period": "14"}}},
+			BuyRule: "ema < 30", SellRule: "ema > 70"},
+		"source": {RuleIndicators: []*vespyr.RuleIndicator{
+			{Name: "ema", Type: vespyr.IndicatorEMA, Source: vespyr.PriceSourceClose, Args: map[string]string{"period": "14"}}},
+			BuyRule: "ema < 30", SellRule: "ema > 70"},
+		"duplicate indicator": {RuleIndicators: append(indicators, indicators[0]),
+			BuyRule: "rsi < 30", SellRule: "rsi > 70"},
+	} {
+		assert.Error(t, strategy.Validate(), name)
+	}
+
+	strategy := &vespyr.RuleStrategy{
+		RuleIndicators: append(indicators, &vespyr.RuleIndicator{
+			Name: "price", Type: "price", Source: vespyr.PriceSourceClose}),
+		BuyRule:  "not (rsi >= 30) and abs(price - 10) <= 1",
+		SellRule: "crosses_above(rsi, 70)",
+	}
+	assert.NoError(t, strategy.Validate())
+	assert.Equal(t, "sma-1-close", strategy.Indicators()[1].Name())
+}
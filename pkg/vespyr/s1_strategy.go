@@ -12,6 +12,22 @@ const (
 	rsiPeriod = 14
 )
 
+func init() {
+	RegisterStrategyType(&StrategyType{
+		Name:        TradingStrategyS1,
+		Description: "trades DEMA crossovers filtered by the RSI",
+		New:         func() StrategyInterface { return &S1Strategy{} },
+		Parameters: []*StrategyParameter{
+			{Name: "ema_short_period", Description: "the EMA short period", Default: 10, Min: 0, Max: 50, Integer: true},
+			{Name: "ema_long_period", Description: "the EMA long period", Default: 25, Min: 0, Max: 100, Integer: true},
+			{Name: "ema_up_threshold", Description: "the EMA up threshold", Default: 0, Min: 0, Max: .002},
+			{Name: "ema_down_threshold", Description: "the EMA down threshold", Default: 0, Min: -.002, Max: 0},
+			{Name: "rsi_exit_threshold", Description: "the RSI exit threshold", Default: 100, Min: 0, Max: 100},
+			{Name: "rsi_entrance_threshold", Description: "the RSI entrance threshold", Default: 0, Min: 0, Max: 100},
+		},
+	})
+}
+
 // S1Strategy is a custom trading strategy.
 type S1Strategy struct {
 	EMAShortPeriod       uint    `yaml:"ema_short_period"`
@@ -20,6 +36,8 @@ type S1Strategy struct {
 	EMADownThreshold     float64 `yaml:"ema_down_threshold"`
 	RSIExitThreshold     float64 `yaml:"rsi_exit_threshold"`
 	RSIEntranceThreshold float64 `yaml:"rsi_entrance_threshold"`
+	RSIOptions           `yaml:",inline"`
+	TimeframeOptions     `yaml:",inline"`
 
 	strategy *TradingStrategyModel
 }
@@ -31,99 +49,112 @@ func (s *S1Strategy) SetTradingStrategy(t *TradingStrategyModel) {
 
 // String returns the string representation of the strategy.
 func (s *S1Strategy) String() string {
-	return fmt.Sprintf("S1: ema short period: %d, ema long period: %d, ema up threshold: %f, ema down threshold: %f, rsi entrance threshold: %f, rsi exit threshold: %f",
+	str := fmt.Sprintf("S1: ema short period: %d, ema long period: %d, ema up threshold: %f, ema down threshold: %f, rsi entrance threshold: %f, rsi exit threshold: %f",
 		s.EMAShortPeriod, s.EMALongPeriod, s.EMAUpThreshold, s.EMADownThreshold,
 		s.RSIEntranceThreshold, s.RSIExitThreshold,
 	)
+	if s.RSIMode != "" || s.RSIPriceSource != "" {
+		str += fmt.Sprintf(", rsi mode: %s, rsi price source: %s", s.RSIMode, s.RSIPriceSource)
+	}
+	if s.TimeframeMinutes != 0 {
+		str += fmt.Sprintf(", timeframe: %dm", s.TimeframeMinutes)
+	}
+	return str
+}
+
+func (s *S1Strategy) demaIndicator() Indicator {
+	return s.timeframe(NewDEMAIndicator(s.EMAShortPeriod, s.EMALongPeriod))
+}
+
+func (s *S1Strategy) rsiIndicator() Indicator {
+	return s.timeframe(s.newRSIIndicator(rsiPeriod))
 }
 
 // Indicators returns the indicators returned by the strategy.
 func (s *S1Strategy) Indicators() []Indicator {
 	var indicators []Indicator
-	indicators = append(indicators, NewDEMAIndicator(s.EMAShortPeriod, s.EMALongPeriod))
-	indicators = append(indicators, NewEMAIndicator(s.EMAShortPeriod))
-	indicators = append(indicators, NewEMAIndicator(s.EMALongPeriod))
-	indicators = append(indicators, NewRSIIndicator(rsiPeriod))
+	indicators = append(indicators, s.demaIndicator())
+	indicators = append(indicators, s.timeframe(NewEMAIndicator(s.EMAShortPeriod)))
+	indicators = append(indicators, s.timeframe(NewEMAIndicator(s.EMALongPeriod)))
+	indicators = append(indicators, s.rsiIndicator())
 	return indicators
 }
 
+// values returns the DEMA and RSI values at a position in the
+// history.
+func (s *S1Strategy) values(history []*IndicatorSet, current int) (*IndicatorValue, *IndicatorValue, error) {
+	dema, err := indicatorValue(history, current, s.demaIndicator().Name())
+	if err != nil {
+		return nil, nil, err
+	}
+	rsi, err := indicatorValue(history, current, s.rsiIndicator().Name())
+	if err != nil {
+		return nil, nil, err
+	}
+	return dema, rsi, nil
+}
+
 // Buy determines whether the currency should be bought using the
 // indicator history.
 func (s *S1Strategy) Buy(history []*IndicatorSet, current int) (bool, error) {
-	if len(history)-1 < current {
-		return false, ErrNotEnoughData
+	dema, rsi, err := s.values(history, current)
+	if err != nil {
+		return false, err
 	}
 
 	// Don't reenter a trade if we've spent multiple ticks on
 	// it. This occurs when we exit a trade via RSI while still
 	// having short EMA > large EMA.
-	if current-1 >= 0 {
-		lastDEMA := history[current-1].Values[0]
-		lastRSI := history[current-1].Values[3]
+	if lastDEMA, lastRSI, err := s.values(history, current-1); err == nil {
 		if lastDEMA.Value >= s.EMAUpThreshold && lastRSI.Value >= s.RSIExitThreshold {
 			return false, nil
 		}
 	}
 
-	currentValues := history[current].Values
-	rsi := currentValues[3]
-	dema := currentValues[0]
-
 	logrus.Debugf("s1 buy rsi: %f, rsi entrance threshold: %f, dema: %f, dema up threshold: %f",
 		rsi.Value, s.RSIEntranceThreshold, dema.Value, s.EMAUpThreshold)
 
 	// Enter the trade early if RSI is at the right threshold.
-	if rsi == nil {
-		return false, ErrNotEnoughData
-	}
 	if rsi.Value <= s.RSIEntranceThreshold {
 		return true, nil
 	}
 
-	if dema == nil {
-		return false, ErrNotEnoughData
-	}
 	return (dema.Value > s.EMAUpThreshold), nil
 }
 
 // Sell determines whether the currency should be sold using the
 // indicator history.
 func (s *S1Strategy) Sell(history []*IndicatorSet, current int) (bool, error) {
-	if len(history)-1 < current {
-		return false, ErrNotEnoughData
+	dema, rsi, err := s.values(history, current)
+	if err != nil {
+		return false, err
 	}
 
-	currentValues := history[current].Values
-	dema := currentValues[0]
-	rsi := currentValues[3]
-
 	logrus.Debugf("s1 sell rsi: %f, rsi exit threshold: %f, dema: %f, dema down threshold: %f",
 		rsi.Value, s.RSIExitThreshold, dema.Value, s.EMADownThreshold)
 
 	// Exit the trade early if the RSI value is larger than some
 	// threshold.
-	if rsi == nil {
-		return false, ErrNotEnoughData
-	}
 	if rsi.Value >= s.RSIExitThreshold {
 		return true, nil
 	}
 
-	if dema == nil {
-		return false, ErrNotEnoughData
-	}
-
 	return (dema.Value < s.EMADownThreshold), nil
 }
 
-// Rand creates a random version of the strategy.
+// Rand creates a random version of the strategy within its registered
+// parameters.
 func (s *S1Strategy) Rand(rng *rand.Rand) {
-	s.EMAShortPeriod = uint(rng.Float64() * 50)
-	s.EMALongPeriod = 2 * s.EMAShortPeriod
-	s.EMAUpThreshold = rng.Float64() * .002
-	s.EMADownThreshold = -s.EMAUpThreshold
-	s.RSIExitThreshold = rng.Float64() * 100
-	s.RSIEntranceThreshold = rng.Float64() * 100
+	s.EMAShortPeriod = uint(s1Parameter("ema_short_period").Rand(rng))
+	s.EMALongPeriod = uint(s1Parameter("ema_long_period").Clamp(2 * float64(s.EMAShortPeriod)))
+	s.EMAUpThreshold = s1Parameter("ema_up_threshold").Rand(rng)
+	s.EMADownThreshold = s1Parameter("ema_down_threshold").Clamp(-s.EMAUpThreshold)
+	s.RSIExitThreshold = s1Parameter("rsi_exit_threshold").Rand(rng)
+	s.RSIEntranceThreshold = s1Parameter("rsi_entrance_threshold").Rand(rng)
+}
+
+func s1Parameter(name string) *StrategyParameter {
+	return strategyParameter(TradingStrategyS1, name)
 }
 
 // Clone returns a clone of the current strategy.
@@ -135,31 +166,27 @@ func (s *S1Strategy) Clone() StrategyGenome {
 		EMADownThreshold:     s.EMADownThreshold,
 		RSIExitThreshold:     s.RSIExitThreshold,
 		RSIEntranceThreshold: s.RSIEntranceThreshold,
+		RSIOptions:           s.RSIOptions,
+		TimeframeOptions:     s.TimeframeOptions,
 	}
 }
 
-// Mutate mutates the underlying strategy.
+// Mutate mutates the underlying strategy within its registered
+// parameters.
 func (s *S1Strategy) Mutate(rng *rand.Rand) {
 	mutateProb := 0.8
 
-	thresholds := []float64{s.EMADownThreshold, s.EMAUpThreshold}
-	gago.MutNormalFloat64(thresholds, mutateProb, rng)
-	s.EMADownThreshold, s.EMAUpThreshold = thresholds[0], thresholds[1]
-
 	if rng.Float64() < mutateProb {
-		x := int(s.EMAShortPeriod)
-		x += int(float64(s.EMAShortPeriod) * rng.NormFloat64())
-		if x > 0 {
-			s.EMAShortPeriod = uint(x) % 100
-		}
+		s.EMADownThreshold = s1Parameter("ema_down_threshold").Mutate(rng, s.EMADownThreshold)
 	}
-
 	if rng.Float64() < mutateProb {
-		x := int(s.EMALongPeriod)
-		x += int(float64(s.EMALongPeriod) * rng.NormFloat64())
-		if x > 0 {
-			s.EMALongPeriod = uint(x) % 100
-		}
+		s.EMAUpThreshold = s1Parameter("ema_up_threshold").Mutate(rng, s.EMAUpThreshold)
+	}
+	if rng.Float64() < mutateProb {
+		s.EMAShortPeriod = uint(s1Parameter("ema_short_period").Mutate(rng, float64(s.EMAShortPeriod)))
+	}
+	if rng.Float64() < mutateProb {
+		s.EMALongPeriod = uint(s1Parameter("ema_long_period").Mutate(rng, float64(s.EMALongPeriod)))
 	}
 
 	if s.EMALongPeriod < s.EMAShortPeriod {
@@ -167,25 +194,10 @@ func (s *S1Strategy) Mutate(rng *rand.Rand) {
 	}
 
 	if rng.Float64() < mutateProb {
-		x := s.RSIExitThreshold
-		x += s.RSIExitThreshold * rng.NormFloat64()
-		if x >= 0 {
-			s.RSIExitThreshold = x
-		}
-		if x > 100 {
-			s.RSIExitThreshold = 100
-		}
+		s.RSIExitThreshold = s1Parameter("rsi_exit_threshold").Mutate(rng, s.RSIExitThreshold)
 	}
-
 	if rng.Float64() < mutateProb {
-		x := s.RSIEntranceThreshold
-		x += s.RSIEntranceThreshold * rng.NormFloat64()
-		if x >= 0 {
-			s.RSIEntranceThreshold = x
-		}
-		if x > 100 {
-			s.RSIEntranceThreshold = 100
-		}
+		s.RSIEntranceThreshold = s1Parameter("rsi_entrance_threshold").Mutate(rng, s.RSIEntranceThreshold)
 	}
 }
 
@@ -211,6 +223,8 @@ func (s *S1Strategy) Crossover(m StrategyGenome,
 		EMADownThreshold:     c1[3],
 		RSIExitThreshold:     c1[4],
 		RSIEntranceThreshold: c1[5],
+		RSIOptions:           s.RSIOptions,
+		TimeframeOptions:     s.TimeframeOptions,
 	}
 	if s1.EMALongPeriod < s1.EMAShortPeriod {
 		s1.EMAShortPeriod = s1.EMALongPeriod
@@ -223,6 +237,8 @@ func (s *S1Strategy) Crossover(m StrategyGenome,
 		EMADownThreshold:     c2[3],
 		RSIExitThreshold:     c2[4],
 		RSIEntranceThreshold: c2[5],
+		RSIOptions:           s.RSIOptions,
+		TimeframeOptions:     s.TimeframeOptions,
 	}
 	if s2.EMALongPeriod < s2.EMAShortPeriod {
 		s2.EMAShortPeriod = s2.EMALongPeriod
@@ -0,0 +1,184 @@
+package vespyr
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// SpreadModeRatio measures the spread as the ratio of the two
+	// products' prices.
+	SpreadModeRatio = "ratio"
+	// SpreadModeResidual measures the spread as the residual of a
+	// rolling regression of one product's log price on the
+	// other's, which is stationary when the products are
+	// cointegrated.
+	SpreadModeResidual = "residual"
+
+	// SpreadZScore is the number of standard deviations that the
+	// spread is from its mean, which is the indicator's primary
+	// value.
+	SpreadZScore = "z-score"
+	// SpreadValue is the current spread.
+	SpreadValue = "spread"
+	// SpreadMean is the mean spread over the indicator's period.
+	SpreadMean = "mean"
+	// SpreadStdDev is the standard deviation of the spread over
+	// the indicator's period.
+	SpreadStdDev = "stddev"
+	// SpreadHedgeRatio is the regression's slope, or how much of
+	// the second product moves with one unit of the first.
+	SpreadHedgeRatio = "hedge-ratio"
+)
+
+// PairIndicator is an indicator calculated from the candlesticks of
+// two products. The second product's candlestick for a tick is added
+// before the first's.
+type PairIndicator interface {
+	Indicator
+	PairProduct() Product
+	AddPairCandlestick(*CandlestickModel) error
+}
+
+// SpreadIndicator calculates the rolling z-score of the spread between
+// the closing prices of a product and a second one. Ticks where the
+// second product has no candlestick have no value.
+type SpreadIndicator struct {
+	pair      Product
+	mode      string
+	period    uint
+	spreads   *rollingWindow
+	logPrices *rollingWindow
+	logPairs  *rollingWindow
+	pairPrice float64
+	pairTime  time.Time
+	missing   bool
+	value     *IndicatorValue
+}
+
+// NewSpreadIndicator returns a new SpreadIndicator.
+func NewSpreadIndicator(pair Product, mode string, period uint) *SpreadIndicator {
+	return &SpreadIndicator{
+		pair:      pair,
+		mode:      mode,
+		period:    period,
+		spreads:   newRollingWindow(period),
+		logPrices: newRollingWindow(period),
+		logPairs:  newRollingWindow(period),
+	}
+}
+
+// Name returns the name of the indicator.
+func (s *SpreadIndicator) Name() string {
+	return fmt.Sprintf("%s-%s-%s-%d", IndicatorSpread, s.mode, s.pair, s.period)
+}
+
+// PairProduct returns the second product.
+func (s *SpreadIndicator) PairProduct() Product {
+	return s.pair
+}
+
+// AddPairCandlestick adds a candlestick of the second product.
+func (s *SpreadIndicator) AddPairCandlestick(c *CandlestickModel) error {
+	if c.Volume == 0 {
+		return nil
+	}
+	if c.Close <= 0 {
+		return errors.Errorf("error: invalid %s price: %f", c.Product, c.Close)
+	}
+	s.pairPrice = c.Close
+	s.pairTime = c.StartTime
+	return nil
+}
+
+// AddCandlestick adds a candlestick to the indicator.
+func (s *SpreadIndicator) AddCandlestick(c *CandlestickModel) error {
+	if c.Volume == 0 {
+		return nil
+	}
+	if c.Close <= 0 {
+		return errors.Errorf("error: invalid %s price: %f", c.Product, c.Close)
+	}
+	s.missing = !s.pairTime.Equal(c.StartTime)
+	if s.missing {
+		return nil
+	}
+
+	value := &IndicatorValue{
+		Time:          c.StartTime,
+		IndicatorName: s.Name(),
+	}
+
+	var spread, mean, stddev, hedgeRatio float64
+	switch s.mode {
+	case SpreadModeRatio:
+		s.spreads.add(c.Close / s.pairPrice)
+		if !s.spreads.full() {
+			return nil
+		}
+		spread, mean, stddev = c.Close/s.pairPrice, s.spreads.mean(), s.spreads.stddev()
+		hedgeRatio = 1
+	case SpreadModeResidual:
+		s.logPrices.add(math.Log(c.Close))
+		s.logPairs.add(math.Log(s.pairPrice))
+		if !s.logPrices.full() {
+			return nil
+		}
+		spread, stddev, hedgeRatio = s.residual()
+	default:
+		return errors.Errorf("error: unknown spread mode: %s", s.mode)
+	}
+
+	value.Value = 0
+	if stddev > 0 {
+		value.Value = (spread - mean) / stddev
+	}
+	value.Components = []*IndicatorComponent{
+		{Name: SpreadZScore, Value: value.Value},
+		{Name: SpreadValue, Value: spread},
+		{Name: SpreadMean, Value: mean},
+		{Name: SpreadStdDev, Value: stddev},
+		{Name: SpreadHedgeRatio, Value: hedgeRatio},
+	}
+	s.value = value
+
+	return nil
+}
+
+// residual regresses the log prices on the second product's log prices
+// over the period, returning the last residual, the residuals'
+// standard deviation and the slope. Residuals of a least squares fit
+// have a mean of zero.
+func (s *SpreadIndicator) residual() (float64, float64, float64) {
+	meanPrice, meanPair := s.logPrices.mean(), s.logPairs.mean()
+
+	var covariance, variance float64
+	for i, pair := range s.logPairs.values {
+		covariance += (s.logPrices.values[i] - meanPrice) * (pair - meanPair)
+		variance += (pair - meanPair) * (pair - meanPair)
+	}
+	var slope float64
+	if variance > 0 {
+		slope = covariance / variance
+	}
+	intercept := meanPrice - slope*meanPair
+
+	var residual, squares float64
+	for i, pair := range s.logPairs.values {
+		residual = s.logPrices.values[i] - intercept - slope*pair
+		squares += residual * residual
+	}
+
+	return residual, math.Sqrt(squares / float64(len(s.logPairs.values))), slope
+}
+
+// Value returns the z-score of the last spread.
+func (s *SpreadIndicator) Value() (*IndicatorValue, error) {
+	if s.missing || s.value == nil {
+		return nil, ErrNotEnoughData
+	}
+	return s.value, nil
+}
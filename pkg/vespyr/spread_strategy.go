@@ -0,0 +1,217 @@
+package vespyr
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/MaxHalford/gago"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	RegisterStrategyType(&StrategyType{
+		Name:        TradingStrategySpread,
+		Description: "holds whichever of two products is cheap relative to the other when their spread stretches",
+		New:         func() StrategyInterface { return &SpreadStrategy{} },
+		Parameters: []*StrategyParameter{
+			{Name: "period", Description: "the spread's rolling window", Default: 50, Min: 10, Max: 200, Integer: true},
+			{Name: "entry_z_score", Description: "the z-score beyond which to hold the cheap product", Default: 2, Min: 1, Max: 3},
+			{Name: "exit_z_score", Description: "the z-score within which to hold the budget", Default: .5, Min: 0, Max: 1},
+		},
+	})
+}
+
+// PairStrategyInterface is a strategy that trades two products. Rather
+// than buying and selling one product, it picks which product to hold.
+type PairStrategyInterface interface {
+	StrategyInterface
+	PairProduct() Product
+	// Position returns the product that should be held given the
+	// one that's held now. An empty product holds the budget.
+	Position(history []*IndicatorSet, current int, held Product) (Product, error)
+}
+
+// SpreadStrategy trades the spread between the model's product and a
+// second one, such as ETH-USD against BTC-USD. Exchanges don't allow
+// short selling, so instead of shorting whichever product is rich
+// relative to the other, it holds the cheap one: the model's product
+// when the spread's z-score falls to -EntryZScore and the pair product
+// when it rises to EntryZScore. The position is sold once the z-score
+// reverts to within ExitZScore of zero.
+type SpreadStrategy struct {
+	// Pair defaults to BTC-USD and Mode to ratio.
+	Pair        Product `yaml:"pair_product,omitempty"`
+	Mode        string  `yaml:"mode,omitempty"`
+	Period      uint    `yaml:"period"`
+	EntryZScore float64 `yaml:"entry_z_score"`
+	ExitZScore  float64 `yaml:"exit_z_score"`
+
+	strategy *TradingStrategyModel
+}
+
+// Validate validates the strategy's parameters.
+func (s *SpreadStrategy) Validate() error {
+	if _, ok := ProductToMetadata[s.PairProduct()]; !ok {
+		return errors.Errorf("error: unknown pair product: %s", s.Pair)
+	}
+	if mode := s.mode(); mode != SpreadModeRatio && mode != SpreadModeResidual {
+		return errors.Errorf("error: unknown spread mode: %s", s.Mode)
+	}
+	if s.Period < 2 {
+		return errors.Errorf("error: spread period must be at least 2: %d", s.Period)
+	}
+	if s.EntryZScore <= 0 || s.ExitZScore < 0 || s.ExitZScore >= s.EntryZScore {
+		return errors.Errorf("error: exit z-score %f must be between 0 and the entry z-score %f",
+			s.ExitZScore, s.EntryZScore)
+	}
+	return nil
+}
+
+// String returns the string representation of the strategy.
+func (s *SpreadStrategy) String() string {
+	return fmt.Sprintf("Spread: pair: %s, mode: %s, period: %d, entry z-score: %f, exit z-score: %f",
+		s.PairProduct(), s.mode(), s.Period, s.EntryZScore, s.ExitZScore)
+}
+
+// SetTradingStrategy sets the underlying trading strategy.
+func (s *SpreadStrategy) SetTradingStrategy(t *TradingStrategyModel) {
+	s.strategy = t
+}
+
+// PairProduct returns the product that the model's product is traded
+// against.
+func (s *SpreadStrategy) PairProduct() Product {
+	if s.Pair == "" {
+		return ProductBTCUSD
+	}
+	return s.Pair
+}
+
+func (s *SpreadStrategy) mode() string {
+	if s.Mode == "" {
+		return SpreadModeRatio
+	}
+	return s.Mode
+}
+
+// Indicators returns the indicators used by the strategy.
+func (s *SpreadStrategy) Indicators() []Indicator {
+	return []Indicator{s.spreadIndicator()}
+}
+
+func (s *SpreadStrategy) spreadIndicator() *SpreadIndicator {
+	return NewSpreadIndicator(s.PairProduct(), s.mode(), s.Period)
+}
+
+func (s *SpreadStrategy) product() Product {
+	if s.strategy == nil {
+		return ""
+	}
+	return s.strategy.Product
+}
+
+// Position returns the product that should be held.
+func (s *SpreadStrategy) Position(history []*IndicatorSet, current int, held Product) (Product, error) {
+	spread, err := indicatorValue(history, current, s.spreadIndicator().Name())
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case spread.Value <= -s.EntryZScore:
+		return s.product(), nil
+	case spread.Value >= s.EntryZScore:
+		return s.PairProduct(), nil
+	case math.Abs(spread.Value) <= s.ExitZScore:
+		return "", nil
+	default:
+		return held, nil
+	}
+}
+
+// Buy determines whether the model's product should be bought, which
+// ignores the pair product.
+func (s *SpreadStrategy) Buy(history []*IndicatorSet, current int) (bool, error) {
+	position, err := s.Position(history, current, "")
+	if err != nil {
+		return false, err
+	}
+	return position != "" && position == s.product(), nil
+}
+
+// Sell determines whether the model's product should be sold.
+func (s *SpreadStrategy) Sell(history []*IndicatorSet, current int) (bool, error) {
+	position, err := s.Position(history, current, s.product())
+	if err != nil {
+		return false, err
+	}
+	return position != s.product(), nil
+}
+
+// Rand creates a random version of the strategy within its registered
+// parameters.
+func (s *SpreadStrategy) Rand(rng *rand.Rand) {
+	s.Period = uint(spreadParameter("period").Rand(rng))
+	s.EntryZScore = spreadParameter("entry_z_score").Rand(rng)
+	s.ExitZScore = spreadParameter("exit_z_score").Rand(rng)
+}
+
+func spreadParameter(name string) *StrategyParameter {
+	return strategyParameter(TradingStrategySpread, name)
+}
+
+// Clone returns a clone of the current strategy.
+func (s *SpreadStrategy) Clone() StrategyGenome {
+	return &SpreadStrategy{
+		Pair:        s.Pair,
+		Mode:        s.Mode,
+		Period:      s.Period,
+		EntryZScore: s.EntryZScore,
+		ExitZScore:  s.ExitZScore,
+	}
+}
+
+// Mutate mutates the underlying strategy within its registered
+// parameters.
+func (s *SpreadStrategy) Mutate(rng *rand.Rand) {
+	mutateProb := 0.8
+
+	if rng.Float64() < mutateProb {
+		s.Period = uint(spreadParameter("period").Mutate(rng, float64(s.Period)))
+	}
+	if rng.Float64() < mutateProb {
+		s.EntryZScore = spreadParameter("entry_z_score").Mutate(rng, s.EntryZScore)
+	}
+	if rng.Float64() < mutateProb {
+		s.ExitZScore = spreadParameter("exit_z_score").Mutate(rng, s.ExitZScore)
+	}
+}
+
+// Crossover crosses over a SpreadStrategy with a different one.
+func (s *SpreadStrategy) Crossover(m StrategyGenome,
+	r *rand.Rand) (StrategyGenome, StrategyGenome) {
+	mate := m.(*SpreadStrategy)
+
+	p1 := []float64{float64(s.Period), s.EntryZScore, s.ExitZScore}
+	p2 := []float64{float64(mate.Period), mate.EntryZScore, mate.ExitZScore}
+
+	c1, c2 := gago.CrossUniformFloat64(p1, p2, r)
+
+	s1 := &SpreadStrategy{
+		Pair:        s.Pair,
+		Mode:        s.Mode,
+		Period:      uint(c1[0]),
+		EntryZScore: c1[1],
+		ExitZScore:  c1[2],
+	}
+	s2 := &SpreadStrategy{
+		Pair:        s.Pair,
+		Mode:        s.Mode,
+		Period:      uint(c2[0]),
+		EntryZScore: c2[1],
+		ExitZScore:  c2[2],
+	}
+
+	return s1, s2
+}
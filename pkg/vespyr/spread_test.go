@@ -0,0 +1,167 @@
+package vespyr_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/DavidHuie/vespyr/pkg/vespyr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// spreadTestCandles returns ETH and BTC candlesticks whose ratio
+// oscillates around a constant.
+func spreadTestCandles() ([]*vespyr.CandlestickModel, []*vespyr.CandlestickModel) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	var eth, btc []*vespyr.CandlestickModel
+	for i := 0; i < 200; i++ {
+		btcPrice := 10000 + 50*float64(i)
+		ethPrice := btcPrice * .05 * (1 + .05*math.Sin(float64(i)/4))
+		for _, c := range []struct {
+			product vespyr.Product
+			price   float64
+			candles *[]*vespyr.CandlestickModel
+		}{
+			{vespyr.ProductETHUSD, ethPrice, &eth},
+			{vespyr.ProductBTCUSD, btcPrice, &btc},
+		} {
+			*c.candles = append(*c.candles, &vespyr.CandlestickModel{
+				StartTime: start.Add(time.Duration(i) * 15 * time.Minute),
+				EndTime:   start.Add(time.Duration(i+1) * 15 * time.Minute),
+				Product:   c.product,
+				Open:      c.price,
+				Close:     c.price,
+				Low:       c.price,
+				High:      c.price,
+				Volume:    1,
+			})
+		}
+	}
+	return eth, btc
+}
+
+func TestSpreadIndicator(t *testing.T) {
+	eth, btc := spreadTestCandles()
+
+	t.Run("ratio", func(t *testing.T) {
+		indicator := vespyr.NewSpreadIndicator(vespyr.ProductBTCUSD, vespyr.SpreadModeRatio, 3)
+		assert.Equal(t, "spread-ratio-BTC-USD-3", indicator.Name())
+		assert.Equal(t, vespyr.ProductBTCUSD, indicator.PairProduct())
+
+		for i := 0; i < 3; i++ {
+			_, err := indicator.Value()
+			assert.Equal(t, vespyr.ErrNotEnoughData, err)
+			assert.NoError(t, indicator.AddPairCandlestick(btc[i]))
+			assert.NoError(t, indicator.AddCandlestick(eth[i]))
+		}
+
+		var ratios []float64
+		for i := 0; i < 3; i++ {
+			ratios = append(ratios, eth[i].Close/btc[i].Close)
+		}
+		mean := (ratios[0] + ratios[1] + ratios[2]) / 3
+		var variance float64
+		for _, r := range ratios {
+			variance += (r - mean) * (r - mean)
+		}
+		stddev := math.Sqrt(variance / 3)
+
+		value, err := indicator.Value()
+		assert.NoError(t, err)
+		assert.InDelta(t, (ratios[2]-mean)/stddev, value.Value, 1e-9)
+		spread, err := value.Component(vespyr.SpreadValue)
+		assert.NoError(t, err)
+		assert.InDelta(t, ratios[2], spread, 1e-12)
+
+		// Ticks without a BTC candlestick have no value.
+		assert.NoError(t, indicator.AddCandlestick(eth[3]))
+		_, err = indicator.Value()
+		assert.Equal(t, vespyr.ErrNotEnoughData, err)
+	})
+
+	t.Run("residual", func(t *testing.T) {
+		indicator := vespyr.NewSpreadIndicator(vespyr.ProductBTCUSD, vespyr.SpreadModeResidual, 50)
+		for i := 0; i < 50; i++ {
+			// ETH moves with BTC squared, plus noise.
+			price := btc[i].Close * btc[i].Close / 1e6 * (1 + .01*math.Sin(float64(i)))
+			c := *eth[i]
+			c.Close = price
+			assert.NoError(t, indicator.AddPairCandlestick(btc[i]))
+			assert.NoError(t, indicator.AddCandlestick(&c))
+		}
+
+		value, err := indicator.Value()
+		assert.NoError(t, err)
+		hedgeRatio, err := value.Component(vespyr.SpreadHedgeRatio)
+		assert.NoError(t, err)
+		assert.InDelta(t, 2, hedgeRatio, .1)
+		mean, err := value.Component(vespyr.SpreadMean)
+		assert.NoError(t, err)
+		assert.Equal(t, float64(0), mean)
+		assert.True(t, math.Abs(value.Value) < 3)
+	})
+}
+
+func TestSpreadStrategyBacktest(t *testing.T) {
+	eth, btc := spreadTestCandles()
+
+	backend := new(vespyr.MockBackend)
+	backend.On("FindCandlesticks", mock.Anything, mock.Anything, vespyr.ProductETHUSD, int64(15)).
+		Return(eth, nil)
+	backend.On("FindCandlesticks", mock.Anything, mock.Anything, vespyr.ProductBTCUSD, int64(15)).
+		Return(btc, nil)
+
+	model, err := vespyr.ParseStrategyFile([]byte(`
+product: ETH-USD
+tick_size_minutes: 15
+history_ticks: 20
+trading_strategy: spread
+trading_strategy_data:
+  pair_product: BTC-USD
+  period: 20
+  entry_z_score: 1.2
+  exit_z_score: 0
+`))
+	assert.NoError(t, err)
+
+	backtester, err := vespyr.NewBacktester(eth[20].StartTime, eth[len(eth)-1].EndTime,
+		model, backend, rand.NewSource(1))
+	assert.NoError(t, err)
+	assert.NoError(t, backtester.Backtest())
+
+	results := backtester.Results()
+	assert.True(t, results.ProfitTrades+results.LossTrades > 0)
+
+	// Both products are bought, and a position is rotated from one
+	// to the other on a single tick.
+	bought := make(map[string]bool)
+	var rotations int
+	trades := backtester.Trades()
+	for i, trade := range trades {
+		if trade.Side == vespyr.OrderBuy {
+			bought[trade.SizeCurrency] = true
+			if i > 0 && trades[i-1].Side == vespyr.OrderSell && trades[i-1].Time.Equal(trade.Time) {
+				rotations++
+			}
+		}
+	}
+	assert.True(t, bought[vespyr.CurrencyETH])
+	assert.True(t, bought[vespyr.CurrencyBTC])
+	assert.True(t, rotations > 0)
+
+	last := trades[len(trades)-1]
+	if last.Side == vespyr.OrderBuy && last.SizeCurrency == vespyr.CurrencyBTC {
+		assert.Equal(t, vespyr.ProductBTCUSD, model.InvestedProduct)
+	} else {
+		assert.Equal(t, vespyr.Product(""), model.InvestedProduct)
+	}
+
+	// Pair strategies can't be part of a portfolio.
+	portfolio, err := vespyr.NewPortfolioBacktester(eth[20].StartTime, eth[len(eth)-1].EndTime,
+		[]*vespyr.TradingStrategyModel{model}, backend, 1000, vespyr.PortfolioRiskRules{}, 1)
+	assert.NoError(t, err)
+	_, err = portfolio.Backtest()
+	assert.Error(t, err)
+}
@@ -0,0 +1,92 @@
+package vespyr
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// StochasticK is the %K line, which is the stochastic
+	// oscillator's primary value.
+	StochasticK = "k"
+	// StochasticD is the %D line, a simple moving average of %K.
+	StochasticD = "d"
+)
+
+// StochasticIndicator calculates the stochastic oscillator: where the
+// price sits within the highest high and lowest low of the last
+// kPeriod candlesticks, from 0 to 100, smoothed over dPeriod values.
+type StochasticIndicator struct {
+	kPeriod  uint
+	dPeriod  uint
+	source   PriceSource
+	highs    *rollingWindow
+	lows     *rollingWindow
+	k        *rollingWindow
+	lastTime time.Time
+}
+
+// NewStochasticIndicator returns a new StochasticIndicator.
+func NewStochasticIndicator(kPeriod, dPeriod uint, source PriceSource) *StochasticIndicator {
+	return &StochasticIndicator{
+		kPeriod: kPeriod,
+		dPeriod: dPeriod,
+		source:  source,
+		highs:   newRollingWindow(kPeriod),
+		lows:    newRollingWindow(kPeriod),
+		k:       newRollingWindow(dPeriod),
+	}
+}
+
+// AddCandlestick adds a candlestick to the indicator.
+func (s *StochasticIndicator) AddCandlestick(c *CandlestickModel) error {
+	if c.Volume == 0 {
+		return nil
+	}
+
+	price, err := s.source.Price(c)
+	if err != nil {
+		return errors.Wrapf(err, "error calculating %s price", s.source)
+	}
+
+	s.highs.add(c.High)
+	s.lows.add(c.Low)
+	s.lastTime = c.StartTime
+	if !s.highs.full() {
+		return nil
+	}
+
+	// A range without movement puts the price in the middle.
+	k := float64(50)
+	high, low := s.highs.highest(s.kPeriod), s.lows.lowest(s.kPeriod)
+	if high > low {
+		k = 100 * (price - low) / (high - low)
+	}
+	s.k.add(k)
+
+	return nil
+}
+
+// Name returns the name of the indicator.
+func (s *StochasticIndicator) Name() string {
+	return fmt.Sprintf("%s-%d-%d-%s", IndicatorStochastic, s.kPeriod, s.dPeriod, s.source)
+}
+
+// Value returns the last calculated %K and %D values.
+func (s *StochasticIndicator) Value() (*IndicatorValue, error) {
+	if !s.k.full() {
+		return nil, ErrNotEnoughData
+	}
+	k := s.k.values[len(s.k.values)-1]
+	return &IndicatorValue{
+		Time:          s.lastTime,
+		Value:         k,
+		IndicatorName: s.Name(),
+		Components: []*IndicatorComponent{
+			{Name: StochasticK, Value: k},
+			{Name: StochasticD, Value: s.k.mean()},
+		},
+	}, nil
+}
@@ -2,6 +2,8 @@ package vespyr
 
 import (
 	"fmt"
+	"math"
+	"strings"
 	"time"
 
 	"github.com/jonboulle/clockwork"
@@ -30,6 +32,36 @@ const (
 	// IndicatorMACDWithSignal refers to the MACD with signal
 	// indicator.
 	IndicatorMACDWithSignal = "macd-with-signal"
+	// IndicatorBollinger refers to the Bollinger Bands indicator.
+	IndicatorBollinger = "bollinger"
+	// IndicatorSMA refers to the simple moving average indicator.
+	IndicatorSMA = "sma"
+	// IndicatorWMA refers to the weighted moving average indicator.
+	IndicatorWMA = "wma"
+	// IndicatorHMA refers to the Hull moving average indicator.
+	IndicatorHMA = "hma"
+	// IndicatorStdDev refers to the rolling standard deviation
+	// indicator.
+	IndicatorStdDev = "stddev"
+	// IndicatorATR refers to the average true range indicator.
+	IndicatorATR = "atr"
+	// IndicatorADX refers to the average directional index
+	// indicator.
+	IndicatorADX = "adx"
+	// IndicatorStochastic refers to the stochastic oscillator.
+	IndicatorStochastic = "stochastic"
+	// IndicatorOBV refers to the on-balance volume indicator.
+	IndicatorOBV = "obv"
+	// IndicatorVWAP refers to the volume weighted average price
+	// indicator.
+	IndicatorVWAP = "vwap"
+	// IndicatorIchimoku refers to the Ichimoku cloud indicator.
+	IndicatorIchimoku = "ichimoku"
+	// IndicatorKeltner refers to the Keltner channel indicator.
+	IndicatorKeltner = "keltner"
+	// IndicatorSpread refers to the z-score of the spread between
+	// two products.
+	IndicatorSpread = "spread"
 
 	// TradingStrategyEMACrossover is a trading strategy that buys
 	// and sells using EMA crossovers.
@@ -40,6 +72,24 @@ const (
 	// TradingStrategyS1 is the first proprietary Vespyr trading
 	// strategy.
 	TradingStrategyS1 = "s1"
+	// TradingStrategyRSIBollinger is a trading strategy that buys
+	// and sells when RSI and Bollinger Bands agree.
+	TradingStrategyRSIBollinger = "rsi-bollinger"
+	// TradingStrategyRule is a trading strategy whose indicators
+	// and rules are defined in YAML.
+	TradingStrategyRule = "rule"
+	// TradingStrategySpread is a trading strategy that trades the
+	// spread between two products.
+	TradingStrategySpread = "spread"
+	// TradingStrategyEnsemble is a trading strategy that buys and
+	// sells by a vote of other strategies.
+	TradingStrategyEnsemble = "ensemble"
+	// TradingStrategyGrid is a trading strategy that buys and
+	// sells levels of a grid within a price band.
+	TradingStrategyGrid = "grid"
+	// TradingStrategyDCA is a trading strategy that buys on a
+	// schedule, buying more on dips.
+	TradingStrategyDCA = "dca"
 
 	// strategyCurrencyPrecision this defines how many decimal
 	// places should be used in currency sizes when placing
@@ -58,6 +108,11 @@ var (
 
 // Indicator is an interface to a type that can generate an
 // IndicatorValue for a candlestick.
+//
+// Indicators ignore candlesticks without volume, which reprojection
+// uses to fill gaps, so their values can differ from a charting
+// platform's wherever a product didn't trade. The Wilder RSI is the
+// exception: it only ignores empty candlesticks that have no price.
 type Indicator interface {
 	AddCandlestick(c *CandlestickModel) error
 	Value() (*IndicatorValue, error)
@@ -70,6 +125,43 @@ type IndicatorValue struct {
 	Time          time.Time
 	Value         float64
 	IndicatorName string
+	// Components are the named outputs of indicators that produce
+	// several values, such as the MACD line, signal and histogram.
+	// Value holds the indicator's primary component.
+	Components []*IndicatorComponent
+}
+
+// IndicatorComponent is a named output of an indicator.
+type IndicatorComponent struct {
+	Name  string
+	Value float64
+}
+
+// Component returns the value of a named component.
+func (v *IndicatorValue) Component(name string) (float64, error) {
+	for _, c := range v.Components {
+		if c.Name == name {
+			return c.Value, nil
+		}
+	}
+	return 0, errors.Errorf("error: indicator %s has no component: %s", v.IndicatorName, name)
+}
+
+// columns returns a name and value for every output of the indicator:
+// one per component, or the value itself for indicators with a single
+// output.
+func (v *IndicatorValue) columns() ([]string, []float64) {
+	if len(v.Components) == 0 {
+		return []string{v.IndicatorName}, []float64{v.Value}
+	}
+
+	var names []string
+	var values []float64
+	for _, c := range v.Components {
+		names = append(names, fmt.Sprintf("%s.%s", v.IndicatorName, c.Name))
+		values = append(values, c.Value)
+	}
+	return names, values
 }
 
 // IndicatorSet describes a collection of indicators for a specific
@@ -79,6 +171,51 @@ type IndicatorSet struct {
 	Values []*IndicatorValue
 }
 
+// Value returns the value of an indicator by name. Indicators that
+// don't have enough data yet have no value, so a missing indicator
+// returns ErrNotEnoughData.
+func (s *IndicatorSet) Value(indicator string) (*IndicatorValue, error) {
+	for _, v := range s.Values {
+		if v != nil && v.IndicatorName == indicator {
+			return v, nil
+		}
+	}
+	return nil, ErrNotEnoughData
+}
+
+// Component returns a named component of an indicator's value.
+func (s *IndicatorSet) Component(indicator, component string) (float64, error) {
+	value, err := s.Value(indicator)
+	if err != nil {
+		return 0, err
+	}
+	return value.Component(component)
+}
+
+// Map returns every output of the set's indicators keyed by name.
+func (s *IndicatorSet) Map() map[string]interface{} {
+	m := make(map[string]interface{})
+	for _, v := range s.Values {
+		if v == nil {
+			continue
+		}
+		names, values := v.columns()
+		for i, name := range names {
+			m[name] = values[i]
+		}
+	}
+	return m
+}
+
+// indicatorValue returns the value of an indicator at a position in
+// the history.
+func indicatorValue(history []*IndicatorSet, current int, indicator string) (*IndicatorValue, error) {
+	if current < 0 || len(history)-1 < current {
+		return nil, ErrNotEnoughData
+	}
+	return history[current].Value(indicator)
+}
+
 // StrategyInterface describes the exposed by a trading strategy.
 type StrategyInterface interface {
 	Indicators() []Indicator
@@ -147,6 +284,22 @@ func (t *TradingStrategy) SeedIndicators(c *CandlestickModel) error {
 	return nil
 }
 
+// SeedPairCandlestick adds a candlestick of a second product to the
+// indicators that use it. It must be added before the strategy
+// product's candlestick for the same tick.
+func (t *TradingStrategy) SeedPairCandlestick(c *CandlestickModel) error {
+	for _, name := range t.indicatorNames {
+		ind, ok := t.indicators[name].(PairIndicator)
+		if !ok || ind.PairProduct() != c.Product {
+			continue
+		}
+		if err := ind.AddPairCandlestick(c); err != nil {
+			return errors.Wrapf(err, "error seeding indicator: %s", name)
+		}
+	}
+	return nil
+}
+
 // LastCandlestickTime returns the ending time of the last candlestick
 // that was processed.
 func (t *TradingStrategy) LastCandlestickTime() time.Time {
@@ -163,6 +316,19 @@ func (t *TradingStrategy) nextTick() {
 
 // ProcessTick processes a single trading strategy model tick.
 func (t *TradingStrategy) ProcessTick(m *TradingStrategyModel) error {
+	if _, ok := t.strategy.(PairStrategyInterface); ok {
+		if err := t.TryRebalance(m); err != nil {
+			return errors.Wrapf(err, "error trying to rebalance")
+		}
+		return nil
+	}
+	if _, ok := t.strategy.(LevelStrategyInterface); ok {
+		if err := t.TryLevels(m); err != nil {
+			return errors.Wrapf(err, "error trying level orders")
+		}
+		return nil
+	}
+
 	switch m.State {
 	case StrategyStateTryingToBuy:
 		if err := t.TryBuy(m); err != nil {
@@ -322,30 +488,257 @@ Current profit %%: %f`, OrderSell, m.Product, m.ID, t.strategy, t.orderStrategy,
 			},
 		})
 
-		prop := m.Budget / m.InitialBudget
-		if prop < minimumBudgetProportion {
-			logrus.Infof("deactivating strategy %d for falling below minimum budget proportion %f: %f", m.ID, prop, m.Budget)
+		if err := t.checkMinimumBudget(m); err != nil {
+			return err
+		}
+	} else {
+		msg := fmt.Sprintf("skipped sell with strategy %d: %s", m.ID, t.strategy)
+		logrus.Debug(msg)
+	}
+
+	return nil
+}
+
+// checkMinimumBudget deactivates the strategy if its budget has fallen
+// below the minimum proportion of its initial budget.
+func (t *TradingStrategy) checkMinimumBudget(m *TradingStrategyModel) error {
+	prop := m.Budget / m.InitialBudget
+	if prop >= minimumBudgetProportion {
+		return nil
+	}
+
+	logrus.Infof("deactivating strategy %d for falling below minimum budget proportion %f: %f", m.ID, prop, m.Budget)
 
-			msg := fmt.Sprintf(`ID: %d
+	msg := fmt.Sprintf(`ID: %d
 Type: %s
 Reason: budget %f %s fell below minimum proportion %f`, m.ID, t.strategy,
-				m.Budget, m.BudgetCurrency, minimumBudgetProportion)
-			PostTradesSlackMessage("", slack.PostMessageParameters{
-				AsUser: true,
-				Attachments: []slack.Attachment{
-					{Title: "Strategy Deactivated", Text: msg, Color: "#ff5c3f"},
-				},
+		m.Budget, m.BudgetCurrency, minimumBudgetProportion)
+	PostTradesSlackMessage("", slack.PostMessageParameters{
+		AsUser: true,
+		Attachments: []slack.Attachment{
+			{Title: "Strategy Deactivated", Text: msg, Color: "#ff5c3f"},
+		},
+	})
+
+	m.DeactivatedAt = t.clock.Now()
+	if err := t.backend.UpdateTradingStrategy(m); err != nil {
+		return errors.Wrapf(err, "error updating strategy after deactivating")
+	}
+	return nil
+}
+
+// TryRebalance moves a pair strategy's position to the product that
+// the strategy picks, selling the held product and then buying the
+// other with the proceeds. The model is updated with every order that
+// filled, even if a later one fails.
+func (t *TradingStrategy) TryRebalance(m *TradingStrategyModel) error {
+	if err := ValidateIndicatorSets(int(m.HistoryTicks), t.currentTick, t.indicatorSets); err != nil {
+		return errors.Wrapf(err, "error validating indicator sets")
+	}
+
+	var held Product
+	if m.Invested > 0 {
+		held = m.HeldProduct()
+	}
+	target, err := t.strategy.(PairStrategyInterface).Position(t.indicatorSets, t.currentTick, held)
+	if err != nil {
+		return errors.Wrapf(err, "error using strategy")
+	}
+	if target == held {
+		logrus.Debugf("skipped rebalance with strategy %d: %s", m.ID, t.strategy)
+		return nil
+	}
+
+	var orders []*PerformOrderArgs
+	if held != "" {
+		orders = append(orders, &PerformOrderArgs{
+			Product:         held,
+			Side:            OrderSell,
+			Cost:            m.Invested,
+			TradingStrategy: m,
+		})
+	}
+	if target != "" {
+		metadata, ok := ProductToMetadata[target]
+		if !ok || metadata.MarketOrderBuyCurrency != m.BudgetCurrency {
+			return errors.Errorf("error: %s can't be bought with %s", target, m.BudgetCurrency)
+		}
+		orders = append(orders, &PerformOrderArgs{
+			Product:         target,
+			Side:            OrderBuy,
+			Cost:            m.Budget,
+			TradingStrategy: m,
+		})
+	}
+
+	initialBudget, initialInvested := m.Budget, m.Invested
+	responses, orderErr := PerformOrders(t.orderStrategy, orders)
+	for i, response := range responses {
+		if orders[i].Side == OrderSell {
+			m.Invested = 0
+			m.InvestedProduct = ""
+			m.Budget = TruncateFloat(response.FilledSize, tradeCurrencyPrecision)
+			m.State = StrategyStateTryingToBuy
+			continue
+		}
+		m.Budget = 0
+		m.Invested = TruncateFloat(response.FilledSize, tradeCurrencyPrecision)
+		m.InvestedCurrency = ProductToMetadata[target].MarketOrderSellCurrency
+		if target != m.Product {
+			m.InvestedProduct = target
+		}
+		m.State = StrategyStateTryingToSell
+	}
+
+	if len(responses) > 0 {
+		if err := t.backend.UpdateTradingStrategy(m); err != nil {
+			return errors.Wrapf(err, "error updating trading strategy model in database")
+		}
+
+		slackMsg := fmt.Sprintf(`Type: rebalance
+Products: %s, %s
+Strategy ID: %d
+Strategy type: %s
+Order strategy: %s
+Tick size (minutes): %d
+From: %s (%f invested, %f %s budget)
+To: %s (%f invested, %f %s budget)`, m.Product, t.strategy.(PairStrategyInterface).PairProduct(),
+			m.ID, t.strategy, t.orderStrategy, m.TickSizeMinutes,
+			held, initialInvested, initialBudget, m.BudgetCurrency,
+			target, m.Invested, m.Budget, m.BudgetCurrency)
+		PostTradesSlackMessage("", slack.PostMessageParameters{
+			AsUser: true,
+			Attachments: []slack.Attachment{
+				{Title: "Market Orders", Text: slackMsg, Color: "#2afc43"},
+			},
+		})
+	}
+	if orderErr != nil {
+		return errors.Wrapf(orderErr, "error performing rebalance orders")
+	}
+
+	if m.Invested == 0 {
+		return t.checkMinimumBudget(m)
+	}
+	return nil
+}
+
+// TryLevels makes the orders of a level strategy. Sells are made before
+// buys so that buys can spend their proceeds, and buys are limited to
+// the remaining budget. The model is updated with every order that
+// filled, even if a later one fails.
+func (t *TradingStrategy) TryLevels(m *TradingStrategyModel) error {
+	if err := ValidateIndicatorSets(int(m.HistoryTicks), t.currentTick, t.indicatorSets); err != nil {
+		return errors.Wrapf(err, "error validating indicator sets")
+	}
+
+	orders, err := t.strategy.(LevelStrategyInterface).Orders(t.indicatorSets, t.currentTick, m.Levels, m.Budget)
+	if err != nil {
+		return errors.Wrapf(err, "error using strategy")
+	}
+	if len(orders) == 0 {
+		logrus.Debugf("skipped level orders with strategy %d: %s", m.ID, t.strategy)
+		return nil
+	}
+
+	var sells, buys []*LevelOrder
+	for _, o := range orders {
+		if o.Side == OrderSell {
+			sells = append(sells, o)
+		} else {
+			buys = append(buys, o)
+		}
+	}
+
+	var msgs []string
+	orderErr := func() error {
+		for _, o := range sells {
+			response, err := t.orderStrategy.PerformOrder(&PerformOrderArgs{
+				Product:         m.Product,
+				Side:            OrderSell,
+				Cost:            o.Level.Size,
+				TradingStrategy: m,
 			})
+			if err != nil {
+				return errors.Wrapf(err, "error performing sell order for level %d", o.Level.Level)
+			}
+
+			proceeds := TruncateFloat(response.FilledSize, tradeCurrencyPrecision)
+			m.Budget = TruncateFloat(m.Budget+proceeds, tradeCurrencyPrecision)
+			m.Invested = TruncateFloat(m.Invested-o.Level.Size, tradeCurrencyPrecision)
+			m.Levels = removeLevel(m.Levels, o.Level)
+			msgs = append(msgs, fmt.Sprintf("Sold level %d: %f %s for %f %s", o.Level.Level,
+				o.Level.Size, m.InvestedCurrency, proceeds, m.BudgetCurrency))
+		}
 
-			m.DeactivatedAt = t.clock.Now()
-			if err := t.backend.UpdateTradingStrategy(m); err != nil {
-				return errors.Wrapf(err, "error updating strategy after deactivating")
+		for _, o := range buys {
+			cost := math.Min(o.Cost, m.Budget)
+			if cost <= 0 {
+				continue
 			}
+			response, err := t.orderStrategy.PerformOrder(&PerformOrderArgs{
+				Product:         m.Product,
+				Side:            OrderBuy,
+				Cost:            cost,
+				TradingStrategy: m,
+			})
+			if err != nil {
+				return errors.Wrapf(err, "error performing buy order for level %d", o.Level.Level)
+			}
+
+			level := *o.Level
+			level.Cost = cost
+			level.Size = TruncateFloat(response.FilledSize, tradeCurrencyPrecision)
+			m.Budget = TruncateFloat(m.Budget-cost, tradeCurrencyPrecision)
+			m.Invested = TruncateFloat(m.Invested+level.Size, tradeCurrencyPrecision)
+			m.Levels = append(m.Levels, &level)
+			msgs = append(msgs, fmt.Sprintf("Bought level %d: %f %s for %f %s", level.Level,
+				level.Size, m.InvestedCurrency, cost, m.BudgetCurrency))
 		}
-	} else {
-		msg := fmt.Sprintf("skipped sell with strategy %d: %s", m.ID, t.strategy)
-		logrus.Debug(msg)
+		return nil
+	}()
+
+	if len(msgs) > 0 {
+		m.State = StrategyStateTryingToBuy
+		if m.Invested > 0 {
+			m.State = StrategyStateTryingToSell
+		}
+		if err := t.backend.UpdateTradingStrategy(m); err != nil {
+			return errors.Wrapf(err, "error updating trading strategy model in database")
+		}
+
+		slackMsg := fmt.Sprintf(`Product: %s
+Strategy ID: %d
+Strategy type: %s
+Order strategy: %s
+Tick size (minutes): %d
+%s
+Budget (%s): %f
+Invested (%s): %f`, m.Product, m.ID, t.strategy, t.orderStrategy, m.TickSizeMinutes,
+			strings.Join(msgs, "\n"), m.BudgetCurrency, m.Budget, m.InvestedCurrency, m.Invested)
+		PostTradesSlackMessage("", slack.PostMessageParameters{
+			AsUser: true,
+			Attachments: []slack.Attachment{
+				{Title: "Level Orders", Text: slackMsg, Color: "#2afc43"},
+			},
+		})
+	}
+	if orderErr != nil {
+		return orderErr
 	}
 
+	if len(m.Levels) == 0 {
+		return t.checkMinimumBudget(m)
+	}
 	return nil
 }
+
+func removeLevel(levels []*StrategyLevel, level *StrategyLevel) []*StrategyLevel {
+	var remaining []*StrategyLevel
+	for _, l := range levels {
+		if l != level {
+			remaining = append(remaining, l)
+		}
+	}
+	return remaining
+}
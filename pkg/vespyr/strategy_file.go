@@ -0,0 +1,95 @@
+package vespyr
+
+import (
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	defaultStrategyFileHistoryTicks  = 1000
+	defaultStrategyFileInitialBudget = 100
+)
+
+// StrategyFile describes a trading strategy model in YAML. The
+// trading strategy data is decoded by the strategy named in
+// trading_strategy, for example:
+//
+//	product: BTC-USD
+//	tick_size_minutes: 15
+//	trading_strategy: rsi
+//	trading_strategy_data:
+//	  period: 14
+//	  buy_threshold: 30
+//	  sell_threshold: 70
+type StrategyFile struct {
+	Product             Product       `yaml:"product"`
+	HistoryTicks        uint          `yaml:"history_ticks"`
+	InitialBudget       float64       `yaml:"initial_budget"`
+	TickSizeMinutes     uint          `yaml:"tick_size_minutes"`
+	TradingStrategy     string        `yaml:"trading_strategy"`
+	TradingStrategyData yaml.MapSlice `yaml:"trading_strategy_data"`
+}
+
+// Model returns a new trading strategy model using the file's
+// settings. The strategy data is validated by decoding it.
+func (s *StrategyFile) Model() (*TradingStrategyModel, error) {
+	meta, ok := ProductToMetadata[s.Product]
+	if !ok {
+		return nil, errors.Errorf("error: unknown product: %s", s.Product)
+	}
+	if s.TickSizeMinutes == 0 {
+		return nil, errors.New("error: tick_size_minutes must be set")
+	}
+
+	model := &TradingStrategyModel{
+		Product:          s.Product,
+		HistoryTicks:     s.HistoryTicks,
+		State:            StrategyStateTryingToBuy,
+		InitialBudget:    s.InitialBudget,
+		Budget:           s.InitialBudget,
+		BudgetCurrency:   meta.MarketOrderBuyCurrency,
+		InvestedCurrency: meta.MarketOrderSellCurrency,
+		TickSizeMinutes:  s.TickSizeMinutes,
+		TradingStrategy:  s.TradingStrategy,
+	}
+	if model.HistoryTicks == 0 {
+		model.HistoryTicks = defaultStrategyFileHistoryTicks
+	}
+	if model.InitialBudget == 0 {
+		model.InitialBudget = defaultStrategyFileInitialBudget
+		model.Budget = defaultStrategyFileInitialBudget
+	}
+
+	data, err := yaml.Marshal(s.TradingStrategyData)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error YAML marshalling trading strategy data")
+	}
+	model.TradingStrategyData = data
+
+	if _, err := model.Strategy(); err != nil {
+		return nil, errors.Wrapf(err, "error decoding trading strategy")
+	}
+
+	return model, nil
+}
+
+// ParseStrategyFile parses a YAML strategy file into a trading
+// strategy model.
+func ParseStrategyFile(b []byte) (*TradingStrategyModel, error) {
+	var file StrategyFile
+	if err := yaml.UnmarshalStrict(b, &file); err != nil {
+		return nil, errors.Wrapf(err, "error YAML unmarshaling strategy file")
+	}
+	return file.Model()
+}
+
+// LoadStrategyFile reads and parses a YAML strategy file.
+func LoadStrategyFile(path string) (*TradingStrategyModel, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading strategy file")
+	}
+	return ParseStrategyFile(b)
+}
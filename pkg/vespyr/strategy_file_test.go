@@ -0,0 +1,50 @@
+package vespyr_test
+
+import (
+	"testing"
+
+	"github.com/DavidHuie/vespyr/pkg/vespyr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStrategyFile(t *testing.T) {
+	model, err := vespyr.ParseStrategyFile([]byte(`
+product: BTC-USD
+tick_size_minutes: 15
+trading_strategy: rsi
+trading_strategy_data:
+  period: 14
+  buy_threshold: 30
+  sell_threshold: 70
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, vespyr.ProductBTCUSD, model.Product)
+	assert.Equal(t, uint(15), model.TickSizeMinutes)
+	assert.Equal(t, uint(1000), model.HistoryTicks)
+	assert.Equal(t, float64(100), model.InitialBudget)
+	assert.Equal(t, float64(100), model.Budget)
+	assert.Equal(t, vespyr.StrategyStateTryingToBuy, model.State)
+
+	strategy, err := model.Strategy()
+	assert.NoError(t, err)
+	rsi, ok := strategy.(*vespyr.RSIStrategy)
+	assert.True(t, ok)
+	assert.Equal(t, uint(14), rsi.Period)
+	assert.Equal(t, float64(30), rsi.BuyThreshold)
+	assert.Equal(t, float64(70), rsi.SellThreshold)
+
+	_, err = vespyr.ParseStrategyFile([]byte(`
+product: BTC-USD
+tick_size_minutes: 15
+trading_strategy: unknown
+`))
+	assert.Error(t, err)
+
+	_, err = vespyr.ParseStrategyFile([]byte(`
+product: BTC-USD
+tick_size_minutes: 15
+trading_strategy: rsi
+unknown_field: 1
+`))
+	assert.Error(t, err)
+}
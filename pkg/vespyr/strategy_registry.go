@@ -0,0 +1,196 @@
+package vespyr
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// StrategyParameter describes a numeric parameter of a trading
+// strategy's YAML data.
+type StrategyParameter struct {
+	// Name is the parameter's YAML key.
+	Name        string
+	Description string
+	Default     float64
+	// Min and Max bound the values that the strategy's genome
+	// picks when it's randomized or mutated.
+	Min     float64
+	Max     float64
+	Integer bool
+}
+
+// parameterMutationScale is the standard deviation of a parameter's
+// mutation as a fraction of its range.
+const parameterMutationScale = .1
+
+// Rand returns a random value within the parameter's bounds.
+func (p *StrategyParameter) Rand(rng *rand.Rand) float64 {
+	if p.Integer {
+		return p.Clamp(math.Floor(p.Min + rng.Float64()*(p.Max-p.Min+1)))
+	}
+	return p.Min + rng.Float64()*(p.Max-p.Min)
+}
+
+// Mutate adds normally distributed noise to a value, scaled to the
+// parameter's range so that values of zero can mutate too, and clamps
+// it to the parameter's bounds.
+func (p *StrategyParameter) Mutate(rng *rand.Rand, x float64) float64 {
+	return p.Clamp(x + parameterMutationScale*(p.Max-p.Min)*rng.NormFloat64())
+}
+
+// Clamp returns the closest value to x within the parameter's bounds,
+// rounded if the parameter is an integer.
+func (p *StrategyParameter) Clamp(x float64) float64 {
+	if p.Integer {
+		x = math.Round(x)
+	}
+	return math.Max(p.Min, math.Min(p.Max, x))
+}
+
+// StrategyType describes a trading strategy that can be stored in a
+// TradingStrategyModel.
+type StrategyType struct {
+	Name        string
+	Description string
+	// New returns an empty strategy that YAML data is decoded into.
+	New        func() StrategyInterface
+	Parameters []*StrategyParameter
+}
+
+var strategyTypes = make(map[string]*StrategyType)
+
+// RegisterStrategyType registers a trading strategy type. It panics
+// if a type with the same name or strategy is already registered.
+func RegisterStrategyType(t *StrategyType) {
+	if _, ok := strategyTypes[t.Name]; ok {
+		panic(fmt.Sprintf("strategy type already registered: %s", t.Name))
+	}
+	for _, existing := range strategyTypes {
+		if reflect.TypeOf(existing.New()) == reflect.TypeOf(t.New()) {
+			panic(fmt.Sprintf("strategy already registered as: %s", existing.Name))
+		}
+	}
+	strategyTypes[t.Name] = t
+}
+
+// LookupStrategyType returns the registered strategy type with a
+// name.
+func LookupStrategyType(name string) (*StrategyType, error) {
+	t, ok := strategyTypes[name]
+	if !ok {
+		return nil, errors.Errorf("error: unknown trading strategy: %s", name)
+	}
+	return t, nil
+}
+
+// StrategyTypes returns every registered strategy type sorted by name.
+func StrategyTypes() []*StrategyType {
+	var types []*StrategyType
+	for _, t := range strategyTypes {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i].Name < types[j].Name })
+	return types
+}
+
+// strategyParameter returns a registered parameter of a strategy
+// type. It panics if there isn't one, since strategies only look up
+// their own parameters.
+func strategyParameter(strategyType, name string) *StrategyParameter {
+	if t, ok := strategyTypes[strategyType]; ok {
+		for _, p := range t.Parameters {
+			if p.Name == name {
+				return p
+			}
+		}
+	}
+	panic(fmt.Sprintf("unknown parameter of %s strategies: %s", strategyType, name))
+}
+
+func strategyTypeOf(s StrategyInterface) (*StrategyType, error) {
+	for _, t := range strategyTypes {
+		if reflect.TypeOf(t.New()) == reflect.TypeOf(s) {
+			return t, nil
+		}
+	}
+	return nil, errors.Errorf("error: unknown trading strategy")
+}
+
+// Decode decodes a strategy's YAML data. Strategies that have a
+// Validate method are validated.
+func (t *StrategyType) Decode(data []byte) (StrategyInterface, error) {
+	strategy := t.New()
+	if err := yaml.Unmarshal(data, strategy); err != nil {
+		return nil, errors.Wrapf(err, "error YAML unmarshaling trading strategy data")
+	}
+	if v, ok := strategy.(interface {
+		Validate() error
+	}); ok {
+		if err := v.Validate(); err != nil {
+			return nil, errors.Wrapf(err, "error validating %s strategy", t.Name)
+		}
+	}
+	return strategy, nil
+}
+
+// Defaults returns the default values of the type's parameters.
+func (t *StrategyType) Defaults() map[string]interface{} {
+	defaults := make(map[string]interface{})
+	for _, p := range t.Parameters {
+		defaults[p.Name] = p.Default
+	}
+	return defaults
+}
+
+// ParseStrategyParameter parses a strategy parameter of the form
+// name=value. Values are YAML, so options like rsi_mode=wilder can be
+// set too.
+func ParseStrategyParameter(spec string) (string, interface{}, error) {
+	fields := strings.SplitN(spec, "=", 2)
+	if len(fields) != 2 || strings.TrimSpace(fields[0]) == "" {
+		return "", nil, errors.Errorf("error: invalid parameter: %s", spec)
+	}
+
+	var value interface{}
+	if err := yaml.Unmarshal([]byte(fields[1]), &value); err != nil {
+		return "", nil, errors.Wrapf(err, "error parsing parameter: %s", spec)
+	}
+	return strings.TrimSpace(fields[0]), value, nil
+}
+
+// Data returns the YAML data of a strategy of this type. The type's
+// defaults are overridden by the base data and then by params.
+// Unknown parameters and invalid strategies are rejected.
+func (t *StrategyType) Data(base []byte, params map[string]interface{}) ([]byte, error) {
+	data := t.Defaults()
+	overrides := make(map[string]interface{})
+	if err := yaml.Unmarshal(base, &overrides); err != nil {
+		return nil, errors.Wrapf(err, "error YAML unmarshaling trading strategy data")
+	}
+	for name, value := range overrides {
+		data[name] = value
+	}
+	for name, value := range params {
+		data[name] = value
+	}
+
+	b, err := yaml.Marshal(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error YAML marshalling trading strategy data")
+	}
+	if err := yaml.UnmarshalStrict(b, t.New()); err != nil {
+		return nil, errors.Wrapf(err, "error decoding %s strategy", t.Name)
+	}
+	if _, err := t.Decode(b); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
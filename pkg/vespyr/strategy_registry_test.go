@@ -0,0 +1,119 @@
+package vespyr_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/DavidHuie/vespyr/pkg/vespyr"
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestStrategyTypes(t *testing.T) {
+	var names []string
+	for _, strategyType := range vespyr.StrategyTypes() {
+		names = append(names, strategyType.Name)
+	}
+	assert.Equal(t, []string{
+		vespyr.TradingStrategyDCA,
+		vespyr.TradingStrategyEMACrossover,
+		vespyr.TradingStrategyEnsemble,
+		vespyr.TradingStrategyGrid,
+		vespyr.TradingStrategyRSI,
+		vespyr.TradingStrategyRSIBollinger,
+		vespyr.TradingStrategyRule,
+		vespyr.TradingStrategyS1,
+		vespyr.TradingStrategySpread,
+	}, names)
+
+	rng := rand.New(rand.NewSource(1))
+	for _, strategyType := range vespyr.StrategyTypes() {
+		if len(strategyType.Parameters) == 0 {
+			continue
+		}
+
+		// Every type's defaults make a valid strategy.
+		data, err := strategyType.Data(nil, nil)
+		assert.NoError(t, err, strategyType.Name)
+		model := &vespyr.TradingStrategyModel{TradingStrategy: strategyType.Name, TradingStrategyData: data}
+		strategy, err := model.Strategy()
+		assert.NoError(t, err, strategyType.Name)
+
+		// Random and mutated genomes stay within the registered
+		// bounds.
+		for i := 0; i < 100; i++ {
+			if i%10 == 0 {
+				strategy.(vespyr.StrategyGenome).Rand(rng)
+			} else {
+				strategy.(vespyr.StrategyGenome).Mutate(rng)
+			}
+			assert.NoError(t, model.SetStrategy(strategy))
+			assert.Equal(t, strategyType.Name, model.TradingStrategy)
+
+			values := make(map[string]float64)
+			assert.NoError(t, yaml.Unmarshal(model.TradingStrategyData, &values))
+			for _, p := range strategyType.Parameters {
+				v, ok := values[p.Name]
+				assert.True(t, ok, "%s %s", strategyType.Name, p.Name)
+				assert.True(t, v >= p.Min-1e-6 && v <= p.Max+1e-6, "%s %s: %f", strategyType.Name, p.Name, v)
+				if p.Integer {
+					assert.Equal(t, math.Trunc(v), v)
+				}
+			}
+		}
+	}
+
+	_, err := vespyr.LookupStrategyType("unknown")
+	assert.Error(t, err)
+	model := &vespyr.TradingStrategyModel{}
+	assert.Error(t, model.SetStrategy(new(vespyr.MockStrategyInterface)))
+}
+
+func TestStrategyTypeData(t *testing.T) {
+	strategyType, err := vespyr.LookupStrategyType(vespyr.TradingStrategyRSI)
+	assert.NoError(t, err)
+
+	name, value, err := vespyr.ParseStrategyParameter("rsi_mode=wilder")
+	assert.NoError(t, err)
+	assert.Equal(t, "rsi_mode", name)
+	assert.Equal(t, "wilder", value)
+	_, value, err = vespyr.ParseStrategyParameter("period = 10")
+	assert.NoError(t, err)
+	assert.Equal(t, 10, value)
+	_, _, err = vespyr.ParseStrategyParameter("period")
+	assert.Error(t, err)
+
+	data, err := strategyType.Data([]byte("buy_threshold: 20\nperiod: 12\n"),
+		map[string]interface{}{"period": 10, "rsi_mode": "wilder"})
+	assert.NoError(t, err)
+	model := &vespyr.TradingStrategyModel{TradingStrategy: vespyr.TradingStrategyRSI, TradingStrategyData: data}
+	strategy, err := model.Strategy()
+	assert.NoError(t, err)
+	assert.Equal(t, (&vespyr.RSIStrategy{
+		Period:        10,
+		BuyThreshold:  20,
+		SellThreshold: 70,
+		RSIOptions:    vespyr.RSIOptions{RSIMode: vespyr.RSIModeWilder},
+	}).String(), strategy.String())
+
+	_, err = strategyType.Data(nil, map[string]interface{}{"unknown": 1})
+	assert.Error(t, err)
+	_, err = strategyType.Data(nil, map[string]interface{}{"rsi_mode": "unknown"})
+	assert.Error(t, err)
+
+	// Rule strategies have no defaults, so their rules must be given.
+	rule, err := vespyr.LookupStrategyType(vespyr.TradingStrategyRule)
+	assert.NoError(t, err)
+	_, err = rule.Data(nil, nil)
+	assert.Error(t, err)
+	_, err = rule.Data([]byte(`
+indicators:
+- name: rsi
+  type: rsi
+  period: 14
+buy: rsi < buy
+sell: rsi > 70
+`), map[string]interface{}{"buy": 25})
+	assert.NoError(t, err)
+}
@@ -608,3 +608,38 @@ func TestValidateIndicatorSets(t *testing.T) {
 	})
 
 }
+
+func TestIndicatorSet(t *testing.T) {
+	set := &vespyr.IndicatorSet{Values: []*vespyr.IndicatorValue{
+		{IndicatorName: "dema-1-2", Value: 3},
+		nil,
+		{IndicatorName: "macd-with-signal-2-ema-1-2", Value: 1, Components: []*vespyr.IndicatorComponent{
+			{Name: vespyr.MACDLine, Value: 4},
+			{Name: vespyr.MACDSignal, Value: 3},
+			{Name: vespyr.MACDHistogram, Value: 1},
+		}},
+	}}
+
+	value, err := set.Value("dema-1-2")
+	assert.NoError(t, err)
+	assert.Equal(t, float64(3), value.Value)
+
+	_, err = set.Value("rsi-14")
+	assert.Equal(t, vespyr.ErrNotEnoughData, err)
+
+	signal, err := set.Component("macd-with-signal-2-ema-1-2", vespyr.MACDSignal)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(3), signal)
+
+	_, err = set.Component("macd-with-signal-2-ema-1-2", "width")
+	assert.Error(t, err)
+	_, err = set.Component("rsi-14", vespyr.MACDSignal)
+	assert.Equal(t, vespyr.ErrNotEnoughData, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"dema-1-2":                             float64(3),
+		"macd-with-signal-2-ema-1-2.macd":      float64(4),
+		"macd-with-signal-2-ema-1-2.signal":    float64(3),
+		"macd-with-signal-2-ema-1-2.histogram": float64(1),
+	}, set.Map())
+}
@@ -0,0 +1,372 @@
+package vespyr
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// SweepModeGrid backtests every combination of parameter
+	// values.
+	SweepModeGrid = "grid"
+	// SweepModeRandom backtests randomly sampled combinations of
+	// parameter values.
+	SweepModeRandom = "random"
+
+	// sweepPrecision is the number of decimal places that swept
+	// values are rounded to, so that steps such as 0.1 don't
+	// accumulate floating point error.
+	sweepPrecision = 9
+)
+
+// SweepParameter is the range of values a strategy parameter is swept
+// over.
+type SweepParameter struct {
+	Name string
+	Min  float64
+	Max  float64
+	Step float64
+}
+
+// ParseSweepParameter parses a parameter range of the form
+// name=min:max:step. The step defaults to 1, and name=value sweeps a
+// single value.
+func ParseSweepParameter(spec string) (*SweepParameter, error) {
+	fields := strings.SplitN(spec, "=", 2)
+	if len(fields) != 2 || fields[0] == "" {
+		return nil, errors.Errorf("error: invalid parameter range: %s", spec)
+	}
+
+	var values []float64
+	for _, v := range strings.Split(fields[1], ":") {
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error parsing parameter range: %s", spec)
+		}
+		values = append(values, f)
+	}
+
+	p := &SweepParameter{Name: strings.TrimSpace(fields[0]), Step: 1}
+	switch len(values) {
+	case 1:
+		p.Min, p.Max = values[0], values[0]
+	case 2:
+		p.Min, p.Max = values[0], values[1]
+	case 3:
+		p.Min, p.Max, p.Step = values[0], values[1], values[2]
+	default:
+		return nil, errors.Errorf("error: invalid parameter range: %s", spec)
+	}
+	if p.Step <= 0 || p.Max < p.Min {
+		return nil, errors.Errorf("error: invalid parameter range: %s", spec)
+	}
+
+	return p, nil
+}
+
+// Values returns every value in the parameter's range. Values are
+// rounded to sweepPrecision decimal places.
+func (s *SweepParameter) Values() []float64 {
+	var values []float64
+	scale := math.Pow10(sweepPrecision)
+	n := int(math.Floor((s.Max-s.Min)/s.Step+1e-9)) + 1
+	for i := 0; i < n; i++ {
+		values = append(values, math.Round((s.Min+float64(i)*s.Step)*scale)/scale)
+	}
+	return values
+}
+
+// GridSweep returns every combination of parameter values.
+func GridSweep(params []*SweepParameter) []map[string]float64 {
+	combinations := []map[string]float64{{}}
+	for _, p := range params {
+		var next []map[string]float64
+		for _, c := range combinations {
+			for _, v := range p.Values() {
+				combination := map[string]float64{p.Name: v}
+				for name, value := range c {
+					combination[name] = value
+				}
+				next = append(next, combination)
+			}
+		}
+		combinations = next
+	}
+	return combinations
+}
+
+// RandomSweep samples combinations of parameter values. Values are
+// drawn from the same points as a grid sweep.
+func RandomSweep(params []*SweepParameter, samples int, rng *rand.Rand) []map[string]float64 {
+	var combinations []map[string]float64
+	for i := 0; i < samples; i++ {
+		combination := make(map[string]float64)
+		for _, p := range params {
+			values := p.Values()
+			combination[p.Name] = values[rng.Intn(len(values))]
+		}
+		combinations = append(combinations, combination)
+	}
+	return combinations
+}
+
+// SweepResult is the result of backtesting a single parameter
+// combination.
+type SweepResult struct {
+	Parameters map[string]float64
+	Model      *TradingStrategyModel
+	Results    *BacktestResults
+	Trades     []*BacktestTradeModel
+	Score      float64
+	Err        error
+}
+
+// Sweeper backtests many parameter combinations of a strategy in
+// parallel.
+type Sweeper struct {
+	startTime   time.Time
+	endTime     time.Time
+	model       *TradingStrategyModel
+	backend     Backend
+	objective   Objective
+	concurrency int
+	seed        int64
+	cache       *IndicatorCache
+	fillModel   FillModel
+}
+
+// NewSweeper creates a new Sweeper. The model's strategy data
+// provides the values of parameters that aren't swept.
+func NewSweeper(startTime, endTime time.Time, model *TradingStrategyModel,
+	backend Backend, concurrency int, seed int64) *Sweeper {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &Sweeper{
+		startTime:   startTime,
+		endTime:     endTime,
+		model:       model,
+		backend:     NewCacheingBackend(backend),
+		objective:   defaultObjective(),
+		concurrency: concurrency,
+		seed:        seed,
+		cache:       NewIndicatorCache(),
+	}
+}
+
+// SetObjective sets the objective that results are scored with.
+func (s *Sweeper) SetObjective(o Objective) {
+	s.objective = o
+}
+
+// SetFillModel sets the model that every backtest's orders are filled
+// with.
+func (s *Sweeper) SetFillModel(m FillModel) {
+	s.fillModel = m
+}
+
+// Model returns a copy of the sweeper's model with parameters
+// overridden. Parameters that the strategy doesn't have are an error.
+// Nested parameters are dotted paths, where list items are chosen by
+// index or by name, so indicators.trend.period sets the period of a
+// rule strategy's trend indicator.
+func (s *Sweeper) Model(params map[string]float64) (*TradingStrategyModel, error) {
+	data := make(map[interface{}]interface{})
+	if err := yaml.Unmarshal(s.model.TradingStrategyData, &data); err != nil {
+		return nil, errors.Wrapf(err, "error YAML unmarshaling trading strategy data")
+	}
+	for name, value := range params {
+		if err := setSweepParameter(data, strings.Split(name, "."), value); err != nil {
+			return nil, errors.Wrapf(err, "error setting parameter %s", name)
+		}
+	}
+
+	b, err := yaml.Marshal(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error YAML marshalling trading strategy data")
+	}
+
+	model := s.model.Copy()
+	model.TradingStrategyData = b
+
+	strategy, err := model.Strategy()
+	if err != nil {
+		return nil, errors.Wrapf(err, "error decoding trading strategy")
+	}
+	if err := yaml.UnmarshalStrict(b, strategy); err != nil {
+		return nil, errors.Wrapf(err, "error applying parameters to %s strategy", model.TradingStrategy)
+	}
+
+	return model, nil
+}
+
+// setSweepParameter sets the value at a parameter's path in YAML
+// strategy data. The last key of the path may be new, but every other
+// key has to exist.
+func setSweepParameter(data interface{}, path []string, value float64) error {
+	key := path[0]
+	last := len(path) == 1
+
+	switch d := data.(type) {
+	case map[interface{}]interface{}:
+		if last {
+			d[key] = value
+			return nil
+		}
+		child, ok := d[key]
+		if !ok {
+			return errors.Errorf("error: unknown key: %s", key)
+		}
+		return setSweepParameter(child, path[1:], value)
+	case []interface{}:
+		for i, item := range d {
+			if strconv.Itoa(i) != key && !sweepItemNamed(item, key) {
+				continue
+			}
+			if last {
+				d[i] = value
+				return nil
+			}
+			return setSweepParameter(item, path[1:], value)
+		}
+		return errors.Errorf("error: unknown list item: %s", key)
+	}
+
+	return errors.Errorf("error: %s isn't a map or a list", key)
+}
+
+func sweepItemNamed(item interface{}, name string) bool {
+	m, ok := item.(map[interface{}]interface{})
+	return ok && m["name"] == name
+}
+
+func (s *Sweeper) run(params map[string]float64) *SweepResult {
+	result := &SweepResult{Parameters: params}
+
+	model, err := s.Model(params)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Model = model
+
+	backtester, err := NewBacktester(s.startTime, s.endTime, model, s.backend,
+		rand.NewSource(s.seed))
+	if err != nil {
+		result.Err = errors.Wrapf(err, "error creating backtester")
+		return result
+	}
+	backtester.SetIndicatorCache(s.cache)
+	backtester.SetFillModel(s.fillModel)
+	if err := backtester.Backtest(); err != nil {
+		result.Err = errors.Wrapf(err, "error running backtest")
+		return result
+	}
+
+	result.Results = backtester.Results()
+	result.Trades = backtester.Trades()
+	result.Score = s.objective.Score(result.Results)
+	return result
+}
+
+// Run backtests every combination, returning results in the same
+// order as the combinations.
+func (s *Sweeper) Run(combinations []map[string]float64) []*SweepResult {
+	results := make([]*SweepResult, len(combinations))
+
+	c := make(chan int)
+	wg := &sync.WaitGroup{}
+	for i := 0; i < s.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range c {
+				results[i] = s.run(combinations[i])
+				logrus.Debugf("finished sweep combination %d/%d", i+1, len(combinations))
+			}
+		}()
+	}
+
+	for i := range combinations {
+		c <- i
+	}
+	close(c)
+	wg.Wait()
+
+	return results
+}
+
+func finiteOrZero(f float64) float64 {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0
+	}
+	return f
+}
+
+// BacktestRun returns the model to persist for a successful result.
+func (s *Sweeper) BacktestRun(sweepName string, result *SweepResult) *BacktestRunModel {
+	params := make(map[string]interface{})
+	for name, value := range result.Parameters {
+		params[name] = value
+	}
+
+	run := NewBacktestRunModel(result.Model, s.startTime, s.endTime, s.seed,
+		s.objective, result.Results)
+	run.SweepName = sweepName
+	run.Parameters = params
+	return run
+}
+
+// WriteSweepResults writes a table of results ordered from the best
+// score to the worst. Failed combinations are listed last.
+func WriteSweepResults(w io.Writer, params []*SweepParameter, results []*SweepResult) error {
+	sorted := make([]*SweepResult, len(results))
+	copy(sorted, results)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if (sorted[i].Err == nil) != (sorted[j].Err == nil) {
+			return sorted[i].Err == nil
+		}
+		return sorted[i].Score > sorted[j].Score
+	})
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	var header []string
+	for _, p := range params {
+		header = append(header, p.Name)
+	}
+	header = append(header, "score", "trades", "net_profit", "sharpe_ratio", "max_drawdown")
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+
+	for _, r := range sorted {
+		var row []string
+		for _, p := range params {
+			row = append(row, strconv.FormatFloat(r.Parameters[p.Name], 'f', -1, 64))
+		}
+		if r.Err != nil {
+			row = append(row, strings.Replace(fmt.Sprintf("error: %s", r.Err), "\n", " ", -1))
+		} else {
+			row = append(row,
+				fmt.Sprintf("%f", r.Score),
+				fmt.Sprintf("%d", r.Results.ProfitTrades+r.Results.LossTrades),
+				fmt.Sprintf("%f", r.Results.NetProfit()),
+				fmt.Sprintf("%f", r.Results.SharpeRatio()),
+				fmt.Sprintf("%f", r.Results.MaxDrawdown()),
+			)
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+
+	return errors.Wrapf(tw.Flush(), "error writing sweep results")
+}
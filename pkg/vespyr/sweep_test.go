@@ -0,0 +1,135 @@
+package vespyr_test
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DavidHuie/vespyr/pkg/vespyr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestParseSweepParameter(t *testing.T) {
+	p, err := vespyr.ParseSweepParameter("period=5:15:5")
+	assert.NoError(t, err)
+	assert.Equal(t, &vespyr.SweepParameter{Name: "period", Min: 5, Max: 15, Step: 5}, p)
+	assert.Equal(t, []float64{5, 10, 15}, p.Values())
+
+	p, err = vespyr.ParseSweepParameter("threshold=0.1:0.3:0.1")
+	assert.NoError(t, err)
+	assert.Len(t, p.Values(), 3)
+
+	p, err = vespyr.ParseSweepParameter("threshold=0.1:0.7:0.1")
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{.1, .2, .3, .4, .5, .6, .7}, p.Values())
+
+	p, err = vespyr.ParseSweepParameter("period=7")
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{7}, p.Values())
+
+	for _, spec := range []string{"period", "period=a", "period=5:1", "period=1:5:0", "=1"} {
+		_, err := vespyr.ParseSweepParameter(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestGridAndRandomSweep(t *testing.T) {
+	params := []*vespyr.SweepParameter{
+		{Name: "short_period", Min: 1, Max: 2, Step: 1},
+		{Name: "long_period", Min: 10, Max: 30, Step: 10},
+	}
+
+	grid := vespyr.GridSweep(params)
+	assert.Len(t, grid, 6)
+	assert.Equal(t, map[string]float64{"short_period": 1, "long_period": 20}, grid[1])
+
+	first := vespyr.RandomSweep(params, 5, rand.New(rand.NewSource(3)))
+	second := vespyr.RandomSweep(params, 5, rand.New(rand.NewSource(3)))
+	assert.Len(t, first, 5)
+	assert.Equal(t, first, second)
+}
+
+func TestSweeperNestedParameters(t *testing.T) {
+	model, err := vespyr.ParseStrategyFile([]byte(ruleStrategyFile))
+	assert.NoError(t, err)
+	sweeper := vespyr.NewSweeper(time.Now(), time.Now(), model, new(vespyr.MockBackend), 1, 1)
+
+	swept, err := sweeper.Model(map[string]float64{
+		"indicators.dema.long_period": 30,
+		"indicators.2.deviations":     2.5,
+		"rsi_buy":                     25,
+	})
+	assert.NoError(t, err)
+	s, err := swept.Strategy()
+	assert.NoError(t, err)
+	strategy := s.(*vespyr.RuleStrategy)
+	assert.Equal(t, "30", strategy.RuleIndicators[0].Args["long_period"])
+	assert.Equal(t, "10", strategy.RuleIndicators[0].Args["short_period"])
+	assert.Equal(t, "2.5", strategy.RuleIndicators[2].Args["deviations"])
+	assert.Equal(t, float64(25), strategy.Parameters["rsi_buy"])
+
+	for _, name := range []string{"indicators.trend.period", "indicators.5.period", "rsi_buy.period", "missing.period"} {
+		_, err := sweeper.Model(map[string]float64{name: 1})
+		assert.Error(t, err, name)
+	}
+}
+
+func TestSweeper(t *testing.T) {
+	model := &vespyr.TradingStrategyModel{
+		Product:          vespyr.ProductBTCUSD,
+		HistoryTicks:     1,
+		State:            vespyr.StrategyStateTryingToBuy,
+		InitialBudget:    500,
+		Budget:           500,
+		BudgetCurrency:   vespyr.CurrencyUSD,
+		InvestedCurrency: vespyr.CurrencyBTC,
+		TickSizeMinutes:  15,
+	}
+	assert.NoError(t, model.SetStrategy(&vespyr.EMACrossoverStrategy{ShortPeriod: 1, LongPeriod: 5}))
+
+	endTime := time.Now()
+	startTime := endTime.Add(-time.Minute * 30)
+
+	backend := new(vespyr.MockBackend)
+	backend.On("FindCandlesticks", startTime.Add(-15*time.Minute), endTime, model.Product, int64(model.TickSizeMinutes)).
+		Return([]*vespyr.CandlestickModel{
+			{Close: 10, Volume: 1, EndTime: startTime.Add(100 * time.Minute)},
+			{Close: 9, Volume: 1, EndTime: startTime.Add(100 * time.Minute)},
+			{Close: 11, Volume: 1, EndTime: startTime.Add(100 * time.Minute)},
+			{Close: 8, Volume: 1, EndTime: startTime.Add(100 * time.Minute)},
+		}, nil).Once()
+	defer mock.AssertExpectationsForObjects(t, backend)
+
+	sweeper := vespyr.NewSweeper(startTime, endTime, model, backend, 2, 1)
+
+	params := []*vespyr.SweepParameter{{Name: "long_period", Min: 2, Max: 4, Step: 1}}
+	combinations := append(vespyr.GridSweep(params), map[string]float64{"unknown": 1})
+	results := sweeper.Run(combinations)
+	assert.Len(t, results, 4)
+
+	for _, r := range results[:3] {
+		assert.NoError(t, r.Err)
+		strategy, err := r.Model.Strategy()
+		assert.NoError(t, err)
+		assert.Equal(t, uint(r.Parameters["long_period"]), strategy.(*vespyr.EMACrossoverStrategy).LongPeriod)
+		assert.Equal(t, uint(1), strategy.(*vespyr.EMACrossoverStrategy).ShortPeriod)
+	}
+	assert.Error(t, results[3].Err)
+
+	run := sweeper.BacktestRun("test", results[0])
+	assert.Equal(t, "test", run.SweepName)
+	assert.Equal(t, map[string]interface{}{"long_period": float64(2)}, run.Parameters)
+	assert.Equal(t, vespyr.ObjectiveSharpe, run.Objective)
+	assert.Equal(t, startTime, run.StartTime)
+	assert.Equal(t, int64(1), run.Seed)
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, vespyr.WriteSweepResults(buf, params, results))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 5)
+	assert.True(t, strings.HasPrefix(lines[0], "long_period"))
+	assert.Contains(t, lines[4], "error")
+}
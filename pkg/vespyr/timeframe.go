@@ -0,0 +1,142 @@
+package vespyr
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// TimeframeIndicator calculates an indicator on candlesticks with a
+// longer tick size than the strategy's, so that a strategy can, for
+// example, confirm a 15 minute signal with the 4 hour trend. The
+// strategy's candlesticks are reprojected into the longer tick size,
+// and the indicator's value is its value after the last complete
+// candlestick of that size, so it never looks ahead of the strategy's
+// tick. The tick size must be a multiple of the strategy's, and the
+// strategy's history ticks must cover enough of the longer candlesticks
+// to warm the indicator up.
+type TimeframeIndicator struct {
+	indicator       Indicator
+	tickSizeMinutes uint
+	bucket          time.Time
+	pending         []*CandlestickModel
+}
+
+// NewTimeframeIndicator returns a new TimeframeIndicator.
+func NewTimeframeIndicator(indicator Indicator, tickSizeMinutes uint) *TimeframeIndicator {
+	return &TimeframeIndicator{
+		indicator:       indicator,
+		tickSizeMinutes: tickSizeMinutes,
+	}
+}
+
+// TimeframeOptions selects the tick size that a strategy's indicators
+// are calculated on. The zero value uses the strategy's tick size, and
+// other values must be multiples of it. Only the EMA crossover, RSI,
+// RSI and Bollinger Bands, and S1 strategies support timeframes; rule
+// strategies set them per indicator.
+type TimeframeOptions struct {
+	TimeframeMinutes uint `yaml:"timeframe_minutes,omitempty"`
+}
+
+// timeframe wraps an indicator in a TimeframeIndicator when a
+// timeframe is set.
+func (o TimeframeOptions) timeframe(indicator Indicator) Indicator {
+	if o.TimeframeMinutes == 0 {
+		return indicator
+	}
+	return NewTimeframeIndicator(indicator, o.TimeframeMinutes)
+}
+
+// String returns the options, or an empty string for the defaults.
+func (o TimeframeOptions) String() string {
+	if o.TimeframeMinutes == 0 {
+		return ""
+	}
+	return fmt.Sprintf("-timeframe(%dm)", o.TimeframeMinutes)
+}
+
+// Name returns the name of the indicator.
+func (t *TimeframeIndicator) Name() string {
+	return fmt.Sprintf("%s@%dm", t.indicator.Name(), t.tickSizeMinutes)
+}
+
+// TickSizeMinutes returns the tick size that the indicator is
+// calculated on.
+func (t *TimeframeIndicator) TickSizeMinutes() uint {
+	return t.tickSizeMinutes
+}
+
+func (t *TimeframeIndicator) tickSize() time.Duration {
+	return time.Duration(t.tickSizeMinutes) * time.Minute
+}
+
+// timeframeBucket returns the start of the bucket that a candlestick
+// falls in, checking that the candlestick doesn't span buckets.
+func timeframeBucket(c *CandlestickModel, tickSizeMinutes uint) (time.Time, error) {
+	if tickSizeMinutes == 0 {
+		return time.Time{}, errors.New("error: timeframe tick size must be set")
+	}
+	bucket := CandlestickBucket(c.StartTime, int64(tickSizeMinutes))
+	if c.EndTime.After(bucket.Add(time.Duration(tickSizeMinutes) * time.Minute)) {
+		return time.Time{}, errors.Errorf("error: %d minute timeframe isn't a multiple of the candlestick size",
+			tickSizeMinutes)
+	}
+	return bucket, nil
+}
+
+// AddCandlestick adds a candlestick to the indicator. The wrapped
+// indicator receives a candlestick once every one in its bucket has
+// been added.
+func (t *TimeframeIndicator) AddCandlestick(c *CandlestickModel) error {
+	bucket, err := timeframeBucket(c, t.tickSizeMinutes)
+	if err != nil {
+		return err
+	}
+
+	// Buckets with missing candlesticks are complete once a later
+	// one starts.
+	if len(t.pending) > 0 && !bucket.Equal(t.bucket) {
+		if err := t.flush(); err != nil {
+			return err
+		}
+	}
+
+	t.bucket = bucket
+	t.pending = append(t.pending, c)
+
+	if !c.EndTime.Before(bucket.Add(t.tickSize())) {
+		return t.flush()
+	}
+	return nil
+}
+
+func (t *TimeframeIndicator) flush() error {
+	candles, err := reprojectTimeframe(t.pending, t.tickSizeMinutes)
+	if err != nil {
+		return errors.Wrapf(err, "error reprojecting candlesticks")
+	}
+	t.pending = nil
+
+	for _, c := range candles {
+		if err := t.indicator.AddCandlestick(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Value returns the wrapped indicator's value after the last complete
+// candlestick.
+func (t *TimeframeIndicator) Value() (*IndicatorValue, error) {
+	value, err := t.indicator.Value()
+	if err != nil {
+		return nil, err
+	}
+
+	// The wrapped indicator names its value after itself.
+	v := *value
+	v.IndicatorName = t.Name()
+	return &v, nil
+}
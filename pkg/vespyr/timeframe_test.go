@@ -0,0 +1,213 @@
+package vespyr_test
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DavidHuie/vespyr/pkg/vespyr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func timeframeTestCandles() []*vespyr.CandlestickModel {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	var candles []*vespyr.CandlestickModel
+	for i := 0; i < 96; i++ {
+		c := &vespyr.CandlestickModel{
+			StartTime: start.Add(time.Duration(i) * 15 * time.Minute),
+			EndTime:   start.Add(time.Duration(i+1) * 15 * time.Minute),
+			Product:   vespyr.ProductBTCUSD,
+		}
+		// Some candlesticks have no trades.
+		if i%7 != 3 {
+			price := 100 + 10*math.Sin(float64(i)/5)
+			c.Open, c.Close = price-1, price
+			c.Low, c.High = price-2, price+1
+			c.Volume = 1
+		}
+		candles = append(candles, c)
+	}
+	return candles
+}
+
+// timeframeTestExpected calculates an hourly EMA directly and returns
+// its value at the end of every 15 minute candlestick.
+func timeframeTestExpected(t *testing.T, candles []*vespyr.CandlestickModel) []*vespyr.IndicatorValue {
+	var traded []*vespyr.CandlestickModel
+	for _, c := range candles {
+		if c.Volume != 0 {
+			traded = append(traded, c)
+		}
+	}
+	hourly, err := vespyr.ReprojectCandlesticks(traded, vespyr.ProductBTCUSD, 60)
+	assert.NoError(t, err)
+
+	ema := vespyr.NewEMAIndicator(3)
+	var expected []*vespyr.IndicatorValue
+	var value *vespyr.IndicatorValue
+	j := 0
+	for _, c := range candles {
+		for j < len(hourly) && !hourly[j].EndTime.After(c.EndTime) {
+			assert.NoError(t, ema.AddCandlestick(hourly[j]))
+			value, _ = ema.Value()
+			j++
+		}
+		expected = append(expected, value)
+	}
+	return expected
+}
+
+func TestTimeframeIndicator(t *testing.T) {
+	candles := timeframeTestCandles()
+	expected := timeframeTestExpected(t, candles)
+
+	indicator := vespyr.NewTimeframeIndicator(vespyr.NewEMAIndicator(3), 60)
+	assert.Equal(t, "ema-3@60m", indicator.Name())
+	assert.Equal(t, uint(60), indicator.TickSizeMinutes())
+
+	for i, c := range candles {
+		assert.NoError(t, indicator.AddCandlestick(c))
+		value, err := indicator.Value()
+		if expected[i] == nil {
+			assert.Equal(t, vespyr.ErrNotEnoughData, err, "%d", i)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, "ema-3@60m", value.IndicatorName)
+		assert.Equal(t, expected[i].Value, value.Value, "%d", i)
+	}
+
+	// Values only change once an hour ends.
+	assert.Equal(t, expected[40], expected[42])
+	assert.NotEqual(t, expected[42].Value, expected[43].Value)
+
+	// Candlesticks can't span the timeframe's buckets.
+	indicator = vespyr.NewTimeframeIndicator(vespyr.NewEMAIndicator(3), 40)
+	var err error
+	for _, c := range candles {
+		if err = indicator.AddCandlestick(c); err != nil {
+			break
+		}
+	}
+	assert.Error(t, err)
+}
+
+func TestTimeframeIndicatorBacktest(t *testing.T) {
+	candles := timeframeTestCandles()
+	expected := timeframeTestExpected(t, candles)
+
+	backend := new(vespyr.MockBackend)
+	backend.On("FindCandlesticks", mock.Anything, mock.Anything, vespyr.ProductBTCUSD, int64(15)).
+		Return(candles, nil)
+
+	model, err := vespyr.ParseStrategyFile([]byte(`
+product: BTC-USD
+tick_size_minutes: 15
+history_ticks: 96
+trading_strategy: rule
+trading_strategy_data:
+  indicators:
+  - name: trend
+    type: ema
+    period: 3
+    tick_size_minutes: 60
+  - name: price
+    type: price
+    source: close
+  buy: price > trend
+  sell: price < trend
+`))
+	assert.NoError(t, err)
+
+	backtester, err := vespyr.NewBacktester(candles[0].StartTime, candles[len(candles)-1].EndTime,
+		model, backend, rand.NewSource(1))
+	assert.NoError(t, err)
+	assert.NoError(t, backtester.Backtest())
+	results := backtester.Results()
+	assert.True(t, results.ProfitTrades+results.LossTrades > 0)
+
+	reader, err := backtester.ResultsCSV()
+	assert.NoError(t, err)
+	rows, err := csv.NewReader(reader).ReadAll()
+	assert.NoError(t, err)
+	if !assert.True(t, len(rows) > 1) {
+		return
+	}
+	assert.Equal(t, "ema-3@60m", rows[0][11])
+
+	byTime := make(map[string]*vespyr.IndicatorValue)
+	for i, c := range candles {
+		byTime[c.StartTime.Format(time.RFC3339)] = expected[i]
+	}
+	for _, row := range rows[1:] {
+		value := byTime[row[0]]
+		if assert.NotNil(t, value, row[0]) {
+			assert.Equal(t, fmt.Sprintf("%f", value.Value), row[11], row[0])
+		}
+	}
+}
+
+func TestStrategyTimeframeOptions(t *testing.T) {
+	for _, strategyType := range []string{
+		vespyr.TradingStrategyEMACrossover,
+		vespyr.TradingStrategyRSI,
+		vespyr.TradingStrategyRSIBollinger,
+		vespyr.TradingStrategyS1,
+	} {
+		st, err := vespyr.LookupStrategyType(strategyType)
+		assert.NoError(t, err)
+		data, err := st.Data(nil, map[string]interface{}{"timeframe_minutes": 60})
+		assert.NoError(t, err, strategyType)
+		model := &vespyr.TradingStrategyModel{TradingStrategy: strategyType, TradingStrategyData: data}
+		strategy, err := model.Strategy()
+		if !assert.NoError(t, err, strategyType) {
+			continue
+		}
+
+		// Every indicator is calculated on the longer timeframe,
+		// and the timeframe survives cloning.
+		for _, indicator := range strategy.Indicators() {
+			assert.True(t, strings.HasSuffix(indicator.Name(), "@60m"), indicator.Name())
+		}
+		clone := strategy.(vespyr.StrategyGenome).Clone()
+		assert.Equal(t, strategy.String(), clone.String())
+	}
+
+	candles := timeframeTestCandles()
+	backend := new(vespyr.MockBackend)
+	backend.On("FindCandlesticks", mock.Anything, mock.Anything, vespyr.ProductBTCUSD, int64(15)).
+		Return(candles, nil)
+
+	model, err := vespyr.ParseStrategyFile([]byte(`
+product: BTC-USD
+tick_size_minutes: 15
+history_ticks: 40
+trading_strategy: ema-crossover
+trading_strategy_data:
+  short_period: 2
+  long_period: 4
+  up_threshold: 0
+  down_threshold: 0
+  timeframe_minutes: 60
+`))
+	assert.NoError(t, err)
+
+	backtester, err := vespyr.NewBacktester(candles[40].StartTime, candles[len(candles)-1].EndTime,
+		model, backend, rand.NewSource(1))
+	assert.NoError(t, err)
+	assert.NoError(t, backtester.Backtest())
+
+	// After the first tick, signals only change when an hour
+	// closes.
+	for _, trade := range backtester.Trades() {
+		if trade.Time.After(candles[40].StartTime) {
+			assert.Equal(t, 45, trade.Time.Minute(), "%s", trade.Time)
+		}
+	}
+	assert.True(t, len(backtester.Trades()) > 2, "%d trades", len(backtester.Trades()))
+}
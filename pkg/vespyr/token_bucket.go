@@ -0,0 +1,79 @@
+package vespyr
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/pkg/errors"
+)
+
+// TokenBucket is a rate limiter that can be shared between goroutines
+// making requests to the same exchange.
+type TokenBucket struct {
+	mutex    sync.Mutex
+	clock    clockwork.Clock
+	rate     float64
+	capacity float64
+	tokens   float64
+	last     time.Time
+}
+
+// NewTokenBucket creates a full TokenBucket that refills at rate
+// tokens per second up to capacity tokens. Both must be positive for
+// tokens to ever be available.
+func NewTokenBucket(rate float64, capacity int, clock clockwork.Clock) (*TokenBucket, error) {
+	if rate <= 0 {
+		return nil, errors.Errorf("error: token bucket rate must be positive: %f", rate)
+	}
+	if capacity < 1 {
+		return nil, errors.Errorf("error: token bucket capacity must be at least 1: %d", capacity)
+	}
+
+	return &TokenBucket{
+		clock:    clock,
+		rate:     rate,
+		capacity: float64(capacity),
+		tokens:   float64(capacity),
+		last:     clock.Now(),
+	}, nil
+}
+
+func (t *TokenBucket) refill() {
+	now := t.clock.Now()
+	t.tokens += now.Sub(t.last).Seconds() * t.rate
+	if t.tokens > t.capacity {
+		t.tokens = t.capacity
+	}
+	t.last = now
+}
+
+// Take removes a token from the bucket if one is available.
+func (t *TokenBucket) Take() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.refill()
+	if t.tokens < 1 {
+		return false
+	}
+	t.tokens--
+	return true
+}
+
+// Wait blocks until a token can be removed from the bucket.
+func (t *TokenBucket) Wait() {
+	for {
+		t.mutex.Lock()
+		t.refill()
+		if t.tokens >= 1 {
+			t.tokens--
+			t.mutex.Unlock()
+			return
+		}
+		wait := time.Duration((1 - t.tokens) / t.rate * float64(time.Second))
+		t.mutex.Unlock()
+
+		t.clock.Sleep(wait)
+	}
+}
@@ -0,0 +1,42 @@
+package vespyr_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DavidHuie/vespyr/pkg/vespyr"
+	"github.com/jonboulle/clockwork"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucket(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	bucket, err := vespyr.NewTokenBucket(2, 2, clock)
+	assert.NoError(t, err)
+
+	assert.True(t, bucket.Take())
+	assert.True(t, bucket.Take())
+	assert.False(t, bucket.Take())
+
+	clock.Advance(500 * time.Millisecond)
+	assert.True(t, bucket.Take())
+	assert.False(t, bucket.Take())
+
+	// The bucket never holds more than its capacity.
+	clock.Advance(time.Minute)
+	assert.True(t, bucket.Take())
+	assert.True(t, bucket.Take())
+	assert.False(t, bucket.Take())
+
+	for _, invalid := range []struct {
+		rate     float64
+		capacity int
+	}{
+		{0, 1},
+		{-1, 1},
+		{1, 0},
+	} {
+		_, err := vespyr.NewTokenBucket(invalid.rate, invalid.capacity, clock)
+		assert.Error(t, err)
+	}
+}
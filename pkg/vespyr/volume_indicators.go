@@ -0,0 +1,122 @@
+package vespyr
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// OBVIndicator calculates on-balance volume: a running total that adds
+// the volume of candlesticks whose price rose and subtracts the volume
+// of those whose price fell. It starts at zero.
+type OBVIndicator struct {
+	source    PriceSource
+	obv       float64
+	lastPrice float64
+	count     uint
+	lastTime  time.Time
+}
+
+// NewOBVIndicator returns a new OBVIndicator.
+func NewOBVIndicator(source PriceSource) *OBVIndicator {
+	return &OBVIndicator{source: source}
+}
+
+// AddCandlestick adds a candlestick to the indicator.
+func (o *OBVIndicator) AddCandlestick(c *CandlestickModel) error {
+	if c.Volume == 0 {
+		return nil
+	}
+
+	price, err := o.source.Price(c)
+	if err != nil {
+		return errors.Wrapf(err, "error calculating %s price", o.source)
+	}
+
+	if o.count > 0 {
+		switch {
+		case price > o.lastPrice:
+			o.obv += c.Volume
+		case price < o.lastPrice:
+			o.obv -= c.Volume
+		}
+	}
+	o.count++
+	o.lastPrice = price
+	o.lastTime = c.StartTime
+
+	return nil
+}
+
+// Name returns the name of the indicator.
+func (o *OBVIndicator) Name() string {
+	return fmt.Sprintf("%s-%s", IndicatorOBV, o.source)
+}
+
+// Value returns the current on-balance volume.
+func (o *OBVIndicator) Value() (*IndicatorValue, error) {
+	if o.count == 0 {
+		return nil, ErrNotEnoughData
+	}
+	return &IndicatorValue{
+		Time:          o.lastTime,
+		Value:         o.obv,
+		IndicatorName: o.Name(),
+	}, nil
+}
+
+// VWAPIndicator calculates the volume weighted average price over a
+// rolling window of candlesticks.
+type VWAPIndicator struct {
+	period   uint
+	source   PriceSource
+	values   *rollingWindow
+	volumes  *rollingWindow
+	lastTime time.Time
+}
+
+// NewVWAPIndicator returns a new VWAPIndicator.
+func NewVWAPIndicator(period uint, source PriceSource) *VWAPIndicator {
+	return &VWAPIndicator{
+		period:  period,
+		source:  source,
+		values:  newRollingWindow(period),
+		volumes: newRollingWindow(period),
+	}
+}
+
+// AddCandlestick adds a candlestick to the indicator.
+func (v *VWAPIndicator) AddCandlestick(c *CandlestickModel) error {
+	if c.Volume == 0 {
+		return nil
+	}
+
+	price, err := v.source.Price(c)
+	if err != nil {
+		return errors.Wrapf(err, "error calculating %s price", v.source)
+	}
+
+	v.values.add(price * c.Volume)
+	v.volumes.add(c.Volume)
+	v.lastTime = c.StartTime
+
+	return nil
+}
+
+// Name returns the name of the indicator.
+func (v *VWAPIndicator) Name() string {
+	return fmt.Sprintf("%s-%d-%s", IndicatorVWAP, v.period, v.source)
+}
+
+// Value returns the VWAP of the last window.
+func (v *VWAPIndicator) Value() (*IndicatorValue, error) {
+	if !v.volumes.full() {
+		return nil, ErrNotEnoughData
+	}
+	return &IndicatorValue{
+		Time:          v.lastTime,
+		Value:         v.values.sum() / v.volumes.sum(),
+		IndicatorName: v.Name(),
+	}, nil
+}
@@ -0,0 +1,286 @@
+package vespyr
+
+import (
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/montanaflynn/stats"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// WalkForwardWindow is a training period followed by the test period
+// that the trained strategy is validated on.
+type WalkForwardWindow struct {
+	TrainStart time.Time
+	TrainEnd   time.Time
+	TestStart  time.Time
+	TestEnd    time.Time
+}
+
+// WalkForwardWindows splits a time range into rolling windows. Each
+// window trains on trainPeriod and tests on the following testPeriod,
+// and windows advance by testPeriod so that the test periods are
+// contiguous.
+func WalkForwardWindows(start, end time.Time, trainPeriod, testPeriod time.Duration) ([]*WalkForwardWindow, error) {
+	if trainPeriod <= 0 || testPeriod <= 0 {
+		return nil, errors.New("error: train and test periods must be positive")
+	}
+
+	var windows []*WalkForwardWindow
+	for trainStart := start; !trainStart.Add(trainPeriod + testPeriod).After(end); trainStart = trainStart.Add(testPeriod) {
+		windows = append(windows, &WalkForwardWindow{
+			TrainStart: trainStart,
+			TrainEnd:   trainStart.Add(trainPeriod),
+			TestStart:  trainStart.Add(trainPeriod),
+			TestEnd:    trainStart.Add(trainPeriod + testPeriod),
+		})
+	}
+	if len(windows) == 0 {
+		return nil, errors.New("error: time range is shorter than a single train and test period")
+	}
+
+	return windows, nil
+}
+
+// WalkForwardStep contains the result of optimizing a single window.
+type WalkForwardStep struct {
+	Window        *WalkForwardWindow
+	Model         *TradingStrategyModel
+	Strategy      StrategyInterface
+	InSampleScore float64
+	OutOfSample   *BacktestResults
+	Trades        []*BacktestTradeModel
+	Seed          int64
+}
+
+// OutOfSampleReturn returns the ratio of the final out of sample
+// portfolio value to the initial budget. The final value is taken at
+// the end of the test period rather than at its last daily sample.
+func (w *WalkForwardStep) OutOfSampleReturn() float64 {
+	if w.OutOfSample.InitialBudget == 0 {
+		return 1
+	}
+	return w.OutOfSample.FinalPortfolioValue / w.OutOfSample.InitialBudget
+}
+
+// ParameterStats summarizes the values a numeric strategy parameter
+// took across walk forward windows.
+type ParameterStats struct {
+	Mean   float64
+	StdDev float64
+	Min    float64
+	Max    float64
+}
+
+// WalkForwardResults contains the results of a walk forward
+// optimization.
+type WalkForwardResults struct {
+	InitialBudget float64
+	Steps         []*WalkForwardStep
+}
+
+// Equity returns the out of sample portfolio value per day with each
+// test period compounding on the previous one.
+func (w *WalkForwardResults) Equity() []float64 {
+	var equity []float64
+	capital := w.InitialBudget
+	for _, step := range w.Steps {
+		initial := step.OutOfSample.InitialBudget
+		if initial == 0 {
+			continue
+		}
+		for _, v := range step.OutOfSample.PortfolioValuePerDay {
+			equity = append(equity, capital*v/initial)
+		}
+		capital *= step.OutOfSampleReturn()
+	}
+	return equity
+}
+
+// FinalEquity returns the out of sample portfolio value at the end of
+// the last test period.
+func (w *WalkForwardResults) FinalEquity() float64 {
+	capital := w.InitialBudget
+	for _, step := range w.Steps {
+		capital *= step.OutOfSampleReturn()
+	}
+	return capital
+}
+
+// ParameterStability summarizes how much each numeric strategy
+// parameter changed between windows. Large deviations suggest that
+// the optimizer is fitting noise.
+func (w *WalkForwardResults) ParameterStability() (map[string]*ParameterStats, error) {
+	values := make(map[string][]float64)
+	for _, step := range w.Steps {
+		b, err := yaml.Marshal(step.Strategy)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error YAML marshalling strategy")
+		}
+		var params map[string]interface{}
+		if err := yaml.Unmarshal(b, &params); err != nil {
+			return nil, errors.Wrapf(err, "error YAML unmarshaling strategy")
+		}
+		for name, v := range params {
+			switch n := v.(type) {
+			case int:
+				values[name] = append(values[name], float64(n))
+			case float64:
+				values[name] = append(values[name], n)
+			}
+		}
+	}
+
+	stability := make(map[string]*ParameterStats)
+	for name, v := range values {
+		s := &ParameterStats{}
+		s.Mean, _ = stats.Mean(v)
+		s.StdDev, _ = stats.StandardDeviation(v)
+		s.Min, _ = stats.Min(v)
+		s.Max, _ = stats.Max(v)
+		stability[name] = s
+	}
+	return stability, nil
+}
+
+// ParameterNames returns the sorted names of the parameters in a
+// stability summary.
+func ParameterNames(stability map[string]*ParameterStats) []string {
+	var names []string
+	for name := range stability {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WalkForwardOptimizer optimizes a strategy on rolling train windows
+// and validates each winner on the window that follows.
+type WalkForwardOptimizer struct {
+	model          *TradingStrategyModel
+	backend        Backend
+	generations    int
+	populationSize int
+	objective      Objective
+	seed           int64
+	fillModel      FillModel
+}
+
+// NewWalkForwardOptimizer creates a new WalkForwardOptimizer.
+func NewWalkForwardOptimizer(model *TradingStrategyModel, backend Backend,
+	generations, populationSize int) *WalkForwardOptimizer {
+	return &WalkForwardOptimizer{
+		model:          model,
+		backend:        NewCacheingBackend(backend),
+		generations:    generations,
+		populationSize: populationSize,
+	}
+}
+
+// SetObjective sets the objective used to optimize each training
+// window.
+func (w *WalkForwardOptimizer) SetObjective(o Objective) {
+	w.objective = o
+}
+
+// SetSeed sets the seed that drives the genetic algorithm and every
+// backtest.
+func (w *WalkForwardOptimizer) SetSeed(seed int64) {
+	w.seed = seed
+}
+
+// SetFillModel sets the model that orders are filled with in and out
+// of sample.
+func (w *WalkForwardOptimizer) SetFillModel(m FillModel) {
+	w.fillModel = m
+}
+
+// Optimize finds the best strategy for a training period, returning
+// the strategy and its in sample score.
+func (w *WalkForwardOptimizer) Optimize(start, end time.Time) (StrategyInterface, float64, error) {
+	factory, err := NewBacktesterGenomeFactory(start, end, w.model, w.backend)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "error creating genome factory")
+	}
+	if w.objective != nil {
+		factory.SetObjective(w.objective)
+	}
+	factory.SetFillModel(w.fillModel)
+
+	ga := NewGeneticOptimizer(factory, w.populationSize, w.seed)
+	ga.Initialize()
+
+	for i := 0; i < w.generations; i++ {
+		if err := ga.Enhance(); err != nil {
+			return nil, 0, errors.Wrapf(err, "error enhancing generation")
+		}
+	}
+
+	genome := ga.Best.Genome.(*BacktesterGenome)
+	return genome.strategy.Clone(), -ga.Best.Fitness, nil
+}
+
+// Validate backtests a strategy on a test period.
+func (w *WalkForwardOptimizer) Validate(strategy StrategyInterface, start, end time.Time) (*BacktestResults, error) {
+	step, err := w.validate(strategy, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return step.OutOfSample, nil
+}
+
+func (w *WalkForwardOptimizer) validate(strategy StrategyInterface, start, end time.Time) (*WalkForwardStep, error) {
+	model := w.model.Copy()
+	if err := model.SetStrategy(strategy); err != nil {
+		return nil, errors.Wrapf(err, "error setting model strategy")
+	}
+
+	backtester, err := NewBacktester(start, end, model, w.backend,
+		rand.NewSource(w.seed))
+	if err != nil {
+		return nil, errors.Wrapf(err, "error creating backtester")
+	}
+	backtester.SetFillModel(w.fillModel)
+	if err := backtester.Backtest(); err != nil {
+		return nil, errors.Wrapf(err, "error running backtest")
+	}
+
+	return &WalkForwardStep{
+		Model:       model,
+		Strategy:    strategy,
+		OutOfSample: backtester.Results(),
+		Trades:      backtester.Trades(),
+		Seed:        w.seed,
+	}, nil
+}
+
+// Run optimizes and validates every window in order.
+func (w *WalkForwardOptimizer) Run(windows []*WalkForwardWindow) (*WalkForwardResults, error) {
+	results := &WalkForwardResults{
+		InitialBudget: w.model.InitialBudget,
+	}
+
+	for i, window := range windows {
+		logrus.Infof("optimizing walk forward window %d/%d: %s - %s", i+1,
+			len(windows), window.TrainStart, window.TrainEnd)
+
+		strategy, score, err := w.Optimize(window.TrainStart, window.TrainEnd)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error optimizing window %d", i+1)
+		}
+
+		step, err := w.validate(strategy, window.TestStart, window.TestEnd)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error validating window %d", i+1)
+		}
+		step.Window = window
+		step.InSampleScore = score
+
+		results.Steps = append(results.Steps, step)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,63 @@
+package vespyr_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DavidHuie/vespyr/pkg/vespyr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalkForwardWindows(t *testing.T) {
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	windows, err := vespyr.WalkForwardWindows(start, start.Add(10*day), 4*day, 2*day)
+	assert.NoError(t, err)
+	assert.Len(t, windows, 3)
+	assert.Equal(t, &vespyr.WalkForwardWindow{
+		TrainStart: start.Add(2 * day),
+		TrainEnd:   start.Add(6 * day),
+		TestStart:  start.Add(6 * day),
+		TestEnd:    start.Add(8 * day),
+	}, windows[1])
+	assert.Equal(t, start.Add(10*day), windows[2].TestEnd)
+
+	_, err = vespyr.WalkForwardWindows(start, start.Add(day), 4*day, 2*day)
+	assert.Error(t, err)
+}
+
+func TestWalkForwardResults(t *testing.T) {
+	results := &vespyr.WalkForwardResults{
+		InitialBudget: 100,
+		Steps: []*vespyr.WalkForwardStep{
+			{
+				Strategy: &vespyr.RSIStrategy{Period: 10, BuyThreshold: 30, SellThreshold: 70},
+				OutOfSample: &vespyr.BacktestResults{
+					InitialBudget:        100,
+					PortfolioValuePerDay: []float64{105, 110},
+					FinalPortfolioValue:  120,
+				},
+			},
+			{
+				Strategy: &vespyr.RSIStrategy{Period: 20, BuyThreshold: 30, SellThreshold: 80},
+				OutOfSample: &vespyr.BacktestResults{
+					InitialBudget:        100,
+					PortfolioValuePerDay: []float64{90},
+					FinalPortfolioValue:  90,
+				},
+			},
+		},
+	}
+
+	// Each test period compounds from the value at its end, after
+	// its last daily sample.
+	assert.InDeltaSlice(t, []float64{105, 110, 108}, results.Equity(), 1e-9)
+	assert.InDelta(t, 108, results.FinalEquity(), 1e-9)
+
+	stability, err := results.ParameterStability()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"buy_threshold", "period", "sell_threshold"}, vespyr.ParameterNames(stability))
+	assert.Equal(t, &vespyr.ParameterStats{Mean: 15, StdDev: 5, Min: 10, Max: 20}, stability["period"])
+	assert.Equal(t, float64(0), stability["buy_threshold"].StdDev)
+}